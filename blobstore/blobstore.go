@@ -0,0 +1,33 @@
+// Package blobstore keeps uploaded files, such as product images, outside the
+// database: on the local filesystem or in an S3-compatible bucket.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrNotFound is returned for keys that hold no blob
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs under slash-separated keys
+type Store interface {
+	// Put stores data under key, replacing what was there
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the blob under key, if there is one
+	Delete(ctx context.Context, key string) error
+}
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// checkKey refuses keys that could escape the store or need escaping in a URL
+func checkKey(key string) error {
+	if !keyPattern.MatchString(key) || strings.Contains(key, "..") || strings.Contains(key, "//") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config locates a bucket of an S3-compatible service, such as AWS S3 or
+// MinIO, and the credentials to use it
+type S3Config struct {
+	// Endpoint is the base URL of the service, such as https://s3.eu-west-1.amazonaws.com
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket in the path rather than the host name,
+	// as most self-hosted services expect
+	PathStyle bool
+}
+
+// S3 keeps blobs as objects of a bucket, signing requests with AWS Signature Version 4
+type S3 struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3 keeps blobs in the bucket of config
+func NewS3(config S3Config) (*S3, error) {
+	endpoint, err := url.Parse(strings.TrimRight(config.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint %q needs a scheme and a host", config.Endpoint)
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	return &S3{config: config, endpoint: endpoint, client: &http.Client{Timeout: time.Minute}}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	response, err := s.do(ctx, http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return s.check(response, key)
+}
+
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	response, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if err := s.check(response, key); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(response.Body)
+}
+
+// Delete succeeds for missing keys too, as S3 does
+func (s *S3) Delete(ctx context.Context, key string) error {
+	response, err := s.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return s.check(response, key)
+}
+
+func (s *S3) check(response *http.Response, key string) error {
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case response.StatusCode >= 300:
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("S3 %s: %s: %s", key, response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// objectURL addresses key in the bucket. Keys never need escaping, see checkKey.
+func (s *S3) objectURL(key string) *url.URL {
+	object := *s.endpoint
+	if s.config.PathStyle {
+		object.Path = s.endpoint.Path + "/" + s.config.Bucket + "/" + key
+	} else {
+		object.Host = s.config.Bucket + "." + s.endpoint.Host
+		object.Path = s.endpoint.Path + "/" + key
+	}
+	return &object
+}
+
+func (s *S3) do(ctx context.Context, method string, key string, body []byte, contentType string) (*http.Response, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	s.sign(request, body, time.Now().UTC())
+	return s.client.Do(request)
+}
+
+// sign adds the AWS Signature Version 4 headers to request, see
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+func (s *S3) sign(request *http.Request, body []byte, now time.Time) {
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonical, signedHeaders := canonicalRequest(request, payloadHash)
+	scope := day + "/" + s.config.Region + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), day)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalRequest is the form of request that gets signed, signing the host
+// and every header it carries, with the names of those headers
+func canonicalRequest(request *http.Request, payloadHash string) (string, string) {
+	headers := map[string]string{"host": request.URL.Host}
+	for name := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(request.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	return strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
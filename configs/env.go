@@ -1,8 +1,10 @@
 package configs
 
 import (
+	"appadming/blobstore"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,3 +16,67 @@ func EnvMongoURI() string {
 	}
 	return os.Getenv("MONGOURI")
 }
+
+// EnvStorage selects the storage backend, "mongo" (default) or "memory"
+func EnvStorage() string {
+	godotenv.Load()
+	if storage := os.Getenv("STORAGE"); storage != "" {
+		return storage
+	}
+	return "mongo"
+}
+
+// EnvReorderInterval is how often reorder suggestions are recomputed, set by
+// REORDER_INTERVAL as a duration such as "30m" (default 1h). Zero or less
+// turns the background job off.
+func EnvReorderInterval() time.Duration {
+	godotenv.Load()
+	if interval, err := time.ParseDuration(os.Getenv("REORDER_INTERVAL")); err == nil {
+		return interval
+	}
+	return time.Hour
+}
+
+// EnvCheckpointInterval is how often the costs of products are checkpointed,
+// set by COST_CHECKPOINT_INTERVAL as a duration (default 24h). Zero or less
+// turns the background job off.
+func EnvCheckpointInterval() time.Duration {
+	godotenv.Load()
+	if interval, err := time.ParseDuration(os.Getenv("COST_CHECKPOINT_INTERVAL")); err == nil {
+		return interval
+	}
+	return 24 * time.Hour
+}
+
+// EnvBlobStore selects where uploaded files are kept, "local" (default) or "s3"
+func EnvBlobStore() string {
+	godotenv.Load()
+	if blobStore := os.Getenv("BLOB_STORE"); blobStore != "" {
+		return blobStore
+	}
+	return "local"
+}
+
+// EnvBlobDir is the directory the local blob store keeps files in, set by
+// BLOB_DIR (default "uploads")
+func EnvBlobDir() string {
+	godotenv.Load()
+	if dir := os.Getenv("BLOB_DIR"); dir != "" {
+		return dir
+	}
+	return "uploads"
+}
+
+// EnvS3 reads the bucket of the S3 blob store from S3_ENDPOINT, S3_REGION,
+// S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY and S3_PATH_STYLE
+func EnvS3() blobstore.S3Config {
+	godotenv.Load()
+	return blobstore.S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Region:    os.Getenv("S3_REGION"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+	}
+}
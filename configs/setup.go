@@ -16,7 +16,8 @@ func ConnectDB() *mongo.Client {
 		log.Fatal(err)
 	}
 
-	ctx, _ := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
 	err = client.Connect(ctx)
 	if err != nil {
 		log.Fatal(err)
@@ -31,9 +32,6 @@ func ConnectDB() *mongo.Client {
 	return client
 }
 
-// Client instance
-var DB *mongo.Client = ConnectDB()
-
 // getting database collections
 func GetCollection(client *mongo.Client, collectionName string) *mongo.Collection {
 	collection := client.Database("AppAdming").Collection(collectionName)
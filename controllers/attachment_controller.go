@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"appadming/blobstore"
+	"appadming/models"
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"appadming/spreadsheet"
+	"context"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var attachmentValidate = validator.New()
+
+func UploadACustomerAttachment(store *repositories.Store, blobs blobstore.Store) gin.HandlerFunc {
+	return uploadAttachment(store, blobs, models.AttachedToCustomer, "customerId")
+}
+
+func UploadASellAttachment(store *repositories.Store, blobs blobstore.Store) gin.HandlerFunc {
+	return uploadAttachment(store, blobs, models.AttachedToSell, "sellsId")
+}
+
+// uploadAttachment keeps the multipart "file" with its "document_type" and
+// "note" for the customer or sale named by param
+func uploadAttachment(store *repositories.Store, blobs blobstore.Store, ownerType string, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		var upload models.AttachmentUpload
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(c.Param(param))
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxAttachmentSize+1<<20)
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": "file is required"}})
+			return
+		}
+		if err := c.ShouldBind(&upload); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := attachmentValidate.Struct(&upload); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		defer file.Close()
+		data, err := spreadsheet.ReadLimited(file, services.MaxAttachmentSize)
+		if err != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, responses.CommonResponse{Status: http.StatusRequestEntityTooLarge, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+		attachment, err := services.AddAttachment(ctx, store, blobs, ownerType, objId, upload, header.Filename, data, userId, time.Now())
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": attachment}})
+	}
+}
+
+// GetACustomerAttachments lists the documents of a customer and of their sales
+func GetACustomerAttachments(store *repositories.Store) gin.HandlerFunc {
+	return listAttachments(store, models.AttachedToCustomer, "customerId")
+}
+
+func GetASellAttachments(store *repositories.Store) gin.HandlerFunc {
+	return listAttachments(store, models.AttachedToSell, "sellsId")
+}
+
+func listAttachments(store *repositories.Store, ownerType string, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(c.Param(param))
+
+		query, err := parseListQuery(c, repositories.AttachmentFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		attachments, err := services.ListAttachments(ctx, store, ownerType, objId, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(attachments)},
+		)
+	}
+}
+
+// DownloadAnAttachment sends a document to the caller, recording the download
+func DownloadAnAttachment(store *repositories.Store, blobs blobstore.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		attachmentId := c.Param("attachmentId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(attachmentId)
+
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+		attachment, data, err := services.DownloadAttachment(ctx, store, blobs, objId, userId, c.ClientIP(), time.Now())
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		// identity documents must not linger in shared caches
+		c.Header("Cache-Control", "no-store")
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+		c.Data(http.StatusOK, attachment.Content_type, data)
+	}
+}
+
+func DeleteAnAttachment(store *repositories.Store, blobs blobstore.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		attachmentId := c.Param("attachmentId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(attachmentId)
+
+		if err := services.DeleteAttachment(ctx, store, blobs, objId); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "attachment successfully deleted!"}},
+		)
+	}
+}
+
+// GetAllAttachmentDownloads lists the download audit, of one attachment with
+// ?attachment=, one customer with ?customer= or one user with ?user=
+func GetAllAttachmentDownloads(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		query, err := parseListQuery(c, repositories.AttachmentDownloadFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		downloads, err := store.Downloads.List(ctx, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(downloads)},
+		)
+	}
+}
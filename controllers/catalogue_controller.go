@@ -0,0 +1,341 @@
+package controllers
+
+import (
+	"appadming/models"
+	"appadming/printing"
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"appadming/spreadsheet"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var catalogueValidate = validator.New()
+
+func CreateCategory(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		var category models.Category
+		defer cancel()
+
+		//validate the request body
+		if err := c.BindJSON(&category); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := catalogueValidate.Struct(&category); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		newCategory, err := services.CreateCategory(ctx, store, models.Category{
+			Name:        category.Name,
+			Parent_id:   category.Parent_id,
+			Description: category.Description,
+		})
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newCategory}})
+	}
+}
+
+func GetACategory(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		categoryId := c.Param("categoryId")
+		defer cancel()
+
+		objId, _ := primitive.ObjectIDFromHex(categoryId)
+
+		category, err := store.Categories.FindByID(ctx, objId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": category}})
+	}
+}
+
+func EditACategory(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		categoryId := c.Param("categoryId")
+		var category models.Category
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(categoryId)
+
+		//validate the request body
+		if err := c.BindJSON(&category); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := catalogueValidate.Struct(&category); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		updatedCategory, err := services.UpdateCategory(ctx, store, objId, category)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedCategory}})
+	}
+}
+
+func DeleteACategory(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		categoryId := c.Param("categoryId")
+		defer cancel()
+
+		objId, _ := primitive.ObjectIDFromHex(categoryId)
+
+		if err := services.DeleteCategory(ctx, store, objId); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "category successfully deleted!"}},
+		)
+	}
+}
+
+// GetAllCategories lists categories, the children of one with ?parent=
+func GetAllCategories(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		query, err := parseListQuery(c, repositories.CategoryFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		categories, err := store.Categories.List(ctx, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(categories)},
+		)
+	}
+}
+
+func CreateBrand(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		var brand models.Brand
+		defer cancel()
+
+		//validate the request body
+		if err := c.BindJSON(&brand); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := catalogueValidate.Struct(&brand); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		newBrand := models.Brand{
+			Id:          primitive.NewObjectID(),
+			Name:        brand.Name,
+			Description: brand.Description,
+		}
+
+		if err := store.Brands.Create(ctx, &newBrand); err != nil {
+			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newBrand}})
+	}
+}
+
+func GetABrand(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		brandId := c.Param("brandId")
+		defer cancel()
+
+		objId, _ := primitive.ObjectIDFromHex(brandId)
+
+		brand, err := store.Brands.FindByID(ctx, objId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": brand}})
+	}
+}
+
+func EditABrand(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		brandId := c.Param("brandId")
+		var brand models.Brand
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(brandId)
+
+		//validate the request body
+		if err := c.BindJSON(&brand); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := catalogueValidate.Struct(&brand); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		updatedBrand, err := store.Brands.Update(ctx, objId, brand)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedBrand}})
+	}
+}
+
+func DeleteABrand(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		brandId := c.Param("brandId")
+		defer cancel()
+
+		objId, _ := primitive.ObjectIDFromHex(brandId)
+
+		if err := services.DeleteBrand(ctx, store, objId); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "brand successfully deleted!"}},
+		)
+	}
+}
+
+func GetAllBrands(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		query, err := parseListQuery(c, repositories.BrandFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		brands, err := store.Brands.List(ctx, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(brands)},
+		)
+	}
+}
+
+// UploadABrandLogo replaces the logo of a brand with the PNG or JPEG uploaded as logo
+func UploadABrandLogo(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		brandId := c.Param("brandId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(brandId)
+
+		header, err := c.FormFile("logo")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": "logo is required"}})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		defer file.Close()
+		logo, err := spreadsheet.ReadLimited(file, printing.MaxLogoSize)
+		if err != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, responses.CommonResponse{Status: http.StatusRequestEntityTooLarge, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		contentType, err := printing.CheckLogo(logo)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		updatedBrand, err := store.Brands.SetLogo(ctx, objId, logo, contentType)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedBrand}})
+	}
+}
+
+func GetABrandLogo(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		brandId := c.Param("brandId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(brandId)
+
+		brand, err := store.Brands.FindByID(ctx, objId)
+		if err == nil && len(brand.Logo) == 0 {
+			err = repositories.ErrNotFound
+		}
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.Data(http.StatusOK, brand.Logo_type, brand.Logo)
+	}
+}
+
+func DeleteABrandLogo(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		brandId := c.Param("brandId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(brandId)
+
+		if _, err := store.Brands.SetLogo(ctx, objId, nil, ""); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "logo successfully deleted!"}})
+	}
+}
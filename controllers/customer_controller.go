@@ -1,26 +1,27 @@
 package controllers
 
 import (
-	"appadming/configs"
 	"appadming/models"
+	"appadming/repositories"
 	"appadming/responses"
+	"appadming/services"
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var customerCollection *mongo.Collection = configs.GetCollection(configs.DB, "customers")
 var validate = validator.New()
 
-func CreateCustomer() gin.HandlerFunc {
+func CreateCustomer(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		var customer models.Customer
 		defer cancel()
 
@@ -49,28 +50,26 @@ func CreateCustomer() gin.HandlerFunc {
 			DueDate:  customer.DueDate,
 		}
 
-		result, err := customerCollection.InsertOne(ctx, newCustomer)
-		if err != nil {
+		if err := store.Customers.Create(ctx, &newCustomer); err != nil {
 			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": result}})
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newCustomer}})
 	}
 }
 
-func GetACustomer() gin.HandlerFunc {
+func GetACustomer(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		customerId := c.Param("customerId")
-		var customer models.Customer
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(customerId)
 
-		err := customerCollection.FindOne(ctx, bson.M{"id": objId}).Decode(&customer)
+		customer, err := store.Customers.FindByID(ctx, objId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
@@ -78,9 +77,9 @@ func GetACustomer() gin.HandlerFunc {
 	}
 }
 
-func EditACustomer() gin.HandlerFunc {
+func EditACustomer(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		customerId := c.Param("customerId")
 		var customer models.Customer
 		defer cancel()
@@ -98,57 +97,37 @@ func EditACustomer() gin.HandlerFunc {
 			return
 		}
 
-		update := bson.M{
-			"name":     customer.Name,
-			"father":   customer.Father,
-			"home":     customer.Home,
-			"village":  customer.Village,
-			"thana":    customer.Thana,
-			"district": customer.District,
-			"phone":    customer.Phone,
-			"email":    customer.Email,
-			"dueDate":  customer.DueDate,
-		}
-		result, err := customerCollection.UpdateOne(ctx, bson.M{"id": objId}, bson.M{"$set": update})
+		updatedCustomer, err := store.Customers.Update(ctx, objId, customer)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		//get updated customer details
-		var updatedCustomer models.Customer
-		if result.MatchedCount == 1 {
-			err := customerCollection.FindOne(ctx, bson.M{"id": objId}).Decode(&updatedCustomer)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-				return
-			}
-		}
-
 		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedCustomer}})
 	}
 }
 
-func DeleteACustomer() gin.HandlerFunc {
+// DeleteACustomer deletes a customer without ledger entries, installment
+// plans, documents or guarantors
+func DeleteACustomer(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		customerId := c.Param("customerId")
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(customerId)
 
-		result, err := customerCollection.DeleteOne(ctx, bson.M{"id": objId})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			return
-		}
-
-		if result.DeletedCount < 1 {
+		err := services.DeleteCustomer(ctx, store, objId)
+		if errors.Is(err, repositories.ErrNotFound) {
 			c.JSON(http.StatusNotFound,
 				responses.CommonResponse{Status: http.StatusNotFound, Message: "error", Data: map[string]interface{}{"data": "customer with specified ID not found!"}},
 			)
 			return
 		}
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
 
 		c.JSON(http.StatusOK,
 			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "customer successfully deleted!"}},
@@ -156,32 +135,53 @@ func DeleteACustomer() gin.HandlerFunc {
 	}
 }
 
-func GetAllCustomers() gin.HandlerFunc {
+func GetAllCustomers(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-		var customers []models.Customer
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		results, err := customerCollection.Find(ctx, bson.M{})
+		query, err := parseListQuery(c, repositories.CustomerFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
 
+		customers, err := store.Customers.List(ctx, query)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		//reading from the db in an optimal way
-		defer results.Close(ctx)
-		for results.Next(ctx) {
-			var singleCustomer models.Customer
-			if err = results.Decode(&singleCustomer); err != nil {
-				c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			}
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(customers)},
+		)
+	}
+}
+
+func SearchCustomers(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
 
-			customers = append(customers, singleCustomer)
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": "q is required"}})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": "limit must be between 1 and 100"}})
+			return
+		}
+
+		matches, err := store.Customers.Search(ctx, q, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
 		}
 
 		c.JSON(http.StatusOK,
-			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": customers}},
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": matches}},
 		)
 	}
 }
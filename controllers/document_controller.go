@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"appadming/models"
+	"appadming/printing"
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// printDocument renders the document gather returns in the format and paper
+// width of the query, PDF on 80mm paper unless asked otherwise
+func printDocument(c *gin.Context, name string, gather func(ctx context.Context) (models.Document, error)) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+	defer cancel()
+
+	format := c.DefaultQuery("format", printing.PDF)
+	paper, err := strconv.Atoi(c.DefaultQuery("paper", strconv.Itoa(printing.Paper80)))
+	if err != nil {
+		err = fmt.Errorf("%w: paper must be a width in mm", printing.ErrUnsupportedFormat)
+	}
+
+	var doc models.Document
+	if err == nil {
+		doc, err = gather(ctx)
+	}
+	// render first so that failures still get a JSON error
+	var out bytes.Buffer
+	if err == nil {
+		err = printing.Render(&out, doc, format, paper)
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, doc.Number, printing.Extension(format))
+	disposition := "inline"
+	if format != printing.PDF {
+		disposition = "attachment"
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filename}))
+	c.Data(http.StatusOK, printing.ContentType(format), out.Bytes())
+}
+
+func GetASellInvoice(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objId, _ := primitive.ObjectIDFromHex(c.Param("sellsId"))
+		printDocument(c, "invoice", func(ctx context.Context) (models.Document, error) {
+			return services.SellInvoice(ctx, store, objId)
+		})
+	}
+}
+
+func GetAHistoryReceipt(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objId, _ := primitive.ObjectIDFromHex(c.Param("historyId"))
+		printDocument(c, "receipt", func(ctx context.Context) (models.Document, error) {
+			return services.HistoryReceipt(ctx, store, objId)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"appadming/blobstore"
+	"appadming/imaging"
+	"appadming/printing"
+	"appadming/repositories"
+	"appadming/services"
+	"appadming/spreadsheet"
+	"errors"
+	"net/http"
+)
+
+// errorStatus maps a repository error to the HTTP status returned to the client
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound), errors.Is(err, blobstore.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrInsufficientStock):
+		return http.StatusConflict
+	case errors.Is(err, repositories.ErrInvalidQuery), errors.Is(err, spreadsheet.ErrUnsupportedFormat),
+		errors.Is(err, printing.ErrUnsupportedFormat), errors.Is(err, printing.ErrInvalidLogo),
+		errors.Is(err, imaging.ErrInvalidImage):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrInvalidSell), errors.Is(err, services.ErrInvalidPlan),
+		errors.Is(err, services.ErrInvalidInvitation), errors.Is(err, services.ErrInvalidImport),
+		errors.Is(err, services.ErrInvalidReturn), errors.Is(err, services.ErrInvalidMovement),
+		errors.Is(err, services.ErrInvalidPurchase), errors.Is(err, services.ErrInvalidReport),
+		errors.Is(err, services.ErrInvalidCatalogue), errors.Is(err, services.ErrInvalidSerial),
+		errors.Is(err, services.ErrInvalidClaim), errors.Is(err, services.ErrInvalidAttachment),
+		errors.Is(err, services.ErrInvalidHistory):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrPlanExists), errors.Is(err, services.ErrLastOwner), errors.Is(err, services.ErrSellReversed),
+		errors.Is(err, services.ErrPurchaseClosed), errors.Is(err, services.ErrSupplierOwed),
+		errors.Is(err, services.ErrCatalogueInUse), errors.Is(err, services.ErrDuplicateSku),
+		errors.Is(err, services.ErrWarrantyExpired), errors.Is(err, services.ErrClaimOpen),
+		errors.Is(err, services.ErrDuplicateGuarantor), errors.Is(err, services.ErrGuarantorInUse),
+		errors.Is(err, services.ErrCustomerInUse),
+		errors.Is(err, services.ErrHistoryLocked), errors.Is(err, services.ErrSellLocked):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	helper "appadming/helpers"
+	"appadming/models"
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"appadming/spreadsheet"
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// exportFormat picks the format from the format query parameter or else the
+// Accept header, defaulting to CSV
+func exportFormat(c *gin.Context) (string, error) {
+	if format := c.Query("format"); format != "" {
+		if !spreadsheet.Writable(format) {
+			return "", fmt.Errorf("%w: %q", spreadsheet.ErrUnsupportedFormat, format)
+		}
+		return format, nil
+	}
+	for _, accepted := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		if format, ok := spreadsheet.FormatFor(mediaType); ok {
+			return format, nil
+		}
+	}
+	return spreadsheet.CSV, nil
+}
+
+// exportContext scopes ctx to the organization query parameter, which may
+// name any organization where the caller's role grants permission
+func exportContext(ctx context.Context, c *gin.Context, store *repositories.Store, permission string) (context.Context, error) {
+	organizationId := c.Query("organization")
+	if organizationId == "" {
+		return ctx, nil
+	}
+	objId, err := primitive.ObjectIDFromHex(organizationId)
+	if err != nil {
+		return ctx, fmt.Errorf("%w: organization must be an id", repositories.ErrInvalidQuery)
+	}
+	role, err := services.MemberRole(ctx, store, c.GetString("uid"), objId)
+	if err != nil {
+		return ctx, err
+	}
+	if !helper.HasPermission(role, permission) {
+		return ctx, repositories.ErrNotFound
+	}
+	return repositories.WithOrganization(ctx, objId), nil
+}
+
+// streamExport writes header and then one row per document as they are read
+// from the store, so that large exports never sit in memory
+func streamExport[T any](c *gin.Context, format string, name string, header []string, stream func(fn func(T) error) error, row func(T) []string) {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("2006-01-02"), format)
+	c.Header("Content-Type", spreadsheet.ContentType(format))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Status(http.StatusOK)
+
+	writer, err := spreadsheet.NewWriter(c.Writer, format, name)
+	if err == nil {
+		err = writer.Write(header)
+	}
+	if err == nil {
+		err = stream(func(doc T) error {
+			values := row(doc)
+			if format == spreadsheet.PDF {
+				// the PDF fonts only cover Latin text
+				for i, value := range values {
+					values[i] = repositories.Transliterate(value)
+				}
+			}
+			return writer.Write(values)
+		})
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// the status is already sent; cut the download short
+		log.Printf("export of %s failed: %v", name, err)
+		c.Abort()
+	}
+}
+
+// exportRequest reads the format, organization and filters shared by every export
+func exportRequest(c *gin.Context, store *repositories.Store, ctx context.Context, permission string, fields repositories.ListFields) (context.Context, string, repositories.ListQuery, bool) {
+	format, err := exportFormat(c)
+	if err == nil {
+		ctx, err = exportContext(ctx, c, store, permission)
+	}
+	var query repositories.ListQuery
+	if err == nil {
+		query, err = parseListQuery(c, fields)
+	}
+	if err != nil {
+		status := errorStatus(err)
+		c.JSON(status, responses.CommonResponse{Status: status, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+		return ctx, "", query, false
+	}
+	return ctx, format, query, true
+}
+
+// customerNames looks customers up once per export
+func customerNames(ctx context.Context, store *repositories.Store) func(id primitive.ObjectID) string {
+	names := map[primitive.ObjectID]string{}
+	return func(id primitive.ObjectID) string {
+		name, ok := names[id]
+		if !ok {
+			if customer, err := store.Customers.FindByID(ctx, id); err == nil {
+				name = customer.Name
+			}
+			names[id] = name
+		}
+		return name
+	}
+}
+
+func exportDate(date primitive.DateTime) string {
+	if date == 0 {
+		return ""
+	}
+	return date.Time().UTC().Format("2006-01-02")
+}
+
+func ExportSells(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		ctx, format, query, ok := exportRequest(c, store, ctx, helper.SellRead, repositories.SellInfoFields)
+		if !ok {
+			return
+		}
+		if len(query.Sort) == 0 {
+			query.Sort = []repositories.SortField{{Key: "date"}}
+		}
+		customerName := customerNames(ctx, store)
+
+		header := []string{"id", "date", "customer_id", "customer", "items", "amount", "paid", "due"}
+		streamExport(c, format, "sells", header, func(fn func(models.SellInfo) error) error {
+			return store.Sells.Stream(ctx, query, fn)
+		}, func(sell models.SellInfo) []string {
+			items := make([]string, len(sell.Lines))
+			for i, line := range sell.Lines {
+				items[i] = fmt.Sprintf("%s x%d", line.Model, line.Quantity)
+			}
+			return []string{
+				sell.Id.Hex(), exportDate(sell.Date), sell.Customer_id.Hex(), customerName(sell.Customer_id),
+				strings.Join(items, "; "), strconv.Itoa(sell.Amount), strconv.Itoa(sell.Paid), strconv.Itoa(sell.Amount - sell.Paid),
+			}
+		})
+	}
+}
+
+func ExportHistorys(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		ctx, format, query, ok := exportRequest(c, store, ctx, helper.HistoryRead, repositories.HistoryFields)
+		if !ok {
+			return
+		}
+		if len(query.Sort) == 0 {
+			query.Sort = []repositories.SortField{{Key: "date"}}
+		}
+		customerName := customerNames(ctx, store)
+
+		header := []string{"id", "date", "type", "customer_id", "customer", "sell_id", "due", "paid", "amount", "note"}
+		streamExport(c, format, "historys", header, func(fn func(models.History) error) error {
+			return store.Historys.Stream(ctx, query, fn)
+		}, func(entry models.History) []string {
+			sellId := ""
+			if !entry.Sell_id.IsZero() {
+				sellId = entry.Sell_id.Hex()
+			}
+			return []string{
+				entry.Id.Hex(), exportDate(entry.Date), entry.Type, entry.Customer_id.Hex(), customerName(entry.Customer_id), sellId,
+				strconv.Itoa(entry.Due), strconv.Itoa(entry.Paid), strconv.Itoa(services.EntryAmount(entry)), entry.Note,
+			}
+		})
+	}
+}
+
+func ExportCustomers(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		ctx, format, query, ok := exportRequest(c, store, ctx, helper.CustomerRead, repositories.CustomerFields)
+		if !ok {
+			return
+		}
+		if len(query.Sort) == 0 {
+			query.Sort = []repositories.SortField{{Key: "name"}}
+		}
+
+		header := []string{"id", "name", "father", "home", "village", "thana", "district", "phone", "email", "due_date"}
+		streamExport(c, format, "customers", header, func(fn func(models.Customer) error) error {
+			return store.Customers.Stream(ctx, query, fn)
+		}, func(customer models.Customer) []string {
+			return []string{
+				customer.Id.Hex(), customer.Name, customer.Father, customer.Home, customer.Village, customer.Thana,
+				customer.District, strconv.Itoa(customer.Phone), customer.Email, exportDate(customer.DueDate),
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"appadming/spreadsheet"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// exportRouter serves ExportCustomers to user, signed in to organizationId
+func exportRouter(store *repositories.Store, organizationId primitive.ObjectID, user string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/export/customers", func(c *gin.Context) {
+		c.Set("uid", user)
+		c.Request = c.Request.WithContext(repositories.WithOrganization(c.Request.Context(), organizationId))
+	}, ExportCustomers(store))
+	return router
+}
+
+func TestExportCustomers(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	shop, branch, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	add := func(organizationId primitive.ObjectID, name string, district string, phone int) {
+		ctx := repositories.WithOrganization(context.Background(), organizationId)
+		customer := models.Customer{Id: primitive.NewObjectID(), Name: name, District: district, Phone: phone}
+		if err := store.Customers.Create(ctx, &customer); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add(shop, "Salam", "Dhaka", 1711)
+	add(shop, "Karim", "Dhaka", 1811)
+	add(shop, "Babul", "Khulna", 1911)
+	add(branch, "Rahim", "Dhaka", 1611)
+	add(other, "Jalal", "Dhaka", 1511)
+	membership := models.Membership{Id: primitive.NewObjectID(), User_id: "u1", Organization_id: branch, Role: models.RoleAuditor}
+	if err := store.Memberships.Create(repositories.WithOrganization(context.Background(), branch), &membership); err != nil {
+		t.Fatal(err)
+	}
+	router := exportRouter(store, shop, "u1")
+
+	tests := []struct {
+		name   string
+		url    string
+		accept string
+		status int
+		format string
+		names  []string
+	}{
+		{"CSV by default, by name", "/export/customers", "", http.StatusOK, spreadsheet.CSV, []string{"Babul", "Karim", "Salam"}},
+		{"filtered and sorted", "/export/customers?district=Dhaka&sort=-phone", "", http.StatusOK, spreadsheet.CSV, []string{"Karim", "Salam"}},
+		{"XLSX from the Accept header", "/export/customers", "text/html, " + spreadsheet.ContentType(spreadsheet.XLSX), http.StatusOK, spreadsheet.XLSX, []string{"Babul", "Karim", "Salam"}},
+		{"another organization of the caller", "/export/customers?organization=" + branch.Hex(), "", http.StatusOK, spreadsheet.CSV, []string{"Rahim"}},
+		{"organization the caller is not a member of", "/export/customers?organization=" + other.Hex(), "", http.StatusNotFound, "", nil},
+		{"unsupported format", "/export/customers?format=docx", "", http.StatusBadRequest, "", nil},
+		{"unknown sort", "/export/customers?sort=age", "", http.StatusBadRequest, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.accept != "" {
+				request.Header.Set("Accept", tt.accept)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.status, recorder.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := recorder.Header().Get("Content-Type"); got != spreadsheet.ContentType(tt.format) {
+				t.Errorf("content type = %q", got)
+			}
+			if got := recorder.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment; filename=customers-") || !strings.HasSuffix(got, "."+tt.format) {
+				t.Errorf("content disposition = %q", got)
+			}
+			rows, err := spreadsheet.ReadAll(recorder.Body.Bytes(), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rows[0][1] != "name" || rows[0][7] != "phone" {
+				t.Errorf("header = %v", rows[0])
+			}
+			names := []string{}
+			for _, row := range rows[1:] {
+				names = append(names, row[1])
+			}
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("names = %v, want %v", names, tt.names)
+			}
+		})
+	}
+}
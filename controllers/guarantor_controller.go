@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var guarantorValidate = validator.New()
+
+func CreateGuarantor(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		var guarantor models.Guarantor
+		defer cancel()
+
+		//validate the request body
+		if err := c.BindJSON(&guarantor); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := guarantorValidate.Struct(&guarantor); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		newGuarantor, err := services.CreateGuarantor(ctx, store, guarantor)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newGuarantor}})
+	}
+}
+
+func GetAGuarantor(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(c.Param("guarantorId"))
+
+		guarantor, err := store.Guarantors.FindByID(ctx, objId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": guarantor}})
+	}
+}
+
+func EditAGuarantor(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		var guarantor models.Guarantor
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(c.Param("guarantorId"))
+
+		//validate the request body
+		if err := c.BindJSON(&guarantor); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := guarantorValidate.Struct(&guarantor); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		updated, err := services.UpdateGuarantor(ctx, store, objId, guarantor)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updated}})
+	}
+}
+
+// DeleteAGuarantor deletes a guarantor who no longer answers for any customer or sale
+func DeleteAGuarantor(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(c.Param("guarantorId"))
+
+		if err := services.DeleteGuarantor(ctx, store, objId); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "guarantor successfully deleted!"}})
+	}
+}
+
+func GetAllGuarantors(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		query, err := parseListQuery(c, repositories.GuarantorFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		guarantors, err := store.Guarantors.List(ctx, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(guarantors)})
+	}
+}
+
+// GetAGuarantorExposure returns every balance the guarantor answers for, as
+// of ?as_of= or now
+func GetAGuarantorExposure(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(c.Param("guarantorId"))
+
+		asOf, err := parseDateQuery(c, "as_of", time.Now(), true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		exposure, err := services.GuarantorExposure(ctx, store, objId, asOf)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": exposure}})
+	}
+}
+
+// GetGuarantorExposureReport lists what every guarantor answers for, largest first
+func GetGuarantorExposureReport(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		asOf, err := parseDateQuery(c, "as_of", time.Now(), true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		report, err := services.GuarantorExposureReport(ctx, store, asOf)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": report}})
+	}
+}
+
+// LinkACustomerGuarantor makes the guarantor in the body answer for the customer
+func LinkACustomerGuarantor(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		var link models.GuarantorLink
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(c.Param("customerId"))
+
+		//validate the request body
+		if err := c.BindJSON(&link); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := guarantorValidate.Struct(&link); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		guarantor, err := services.LinkGuarantor(ctx, store, objId, link.Guarantor_id)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": guarantor}})
+	}
+}
+
+func UnlinkACustomerGuarantor(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		customerId, _ := primitive.ObjectIDFromHex(c.Param("customerId"))
+		guarantorId, _ := primitive.ObjectIDFromHex(c.Param("guarantorId"))
+
+		guarantor, err := services.UnlinkGuarantor(ctx, store, customerId, guarantorId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": guarantor}})
+	}
+}
+
+func GetACustomerGuarantors(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(c.Param("customerId"))
+
+		guarantors, err := services.CustomerGuarantors(ctx, store, objId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": guarantors}})
+	}
+}
+
+// SetASellGuarantor names the guarantor in the body on the sale
+func SetASellGuarantor(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		var link models.GuarantorLink
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(c.Param("sellsId"))
+
+		//validate the request body
+		if err := c.BindJSON(&link); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := guarantorValidate.Struct(&link); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		sell, err := services.SetSellGuarantor(ctx, store, objId, link.Guarantor_id)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": sell}})
+	}
+}
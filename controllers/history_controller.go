@@ -1,26 +1,24 @@
 package controllers
 
 import (
-	"appadming/configs"
 	"appadming/models"
+	"appadming/repositories"
 	"appadming/responses"
+	"appadming/services"
 	"context"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var historyCollection *mongo.Collection = configs.GetCollection(configs.DB, "historys")
 var historyValidate = validator.New()
 
-func CreateHistory() gin.HandlerFunc {
+func CreateHistory(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		var history models.History
 		defer cancel()
 
@@ -36,37 +34,42 @@ func CreateHistory() gin.HandlerFunc {
 			return
 		}
 
+		if history.Type == "" {
+			history.Type = models.HistoryPayment
+		}
+
 		newHistory := models.History{
 			Id:          primitive.NewObjectID(),
+			Type:        history.Type,
 			Due:         history.Due,
 			Paid:        history.Paid,
 			Date:        history.Date,
 			Customer_id: history.Customer_id,
 			Seller_id:   history.Seller_id,
+			Sell_id:     history.Sell_id,
+			Note:        history.Note,
 		}
 
-		result, err := historyCollection.InsertOne(ctx, newHistory)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+		if err := services.CreateHistory(ctx, store, &newHistory); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": result}})
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newHistory}})
 	}
 }
 
-func GetAHistory() gin.HandlerFunc {
+func GetAHistory(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		historyId := c.Param("historyId")
-		var history models.History
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(historyId)
 
-		err := historyCollection.FindOne(ctx, bson.M{"id": objId}).Decode(&history)
+		history, err := store.Historys.FindByID(ctx, objId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
@@ -74,9 +77,9 @@ func GetAHistory() gin.HandlerFunc {
 	}
 }
 
-func EditAHistory() gin.HandlerFunc {
+func EditAHistory(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		historyId := c.Param("historyId")
 		var history models.History
 		defer cancel()
@@ -94,51 +97,27 @@ func EditAHistory() gin.HandlerFunc {
 			return
 		}
 
-		update := bson.M{
-			"Due":         history.Due,
-			"Paid":        history.Paid,
-			"Date":        history.Date,
-			"Customer_id": history.Customer_id,
-			"seller_id":   history.Seller_id,
-		}
-		result, err := historyCollection.UpdateOne(ctx, bson.M{"id": objId}, bson.M{"$set": update})
+		updatedHistory, err := services.EditHistory(ctx, store, objId, history)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		//get updated history details
-		var updatedHistory models.History
-		if result.MatchedCount == 1 {
-			err := historyCollection.FindOne(ctx, bson.M{"id": objId}).Decode(&updatedHistory)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-				return
-			}
-		}
-
 		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedHistory}})
 	}
 }
 
-func DeleteAHistory() gin.HandlerFunc {
+// DeleteAHistory deletes a manual adjustment or write-off; anything else is voided
+func DeleteAHistory(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		historyId := c.Param("historyId")
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(historyId)
 
-		result, err := historyCollection.DeleteOne(ctx, bson.M{"id": objId})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			return
-		}
-
-		if result.DeletedCount < 1 {
-			c.JSON(http.StatusNotFound,
-				responses.CommonResponse{Status: http.StatusNotFound, Message: "error", Data: map[string]interface{}{"data": "history with specified ID not found!"}},
-			)
+		if err := services.DeleteHistory(ctx, store, objId); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
@@ -148,32 +127,56 @@ func DeleteAHistory() gin.HandlerFunc {
 	}
 }
 
-func GetAllHistorys() gin.HandlerFunc {
+// VoidAHistory takes back a payment, adjustment or write-off with an entry
+// the other way, giving the reason in the body
+func VoidAHistory(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-		var historys []models.History
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		var request models.HistoryVoidRequest
 		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(c.Param("historyId"))
+
+		//validate the request body
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
 
-		results, err := historyCollection.Find(ctx, bson.M{})
+		//use the validator library to validate required fields
+		if validationErr := historyValidate.Struct(&request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
 
+		void, err := services.VoidHistory(ctx, store, objId, request.Reason)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		//reading from the db in an optimal way
-		defer results.Close(ctx)
-		for results.Next(ctx) {
-			var singleHistory models.History
-			if err = results.Decode(&singleHistory); err != nil {
-				c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			}
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": void}})
+	}
+}
+
+func GetAllHistorys(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		query, err := parseListQuery(c, repositories.HistoryFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
 
-			historys = append(historys, singleHistory)
+		historys, err := store.Historys.List(ctx, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
 		}
 
 		c.JSON(http.StatusOK,
-			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": historys}},
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(historys)},
 		)
 	}
 }
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"appadming/spreadsheet"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxImportSize bounds the size of an uploaded import file
+const maxImportSize = 10 << 20
+
+// importFunc validates and optionally stores the rows of an import
+type importFunc func(ctx context.Context, store *repositories.Store, rows [][]string, mapping map[string]string, dryRun bool) (models.ImportReport, error)
+
+// importRows reads the uploaded file of an import request and runs it. The
+// multipart form carries the CSV or XLSX file, an optional JSON column
+// mapping from field to column title and mode=commit to store the rows,
+// which are otherwise only checked.
+func importRows(store *repositories.Store, run importFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": "file is required"}})
+			return
+		}
+		format := c.DefaultPostForm("format", spreadsheet.FormatOf(header.Filename))
+
+		var mapping map[string]string
+		if raw := c.PostForm("mapping"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+				c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": "mapping must be a JSON object"}})
+				return
+			}
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		defer file.Close()
+		data, err := spreadsheet.ReadLimited(file, maxImportSize)
+		if err != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, responses.CommonResponse{Status: http.StatusRequestEntityTooLarge, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		rows, err := spreadsheet.ReadAll(data, format)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		dryRun := c.DefaultPostForm("mode", c.DefaultQuery("mode", "dry_run")) != "commit"
+		report, err := run(ctx, store, rows, mapping, dryRun)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		status := http.StatusOK
+		if report.Created > 0 {
+			status = http.StatusCreated
+		}
+		c.JSON(status, responses.CommonResponse{Status: status, Message: "success", Data: map[string]interface{}{"data": report}})
+	}
+}
+
+func ImportCustomers(store *repositories.Store) gin.HandlerFunc {
+	return importRows(store, services.ImportCustomers)
+}
+
+func ImportProducts(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+		importRows(store, func(ctx context.Context, store *repositories.Store, rows [][]string, mapping map[string]string, dryRun bool) (models.ImportReport, error) {
+			return services.ImportProducts(ctx, store, rows, mapping, dryRun, userId)
+		})(c)
+	}
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var planValidate = validator.New()
+
+func CreateASellPlan(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		sellId := c.Param("sellsId")
+		var plan models.InstallmentPlan
+		defer cancel()
+
+		objId, _ := primitive.ObjectIDFromHex(sellId)
+
+		//validate the request body
+		if err := c.BindJSON(&plan); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := planValidate.Struct(&plan); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		newPlan, err := services.CreateInstallmentPlan(ctx, store, objId, plan)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newPlan}})
+	}
+}
+
+func GetASellPlan(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		sellId := c.Param("sellsId")
+		defer cancel()
+
+		objId, _ := primitive.ObjectIDFromHex(sellId)
+
+		plan, err := services.GetInstallmentPlan(ctx, store, objId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": plan}})
+	}
+}
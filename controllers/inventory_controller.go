@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	helper "appadming/helpers"
+	"appadming/models"
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var inventoryValidate = validator.New()
+
+func GetAProductMovements(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		productId := c.Param("productId")
+		defer cancel()
+
+		objId, _ := primitive.ObjectIDFromHex(productId)
+
+		query, err := parseListQuery(c, repositories.StockMovementFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		// the product must be visible to the caller before its journal is
+		if _, err := store.Products.FindByID(ctx, objId); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		movements, err := store.Movements.ListByProduct(ctx, objId, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(movements)},
+		)
+	}
+}
+
+func CreateAProductMovement(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		productId := c.Param("productId")
+		var movement models.StockMovement
+		defer cancel()
+
+		//validate the request body
+		if err := c.BindJSON(&movement); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := inventoryValidate.Struct(&movement); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		objId, _ := primitive.ObjectIDFromHex(productId)
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+
+		newMovement, err := services.RecordMovement(ctx, store, objId, movement, userId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newMovement}})
+	}
+}
+
+// CountStock reconciles a physical count with the stock. Like imports the
+// count is only checked unless mode=commit, which posts the differences.
+func CountStock(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		var count models.StockCount
+		defer cancel()
+
+		//validate the request body
+		if err := c.BindJSON(&count); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := inventoryValidate.Struct(&count); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+		dryRun := c.DefaultQuery("mode", "dry_run") != "commit"
+
+		report, err := services.CountStock(ctx, store, count, userId, dryRun)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		status := http.StatusOK
+		if !dryRun {
+			status = http.StatusCreated
+		}
+		c.JSON(status, responses.CommonResponse{Status: status, Message: "success", Data: map[string]interface{}{"data": report}})
+	}
+}
+
+func TransferAProductStock(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		productId := c.Param("productId")
+		var transfer models.StockTransfer
+		defer cancel()
+
+		//validate the request body
+		if err := c.BindJSON(&transfer); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := inventoryValidate.Struct(&transfer); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		// the destination is hidden unless the caller may adjust its stock too
+		role, err := services.MemberRole(ctx, store, c.GetString("uid"), transfer.Organization_id)
+		if err == nil && !helper.HasPermission(role, helper.StockAdjust) {
+			err = repositories.ErrNotFound
+		}
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		objId, _ := primitive.ObjectIDFromHex(productId)
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+
+		movements, err := services.TransferStock(ctx, store, objId, transfer, userId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": movements}})
+	}
+}
+
+// GetReorderSuggestions lists the products flagged by the last refresh of the
+// reorder suggestions
+func GetReorderSuggestions(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		query, err := parseListQuery(c, repositories.ReorderSuggestionFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		suggestions, err := store.Reorders.List(ctx, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(suggestions)},
+		)
+	}
+}
+
+// RefreshReorderSuggestions recomputes the reorder suggestions without
+// waiting for the background job
+func RefreshReorderSuggestions(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		suggestions, err := services.RefreshReorderSuggestions(ctx, store, time.Now())
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": suggestions}})
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// parseDateQuery reads a YYYY-MM-DD or RFC3339 query parameter. A bare date
+// used as the end of a range covers the whole day.
+func parseDateQuery(c *gin.Context, key string, fallback time.Time, endOfDay bool) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return fallback, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			return date.Add(24*time.Hour - time.Millisecond), nil
+		}
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func GetACustomerStatement(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		customerId := c.Param("customerId")
+		defer cancel()
+
+		objId, _ := primitive.ObjectIDFromHex(customerId)
+
+		from, err := parseDateQuery(c, "from", time.Time{}, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		to, err := parseDateQuery(c, "to", time.Now(), true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		statement, err := services.CustomerStatement(ctx, store, objId, from, to)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": statement}})
+	}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"appadming/repositories"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// listBound is the query parameter comparing a list field with op
+type listBound struct {
+	param string
+	op    string
+}
+
+// parseListQuery reads the pagination, sort and filters of a list request.
+// Every whitelisted field can be matched exactly with field=value, number and
+// date fields also take the field_from and field_to bounds, and sort takes a
+// comma separated list of fields, each prefixed with - for descending order.
+func parseListQuery(c *gin.Context, fields repositories.ListFields) (repositories.ListQuery, error) {
+	var query repositories.ListQuery
+	var err error
+
+	if page := c.Query("page"); page != "" {
+		if query.Page, err = strconv.Atoi(page); err != nil {
+			return query, fmt.Errorf("%w: page must be a number", repositories.ErrInvalidQuery)
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return query, fmt.Errorf("%w: limit must be a number", repositories.ErrInvalidQuery)
+		}
+	}
+	query.Cursor = c.Query("cursor")
+
+	if sort := c.Query("sort"); sort != "" {
+		for _, name := range strings.Split(sort, ",") {
+			name = strings.TrimSpace(name)
+			desc := strings.HasPrefix(name, "-")
+			field, ok := fields[strings.TrimPrefix(name, "-")]
+			if !ok {
+				return query, fmt.Errorf("%w: cannot sort on %s", repositories.ErrInvalidQuery, name)
+			}
+			query.Sort = append(query.Sort, repositories.SortField{Key: field.Key, Desc: desc})
+		}
+	}
+
+	for name, field := range fields {
+		bounds := []listBound{{name, repositories.OpEq}}
+		if field.Kind == repositories.NumberField || field.Kind == repositories.TimeField {
+			bounds = append(bounds, listBound{name + "_from", repositories.OpGte}, listBound{name + "_to", repositories.OpLte})
+		}
+		for _, bound := range bounds {
+			raw, ok := c.GetQuery(bound.param)
+			if !ok {
+				continue
+			}
+			value, err := parseFieldValue(field.Kind, raw, bound.op == repositories.OpLte)
+			if err != nil {
+				return query, fmt.Errorf("%w: %s: %v", repositories.ErrInvalidQuery, bound.param, err)
+			}
+			query.Filters = append(query.Filters, repositories.Filter{Key: field.Key, Op: bound.op, Value: value})
+		}
+	}
+	return query, nil
+}
+
+// parseFieldValue converts a query string value to the type stored for kind.
+// Dates without a time cover the whole day when they are an upper bound.
+func parseFieldValue(kind repositories.FieldKind, raw string, upper bool) (interface{}, error) {
+	switch kind {
+	case repositories.NumberField:
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return n, nil
+		}
+		return strconv.ParseFloat(raw, 64)
+	case repositories.TimeField:
+		if date, err := time.Parse("2006-01-02", raw); err == nil {
+			if upper {
+				return date.Add(24*time.Hour - time.Millisecond), nil
+			}
+			return date, nil
+		}
+		return time.Parse(time.RFC3339, raw)
+	case repositories.IDField:
+		return primitive.ObjectIDFromHex(raw)
+	}
+	return raw, nil
+}
+
+// listData is the response envelope of a page of a collection
+func listData[T any](result repositories.ListResult[T]) map[string]interface{} {
+	return map[string]interface{}{
+		"data":        result.Items,
+		"total_count": result.Total,
+		"page":        result.Page,
+		"limit":       result.Limit,
+		"next_cursor": result.NextCursor,
+	}
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"appadming/repositories"
+	"appadming/responses"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetMyNotifications lists the notifications of the caller in the current
+// organization, only the unread ones with ?unread=true
+func GetMyNotifications(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		query, err := parseListQuery(c, repositories.NotificationFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		notifications, err := store.Notifications.ListForUser(ctx, c.GetString("uid"), c.Query("unread") == "true", query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(notifications)},
+		)
+	}
+}
+
+func ReadANotification(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		notificationId := c.Param("notificationId")
+		defer cancel()
+
+		objId, _ := primitive.ObjectIDFromHex(notificationId)
+
+		notification, err := store.Notifications.MarkRead(ctx, objId, c.GetString("uid"), time.Now())
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": notification}})
+	}
+}
@@ -1,26 +1,45 @@
 package controllers
 
 import (
-	"appadming/configs"
 	"appadming/models"
+	"appadming/printing"
+	"appadming/repositories"
 	"appadming/responses"
+	"appadming/services"
+	"appadming/spreadsheet"
 	"context"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var organizationCollection *mongo.Collection = configs.GetCollection(configs.DB, "organizations")
 var organizationValidate = validator.New()
 
-func CreateOrganization() gin.HandlerFunc {
+// activeOrganization is the organization the caller's token is scoped to
+func activeOrganization(c *gin.Context) primitive.ObjectID {
+	organizationId, _ := primitive.ObjectIDFromHex(c.GetString("org_id"))
+	return organizationId
+}
+
+// switchOrganization makes organizationId the caller's active organization and reissues their tokens
+func switchOrganization(c *gin.Context, store *repositories.Store, organizationId primitive.ObjectID) error {
+	ctx := c.Request.Context()
+	if _, err := services.SwitchOrganization(ctx, store, c.GetString("uid"), organizationId); err != nil {
+		return err
+	}
+	user, err := store.Users.FindByUserID(repositories.Unscoped(ctx), c.GetString("uid"))
+	if err != nil {
+		return err
+	}
+	return issueTokens(c, store, user)
+}
+
+func CreateOrganization(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		var organization models.Orgnization
 		defer cancel()
 
@@ -35,55 +54,72 @@ func CreateOrganization() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
 			return
 		}
-		newOrganization := models.Orgnization{
-			Id:          organization.Id,
-			Name:        organization.Name,
-			Address:     organization.Address,
-			Phone:       organization.Phone,
-			Email:       organization.Email,
-			Created_at:  time.Time{},
-			User_id:     organization.User_id,
-			Designation: organization.Designation,
-		}
-		newOrganization.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		result, err := organizationCollection.InsertOne(ctx, newOrganization)
+		newOrganization, err := services.CreateOrganization(ctx, store, organization, c.GetString("uid"))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": result}})
+		// a user without an organization starts working in the one they created
+		if activeOrganization(c).IsZero() {
+			if err := switchOrganization(c, store, newOrganization.Id); err != nil {
+				c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+				return
+			}
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newOrganization}})
 	}
 }
 
-func GetAOrganization() gin.HandlerFunc {
+func GetAOrganization(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		organizationId := c.Param("organizationId")
-		var organization models.Orgnization
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(organizationId)
 
-		err := organizationCollection.FindOne(ctx, bson.M{"id": objId}).Decode(&organization)
+		member, err := services.IsMember(ctx, store, c.GetString("uid"), objId)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
+		if !member {
+			c.JSON(http.StatusNotFound, responses.CommonResponse{Status: http.StatusNotFound, Message: "error", Data: map[string]interface{}{"data": repositories.ErrNotFound.Error()}})
+			return
+		}
+
+		organization, err := store.Organizations.FindByID(ctx, objId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
 
 		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": organization}})
 	}
 }
 
-func EditAOrganization() gin.HandlerFunc {
+func EditAOrganization(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		organizationId := c.Param("organizationId")
-		var organization models.Orgnization
 		defer cancel()
 		objId, _ := primitive.ObjectIDFromHex(organizationId)
 
+		if objId != activeOrganization(c) {
+			c.JSON(http.StatusNotFound, responses.CommonResponse{Status: http.StatusNotFound, Message: "error", Data: map[string]interface{}{"data": repositories.ErrNotFound.Error()}})
+			return
+		}
+
+		// the body is laid over the stored organization, fields it leaves out keep their value
+		organization, err := store.Organizations.FindByID(ctx, objId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
 		//validate the request body
 		if err := c.BindJSON(&organization); err != nil {
 			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
@@ -96,55 +132,38 @@ func EditAOrganization() gin.HandlerFunc {
 			return
 		}
 
-		update := bson.M{
-			"Id":          organization.Id,
-			"Name":        organization.Name,
-			"Address":     organization.Address,
-			"Phone":       organization.Phone,
-			"Email":       organization.Email,
-			"User_id":     organization.User_id,
-			"Designation": organization.Designation,
-		}
-		result, err := organizationCollection.UpdateOne(ctx, bson.M{"id": objId}, bson.M{"$set": update})
+		updatedOrganization, err := store.Organizations.Update(ctx, objId, organization)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		//get updated organization details
-		var updatedOrganization models.Orgnization
-		if result.MatchedCount == 1 {
-			err := organizationCollection.FindOne(ctx, bson.M{"id": objId}).Decode(&updatedOrganization)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-				return
-			}
-		}
-
 		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedOrganization}})
 	}
 }
 
-func DeleteAOrganization() gin.HandlerFunc {
+func DeleteAOrganization(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		organizationId := c.Param("organizationId")
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(organizationId)
 
-		result, err := organizationCollection.DeleteOne(ctx, bson.M{"id": objId})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			return
+		err := repositories.ErrNotFound
+		if objId == activeOrganization(c) {
+			err = store.Organizations.Delete(ctx, objId)
 		}
-
-		if result.DeletedCount < 1 {
+		if err == repositories.ErrNotFound {
 			c.JSON(http.StatusNotFound,
 				responses.CommonResponse{Status: http.StatusNotFound, Message: "error", Data: map[string]interface{}{"data": "organization with specified ID not found!"}},
 			)
 			return
 		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
 
 		c.JSON(http.StatusOK,
 			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "organization successfully deleted!"}},
@@ -152,32 +171,184 @@ func DeleteAOrganization() gin.HandlerFunc {
 	}
 }
 
-func GetAllOrganizations() gin.HandlerFunc {
+func GetAllOrganizations(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		organizations, err := services.UserOrganizations(ctx, store, c.GetString("uid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": organizations}},
+		)
+	}
+}
+
+func SwitchAOrganization(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationId := c.Param("organizationId")
+		objId, _ := primitive.ObjectIDFromHex(organizationId)
+
+		if err := switchOrganization(c, store, objId); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "organization successfully switched!"}})
+	}
+}
+
+func CreateAInvitation(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-		var organizations []models.Orgnization
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		organizationId := c.Param("organizationId")
+		var invitation models.Invitation
 		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(organizationId)
 
-		results, err := organizationCollection.Find(ctx, bson.M{})
+		if objId != activeOrganization(c) {
+			c.JSON(http.StatusNotFound, responses.CommonResponse{Status: http.StatusNotFound, Message: "error", Data: map[string]interface{}{"data": repositories.ErrNotFound.Error()}})
+			return
+		}
 
+		//validate the request body
+		if err := c.BindJSON(&invitation); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := organizationValidate.Struct(&invitation); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		newInvitation, err := services.CreateInvitation(ctx, store, invitation, c.GetString("uid"))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		//reading from the db in an optimal way
-		defer results.Close(ctx)
-		for results.Next(ctx) {
-			var singleOrganization models.Orgnization
-			if err = results.Decode(&singleOrganization); err != nil {
-				c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			}
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newInvitation}})
+	}
+}
+
+func GetAllInvitations(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		organizationId := c.Param("organizationId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(organizationId)
 
-			organizations = append(organizations, singleOrganization)
+		if objId != activeOrganization(c) {
+			c.JSON(http.StatusNotFound, responses.CommonResponse{Status: http.StatusNotFound, Message: "error", Data: map[string]interface{}{"data": repositories.ErrNotFound.Error()}})
+			return
+		}
+
+		query, err := parseListQuery(c, repositories.InvitationFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		invitations, err := store.Invitations.List(ctx, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
 		}
 
 		c.JSON(http.StatusOK,
-			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": organizations}},
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(invitations)},
 		)
 	}
 }
+
+// UploadAOrganizationLogo replaces the logo printed on invoices and receipts
+// with the PNG or JPEG image of the multipart logo field
+func UploadAOrganizationLogo(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		organizationId := c.Param("organizationId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(organizationId)
+
+		if objId != activeOrganization(c) {
+			c.JSON(http.StatusNotFound, responses.CommonResponse{Status: http.StatusNotFound, Message: "error", Data: map[string]interface{}{"data": repositories.ErrNotFound.Error()}})
+			return
+		}
+
+		header, err := c.FormFile("logo")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": "logo is required"}})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		defer file.Close()
+		logo, err := spreadsheet.ReadLimited(file, printing.MaxLogoSize)
+		if err != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, responses.CommonResponse{Status: http.StatusRequestEntityTooLarge, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		contentType, err := printing.CheckLogo(logo)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		updatedOrganization, err := store.Organizations.SetLogo(ctx, objId, logo, contentType)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedOrganization}})
+	}
+}
+
+func GetAOrganizationLogo(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		organizationId := c.Param("organizationId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(organizationId)
+
+		organization, err := store.Organizations.FindByID(ctx, objId)
+		if err == nil && (objId != activeOrganization(c) || len(organization.Logo) == 0) {
+			err = repositories.ErrNotFound
+		}
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.Data(http.StatusOK, organization.Logo_type, organization.Logo)
+	}
+}
+
+func DeleteAOrganizationLogo(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		organizationId := c.Param("organizationId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(organizationId)
+
+		err := repositories.ErrNotFound
+		if objId == activeOrganization(c) {
+			_, err = store.Organizations.SetLogo(ctx, objId, nil, "")
+		}
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "logo successfully deleted!"}})
+	}
+}
@@ -1,26 +1,27 @@
 package controllers
 
 import (
-	"appadming/configs"
+	"appadming/blobstore"
 	"appadming/models"
+	"appadming/repositories"
 	"appadming/responses"
+	"appadming/services"
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var productCollection *mongo.Collection = configs.GetCollection(configs.DB, "products")
 var productValidate = validator.New()
 
-func CreateProduct() gin.HandlerFunc {
+func CreateProduct(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		var product models.Product
 		defer cancel()
 
@@ -37,40 +38,46 @@ func CreateProduct() gin.HandlerFunc {
 		}
 
 		newProduct := models.Product{
-			Id:          primitive.NewObjectID(),
-			Model:       product.Model,
-			Price:       product.Price,
-			Cost:        product.Cost,
-			Description: product.Description,
-			Category:    product.Category,
-			ImageURL:    product.ImageURL,
-			Stock:       product.Stock,
-			Brand:       product.Brand,
-			Seller_id:   product.Seller_id,
-		}
-
-		result, err := productCollection.InsertOne(ctx, newProduct)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			Id:            primitive.NewObjectID(),
+			Model:         product.Model,
+			Price:         product.Price,
+			Cost:          product.Cost,
+			Description:   product.Description,
+			Category:      product.Category,
+			ImageURL:      product.ImageURL,
+			Stock:         product.Stock,
+			Brand:         product.Brand,
+			Seller_id:     product.Seller_id,
+			Reorder_point: product.Reorder_point,
+			Category_id:   product.Category_id,
+			Brand_id:      product.Brand_id,
+			Sku:           product.Sku,
+			Attributes:    product.Attributes,
+			Serialized:    product.Serialized,
+			Warranty_days: product.Warranty_days,
+		}
+
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+		if err := services.CreateProduct(ctx, store, &newProduct, userId); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": result}})
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newProduct}})
 	}
 }
 
-func GetAProduct() gin.HandlerFunc {
+func GetAProduct(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		productId := c.Param("productId")
-		var product models.Product
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(productId)
 
-		err := productCollection.FindOne(ctx, bson.M{"id": objId}).Decode(&product)
+		product, err := store.Products.FindByID(ctx, objId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
@@ -78,9 +85,9 @@ func GetAProduct() gin.HandlerFunc {
 	}
 }
 
-func EditAProduct() gin.HandlerFunc {
+func EditAProduct(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		productId := c.Param("productId")
 		var product models.Product
 		defer cancel()
@@ -98,57 +105,36 @@ func EditAProduct() gin.HandlerFunc {
 			return
 		}
 
-		update := bson.M{
-			"category":    product.Category,
-			"brand":       product.Brand,
-			"cost":        product.Cost,
-			"description": product.Description,
-			"image_url":   product.ImageURL,
-			"model":       product.Model,
-			"price":       product.Price,
-			"stock":       product.Stock,
-			"seller_id":   product.Seller_id,
-		}
-		result, err := productCollection.UpdateOne(ctx, bson.M{"id": objId}, bson.M{"$set": update})
+		updatedProduct, err := services.UpdateProduct(ctx, store, objId, product)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		//get updated product details
-		var updatedProduct models.Product
-		if result.MatchedCount == 1 {
-			err := productCollection.FindOne(ctx, bson.M{"id": objId}).Decode(&updatedProduct)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-				return
-			}
-		}
-
 		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedProduct}})
 	}
 }
 
-func DeleteAProduct() gin.HandlerFunc {
+// DeleteAProduct deletes a product together with its pictures
+func DeleteAProduct(store *repositories.Store, blobs blobstore.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		productId := c.Param("productId")
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(productId)
 
-		result, err := productCollection.DeleteOne(ctx, bson.M{"id": objId})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			return
-		}
-
-		if result.DeletedCount < 1 {
+		err := services.DeleteProduct(ctx, store, blobs, objId)
+		if errors.Is(err, repositories.ErrNotFound) {
 			c.JSON(http.StatusNotFound,
 				responses.CommonResponse{Status: http.StatusNotFound, Message: "error", Data: map[string]interface{}{"data": "product with specified ID not found!"}},
 			)
 			return
 		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
 
 		c.JSON(http.StatusOK,
 			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "product successfully deleted!"}},
@@ -156,32 +142,101 @@ func DeleteAProduct() gin.HandlerFunc {
 	}
 }
 
-func GetAllProducts() gin.HandlerFunc {
+// GetAllProducts lists products. category_id takes in the whole subtree of
+// the category, attr.<name>=value filters on variant attributes and
+// facets=true adds the counts by category, brand and attribute of every
+// product matching the filters.
+func GetAllProducts(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-		var products []models.Product
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		results, err := productCollection.Find(ctx, bson.M{})
-
+		query, err := parseListQuery(c, repositories.ProductFields)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
-
-		//reading from the db in an optimal way
-		defer results.Close(ctx)
-		for results.Next(ctx) {
-			var singleProduct models.Product
-			if err = results.Decode(&singleProduct); err != nil {
-				c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+		attributes := map[string]string{}
+		for param, values := range c.Request.URL.Query() {
+			if name := strings.TrimPrefix(param, "attr."); name != param && len(values) > 0 {
+				attributes[name] = values[0]
 			}
+		}
+		query, err = services.ProductQuery(ctx, store, query, attributes)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
 
-			products = append(products, singleProduct)
+		products, err := store.Products.List(ctx, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		data := listData(products)
+		if c.Query("facets") == "true" {
+			facets, err := services.ProductFacets(ctx, store, query)
+			if err != nil {
+				c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+				return
+			}
+			data["facets"] = facets
 		}
 
 		c.JSON(http.StatusOK,
-			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": products}},
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: data},
 		)
 	}
 }
+
+// CreateAProductVariant adds a variant with its own SKU, attributes, price,
+// cost and stock to the family of a product
+func CreateAProductVariant(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		productId := c.Param("productId")
+		var variant models.Variant
+		defer cancel()
+
+		//validate the request body
+		if err := c.BindJSON(&variant); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := productValidate.Struct(&variant); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		objId, _ := primitive.ObjectIDFromHex(productId)
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+
+		newProduct, err := services.CreateVariant(ctx, store, objId, variant, userId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newProduct}})
+	}
+}
+
+func GetAProductVariants(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		productId := c.Param("productId")
+		defer cancel()
+
+		objId, _ := primitive.ObjectIDFromHex(productId)
+
+		variants, err := services.ProductVariants(ctx, store, objId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": variants}})
+	}
+}
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"appadming/blobstore"
+	"appadming/imaging"
+	"appadming/models"
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"appadming/spreadsheet"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var imageValidate = validator.New()
+
+// UploadProductImages adds the pictures uploaded as multipart "images" files
+// to a product, after those it already has
+func UploadProductImages(store *repositories.Store, blobs blobstore.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		productId := c.Param("productId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(productId)
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxProductImages*imaging.MaxImageSize+1<<20)
+		form, err := c.MultipartForm()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		headers := form.File["images"]
+		if len(headers) == 0 {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": "images are required"}})
+			return
+		}
+		uploads := make([][]byte, len(headers))
+		for i, header := range headers {
+			file, err := header.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+				return
+			}
+			uploads[i], err = spreadsheet.ReadLimited(file, imaging.MaxImageSize)
+			file.Close()
+			if err != nil {
+				c.JSON(http.StatusRequestEntityTooLarge, responses.CommonResponse{Status: http.StatusRequestEntityTooLarge, Message: "error", Data: map[string]interface{}{"data": header.Filename + ": " + err.Error()}})
+				return
+			}
+		}
+
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+		images, err := services.AddProductImages(ctx, store, blobs, objId, uploads, userId, time.Now())
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": images}})
+	}
+}
+
+// GetAProductImages lists the pictures of a product in order
+func GetAProductImages(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		productId := c.Param("productId")
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(productId)
+
+		if _, err := store.Products.FindByID(ctx, objId); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		images, err := store.Images.FindByProduct(ctx, objId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": images}})
+	}
+}
+
+func GetAProductImage(store *repositories.Store, blobs blobstore.Store) gin.HandlerFunc {
+	return serveProductImage(store, blobs, false)
+}
+
+func GetAProductImageThumbnail(store *repositories.Store, blobs blobstore.Store) gin.HandlerFunc {
+	return serveProductImage(store, blobs, true)
+}
+
+// serveProductImage sends a picture or its thumbnail. Pictures never change
+// once uploaded, so clients may keep them for a year and revalidate by ETag.
+func serveProductImage(store *repositories.Store, blobs blobstore.Store, thumbnail bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		productId := c.Param("productId")
+		imageId := c.Param("imageId")
+		defer cancel()
+		productObjId, _ := primitive.ObjectIDFromHex(productId)
+		imageObjId, _ := primitive.ObjectIDFromHex(imageId)
+
+		image, err := services.FindProductImage(ctx, store, productObjId, imageObjId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+		key, contentType, etag := image.Key, image.Content_type, `"`+image.Id.Hex()+`"`
+		if thumbnail {
+			key, contentType, etag = image.Thumbnail_key, image.Thumbnail_type, `"`+image.Id.Hex()+`-thumbnail"`
+		}
+		c.Header("Cache-Control", "private, max-age=31536000, immutable")
+		c.Header("ETag", etag)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+
+		data, err := blobs.Get(ctx, key)
+		if err != nil {
+			c.Header("Cache-Control", "no-store")
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.Data(http.StatusOK, contentType, data)
+	}
+}
+
+// OrderAProductImages puts the pictures of a product in the order given, the first being shown in lists
+func OrderAProductImages(store *repositories.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		productId := c.Param("productId")
+		var order models.ImageOrder
+		defer cancel()
+		objId, _ := primitive.ObjectIDFromHex(productId)
+
+		//validate the request body
+		if err := c.BindJSON(&order); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := imageValidate.Struct(&order); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		images, err := services.OrderProductImages(ctx, store, objId, order.Ids)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": images}})
+	}
+}
+
+func DeleteAProductImage(store *repositories.Store, blobs blobstore.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		productId := c.Param("productId")
+		imageId := c.Param("imageId")
+		defer cancel()
+		productObjId, _ := primitive.ObjectIDFromHex(productId)
+		imageObjId, _ := primitive.ObjectIDFromHex(imageId)
+
+		if err := services.DeleteProductImage(ctx, store, blobs, productObjId, imageObjId); err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "image successfully deleted!"}},
+		)
+	}
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"appadming/responses"
+	"appadming/services"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var returnValidate = validator.New()
+
+type reverseFunc func(ctx context.Context, store *repositories.Store, sellId primitive.ObjectID, request models.ReturnRequest, userId primitive.ObjectID) (models.SellInfo, error)
+
+// reverseSell binds a return request and applies it to the sale as the caller
+func reverseSell(store *repositories.Store, reverse reverseFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		sellId := c.Param("sellsId")
+		var request models.ReturnRequest
+		defer cancel()
+
+		//validate the request body
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		//use the validator library to validate required fields
+		if validationErr := returnValidate.Struct(&request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": validationErr.Error()}})
+			return
+		}
+
+		objId, _ := primitive.ObjectIDFromHex(sellId)
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+
+		reversedSell, err := reverse(ctx, store, objId, request, userId)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": reversedSell}})
+	}
+}
+
+func ReturnASell(store *repositories.Store) gin.HandlerFunc {
+	return reverseSell(store, services.ReturnSell)
+}
+
+func VoidASell(store *repositories.Store) gin.HandlerFunc {
+	return reverseSell(store, services.VoidSell)
+}
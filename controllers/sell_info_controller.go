@@ -1,26 +1,24 @@
 package controllers
 
 import (
-	"appadming/configs"
 	"appadming/models"
+	"appadming/repositories"
 	"appadming/responses"
+	"appadming/services"
 	"context"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var sellInfoCollection *mongo.Collection = configs.GetCollection(configs.DB, "sells")
 var sellValidate = validator.New()
 
-func CreateSell() gin.HandlerFunc {
+func CreateSell(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		var sell models.SellInfo
 		defer cancel()
 
@@ -36,36 +34,28 @@ func CreateSell() gin.HandlerFunc {
 			return
 		}
 
-		newSell := models.SellInfo{
-			Id:              primitive.NewObjectID(),
-			Products:        sell.Products,
-			Customer_id:     sell.Customer_id,
-			Organization_id: sell.Organization_id,
-			Amount:          sell.Amount,
-		}
-
-		result, err := sellInfoCollection.InsertOne(ctx, newSell)
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
+		newSell, entry, err := services.CreateSell(ctx, store, sell, userId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": result}})
+		c.JSON(http.StatusCreated, responses.CommonResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": newSell, "history": entry}})
 	}
 }
 
-func GetASell() gin.HandlerFunc {
+func GetASell(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-		sellId := c.Param("sellId")
-		var sell models.SellInfo
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		sellId := c.Param("sellsId")
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(sellId)
 
-		err := sellInfoCollection.FindOne(ctx, bson.M{"id": objId}).Decode(&sell)
+		sell, err := store.Sells.FindByID(ctx, objId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
@@ -73,10 +63,12 @@ func GetASell() gin.HandlerFunc {
 	}
 }
 
-func EditASell() gin.HandlerFunc {
+// EditASell changes the note of a sale; its lines, customer and amounts are
+// corrected by returning or voiding it
+func EditASell(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-		sellId := c.Param("sellId")
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		sellId := c.Param("sellsId")
 		var sell models.SellInfo
 		defer cancel()
 		objId, _ := primitive.ObjectIDFromHex(sellId)
@@ -93,85 +85,59 @@ func EditASell() gin.HandlerFunc {
 			return
 		}
 
-		update := bson.M{
-			"Products":        sell.Products,
-			"Customer_id":     sell.Customer_id,
-			"Organization_id": sell.Organization_id,
-			"Amount":          sell.Amount,
-		}
-		result, err := sellInfoCollection.UpdateOne(ctx, bson.M{"id": objId}, bson.M{"$set": update})
+		updatedSell, err := services.EditSell(ctx, store, objId, sell)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		//get updated sell details
-		var updatedSell models.SellInfo
-		if result.MatchedCount == 1 {
-			err := sellInfoCollection.FindOne(ctx, bson.M{"id": objId}).Decode(&updatedSell)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-				return
-			}
-		}
-
 		c.JSON(http.StatusOK, responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedSell}})
 	}
 }
 
-func DeleteASell() gin.HandlerFunc {
+// DeleteASell voids the sale rather than removing it, giving the reason
+// query parameter or "deleted"
+func DeleteASell(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-		sellId := c.Param("sellId")
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		sellId := c.Param("sellsId")
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(sellId)
+		userId, _ := primitive.ObjectIDFromHex(c.GetString("uid"))
 
-		result, err := sellInfoCollection.DeleteOne(ctx, bson.M{"id": objId})
+		request := models.ReturnRequest{Reason: c.DefaultQuery("reason", "deleted")}
+		voidedSell, err := services.VoidSell(ctx, store, objId, request, userId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			return
-		}
-
-		if result.DeletedCount < 1 {
-			c.JSON(http.StatusNotFound,
-				responses.CommonResponse{Status: http.StatusNotFound, Message: "error", Data: map[string]interface{}{"data": "sell with specified ID not found!"}},
-			)
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
 		c.JSON(http.StatusOK,
-			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "sell successfully deleted!"}},
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": voidedSell}},
 		)
 	}
 }
 
-func GetAllSells() gin.HandlerFunc {
+func GetAllSells(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-		var sells []models.SellInfo
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		results, err := sellInfoCollection.Find(ctx, bson.M{})
-
+		query, err := parseListQuery(c, repositories.SellInfoFields)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.JSON(http.StatusBadRequest, responses.CommonResponse{Status: http.StatusBadRequest, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
 			return
 		}
 
-		//reading from the db in an optimal way
-		defer results.Close(ctx)
-		for results.Next(ctx) {
-			var singleSell models.SellInfo
-			if err = results.Decode(&singleSell); err != nil {
-				c.JSON(http.StatusInternalServerError, responses.CommonResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			}
-
-			sells = append(sells, singleSell)
+		sells, err := store.Sells.List(ctx, query)
+		if err != nil {
+			c.JSON(errorStatus(err), responses.CommonResponse{Status: errorStatus(err), Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			return
 		}
 
 		c.JSON(http.StatusOK,
-			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": sells}},
+			responses.CommonResponse{Status: http.StatusOK, Message: "success", Data: listData(sells)},
 		)
 	}
 }
@@ -12,17 +12,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 
-	"appadming/configs"
 	helper "appadming/helpers"
 	"appadming/models"
+	"appadming/repositories"
+	"appadming/services"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var userCollection *mongo.Collection = configs.GetCollection(configs.DB, "users")
 var userValidate = validator.New()
 
 // HashPassword is used to encrypt the password before it is stored in the DB
@@ -50,11 +48,12 @@ func VerifyPassword(userPassword string, providedPassword string) (bool, string)
 }
 
 // CreateUser is the api used to tget a single user
-func SignUp() gin.HandlerFunc {
+func SignUp(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		var user models.User
 		var userResponse models.UserResponse
+		defer cancel()
 
 		if err := c.BindJSON(&user); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -67,8 +66,7 @@ func SignUp() gin.HandlerFunc {
 			return
 		}
 
-		count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
-		defer cancel()
+		emailCount, err := store.Users.CountByEmail(ctx, *user.Email)
 		if err != nil {
 			log.Panic(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while checking for the email"})
@@ -78,15 +76,14 @@ func SignUp() gin.HandlerFunc {
 		password := HashPassword(*user.Password)
 		user.Password = &password
 
-		count, err = userCollection.CountDocuments(ctx, bson.M{"phone": user.Phone})
-		defer cancel()
+		phoneCount, err := store.Users.CountByPhone(ctx, *user.Phone)
 		if err != nil {
 			log.Panic(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while checking for the phone number"})
 			return
 		}
 
-		if count > 0 {
+		if emailCount > 0 || phoneCount > 0 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "this email or phone number already exists"})
 			return
 		}
@@ -95,67 +92,72 @@ func SignUp() gin.HandlerFunc {
 		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		user.ID = primitive.NewObjectID()
 		user.User_id = user.ID.Hex()
-		token, refreshToken, _ := helper.GenerateAllTokens(*user.Email, *user.Name, *&user.User_id)
-		user.Token = &token
-		user.Refresh_token = &refreshToken
 
-		_, insertErr := userCollection.InsertOne(ctx, user)
+		// users join organizations by creating one or through an invitation
+		user.Organization_id = primitive.NilObjectID
+		invite := c.Query("invite")
+		if invite != "" {
+			if _, err := services.CheckInvitation(ctx, store, invite, user.Email); err != nil {
+				c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		insertErr := store.Users.Create(ctx, &user)
 		if insertErr != nil {
 			msg := fmt.Sprintf("User item was not created")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
-		defer cancel()
+
+		if invite != "" {
+			if _, err := services.AcceptInvitation(ctx, store, invite, user); err != nil {
+				c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if user, err = store.Users.FindByUserID(ctx, user.User_id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := issueTokens(c, store, user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 		userResponse.Name = user.Name
 		userResponse.Email = user.Email
 
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:     "access_token",
-			Value:    token,
-			Path:     "/",
-			HttpOnly: true,
-			Secure:   true,
-			SameSite: http.SameSiteNoneMode,
-			MaxAge:   3600 * 24 * 2,
-		})
-
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:     "refresh_token",
-			Value:    refreshToken,
-			Path:     "/",
-			HttpOnly: true,
-			Secure:   true,
-			SameSite: http.SameSiteNoneMode,
-			MaxAge:   3600 * 24 * 7,
-		})
 		c.JSON(http.StatusOK, userResponse)
 
 	}
 }
 
 // Login is the api used to tget a single user
-func Login() gin.HandlerFunc {
+func Login(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		fmt.Println(c.ClientIP())
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		var user models.User
-		var foundUser models.User
 		var userResponse models.UserResponse
+		defer cancel()
 
 		if err := c.BindJSON(&user); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		err := userCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&foundUser)
-		defer cancel()
+		if user.Email == nil || user.Password == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email and password are required"})
+			return
+		}
+
+		foundUser, err := store.Users.FindByEmail(ctx, *user.Email)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "login or passowrd is incorrect"})
 			return
 		}
 
 		passwordIsValid, msg := VerifyPassword(*user.Password, *foundUser.Password)
-		defer cancel()
 		if passwordIsValid != true {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
@@ -165,46 +167,31 @@ func Login() gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
 			return
 		}
-		token, refreshToken, _ := helper.GenerateAllTokens(*foundUser.Email, *foundUser.Name, foundUser.User_id)
-
-		helper.UpdateAllTokens(token, refreshToken, foundUser.User_id)
-		err = userCollection.FindOne(ctx, bson.M{"user_id": foundUser.User_id}).Decode(&foundUser)
-
-		if err != nil {
+		if invite := c.Query("invite"); invite != "" {
+			if _, err := services.AcceptInvitation(ctx, store, invite, foundUser); err != nil {
+				c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			if foundUser, err = store.Users.FindByUserID(ctx, foundUser.User_id); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if err := issueTokens(c, store, foundUser); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 		userResponse.Name = foundUser.Name
 		userResponse.Email = foundUser.Email
 
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:     "access_token",
-			Value:    token,
-			Path:     "/",
-			HttpOnly: true,
-			Secure:   true,
-			SameSite: http.SameSiteNoneMode,
-			MaxAge:   3600 * 24 * 2,
-		})
-
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:     "refresh_token",
-			Value:    refreshToken,
-			Path:     "/",
-			HttpOnly: true,
-			Secure:   true,
-			SameSite: http.SameSiteNoneMode,
-			MaxAge:   3600 * 24 * 7,
-		})
-
 		c.JSON(http.StatusOK, userResponse)
 	}
 }
 
-func Refresh() gin.HandlerFunc {
+func Refresh(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		var user models.User
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
 		clientToken, erro := c.Cookie("refresh_token")
 		if erro != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("No Authorization header provided")})
@@ -220,11 +207,19 @@ func Refresh() gin.HandlerFunc {
 		}
 		userID := &claims.Email
 		fmt.Println(userID)
-		token, _, _ := helper.GenerateAllTokens(claims.Email, claims.Name, claims.Uid)
+		organizationId, _ := primitive.ObjectIDFromHex(claims.Organization_id)
+		role, roleErr := services.MemberRole(ctx, store, claims.Uid, organizationId)
+		if roleErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": roleErr.Error()})
+			return
+		}
+		token, _, _ := helper.GenerateAllTokens(claims.Email, claims.Name, claims.Uid, claims.Organization_id, role)
 
-		helper.UpdateAllTokens(token, clientToken, claims.Uid)
-		userCollection.FindOne(ctx, bson.M{"user_id": claims.Uid}).Decode(&user)
-		defer cancel()
+		if err := helper.UpdateAllTokens(store.Users, token, clientToken, claims.Uid); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		user, _ := store.Users.FindByUserID(ctx, claims.Uid)
 		http.SetCookie(c.Writer, &http.Cookie{
 			Name:     "access_token",
 			Value:    token,
@@ -239,54 +234,35 @@ func Refresh() gin.HandlerFunc {
 	}
 }
 
-func GetUsers() gin.HandlerFunc {
+func GetUsers(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		query, err := parseListQuery(c, repositories.UserFields)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-
-		// recordPerPage := 10
-		recordPerPage, err := strconv.Atoi(c.Query("recordPerPage"))
-		if err != nil || recordPerPage < 1 {
-			recordPerPage = 10
-		}
-
-		page, err1 := strconv.Atoi(c.Query("page"))
-		if err1 != nil || page < 1 {
-			page = 1
+		// recordPerPage is the page size older clients send
+		if recordPerPage, err := strconv.Atoi(c.Query("recordPerPage")); err == nil && c.Query("limit") == "" {
+			query.Limit = recordPerPage
 		}
 
-		startIndex := (page - 1) * recordPerPage
-		startIndex, err = strconv.Atoi(c.Query("startIndex"))
-
-		matchStage := bson.D{{"$match", bson.D{{}}}}
-		groupStage := bson.D{{"$group", bson.D{{"_id", bson.D{{"_id", "null"}}}, {"total_count", bson.D{{"$sum", 1}}}, {"data", bson.D{{"$push", "$$ROOT"}}}}}}
-		projectStage := bson.D{
-			{"$project", bson.D{
-				{"_id", 0},
-				{"total_count", 1},
-				{"user_items", bson.D{{"$slice", []interface{}{"$data", startIndex, recordPerPage}}}},
-			}}}
-
-		result, err := userCollection.Aggregate(ctx, mongo.Pipeline{
-			matchStage, groupStage, projectStage})
-		defer cancel()
+		users, err := store.Users.List(ctx, query)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing user items"})
-		}
-		var allusers []bson.M
-		if err = result.All(ctx, &allusers); err != nil {
-			log.Fatal(err)
+			c.JSON(errorStatus(err), gin.H{"error": "error occured while listing user items"})
+			return
 		}
-		c.JSON(http.StatusOK, allusers[0])
+		response := listData(users)
+		response["user_items"] = users.Items
+		c.JSON(http.StatusOK, response)
 
 	}
 }
 
 // GetUser is the api used to tget a single user
-func GetUser() gin.HandlerFunc {
+func GetUser(store *repositories.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userId := c.Param("user_id")
 
@@ -294,14 +270,12 @@ func GetUser() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-
-		var user models.User
-
-		err := userCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
+
+		user, err := store.Users.FindByUserID(ctx, userId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
@@ -309,3 +283,39 @@ func GetUser() gin.HandlerFunc {
 
 	}
 }
+
+// issueTokens signs fresh tokens for user in its active organization, stores them and sets the auth cookies
+func issueTokens(c *gin.Context, store *repositories.Store, user models.User) error {
+	role, err := services.MemberRole(c.Request.Context(), store, user.User_id, user.Organization_id)
+	if err != nil {
+		return err
+	}
+	token, refreshToken, err := helper.GenerateAllTokens(*user.Email, *user.Name, user.User_id, user.Organization_id.Hex(), role)
+	if err != nil {
+		return err
+	}
+	if err := helper.UpdateAllTokens(store.Users, token, refreshToken, user.User_id); err != nil {
+		return err
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "access_token",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+		MaxAge:   3600 * 24 * 2,
+	})
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+		MaxAge:   3600 * 24 * 7,
+	})
+	return nil
+}
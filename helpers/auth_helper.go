@@ -1,12 +1,123 @@
 package helper
 
 import (
+	"appadming/models"
 	"errors"
 
 	"github.com/gin-gonic/gin"
 )
 
-// CheckUserType renews the user tokens when they login
+// Permissions checked by the routes
+const (
+	CustomerRead   = "customer:read"
+	CustomerCreate = "customer:create"
+	CustomerUpdate = "customer:update"
+	CustomerDelete = "customer:delete"
+	ProductRead    = "product:read"
+	ProductCreate  = "product:create"
+	ProductUpdate  = "product:update"
+	ProductDelete  = "product:delete"
+	StockAdjust    = "stock:adjust"
+	SellRead       = "sell:read"
+	SellCreate     = "sell:create"
+	SellUpdate     = "sell:update"
+	SellReturn     = "sell:return"
+	SellVoid       = "sell:void"
+	HistoryRead    = "history:read"
+	HistoryCreate  = "history:create"
+	HistoryUpdate  = "history:update"
+	HistoryDelete  = "history:delete"
+	PlanRead       = "plan:read"
+	PlanCreate     = "plan:create"
+	ReportRead     = "report:read"
+	UserRead       = "user:read"
+	RoleAssign     = "role:assign"
+
+	SupplierRead    = "supplier:read"
+	SupplierCreate  = "supplier:create"
+	SupplierUpdate  = "supplier:update"
+	SupplierDelete  = "supplier:delete"
+	SupplierPay     = "supplier:pay"
+	PurchaseRead    = "purchase:read"
+	PurchaseCreate  = "purchase:create"
+	PurchaseReceive = "purchase:receive"
+
+	WarrantyRead   = "warranty:read"
+	WarrantyCreate = "warranty:create"
+	WarrantyUpdate = "warranty:update"
+
+	AttachmentRead   = "attachment:read"
+	AttachmentCreate = "attachment:create"
+	AttachmentDelete = "attachment:delete"
+	AttachmentAudit  = "attachment:audit"
+
+	OrganizationUpdate = "organization:update"
+	OrganizationDelete = "organization:delete"
+	MemberInvite       = "member:invite"
+)
+
+var readPermissions = []string{CustomerRead, ProductRead, SellRead, HistoryRead, PlanRead, ReportRead, UserRead, SupplierRead, PurchaseRead, WarrantyRead, AttachmentRead, AttachmentAudit}
+
+// RolePermissions lists what every role is allowed to do
+var RolePermissions = map[string][]string{
+	models.RoleOwner: append([]string{
+		CustomerCreate, CustomerUpdate, CustomerDelete,
+		ProductCreate, ProductUpdate, ProductDelete, StockAdjust,
+		SellCreate, SellUpdate, SellReturn, SellVoid,
+		HistoryCreate, HistoryUpdate, HistoryDelete,
+		SupplierCreate, SupplierUpdate, SupplierDelete, SupplierPay,
+		PurchaseCreate, PurchaseReceive,
+		WarrantyCreate, WarrantyUpdate,
+		AttachmentCreate, AttachmentDelete,
+		PlanCreate, RoleAssign,
+		OrganizationUpdate, OrganizationDelete, MemberInvite,
+	}, readPermissions...),
+	models.RoleManager: append([]string{
+		CustomerCreate, CustomerUpdate, CustomerDelete,
+		ProductCreate, ProductUpdate, ProductDelete, StockAdjust,
+		SellCreate, SellUpdate, SellReturn, SellVoid,
+		HistoryCreate, HistoryUpdate, HistoryDelete,
+		SupplierCreate, SupplierUpdate, SupplierDelete, SupplierPay,
+		PurchaseCreate, PurchaseReceive,
+		WarrantyCreate, WarrantyUpdate,
+		AttachmentCreate, AttachmentDelete,
+		PlanCreate,
+		OrganizationUpdate, MemberInvite,
+	}, readPermissions...),
+	models.RoleCashier: {
+		CustomerRead, CustomerCreate, CustomerUpdate,
+		ProductRead, SupplierRead, PurchaseRead, PurchaseReceive,
+		SellRead, SellCreate, SellReturn,
+		WarrantyRead, WarrantyCreate,
+		AttachmentRead, AttachmentCreate,
+		HistoryRead, HistoryCreate,
+		PlanRead, PlanCreate,
+	},
+	models.RoleCollector: {
+		CustomerRead, SellRead, HistoryRead, HistoryCreate, PlanRead, ReportRead,
+	},
+	models.RoleAuditor: readPermissions,
+}
+
+// HasPermission reports whether role grants permission
+func HasPermission(role string, permission string) bool {
+	for _, granted := range RolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPermission fails unless the caller's role grants permission
+func CheckPermission(c *gin.Context, permission string) (err error) {
+	if !HasPermission(c.GetString("user_type"), permission) {
+		return errors.New("Unauthorized to access this resource")
+	}
+	return nil
+}
+
+// CheckUserType fails unless the caller holds role
 func CheckUserType(c *gin.Context, role string) (err error) {
 	userType := c.GetString("user_type")
 	err = nil
@@ -18,17 +129,10 @@ func CheckUserType(c *gin.Context, role string) (err error) {
 	return err
 }
 
-// MatchUserTypeToUid only allows the user to access their data and no other data. Only the admin can access all user data
+// MatchUserTypeToUid only allows the user to access their data and no other data. Only roles that can read users can access all user data
 func MatchUserTypeToUid(c *gin.Context, userId string) (err error) {
-	userType := c.GetString("user_type")
-	uid := c.GetString("uid")
-	err = nil
-
-	if userType == "USER" && uid != userId {
-		err = errors.New("Unauthorized to access this resource")
-		return err
+	if c.GetString("uid") == userId {
+		return nil
 	}
-	err = CheckUserType(c, userType)
-
-	return err
+	return CheckPermission(c, UserRead)
 }
@@ -1,7 +1,7 @@
 package helper
 
 import (
-	"appadming/configs"
+	"appadming/repositories"
 	"context"
 	"fmt"
 	"log"
@@ -9,40 +9,39 @@ import (
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // SignedDetails
 type SignedDetails struct {
 	Email           string
 	Name            string
-	Organization_id int
+	Organization_id string
+	Role            string
 	Uid             string
 	jwt.StandardClaims
 }
 
-var userCollection *mongo.Collection = configs.GetCollection(configs.DB, "users")
-
 var SECRET_KEY string = os.Getenv("SECRET_KEY")
 
 // GenerateAllTokens generates both teh detailed token and refresh token
-func GenerateAllTokens(email string, name string, uid string) (signedToken string, signedRefreshToken string, err error) {
+func GenerateAllTokens(email string, name string, uid string, organizationId string, role string) (signedToken string, signedRefreshToken string, err error) {
 	claims := &SignedDetails{
-		Email: email,
-		Name:  name,
-		Uid:   uid,
+		Email:           email,
+		Name:            name,
+		Uid:             uid,
+		Organization_id: organizationId,
+		Role:            role,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Local().Add(time.Minute * time.Duration(1)).Unix(),
 		},
 	}
 
 	refreshClaims := &SignedDetails{
-		Email: email,
-		Name:  name,
-		Uid:   uid,
+		Email:           email,
+		Name:            name,
+		Uid:             uid,
+		Organization_id: organizationId,
+		Role:            role,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(7*24)).Unix(),
 		},
@@ -90,37 +89,9 @@ func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
 }
 
 // UpdateAllTokens renews the user tokens when they login
-func UpdateAllTokens(signedToken string, signedRefreshToken string, userId string) {
+func UpdateAllTokens(users repositories.UserRepository, signedToken string, signedRefreshToken string, userId string) error {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-
-	var updateObj primitive.D
-
-	updateObj = append(updateObj, bson.E{"token", signedToken})
-	updateObj = append(updateObj, bson.E{"refresh_token", signedRefreshToken})
-
-	Updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, bson.E{"updated_at", Updated_at})
-
-	upsert := true
-	filter := bson.M{"user_id": userId}
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
-	}
-
-	_, err := userCollection.UpdateOne(
-		ctx,
-		filter,
-		bson.D{
-			{"$set", updateObj},
-		},
-		&opt,
-	)
 	defer cancel()
 
-	if err != nil {
-		log.Panic(err)
-		return
-	}
-
-	return
+	return users.UpdateTokens(ctx, userId, signedToken, signedRefreshToken)
 }
@@ -0,0 +1,120 @@
+// Package imaging checks uploaded pictures and scales them down to thumbnails
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// ErrInvalidImage is returned for uploads that are not PNG, JPEG or GIF pictures of a reasonable size
+var ErrInvalidImage = errors.New("invalid image")
+
+const (
+	// MaxImageSize is the largest picture accepted, in bytes
+	MaxImageSize = 5 << 20
+	// ThumbnailSize is the largest side of a thumbnail, in pixels
+	ThumbnailSize = 320
+	// maxImagePixels bounds what decoding a picture may take in memory
+	maxImagePixels = 4096 * 4096
+)
+
+// Info describes a picture that passed Check
+type Info struct {
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Check verifies data is a PNG, JPEG or GIF picture that can be decoded
+// without exhausting memory, telling its media type from its content rather
+// than from what the client claims
+func Check(data []byte) (Info, error) {
+	if len(data) > MaxImageSize {
+		return Info{}, fmt.Errorf("%w: larger than %d MB", ErrInvalidImage, MaxImageSize>>20)
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width < 1 || config.Height < 1 || config.Width*config.Height > maxImagePixels {
+		return Info{}, fmt.Errorf("%w: %dx%d pixels is more than %d megapixels", ErrInvalidImage, config.Width, config.Height, maxImagePixels/1000000)
+	}
+	return Info{ContentType: "image/" + format, Width: config.Width, Height: config.Height}, nil
+}
+
+// Thumbnail scales the picture in data down to fit size by size pixels,
+// averaging the pixels it merges, and returns it with its media type. Photos
+// become JPEG; PNG and GIF pictures stay PNG to keep their transparency.
+func Thumbnail(data []byte, size int) ([]byte, string, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, height*size/width
+		} else {
+			width, height = width*size/height, size
+		}
+		if width < 1 {
+			width = 1
+		}
+		if height < 1 {
+			height = 1
+		}
+	}
+
+	full := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(full, full.Bounds(), src, bounds.Min, draw.Src)
+	thumb := shrink(full, width, height)
+
+	var out bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&out, thumb, &jpeg.Options{Quality: 85})
+		return out.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(&out, thumb)
+	return out.Bytes(), "image/png", err
+}
+
+// shrink averages the block of src pixels under every pixel of a width by height picture
+func shrink(src *image.RGBA, width int, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := src.Rect.Dx(), src.Rect.Dy()
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, (y+1)*srcHeight/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, (x+1)*srcWidth/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return dst
+}
@@ -1,20 +1,50 @@
 package main
 
 import (
+	"appadming/blobstore"
 	"appadming/configs"
 	middleware "appadming/middlewares"
+	"appadming/repositories"
 	"appadming/routes"
+	"appadming/services"
 	"fmt"
+	"log"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
+// newStore picks the storage backend selected by the STORAGE environment variable
+func newStore() *repositories.Store {
+	if configs.EnvStorage() == "memory" {
+		fmt.Println("Using in-memory storage")
+		return repositories.NewMemoryStore()
+	}
+	return repositories.NewMongoStore(configs.ConnectDB())
+}
+
+// newBlobStore picks where uploaded files are kept, selected by the BLOB_STORE environment variable
+func newBlobStore() blobstore.Store {
+	if configs.EnvBlobStore() == "s3" {
+		blobs, err := blobstore.NewS3(configs.EnvS3())
+		if err != nil {
+			log.Fatal("Error configuring the S3 blob store: ", err)
+		}
+		return blobs
+	}
+	blobs, err := blobstore.NewLocal(configs.EnvBlobDir())
+	if err != nil {
+		log.Fatal("Error creating the blob directory: ", err)
+	}
+	return blobs
+}
+
 func main() {
 	router := gin.New()
 
 	//run database
-	configs.ConnectDB()
+	store := newStore()
+	blobs := newBlobStore()
 
 	router.Use(gin.Logger())
 	// Add CORS middleware
@@ -24,10 +54,10 @@ func main() {
 	config.AllowCredentials = true
 	router.Use(cors.New(config))
 
-	routes.AuthRoutes(router)
+	routes.AuthRoutes(router, store)
 	router.Use(middleware.Authentication())
 
-	routes.UserRoutes(router)
+	routes.UserRoutes(router, store)
 
 	// API-2
 	router.GET("/api-1", func(c *gin.Context) {
@@ -46,10 +76,29 @@ func main() {
 		c.JSON(200, gin.H{"success": "Access granted"})
 	})
 
-	routes.HistoryRoute(router)
-	routes.SellsRoute(router)
-	routes.CustomerRoute(router)
-	routes.ProductRoute(router)
+	routes.HistoryRoute(router, store)
+	routes.SellsRoute(router, store)
+	routes.CustomerRoute(router, store)
+	routes.ProductRoute(router, store, blobs)
+	routes.ReportRoute(router, store)
+	routes.OrganizationRoute(router, store)
+	routes.ImportRoute(router, store)
+	routes.ExportRoute(router, store)
+	routes.SupplierRoute(router, store)
+	routes.PurchaseOrderRoute(router, store)
+	routes.CatalogueRoute(router, store)
+	routes.NotificationRoute(router, store)
+	routes.WarrantyRoute(router, store)
+	routes.ProductImageRoute(router, store, blobs)
+	routes.AttachmentRoute(router, store, blobs)
+	routes.GuarantorRoute(router, store)
+
+	if interval := configs.EnvReorderInterval(); interval > 0 {
+		go services.WatchStock(store, interval)
+	}
+	if interval := configs.EnvCheckpointInterval(); interval > 0 {
+		go services.WatchCosts(store, interval)
+	}
 
 	err := router.Run("0.0.0.0:9000")
 	if err != nil {
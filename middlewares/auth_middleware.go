@@ -5,8 +5,10 @@ import (
 	"net/http"
 
 	helper "appadming/helpers"
+	"appadming/repositories"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Authz validates token and authorizes users
@@ -30,8 +32,26 @@ func Authentication() gin.HandlerFunc {
 		c.Set("name", claims.Name)
 		c.Set("uid", claims.Uid)
 		c.Set("org_id", claims.Organization_id)
+		c.Set("user_type", claims.Role)
+
+		// scope every repository call of this request to the caller's organization
+		organizationId, _ := primitive.ObjectIDFromHex(claims.Organization_id)
+		c.Request = c.Request.WithContext(repositories.WithOrganization(c.Request.Context(), organizationId))
 
 		c.Next()
 
 	}
 }
+
+// Authorize only lets callers whose role grants permission through
+func Authorize(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := helper.CheckPermission(c, permission); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
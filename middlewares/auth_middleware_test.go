@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	helper "appadming/helpers"
+	"appadming/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		role       string
+		permission string
+		want       int
+	}{
+		{models.RoleOwner, helper.OrganizationDelete, http.StatusOK},
+		{models.RoleManager, helper.OrganizationDelete, http.StatusForbidden},
+		{models.RoleManager, helper.SellVoid, http.StatusOK},
+		{models.RoleCashier, helper.SellCreate, http.StatusOK},
+		{models.RoleCashier, helper.SellVoid, http.StatusForbidden},
+		{models.RoleCashier, helper.HistoryDelete, http.StatusForbidden},
+		{models.RoleCollector, helper.HistoryCreate, http.StatusOK},
+		{models.RoleCollector, helper.CustomerCreate, http.StatusForbidden},
+		{models.RoleAuditor, helper.ReportRead, http.StatusOK},
+		{models.RoleAuditor, helper.HistoryCreate, http.StatusForbidden},
+		{"", helper.CustomerRead, http.StatusForbidden},
+		{"stranger", helper.CustomerRead, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.permission, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) { c.Set("user_type", tt.role) }, Authorize(tt.permission), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Document types of attachments
+const (
+	DocumentNID       = "nid"
+	DocumentGuarantor = "guarantor"
+	DocumentContract  = "contract"
+	DocumentOther     = "other"
+)
+
+// What attachments are attached to
+const (
+	AttachedToCustomer = "customer"
+	AttachedToSell     = "sell"
+)
+
+// Attachment is a scanned document kept in the blob store for a customer or
+// one of their sales, such as a national ID or the papers of a guarantor
+type Attachment struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Owner_type      string             `json:"owner_type"`
+	Owner_id        primitive.ObjectID `json:"owner_id"`
+	// Customer_id is the customer of the sale for attachments of sales
+	Customer_id   primitive.ObjectID `json:"customer_id"`
+	Document_type string             `json:"document_type"`
+	Filename      string             `json:"filename"`
+	Content_type  string             `json:"content_type"`
+	Size          int                `json:"size"`
+	Note          string             `json:"note,omitempty"`
+	Key           string             `json:"-"`
+	User_id       primitive.ObjectID `json:"user_id,omitempty"`
+	Created_at    time.Time          `json:"created_at"`
+}
+
+// AttachmentUpload is what is said about an uploaded document
+type AttachmentUpload struct {
+	Document_type string `form:"document_type" validate:"required,oneof=nid guarantor contract other"`
+	Note          string `form:"note"`
+}
+
+// AttachmentDownload records who downloaded an attachment and when
+type AttachmentDownload struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Attachment_id   primitive.ObjectID `json:"attachment_id"`
+	Customer_id     primitive.ObjectID `json:"customer_id"`
+	Document_type   string             `json:"document_type"`
+	Filename        string             `json:"filename"`
+	User_id         primitive.ObjectID `json:"user_id"`
+	Ip              string             `json:"ip,omitempty"`
+	Date            time.Time          `json:"date"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Category is a node of an organization's category tree
+type Category struct {
+	Id   primitive.ObjectID `json:"id,omitempty"`
+	Name string             `json:"name,omitempty" validate:"required"`
+	// Parent_id is the category this one sits under, zero for top level categories
+	Parent_id       primitive.ObjectID `json:"parent_id,omitempty"`
+	Description     string             `json:"description,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+}
+
+// Brand makes products
+type Brand struct {
+	Id          primitive.ObjectID `json:"id,omitempty"`
+	Name        string             `json:"name,omitempty" validate:"required"`
+	Description string             `json:"description,omitempty"`
+	// Logo is a PNG or JPEG image of Logo_type
+	Logo            []byte             `json:"-"`
+	Logo_type       string             `json:"logo_type,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+}
+
+// Variant is a product offered in another colour, storage or the like of the
+// same model, with its own SKU, price, cost and stock
+type Variant struct {
+	Sku           string            `json:"sku,omitempty" validate:"required"`
+	Attributes    map[string]string `json:"attributes,omitempty" validate:"required,min=1"`
+	Price         float64           `json:"price,omitempty" validate:"required"`
+	Cost          float64           `json:"cost,omitempty" validate:"required"`
+	Stock         int               `json:"stock" validate:"min=0"`
+	Reorder_point int               `json:"reorder_point,omitempty" validate:"min=0"`
+	ImageURL      string            `json:"image_url,omitempty"`
+}
+
+// ProductFacets counts the products of a list by category, brand and variant attribute
+type ProductFacets struct {
+	// Categories count the products of every category with its subcategories
+	Categories []CategoryCount             `json:"categories"`
+	Brands     []BrandCount                `json:"brands"`
+	Attributes map[string][]AttributeCount `json:"attributes"`
+}
+
+type CategoryCount struct {
+	Id        primitive.ObjectID `json:"id"`
+	Name      string             `json:"name"`
+	Parent_id primitive.ObjectID `json:"parent_id,omitempty"`
+	Count     int                `json:"count"`
+}
+
+type BrandCount struct {
+	Id    primitive.ObjectID `json:"id"`
+	Name  string             `json:"name"`
+	Count int                `json:"count"`
+}
+
+type AttributeCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// CostCheckpoint is the stock of a product as a costing method left it once
+// every movement dated before Date was replayed, so that later movements are
+// replayed from it instead of from the first one
+type CostCheckpoint struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Product_id      primitive.ObjectID `json:"product_id,omitempty"`
+	Method          string             `json:"method,omitempty"`
+	Date            primitive.DateTime `json:"date,omitempty"`
+	// Layers are the units on hand by the cost they came in at, oldest first
+	Layers    []CostLayer `json:"layers"`
+	Last_cost float64     `json:"last_cost"`
+	// Sales are what the sales still open to returns took out
+	Sales []SaleCost `json:"sales"`
+}
+
+// CostLayer is a quantity of a product brought in at one unit cost
+type CostLayer struct {
+	Quantity  int     `json:"quantity"`
+	Unit_cost float64 `json:"unit_cost"`
+}
+
+// SaleCost is what the units of a product taken out by a sale on Date cost
+// and how many of them came back
+type SaleCost struct {
+	Sell_id  primitive.ObjectID `json:"sell_id"`
+	Quantity int                `json:"quantity"`
+	Returned int                `json:"returned"`
+	Cost     float64            `json:"cost"`
+	Date     primitive.DateTime `json:"date"`
+}
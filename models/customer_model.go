@@ -3,14 +3,24 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Customer struct {
-	Id       primitive.ObjectID `json:"id,omitempty"`
-	Name     string             `json:"name,omitempty" validate:"required"`
-	Father   string             `json:"father,omitempty" validate:"required"`
-	Home     string             `json:"home,omitempty" validate:"required"`
-	Village  string             `json:"village,omitempty" validate:"required"`
-	Thana    string             `json:"thana,omitempty" validate:"required"`
-	District string             `json:"district,omitempty" validate:"required"`
-	Phone    int                `json:"phone,omitempty" validate:"required"`
-	Email    string             `json:"email,omitempty"`
-	DueDate  primitive.DateTime `json:"dueDate,omitempty"`
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Name            string             `json:"name,omitempty" validate:"required"`
+	Father          string             `json:"father,omitempty" validate:"required"`
+	Home            string             `json:"home,omitempty" validate:"required"`
+	Village         string             `json:"village,omitempty" validate:"required"`
+	Thana           string             `json:"thana,omitempty" validate:"required"`
+	District        string             `json:"district,omitempty" validate:"required"`
+	Phone           int                `json:"phone,omitempty" validate:"required"`
+	Email           string             `json:"email,omitempty"`
+	DueDate         primitive.DateTime `json:"dueDate,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	// normalized words and phonetic codes kept up to date by the repository for searching
+	Search_terms []string `json:"-"`
+	Search_codes []string `json:"-"`
+}
+
+// CustomerMatch is a customer found by a search with its relevance
+type CustomerMatch struct {
+	Customer
+	Score float64 `json:"score"`
 }
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Document is an invoice or receipt gathered for printing
+type Document struct {
+	Title        string          `json:"title"`
+	Number       string          `json:"number"`
+	Date         time.Time       `json:"date"`
+	Organization Orgnization     `json:"organization"`
+	Customer     Customer        `json:"customer"`
+	Lines        []DocumentLine  `json:"lines,omitempty"`
+	Totals       []DocumentTotal `json:"totals"`
+	Note         string          `json:"note,omitempty"`
+}
+
+// DocumentLine is one product on an invoice
+type DocumentLine struct {
+	Description string  `json:"description"`
+	Quantity    int     `json:"quantity"`
+	Unit_price  float64 `json:"unit_price"`
+	Discount    float64 `json:"discount,omitempty"`
+	Total       float64 `json:"total"`
+}
+
+// DocumentTotal is a labelled amount printed below the lines
+type DocumentTotal struct {
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"`
+}
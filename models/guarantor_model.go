@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Guarantor is a person who answers for the credit of customers or of single
+// sales. A person is known by their national ID, once per organization.
+type Guarantor struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Name            string             `json:"name,omitempty" validate:"required"`
+	// Relation is what the guarantor is to the customer, such as brother or neighbour
+	Relation string `json:"relation,omitempty"`
+	Father   string `json:"father,omitempty"`
+	Home     string `json:"home,omitempty"`
+	Village  string `json:"village,omitempty" validate:"required"`
+	Thana    string `json:"thana,omitempty" validate:"required"`
+	District string `json:"district,omitempty" validate:"required"`
+	Phone    int    `json:"phone,omitempty" validate:"required"`
+	Nid      string `json:"nid,omitempty" validate:"required"`
+	// Customer_ids are the customers guaranteed for everything they owe
+	Customer_ids []primitive.ObjectID `json:"customer_ids"`
+	Created_at   time.Time            `json:"created_at"`
+}
+
+// GuarantorLink names the guarantor of a customer or a sale
+type GuarantorLink struct {
+	Guarantor_id primitive.ObjectID `json:"guarantor_id" validate:"required"`
+}
+
+// GuarantorExposure is everything a guarantor answers for: the whole balance
+// of the customers they guarantee and what is left of the sales they
+// guarantee for other customers
+type GuarantorExposure struct {
+	Guarantor_id primitive.ObjectID   `json:"guarantor_id"`
+	Name         string               `json:"name"`
+	Phone        int                  `json:"phone"`
+	Nid          string               `json:"nid"`
+	Customers    []GuaranteedCustomer `json:"customers"`
+	Sells        []GuaranteedSell     `json:"sells"`
+	Total        int                  `json:"total"`
+}
+
+// GuaranteedCustomer is the balance of a customer a guarantor answers for
+type GuaranteedCustomer struct {
+	Customer_id primitive.ObjectID `json:"customer_id"`
+	Name        string             `json:"name"`
+	Balance     int                `json:"balance"`
+}
+
+// GuaranteedSell is what is left to pay of a sale a guarantor answers for.
+// Covered sales are those of a customer the guarantor already guarantees
+// whole, left out of the total.
+type GuaranteedSell struct {
+	Sell_id     primitive.ObjectID `json:"sell_id"`
+	Number      string             `json:"number"`
+	Customer_id primitive.ObjectID `json:"customer_id"`
+	Name        string             `json:"name"`
+	Amount      int                `json:"amount"`
+	Balance     int                `json:"balance"`
+	Covered     bool               `json:"covered"`
+}
+
+// ExposureReport lists every guarantor by what they answer for, largest first
+type ExposureReport struct {
+	Rows  []GuarantorExposure `json:"rows"`
+	Total int                 `json:"total"`
+}
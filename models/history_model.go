@@ -4,11 +4,39 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// History types, each entry moves the customer balance by Due - Paid
+const (
+	HistorySale       = "sale"
+	HistoryPayment    = "payment"
+	HistoryAdjustment = "adjustment"
+	HistoryWriteOff   = "writeoff"
+	// HistoryReturn credits the customer with goods taken back and
+	// HistoryRefund charges the cash handed back against that credit
+	HistoryReturn = "return"
+	HistoryRefund = "refund"
+	// HistoryVoid takes back a payment, adjustment or write-off posted by mistake
+	HistoryVoid = "void"
+)
+
 type History struct {
-	Id          primitive.ObjectID `json:"id,omitempty"`
-	Due         int                `json:"Due,omitempty" validate:"required"`
-	Paid        int                `json:"paid,omitempty" validate:"required"`
+	Id primitive.ObjectID `json:"id,omitempty"`
+	// Number is the receipt number, empty on the entry of a sale and the markup
+	// of its installment plan, which refer to the sale by Sell_id
+	Number      string             `json:"number,omitempty"`
+	Type        string             `json:"type,omitempty" validate:"omitempty,oneof=sale payment adjustment writeoff"`
+	Due         int                `json:"Due,omitempty" validate:"min=0"`
+	Paid        int                `json:"paid,omitempty" validate:"min=0"`
 	Date        primitive.DateTime `json:"date,omitempty" validate:"required"`
 	Customer_id primitive.ObjectID `json:"customer_id,omitempty" validate:"required"`
-	Seller_id   primitive.ObjectID `json:"seller_id,omitempty" validate:"required"`
+	Seller_id   primitive.ObjectID `json:"seller_id,omitempty"`
+	Sell_id     primitive.ObjectID `json:"sell_id,omitempty"`
+	Note        string             `json:"note,omitempty"`
+	// Voided_by is the entry taking this one back, Reverses the entry a void takes back
+	Voided_by primitive.ObjectID `json:"voided_by,omitempty"`
+	Reverses  primitive.ObjectID `json:"reverses,omitempty"`
+}
+
+// HistoryVoidRequest asks to take back an entry posted by mistake
+type HistoryVoidRequest struct {
+	Reason string `json:"reason,omitempty" validate:"required"`
 }
@@ -0,0 +1,32 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Outcomes of an imported row
+const (
+	ImportValid   = "valid"
+	ImportCreated = "created"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// ImportRow reports what happened to one data row of an imported file
+type ImportRow struct {
+	Row    int                 `json:"row"`
+	Status string              `json:"status"`
+	Id     *primitive.ObjectID `json:"id,omitempty"`
+	Errors []string            `json:"errors,omitempty"`
+}
+
+// ImportReport summarises an import. In a dry run nothing is stored and rows
+// that would be created are reported as valid.
+type ImportReport struct {
+	Dry_run bool              `json:"dry_run"`
+	Columns map[string]string `json:"columns"`
+	Total   int               `json:"total"`
+	Valid   int               `json:"valid"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRow       `json:"rows"`
+}
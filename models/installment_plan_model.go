@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Installment plan frequencies
+const (
+	PlanWeekly  = "weekly"
+	PlanMonthly = "monthly"
+)
+
+// Installment plan statuses
+const (
+	PlanCurrent   = "current"
+	PlanOverdue   = "overdue"
+	PlanCompleted = "completed"
+)
+
+// InstallmentPlan spreads what is left to pay on a credit sale over dated installments
+type InstallmentPlan struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Sell_id         primitive.ObjectID `json:"sell_id,omitempty"`
+	Customer_id     primitive.ObjectID `json:"customer_id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization_id,omitempty"`
+	Down_payment    int                `json:"down_payment,omitempty" validate:"min=0"`
+	Installments    int                `json:"installments,omitempty" validate:"required,min=1,max=120"`
+	Frequency       string             `json:"frequency,omitempty" validate:"required,oneof=weekly monthly"`
+	Markup_percent  float64            `json:"markup_percent,omitempty" validate:"min=0"`
+	Start_date      primitive.DateTime `json:"start_date,omitempty"`
+	Financed        int                `json:"financed,omitempty"`
+	Markup          int                `json:"markup,omitempty"`
+	Schedule        []Installment      `json:"schedule,omitempty"`
+	Status          string             `json:"status,omitempty"`
+	Created_at      time.Time          `json:"created_at"`
+}
+
+// Installment is one dated amount of an installment plan and what has been paid against it
+type Installment struct {
+	Number   int                `json:"number"`
+	Due_date primitive.DateTime `json:"due_date"`
+	Amount   int                `json:"amount"`
+	Paid     int                `json:"paid"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invitation lets the holder of Token join an organization with a role when they sign up or log in
+type Invitation struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization_id,omitempty"`
+	Email           string             `json:"email,omitempty" validate:"required,email"`
+	Role            string             `json:"role,omitempty" validate:"required,oneof=manager cashier collector auditor"`
+	Designation     string             `json:"designation,omitempty"`
+	Token           string             `json:"token,omitempty"`
+	Invited_by      string             `json:"invited_by,omitempty"`
+	Created_at      time.Time          `json:"created_at"`
+	Expires_at      time.Time          `json:"expires_at"`
+	Accepted_by     string             `json:"accepted_by,omitempty"`
+	Accepted_at     *time.Time         `json:"accepted_at,omitempty"`
+}
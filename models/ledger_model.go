@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LedgerMovement is a history entry with the customer balance after it was applied
+type LedgerMovement struct {
+	History
+	Amount  int `json:"amount"`
+	Balance int `json:"balance"`
+}
+
+// Statement lists the movements on a customer account between two dates
+type Statement struct {
+	Customer_id primitive.ObjectID `json:"customer_id"`
+	From        time.Time          `json:"from"`
+	To          time.Time          `json:"to"`
+	Opening     int                `json:"opening_balance"`
+	Movements   []LedgerMovement   `json:"movements"`
+	Closing     int                `json:"closing_balance"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles a user can hold in an organization
+const (
+	RoleOwner     = "owner"
+	RoleManager   = "manager"
+	RoleCashier   = "cashier"
+	RoleCollector = "collector"
+	RoleAuditor   = "auditor"
+)
+
+// Membership gives a user a role in an organization
+type Membership struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	User_id         string             `json:"user_id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization_id,omitempty"`
+	Role            string             `json:"role,omitempty" validate:"required,oneof=owner manager cashier collector auditor"`
+	Designation     string             `json:"designation,omitempty"`
+	Assigned_by     string             `json:"assigned_by,omitempty"`
+	Created_at      time.Time          `json:"created_at"`
+	Updated_at      time.Time          `json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification types
+const (
+	NotificationLowStock = "low_stock"
+)
+
+// Notification tells a member of an organization about something that needs attention
+type Notification struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	User_id         string             `json:"user_id,omitempty"`
+	Type            string             `json:"type,omitempty"`
+	Product_id      primitive.ObjectID `json:"product_id,omitempty"`
+	Message         string             `json:"message,omitempty"`
+	Created_at      time.Time          `json:"created_at"`
+	Read_at         *time.Time         `json:"read_at,omitempty"`
+}
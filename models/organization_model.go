@@ -6,6 +6,12 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Costing methods valuing the stock of an organization
+const (
+	CostingAverage = "average"
+	CostingFIFO    = "fifo"
+)
+
 type Orgnization struct {
 	Id          primitive.ObjectID `json:"id,omitempty"`
 	Name        string             `json:"name,omitempty" validate:"required"`
@@ -13,6 +19,22 @@ type Orgnization struct {
 	Phone       int                `json:"phone,omitempty" validate:"required"`
 	Email       string             `json:"email,omitempty"`
 	Created_at  time.Time          `json:"created_at"`
-	User_id     primitive.ObjectID `json:"user_id,omitempty" validate:"required"`
+	User_id     primitive.ObjectID `json:"user_id,omitempty"`
 	Designation string             `json:"designation,omitempty"`
+	// Invoice_prefix and Receipt_prefix start the numbers of the organization's documents
+	Invoice_prefix string `json:"invoice_prefix,omitempty" validate:"omitempty,alphanum,max=12"`
+	Receipt_prefix string `json:"receipt_prefix,omitempty" validate:"omitempty,alphanum,max=12"`
+	// Invoice_footer closes every invoice and receipt the organization prints
+	Invoice_footer string `json:"invoice_footer,omitempty"`
+	// Logo heads invoices and receipts, a PNG or JPEG image of Logo_type
+	Logo      []byte `json:"-"`
+	Logo_type string `json:"logo_type,omitempty"`
+	// Costing_method values stock and the cost of goods sold, the weighted average unless fifo
+	Costing_method string `json:"costing_method,omitempty" validate:"omitempty,oneof=average fifo"`
+	// Reorder_windows are the days over which sales velocity is measured and
+	// products projected to run out within Stockout_days are flagged for reordering
+	Reorder_windows []int `json:"reorder_windows,omitempty" validate:"omitempty,max=5,dive,min=1,max=365"`
+	Stockout_days   int   `json:"stockout_days,omitempty" validate:"omitempty,min=1,max=365"`
+	// Guarantor_threshold is the credit above which a sale needs a guarantor, none when zero
+	Guarantor_threshold int `json:"guarantor_threshold,omitempty" validate:"min=0"`
 }
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductImage is a picture of a product kept in the blob store with its
+// thumbnail. Position orders the pictures of a product, the first being the
+// one shown in lists.
+type ProductImage struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Product_id      primitive.ObjectID `json:"product_id"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Position        int                `json:"position"`
+	Content_type    string             `json:"content_type"`
+	Size            int                `json:"size"`
+	Width           int                `json:"width"`
+	Height          int                `json:"height"`
+	Url             string             `json:"url"`
+	Thumbnail_url   string             `json:"thumbnail_url"`
+	// Key and Thumbnail_key locate the picture and its thumbnail in the blob store
+	Key            string             `json:"-"`
+	Thumbnail_key  string             `json:"-"`
+	Thumbnail_type string             `json:"-"`
+	User_id        primitive.ObjectID `json:"user_id,omitempty"`
+	Created_at     time.Time          `json:"created_at"`
+}
+
+// ImageOrder lists every picture of a product in the order they should be shown
+type ImageOrder struct {
+	Ids []primitive.ObjectID `json:"ids" validate:"required,min=1"`
+}
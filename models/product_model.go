@@ -8,9 +8,25 @@ type Product struct {
 	Price       float64            `json:"price,omitempty" validate:"required"`
 	Cost        float64            `json:"cost,omitempty" validate:"required"`
 	Description string             `json:"description,omitempty"`
-	Category    string             `json:"category,omitempty" validate:"required"`
+	Category    string             `json:"category,omitempty" validate:"required_without=Category_id"`
 	ImageURL    string             `json:"image_url,omitempty"`
-	Stock       int                `json:"stock,omitempty" validate:"required"`
-	Brand       int                `json:"brand,omitempty" validate:"required"`
-	Seller_id   primitive.ObjectID `json:"organization,omitempty" validate:"required"`
+	Stock       int                `json:"stock" validate:"min=0"`
+	Brand       int                `json:"brand,omitempty" validate:"required_without=Brand_id"`
+	Seller_id   primitive.ObjectID `json:"organization,omitempty"`
+	// Reorder_point is the stock at which the product should be ordered again
+	Reorder_point int `json:"reorder_point,omitempty" validate:"min=0"`
+	// Category_id places the product in the category tree, Category then
+	// carries the name of that category
+	Category_id primitive.ObjectID `json:"category_id,omitempty"`
+	Brand_id    primitive.ObjectID `json:"brand_id,omitempty"`
+	// Variant_of is the product this one is a variant of, zero for the
+	// product a family of variants starts from
+	Variant_of primitive.ObjectID `json:"variant_of,omitempty"`
+	Sku        string             `json:"sku,omitempty"`
+	// Attributes tell the variants of a model apart, such as colour or storage
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Serialized products track every unit by its serial number or IMEI
+	Serialized bool `json:"serialized,omitempty"`
+	// Warranty_days is how long a unit is under warranty once sold
+	Warranty_days int `json:"warranty_days,omitempty" validate:"min=0"`
 }
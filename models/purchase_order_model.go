@@ -0,0 +1,62 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Purchase order statuses
+const (
+	PurchaseOpen              = "open"
+	PurchasePartiallyReceived = "partially_received"
+	PurchaseReceived          = "received"
+	PurchaseCancelled         = "cancelled"
+)
+
+// PurchaseOrder is stock ordered from a supplier, received in one or more deliveries
+type PurchaseOrder struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Number          string             `json:"number,omitempty"`
+	Supplier_id     primitive.ObjectID `json:"supplier_id,omitempty" validate:"required"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Lines           []PurchaseLine     `json:"lines,omitempty" validate:"required,min=1,dive"`
+	// Amount is the whole-unit cost of every line ordered
+	Amount   int                `json:"amount"`
+	Status   string             `json:"status,omitempty"`
+	Receipts []PurchaseReceipt  `json:"receipts,omitempty"`
+	Note     string             `json:"note,omitempty"`
+	User_id  primitive.ObjectID `json:"user_id,omitempty"`
+	Date     primitive.DateTime `json:"date,omitempty"`
+}
+
+// PurchaseLine is a product ordered at a unit cost and how much of it arrived
+type PurchaseLine struct {
+	Product_id primitive.ObjectID `json:"product_id" validate:"required"`
+	Model      string             `json:"model,omitempty"`
+	Quantity   int                `json:"quantity" validate:"required,min=1"`
+	Unit_cost  float64            `json:"unit_cost" validate:"min=0"`
+	Received   int                `json:"received"`
+}
+
+// PurchaseReceipt is a delivery against a purchase order, billed by the supplier
+type PurchaseReceipt struct {
+	Id         primitive.ObjectID `json:"id,omitempty"`
+	Lines      []ReceiptLine      `json:"lines"`
+	Amount     int                `json:"amount"`
+	Payable_id primitive.ObjectID `json:"payable_id,omitempty"`
+	User_id    primitive.ObjectID `json:"user_id,omitempty"`
+	Date       primitive.DateTime `json:"date,omitempty"`
+	Note       string             `json:"note,omitempty"`
+}
+
+// ReceiptLine is how many units of a product were delivered
+type ReceiptLine struct {
+	Product_id primitive.ObjectID `json:"product_id" validate:"required"`
+	Quantity   int                `json:"quantity" validate:"required,min=1"`
+	Unit_cost  float64            `json:"unit_cost,omitempty"`
+	// Serials register the units delivered of a serialized product
+	Serials []string `json:"serials,omitempty"`
+}
+
+// ReceiveRequest lists what arrived of a purchase order
+type ReceiveRequest struct {
+	Lines []ReceiptLine `json:"lines" validate:"required,min=1,dive"`
+	Note  string        `json:"note,omitempty"`
+}
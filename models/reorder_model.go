@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReorderSuggestion flags a product that fell to its reorder point or is
+// projected to run out, with how many units to order
+type ReorderSuggestion struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Product_id      primitive.ObjectID `json:"product_id"`
+	Model           string             `json:"model"`
+	Stock           int                `json:"stock"`
+	Reorder_point   int                `json:"reorder_point"`
+	// Incoming is what is still outstanding on open purchase orders
+	Incoming   int        `json:"incoming"`
+	Velocities []Velocity `json:"velocities"`
+	// Per_day is the fastest of the velocities, the one projections use
+	Per_day float64 `json:"per_day"`
+	// Days_left is how long the stock lasts at Per_day, nil when nothing sells
+	Days_left           *float64   `json:"days_left"`
+	Stockout_date       *time.Time `json:"stockout_date,omitempty"`
+	Below_reorder_point bool       `json:"below_reorder_point"`
+	Projected_stockout  bool       `json:"projected_stockout"`
+	Suggested_quantity  int        `json:"suggested_quantity"`
+	Computed_at         time.Time  `json:"computed_at"`
+}
+
+// Velocity is how fast a product sold over the last Days days
+type Velocity struct {
+	Days    int     `json:"days"`
+	Sold    int     `json:"sold"`
+	Per_day float64 `json:"per_day"`
+}
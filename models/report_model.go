@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AgingBuckets splits an outstanding balance by how many days it is past due
+type AgingBuckets struct {
+	Current   int `json:"current"`
+	Days1_30  int `json:"days_1_30"`
+	Days31_60 int `json:"days_31_60"`
+	Days61_90 int `json:"days_61_90"`
+	Over90    int `json:"days_90_plus"`
+	Total     int `json:"total"`
+}
+
+// AgingRow is the aged outstanding balance of one customer
+type AgingRow struct {
+	Customer_id primitive.ObjectID `json:"customer_id"`
+	Name        string             `json:"name"`
+	District    string             `json:"district"`
+	Thana       string             `json:"thana"`
+	AgingBuckets
+}
+
+// AgingReport is the receivables aging of every customer with an outstanding balance
+type AgingReport struct {
+	As_of  time.Time    `json:"as_of"`
+	Rows   []AgingRow   `json:"rows"`
+	Totals AgingBuckets `json:"totals"`
+}
+
+// ValuationRow is the stock of one product and what it cost
+type ValuationRow struct {
+	Product_id primitive.ObjectID `json:"product_id"`
+	Model      string             `json:"model"`
+	Category   string             `json:"category"`
+	Quantity   int                `json:"quantity"`
+	Unit_cost  float64            `json:"unit_cost"`
+	Value      float64            `json:"value"`
+}
+
+// ValuationReport values the stock on hand at a date by the costing method
+type ValuationReport struct {
+	As_of  time.Time      `json:"as_of"`
+	Method string         `json:"method"`
+	Rows   []ValuationRow `json:"rows"`
+	Value  float64        `json:"value"`
+}
+
+// ProfitRow is what one group of sales brought in against what the goods cost.
+// Returns are taken off in the group and period they were made.
+type ProfitRow struct {
+	Key            string  `json:"key"`
+	Label          string  `json:"label"`
+	Quantity       int     `json:"quantity"`
+	Revenue        float64 `json:"revenue"`
+	Cogs           float64 `json:"cogs"`
+	Gross_margin   float64 `json:"gross_margin"`
+	Margin_percent float64 `json:"margin_percent"`
+}
+
+// ProfitReport is the gross margin of the sales between two dates
+type ProfitReport struct {
+	From     time.Time   `json:"from"`
+	To       time.Time   `json:"to"`
+	Method   string      `json:"method"`
+	Group_by string      `json:"group_by"`
+	Rows     []ProfitRow `json:"rows"`
+	Totals   ProfitRow   `json:"totals"`
+}
@@ -2,10 +2,82 @@ package models
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// Sale statuses; the lines of a sale never change, returns and voids are
+// recorded next to them
+const (
+	SellCompleted         = "completed"
+	SellPartiallyReturned = "partially_returned"
+	SellReturned          = "returned"
+	SellVoided            = "voided"
+)
+
+// Kinds of sale reversals
+const (
+	ReversalReturn = "return"
+	ReversalVoid   = "void"
+)
+
 type SellInfo struct {
-	Id              primitive.ObjectID `json:"id,omitempty"`
-	Products        []*Product         `json:"products,omitempty" validate:"required"`
+	Id primitive.ObjectID `json:"id,omitempty"`
+	// Number is the invoice number, handed out by the organization's invoice sequence
+	Number          string             `json:"number,omitempty"`
+	Lines           []SellLine         `json:"lines,omitempty" validate:"required,min=1,dive"`
 	Customer_id     primitive.ObjectID `json:"customer,omitempty" validate:"required"`
-	Organization_id primitive.ObjectID `json:"seller,omitempty" validate:"required"`
-	Amount          int                `json:"amount,omitempty" validate:"required"`
+	Organization_id primitive.ObjectID `json:"seller,omitempty"`
+	Amount          int                `json:"amount,omitempty"`
+	Paid            int                `json:"paid,omitempty"`
+	Date            primitive.DateTime `json:"date,omitempty"`
+	Status          string             `json:"status,omitempty"`
+	Reversals       []SellReversal     `json:"reversals,omitempty"`
+	// Guarantor_id answers for what is owed on this sale
+	Guarantor_id primitive.ObjectID `json:"guarantor_id,omitempty"`
+	// Note is free text kept with the sale, the only thing an edit changes
+	Note string `json:"note,omitempty"`
+}
+
+// SellLine is one product on a sale with its price and cost captured when the sale was made
+type SellLine struct {
+	Product_id primitive.ObjectID `json:"product_id,omitempty" validate:"required"`
+	Model      string             `json:"model,omitempty"`
+	Quantity   int                `json:"quantity,omitempty" validate:"required,min=1"`
+	Unit_price float64            `json:"unit_price,omitempty"`
+	Unit_cost  float64            `json:"unit_cost,omitempty"`
+	Discount   float64            `json:"discount,omitempty" validate:"min=0"`
+	Line_total float64            `json:"line_total,omitempty"`
+	// Serials name the units sold, one for each unit of a serialized product
+	Serials []string `json:"serials,omitempty"`
+}
+
+// SellReversal records products taken back from a sale, by whom and why, with
+// the history entries crediting and refunding the customer
+type SellReversal struct {
+	Id        primitive.ObjectID `json:"id,omitempty"`
+	Type      string             `json:"type,omitempty"`
+	Lines     []ReturnLine       `json:"lines,omitempty"`
+	Amount    int                `json:"amount"`
+	Refund    int                `json:"refund"`
+	Reason    string             `json:"reason,omitempty"`
+	User_id   primitive.ObjectID `json:"user_id,omitempty"`
+	Date      primitive.DateTime `json:"date,omitempty"`
+	Credit_id primitive.ObjectID `json:"credit_id,omitempty"`
+	Refund_id primitive.ObjectID `json:"refund_id,omitempty"`
+}
+
+// ReturnLine is a quantity of one product of a sale taken back, worth its
+// share of the discounted line total
+type ReturnLine struct {
+	Product_id primitive.ObjectID `json:"product_id,omitempty" validate:"required"`
+	Model      string             `json:"model,omitempty"`
+	Quantity   int                `json:"quantity,omitempty" validate:"required,min=1"`
+	Amount     float64            `json:"amount,omitempty"`
+	// Serials name the units taken back of a serialized product
+	Serials []string `json:"serials,omitempty"`
+}
+
+// ReturnRequest asks to take lines back from a sale, handing Refund back in
+// cash. Voids take every remaining line and ignore Lines.
+type ReturnRequest struct {
+	Lines  []ReturnLine `json:"lines,omitempty" validate:"dive"`
+	Refund int          `json:"refund,omitempty" validate:"min=0"`
+	Reason string       `json:"reason,omitempty" validate:"required"`
 }
@@ -0,0 +1,20 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Numbered document types, each counted by its own sequence
+const (
+	SequenceInvoice  = "invoice"
+	SequenceReceipt  = "receipt"
+	SequencePurchase = "purchase"
+)
+
+// Sequence is the last number handed out for one type of document of an
+// organization in one year
+type Sequence struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Type            string             `json:"type,omitempty"`
+	Year            int                `json:"year,omitempty"`
+	Value           int                `json:"value,omitempty"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuses of a serialized unit
+const (
+	SerialInStock     = "in_stock"
+	SerialSold        = "sold"
+	SerialDamaged     = "damaged"
+	SerialRemoved     = "removed"
+	SerialTransferred = "transferred"
+	// SerialReplaced units were swapped for another under warranty
+	SerialReplaced = "replaced"
+)
+
+// SerialUnit is one unit of a serialized product, known by its serial number or IMEI
+type SerialUnit struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Serial          string             `json:"serial"`
+	Product_id      primitive.ObjectID `json:"product_id"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Status          string             `json:"status"`
+	// Sell_id, Customer_id and Sold_at tell who bought the unit while it is sold
+	Sell_id        primitive.ObjectID `json:"sell_id,omitempty"`
+	Customer_id    primitive.ObjectID `json:"customer_id,omitempty"`
+	Sold_at        *time.Time         `json:"sold_at,omitempty"`
+	Warranty_until *time.Time         `json:"warranty_until,omitempty"`
+	Received_at    time.Time          `json:"received_at"`
+	// Events is every stock movement and warranty claim of the unit, oldest first
+	Events []SerialEvent `json:"events"`
+}
+
+// SerialEvent is something that happened to a serialized unit
+type SerialEvent struct {
+	Type         string             `json:"type"`
+	Status       string             `json:"status"`
+	Reference_id primitive.ObjectID `json:"reference_id,omitempty"`
+	User_id      primitive.ObjectID `json:"user_id,omitempty"`
+	Date         time.Time          `json:"date"`
+}
+
+// SerialLookup is a unit with the product it is and who bought it
+type SerialLookup struct {
+	Unit           SerialUnit      `json:"unit"`
+	Product        Product         `json:"product"`
+	Customer       *Customer       `json:"customer,omitempty"`
+	Sell_number    string          `json:"sell_number,omitempty"`
+	Under_warranty bool            `json:"under_warranty"`
+	Claims         []WarrantyClaim `json:"claims"`
+}
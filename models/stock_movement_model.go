@@ -0,0 +1,73 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Stock movement types
+const (
+	MovementPurchase   = "purchase"
+	MovementSale       = "sale"
+	MovementReturn     = "return"
+	MovementDamage     = "damage"
+	MovementAdjustment = "adjustment"
+	MovementTransfer   = "transfer"
+	MovementWarranty   = "warranty"
+)
+
+// StockMovement journals a change to the stock of a product. Quantity is
+// negative when stock leaves and Balance is the stock once it was applied.
+type StockMovement struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Product_id      primitive.ObjectID `json:"product_id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Type            string             `json:"type,omitempty" validate:"required,oneof=purchase damage adjustment"`
+	Quantity        int                `json:"quantity" validate:"required"`
+	Balance         int                `json:"balance"`
+	Reason          string             `json:"reason,omitempty" validate:"required"`
+	User_id         primitive.ObjectID `json:"user_id,omitempty"`
+	// Reference_id is the sale, purchase order or transfer the movement belongs to
+	Reference_id primitive.ObjectID `json:"reference_id,omitempty"`
+	Date         primitive.DateTime `json:"date,omitempty"`
+	// Unit_cost is what a unit brought in by a purchase cost
+	Unit_cost float64 `json:"unit_cost,omitempty"`
+	// Serials name the units moved of a serialized product
+	Serials []string `json:"serials,omitempty"`
+}
+
+// StockCount is a physical count of the products of an organization
+type StockCount struct {
+	Counts []ProductCount `json:"counts" validate:"required,min=1,dive"`
+	Reason string         `json:"reason,omitempty"`
+}
+
+// ProductCount is how many units of a product were counted
+type ProductCount struct {
+	Product_id primitive.ObjectID `json:"product_id" validate:"required"`
+	Counted    int                `json:"counted" validate:"min=0"`
+}
+
+// CountLine reconciles the count of a product with its stock, the difference
+// being posted as an adjustment when the count is committed
+type CountLine struct {
+	Product_id  primitive.ObjectID  `json:"product_id"`
+	Model       string              `json:"model"`
+	Expected    int                 `json:"expected"`
+	Counted     int                 `json:"counted"`
+	Difference  int                 `json:"difference"`
+	Movement_id *primitive.ObjectID `json:"movement_id,omitempty"`
+}
+
+// CountReport is the outcome of a stock count
+type CountReport struct {
+	Dry_run bool        `json:"dry_run"`
+	Lines   []CountLine `json:"lines"`
+}
+
+// StockTransfer moves units of a product to a product of another organization
+type StockTransfer struct {
+	Organization_id primitive.ObjectID `json:"organization" validate:"required"`
+	Product_id      primitive.ObjectID `json:"product_id" validate:"required"`
+	Quantity        int                `json:"quantity" validate:"required,min=1"`
+	Reason          string             `json:"reason,omitempty" validate:"required"`
+	// Serials name the units transferred of a serialized product
+	Serials []string `json:"serials,omitempty"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Supplier is a vendor the organization buys stock from
+type Supplier struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Name            string             `json:"name,omitempty" validate:"required"`
+	Contact         string             `json:"contact,omitempty"`
+	Phone           int                `json:"phone,omitempty"`
+	Email           string             `json:"email,omitempty" validate:"omitempty,email"`
+	Address         string             `json:"address,omitempty"`
+	Note            string             `json:"note,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+}
+
+// Payable types, each entry moves what is owed to the supplier by Owed - Paid
+const (
+	PayableBill    = "bill"
+	PayablePayment = "payment"
+)
+
+// Payable is an entry of the account of a supplier: a bill for goods received
+// or a payment made to the supplier
+type Payable struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Type            string             `json:"type,omitempty"`
+	Owed            int                `json:"owed,omitempty"`
+	Paid            int                `json:"paid,omitempty" validate:"required,min=1"`
+	Date            primitive.DateTime `json:"date,omitempty"`
+	Supplier_id     primitive.ObjectID `json:"supplier_id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Purchase_id     primitive.ObjectID `json:"purchase_id,omitempty"`
+	User_id         primitive.ObjectID `json:"user_id,omitempty"`
+	Note            string             `json:"note,omitempty"`
+}
+
+// SupplierAccount is what is owed to a supplier with the entries making it up
+type SupplierAccount struct {
+	Supplier Supplier  `json:"supplier"`
+	Payables []Payable `json:"payables"`
+	Balance  int       `json:"balance"`
+}
+
+// PayablesRow is the balance owed to one supplier
+type PayablesRow struct {
+	Supplier_id primitive.ObjectID `json:"supplier_id"`
+	Name        string             `json:"name"`
+	Balance     int                `json:"balance"`
+}
+
+// PayablesReport sets what is owed to suppliers against what customers owe
+type PayablesReport struct {
+	As_of       time.Time     `json:"as_of"`
+	Rows        []PayablesRow `json:"rows"`
+	Payables    int           `json:"payables"`
+	Receivables int           `json:"receivables"`
+	Net         int           `json:"net"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Warranty claim statuses
+const (
+	ClaimOpen     = "open"
+	ClaimInRepair = "in_repair"
+	ClaimRepaired = "repaired"
+	ClaimReplaced = "replaced"
+	ClaimRejected = "rejected"
+	ClaimClosed   = "closed"
+)
+
+// ClaimTransitions lists the statuses a claim may move to from each status
+var ClaimTransitions = map[string][]string{
+	ClaimOpen:     {ClaimInRepair, ClaimReplaced, ClaimRejected},
+	ClaimInRepair: {ClaimRepaired, ClaimReplaced, ClaimRejected},
+	ClaimRepaired: {ClaimClosed},
+	ClaimReplaced: {ClaimClosed},
+	ClaimRejected: {ClaimClosed},
+}
+
+// WarrantyClaim is a customer bringing a sold unit back under warranty
+type WarrantyClaim struct {
+	Id              primitive.ObjectID `json:"id,omitempty"`
+	Serial          string             `json:"serial" validate:"required"`
+	Unit_id         primitive.ObjectID `json:"unit_id,omitempty"`
+	Product_id      primitive.ObjectID `json:"product_id,omitempty"`
+	Customer_id     primitive.ObjectID `json:"customer_id,omitempty"`
+	Sell_id         primitive.ObjectID `json:"sell_id,omitempty"`
+	Organization_id primitive.ObjectID `json:"organization,omitempty"`
+	Problem         string             `json:"problem" validate:"required"`
+	Status          string             `json:"status,omitempty"`
+	Warranty_until  time.Time          `json:"warranty_until"`
+	// Replacement_serial is the unit handed over when the claim was settled by replacing
+	Replacement_serial string             `json:"replacement_serial,omitempty"`
+	Events             []ClaimEvent       `json:"events,omitempty"`
+	User_id            primitive.ObjectID `json:"user_id,omitempty"`
+	Created_at         time.Time          `json:"created_at"`
+	Updated_at         time.Time          `json:"updated_at"`
+}
+
+// ClaimEvent records a claim moving to Status
+type ClaimEvent struct {
+	Status  string             `json:"status"`
+	Note    string             `json:"note,omitempty"`
+	User_id primitive.ObjectID `json:"user_id,omitempty"`
+	Date    time.Time          `json:"date"`
+}
+
+// ClaimUpdate moves a claim along its workflow. Replacing a unit names the
+// unit in stock handed over instead.
+type ClaimUpdate struct {
+	Status             string `json:"status" validate:"required,oneof=in_repair repaired replaced rejected closed"`
+	Note               string `json:"note,omitempty"`
+	Replacement_serial string `json:"replacement_serial,omitempty"`
+}
@@ -0,0 +1,202 @@
+package printing
+
+import (
+	"appadming/models"
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+// Characters per line in font A and printable dots per line of thermal paper
+var (
+	paperColumns = map[int]int{Paper58: 32, Paper80: 48}
+	paperDots    = map[int]int{Paper58: 384, Paper80: 576}
+)
+
+// escposLogoHeight bounds the dots of paper the logo takes
+const escposLogoHeight = 160
+
+const (
+	esc = 0x1b
+	gs  = 0x1d
+)
+
+// escpos builds the commands of a receipt. Text is printed from the
+// printer's default code page, of which only ASCII is relied on.
+type escpos struct {
+	b       bytes.Buffer
+	columns int
+}
+
+func (p *escpos) command(bytes ...byte) {
+	p.b.Write(bytes)
+}
+
+func (p *escpos) align(center bool) {
+	if center {
+		p.command(esc, 'a', 1)
+		return
+	}
+	p.command(esc, 'a', 0)
+}
+
+func (p *escpos) bold(on bool) {
+	if on {
+		p.command(esc, 'E', 1)
+		return
+	}
+	p.command(esc, 'E', 0)
+}
+
+// large doubles the height and width of characters
+func (p *escpos) large(on bool) {
+	if on {
+		p.command(gs, '!', 0x11)
+		return
+	}
+	p.command(gs, '!', 0)
+}
+
+func (p *escpos) line(value string) {
+	p.b.WriteString(ascii(value))
+	p.b.WriteByte('\n')
+}
+
+// wrapped prints value over as many lines as it needs
+func (p *escpos) wrapped(value string, width int) {
+	for _, line := range wrap(value, width) {
+		p.line(line)
+	}
+}
+
+// pair prints left and right on the two ends of one line, wrapping left
+// above when both do not fit. Leading spaces of left indent every line.
+func (p *escpos) pair(left string, right string) {
+	left, right = ascii(left), ascii(right)
+	indent := left[:len(left)-len(strings.TrimLeft(left, " "))]
+	space := p.columns - len(right) - 1
+	if space < p.columns/2 {
+		space = p.columns / 2
+	}
+	lines := wrap(left, space-len(indent))
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	for _, line := range lines[:len(lines)-1] {
+		p.line(indent + line)
+	}
+	last := indent + lines[len(lines)-1]
+	padding := p.columns - len(last) - len(right)
+	if padding < 1 {
+		padding = 1
+	}
+	p.line(last + strings.Repeat(" ", padding) + right)
+}
+
+func (p *escpos) rule() {
+	p.line(strings.Repeat("-", p.columns))
+}
+
+// raster prints img with GS v 0, dots darker than mid grey being black
+func (p *escpos) raster(img *image.RGBA) {
+	bounds := img.Bounds()
+	width, height := (bounds.Dx()+7)/8, bounds.Dy()
+	p.command(gs, 'v', '0', 0, byte(width), byte(width>>8), byte(height), byte(height>>8))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dots byte
+			for bit := 0; bit < 8; bit++ {
+				if x*8+bit >= bounds.Dx() {
+					continue
+				}
+				c := img.RGBAAt(x*8+bit, y)
+				if 299*int(c.R)+587*int(c.G)+114*int(c.B) < 128*1000 {
+					dots |= 0x80 >> bit
+				}
+			}
+			p.b.WriteByte(dots)
+		}
+	}
+}
+
+// ascii spells value with Latin letters and replaces whatever is left outside
+// ASCII, which the printer's code page may not share
+func ascii(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			return ' '
+		case r < 32 || r > 126:
+			return '?'
+		}
+		return r
+	}, latin(value))
+}
+
+// renderESCPOS prints doc on a receipt roll of paper millimetres
+func renderESCPOS(w io.Writer, doc models.Document, paper int) error {
+	p := &escpos{columns: paperColumns[paper]}
+	p.command(esc, '@')
+
+	p.align(true)
+	if logo := fitLogo(doc.Organization.Logo, paperDots[paper], escposLogoHeight); logo != nil {
+		p.raster(logo)
+	}
+	p.bold(true)
+	p.large(true)
+	// large characters are twice as wide
+	p.wrapped(latin(doc.Organization.Name), p.columns/2)
+	p.large(false)
+	p.bold(false)
+	for _, line := range header(doc.Organization) {
+		p.wrapped(line, p.columns)
+	}
+	p.align(false)
+	p.rule()
+
+	p.bold(true)
+	p.line(strings.ToUpper(doc.Title))
+	p.bold(false)
+	p.wrapped("No. "+doc.Number, p.columns)
+	p.line(doc.Date.Local().Format("02 Jan 2006 15:04"))
+	p.rule()
+	for _, line := range billTo(doc.Customer) {
+		p.wrapped(line, p.columns)
+	}
+
+	if len(doc.Lines) > 0 {
+		p.rule()
+		for _, line := range doc.Lines {
+			p.wrapped(latin(line.Description), p.columns)
+			p.pair(fmt.Sprintf("  %d x %s", line.Quantity, money(line.Unit_price)), money(line.Unit_price*float64(line.Quantity)))
+			if line.Discount != 0 {
+				p.pair("  Discount", money(-line.Discount))
+			}
+		}
+	}
+	p.rule()
+	for i, total := range doc.Totals {
+		last := i == len(doc.Totals)-1
+		p.bold(last)
+		p.pair(total.Label, money(total.Amount))
+	}
+	p.bold(false)
+
+	if doc.Note != "" {
+		p.line("")
+		p.wrapped(latin(doc.Note), p.columns)
+	}
+	if doc.Organization.Invoice_footer != "" {
+		p.line("")
+		p.align(true)
+		p.wrapped(latin(doc.Organization.Invoice_footer), p.columns)
+		p.align(false)
+	}
+
+	// feed the end of the receipt past the cutter and cut
+	p.command(gs, 'V', 66, 3)
+	_, err := p.b.WriteTo(w)
+	return err
+}
@@ -0,0 +1,85 @@
+package printing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+)
+
+// MaxLogoSize bounds the bytes of an uploaded logo
+const MaxLogoSize = 256 << 10
+
+// maxLogoPixels bounds the side of a logo so that decoding stays cheap
+const maxLogoPixels = 2048
+
+// ErrInvalidLogo is returned for logos that are not small PNG or JPEG images
+var ErrInvalidLogo = errors.New("invalid logo")
+
+// CheckLogo verifies data is a PNG or JPEG image fit for a logo and returns its media type
+func CheckLogo(data []byte) (string, error) {
+	if len(data) > MaxLogoSize {
+		return "", fmt.Errorf("%w: larger than %d KB", ErrInvalidLogo, MaxLogoSize>>10)
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidLogo, err)
+	}
+	if config.Width > maxLogoPixels || config.Height > maxLogoPixels {
+		return "", fmt.Errorf("%w: larger than %dx%d pixels", ErrInvalidLogo, maxLogoPixels, maxLogoPixels)
+	}
+	if _, err := decodeLogo(data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidLogo, err)
+	}
+	return "image/" + format, nil
+}
+
+func decodeLogo(data []byte) (image.Image, error) {
+	if _, err := png.DecodeConfig(bytes.NewReader(data)); err == nil {
+		return png.Decode(bytes.NewReader(data))
+	}
+	return jpeg.Decode(bytes.NewReader(data))
+}
+
+// fitLogo decodes data and scales it down to fit maxWidth by maxHeight pixels,
+// flattening transparency onto white paper. It returns nil for an absent or
+// broken logo, which is left off the document.
+func fitLogo(data []byte, maxWidth int, maxHeight int) *image.RGBA {
+	if len(data) == 0 {
+		return nil
+	}
+	src, err := decodeLogo(data)
+	if err != nil {
+		return nil
+	}
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil
+	}
+	scale := 1.0
+	if s := float64(maxWidth) / float64(width); s < scale {
+		scale = s
+	}
+	if s := float64(maxHeight) / float64(height); s < scale {
+		scale = s
+	}
+	width, height = int(float64(width)*scale), int(float64(height)*scale)
+	if width < 1 || height < 1 {
+		return nil
+	}
+
+	// nearest neighbour sampling keeps the edges of logos sharp
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := src.At(bounds.Min.X+x*bounds.Dx()/width, bounds.Min.Y+y*bounds.Dy()/height).RGBA()
+			white := 0xffff - a
+			dst.SetRGBA(x, y, color.RGBA{uint8((r + white) >> 8), uint8((g + white) >> 8), uint8((b + white) >> 8), 0xff})
+		}
+	}
+	return dst
+}
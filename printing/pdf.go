@@ -0,0 +1,275 @@
+package printing
+
+import (
+	"appadming/models"
+	"appadming/spreadsheet"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+// Page layout of printed documents: A4 portrait in points
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 48
+	pdfFontSize   = 10
+	pdfLineHeight = 14
+	pdfLogoWidth  = 160
+	pdfLogoHeight = 64
+	// pdfLogoDPI is the resolution the logo is kept at
+	pdfLogoDPI = 150
+)
+
+// Columns of the line table: the item fills what the numbers leave
+var pdfColumns = []struct {
+	title string
+	right float64
+}{
+	{"Item", 0},
+	{"Qty", 330},
+	{"Unit price", 410},
+	{"Discount", 480},
+	{"Total", pdfPageWidth - pdfMargin},
+}
+
+// Objects written before the pages
+const (
+	pdfCatalog  = 1
+	pdfPages    = 2
+	pdfFont     = 3
+	pdfBoldFont = 4
+	pdfLogo     = 5
+)
+
+// pdfDocument collects the content of pages, written at once by writeTo
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	logo  *image.RGBA
+}
+
+func (d *pdfDocument) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *pdfDocument) text(x float64, y float64, size int, bold bool, value string) {
+	font := pdfFont
+	if bold {
+		font = pdfBoldFont
+	}
+	fmt.Fprintf(d.page(), "BT /F%d %d Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, spreadsheet.PDFString(value))
+}
+
+// textRight prints value ending at x
+func (d *pdfDocument) textRight(x float64, y float64, size int, bold bool, value string) {
+	d.text(x-textWidth(value, size), y, size, bold, value)
+}
+
+func (d *pdfDocument) rule(y float64) {
+	fmt.Fprintf(d.page(), "0.5 w %d %.2f m %d %.2f l S\n", pdfMargin, y, pdfPageWidth-pdfMargin, y)
+}
+
+// textWidth approximates the width of value in Helvetica, exactly for the
+// digits and separators that make up amounts
+func textWidth(value string, size int) float64 {
+	var em float64
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			em += 0.556
+		case r == ',' || r == '.' || r == ' ':
+			em += 0.278
+		case r == '-':
+			em += 0.333
+		default:
+			em += 0.5
+		}
+	}
+	return em * float64(size)
+}
+
+// renderPDF prints doc on as many pages as its lines need, each headed by the
+// organization and the title and the last one closed by the totals and footer
+func renderPDF(w io.Writer, doc models.Document) error {
+	d := &pdfDocument{logo: fitLogo(doc.Organization.Logo, pdfLogoWidth*pdfLogoDPI/72, pdfLogoHeight*pdfLogoDPI/72)}
+	title := strings.ToUpper(doc.Title)
+
+	y := d.head(doc, title)
+	if len(doc.Lines) > 0 {
+		y = d.tableHeader(y)
+		for _, line := range doc.Lines {
+			description := wrap(latin(line.Description), 48)
+			if y-float64(len(description)*pdfLineHeight) < pdfMargin+pdfLineHeight {
+				d.newPage()
+				y = d.tableHeader(d.continued(title, doc.Number))
+			}
+			d.textRight(pdfColumns[1].right, y, pdfFontSize, false, fmt.Sprint(line.Quantity))
+			d.textRight(pdfColumns[2].right, y, pdfFontSize, false, money(line.Unit_price))
+			if line.Discount != 0 {
+				d.textRight(pdfColumns[3].right, y, pdfFontSize, false, money(-line.Discount))
+			}
+			d.textRight(pdfColumns[4].right, y, pdfFontSize, false, money(line.Total))
+			for _, text := range description {
+				d.text(pdfMargin, y, pdfFontSize, false, text)
+				y -= pdfLineHeight
+			}
+		}
+		d.rule(y + pdfLineHeight - 4)
+	}
+
+	// totals, note and footer stay together at the end
+	footer := wrap(latin(doc.Organization.Invoice_footer), 90)
+	note := wrap(latin(doc.Note), 90)
+	needed := float64(len(doc.Totals)+len(note)+len(footer)+3) * pdfLineHeight
+	if y-needed < pdfMargin {
+		d.newPage()
+		y = d.continued(title, doc.Number)
+	}
+	y -= pdfLineHeight / 2
+	for i, total := range doc.Totals {
+		// the last total is the one that matters most
+		last := i == len(doc.Totals)-1
+		d.textRight(pdfColumns[3].right, y, pdfFontSize, last, total.Label)
+		d.textRight(pdfColumns[4].right, y, pdfFontSize, last, money(total.Amount))
+		y -= pdfLineHeight
+	}
+	if len(note) > 0 {
+		y -= pdfLineHeight
+		for _, text := range note {
+			d.text(pdfMargin, y, pdfFontSize, false, text)
+			y -= pdfLineHeight
+		}
+	}
+	for i, text := range footer {
+		d.text((pdfPageWidth-textWidth(text, pdfFontSize-1))/2, pdfMargin+float64(len(footer)-1-i)*pdfLineHeight, pdfFontSize-1, false, text)
+	}
+	return d.writeTo(w)
+}
+
+// head prints the first page heading and returns where the lines start
+func (d *pdfDocument) head(doc models.Document, title string) float64 {
+	d.newPage()
+	top := float64(pdfPageHeight - pdfMargin)
+	x := float64(pdfMargin)
+	if d.logo != nil {
+		width, height := d.logoSize()
+		fmt.Fprintf(d.page(), "q %.2f 0 0 %.2f %d %.2f cm /Im%d Do Q\n", width, height, pdfMargin, top-height, pdfLogo)
+		x += width + 12
+	}
+
+	y := top - 16
+	d.text(x, y, 16, true, latin(doc.Organization.Name))
+	for _, line := range header(doc.Organization) {
+		y -= pdfLineHeight
+		d.text(x, y, pdfFontSize, false, line)
+	}
+
+	d.textRight(pdfPageWidth-pdfMargin, top-20, 20, true, title)
+	d.textRight(pdfPageWidth-pdfMargin, top-20-1.5*pdfLineHeight, pdfFontSize, false, "No. "+doc.Number)
+	d.textRight(pdfPageWidth-pdfMargin, top-20-2.5*pdfLineHeight, pdfFontSize, false, doc.Date.Local().Format("02 Jan 2006 15:04"))
+
+	if d.logo != nil {
+		_, height := d.logoSize()
+		if top-height < y {
+			y = top - height
+		}
+	}
+	y -= 2 * pdfLineHeight
+	d.rule(y + pdfLineHeight - 2)
+
+	y -= pdfLineHeight / 2
+	d.text(pdfMargin, y, pdfFontSize-1, true, "BILL TO")
+	for i, line := range billTo(doc.Customer) {
+		y -= pdfLineHeight
+		d.text(pdfMargin, y, pdfFontSize, i == 0, line)
+	}
+	return y - 2*pdfLineHeight
+}
+
+// continued heads the pages after the first
+func (d *pdfDocument) continued(title string, number string) float64 {
+	y := float64(pdfPageHeight - pdfMargin - pdfFontSize)
+	d.text(pdfMargin, y, pdfFontSize, true, fmt.Sprintf("%s %s (continued)", title, number))
+	return y - 2*pdfLineHeight
+}
+
+func (d *pdfDocument) tableHeader(y float64) float64 {
+	d.text(pdfMargin, y, pdfFontSize, true, pdfColumns[0].title)
+	for _, column := range pdfColumns[1:] {
+		d.textRight(column.right, y, pdfFontSize, true, column.title)
+	}
+	d.rule(y - 4)
+	return y - pdfLineHeight - 4
+}
+
+// logoSize is the size of the logo on the page in points
+func (d *pdfDocument) logoSize() (float64, float64) {
+	bounds := d.logo.Bounds()
+	return float64(bounds.Dx()) * 72 / pdfLogoDPI, float64(bounds.Dy()) * 72 / pdfLogoDPI
+}
+
+// writeTo writes the file: the fixed objects, the logo, then a content stream
+// and a page object for every page
+func (d *pdfDocument) writeTo(w io.Writer) error {
+	var out bytes.Buffer
+	offsets := []int{0}
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets)-1, body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	first := pdfLogo + 1
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", first+2*i+1)
+	}
+	object(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPages))
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	resources := fmt.Sprintf("/Font << /F%d %d 0 R /F%d %d 0 R >>", pdfFont, pdfFont, pdfBoldFont, pdfBoldFont)
+	if d.logo != nil {
+		bounds := d.logo.Bounds()
+		var pixels bytes.Buffer
+		compressor := zlib.NewWriter(&pixels)
+		for y := 0; y < bounds.Dy(); y++ {
+			for x := 0; x < bounds.Dx(); x++ {
+				c := d.logo.RGBAAt(x, y)
+				compressor.Write([]byte{c.R, c.G, c.B})
+			}
+		}
+		compressor.Close()
+		object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+			bounds.Dx(), bounds.Dy(), pixels.Len(), pixels.String()))
+		resources += fmt.Sprintf(" /XObject << /Im%d %d 0 R >>", pdfLogo, pdfLogo)
+	} else {
+		// keep the numbering of the pages fixed
+		object("null")
+	}
+
+	for i, page := range d.pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		fmt.Fprintf(page, "BT /F%d %d Tf %.2f %d Td (%s) Tj ET\n", pdfFont, pdfFontSize-2, pdfPageWidth-pdfMargin-textWidth(footer, pdfFontSize-2), pdfMargin/2, footer)
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+		object(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << %s >> /Contents %d 0 R >>",
+			pdfPages, pdfPageWidth, pdfPageHeight, resources, len(offsets)-1))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, offset := range offsets[1:] {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets), pdfCatalog, xref)
+	_, err := out.WriteTo(w)
+	return err
+}
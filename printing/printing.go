@@ -0,0 +1,158 @@
+// Package printing lays out invoices and receipts as A4 PDF pages and as
+// ESC/POS commands for 58mm and 80mm thermal receipt printers. Bengali text is
+// transliterated since neither the PDF fonts nor the printers' code pages
+// cover it.
+package printing
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats understood by Render
+const (
+	PDF    = "pdf"
+	ESCPOS = "escpos"
+)
+
+// Widths of thermal paper in millimetres
+const (
+	Paper58 = 58
+	Paper80 = 80
+)
+
+// ErrUnsupportedFormat is returned for unknown formats and paper widths
+var ErrUnsupportedFormat = errors.New("unsupported print format")
+
+// ContentType is the media type of documents in format
+func ContentType(format string) string {
+	if format == PDF {
+		return "application/pdf"
+	}
+	return "application/octet-stream"
+}
+
+// Extension names files holding documents in format
+func Extension(format string) string {
+	if format == ESCPOS {
+		return "bin"
+	}
+	return format
+}
+
+// Render writes doc in format. paper is the width of thermal paper and only
+// matters to ESC/POS.
+func Render(w io.Writer, doc models.Document, format string, paper int) error {
+	switch format {
+	case PDF:
+		return renderPDF(w, doc)
+	case ESCPOS:
+		if _, ok := paperColumns[paper]; !ok {
+			return fmt.Errorf("%w: paper must be %d or %d mm wide", ErrUnsupportedFormat, Paper58, Paper80)
+		}
+		return renderESCPOS(w, doc, paper)
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// latin spells value with Latin letters
+func latin(value string) string {
+	return repositories.Transliterate(value)
+}
+
+// money formats amount with two decimals and thousands separators
+func money(amount float64) string {
+	digits := strconv.FormatFloat(amount, 'f', 2, 64)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	whole, fraction := digits[:len(digits)-3], digits[len(digits)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	if sign != "" && strings.Trim(whole+fraction, "0.,") == "" {
+		sign = ""
+	}
+	return sign + whole + fraction
+}
+
+// address joins the parts of an address that are set
+func address(parts ...string) string {
+	var set []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			set = append(set, part)
+		}
+	}
+	return strings.Join(set, ", ")
+}
+
+func phone(number int) string {
+	if number == 0 {
+		return ""
+	}
+	return strconv.Itoa(number)
+}
+
+// header lists the lines printed under the organization name
+func header(organization models.Orgnization) []string {
+	var lines []string
+	if organization.Address != "" {
+		lines = append(lines, latin(organization.Address))
+	}
+	if number := phone(organization.Phone); number != "" {
+		lines = append(lines, "Phone: "+number)
+	}
+	if organization.Email != "" {
+		lines = append(lines, organization.Email)
+	}
+	return lines
+}
+
+// billTo lists the customer lines of a document
+func billTo(customer models.Customer) []string {
+	lines := []string{latin(customer.Name)}
+	if home := address(customer.Home, customer.Village, customer.Thana, customer.District); home != "" {
+		lines = append(lines, latin(home))
+	}
+	if number := phone(customer.Phone); number != "" {
+		lines = append(lines, "Phone: "+number)
+	}
+	return lines
+}
+
+// wrap breaks value into lines of at most width characters, cutting words
+// longer than a line
+func wrap(value string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(value) {
+		for len([]rune(word)) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string([]rune(word)[:width]))
+			word = string([]rune(word)[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,180 @@
+package printing
+
+import (
+	"appadming/models"
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testDocument() models.Document {
+	return models.Document{
+		Title:  "Invoice",
+		Number: "INV-0007",
+		Date:   time.Date(2026, time.March, 1, 15, 4, 0, 0, time.Local),
+		Organization: models.Orgnization{
+			Name:           "Rahman Electronics",
+			Address:        "Savar Bazar, Dhaka",
+			Phone:          1711000000,
+			Invoice_footer: "Goods once sold are taken back within 7 days",
+		},
+		Customer: models.Customer{Name: "করিম", Village: "Kumarpara", District: "Dhaka", Phone: 1811000000},
+		Lines: []models.DocumentLine{
+			{Description: "Samsung Galaxy A15 128GB Light Blue with two year warranty", Quantity: 2, Unit_price: 21500, Discount: 500, Total: 42500},
+			{Description: "Charger", Quantity: 1, Unit_price: 350, Total: 350},
+		},
+		Totals: []models.DocumentTotal{{Label: "Total", Amount: 42850}, {Label: "Paid", Amount: 10000}, {Label: "Due", Amount: 32850}},
+	}
+}
+
+func TestMoney(t *testing.T) {
+	tests := map[float64]string{
+		0:          "0.00",
+		12.5:       "12.50",
+		1000:       "1,000.00",
+		1234567.89: "1,234,567.89",
+		-42850:     "-42,850.00",
+		-0.001:     "0.00",
+	}
+	for amount, want := range tests {
+		if got := money(amount); got != want {
+			t.Errorf("money(%v) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		value string
+		width int
+		want  []string
+	}{
+		{"Goods once sold are not taken back", 12, []string{"Goods once", "sold are not", "taken back"}},
+		{"IMEI 356789012345678", 8, []string{"IMEI", "35678901", "2345678"}},
+		{"  ", 8, nil},
+	}
+	for _, tt := range tests {
+		if got := wrap(tt.value, tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrap(%q, %d) = %q, want %q", tt.value, tt.width, got, tt.want)
+		}
+	}
+}
+
+// escposText drops the commands of a receipt, leaving its printed lines
+func escposText(receipt []byte) []string {
+	var text []byte
+	for i := 0; i < len(receipt); i++ {
+		switch receipt[i] {
+		case esc:
+			if receipt[i+1] == '@' {
+				i++
+			} else {
+				i += 2
+			}
+		case gs:
+			if receipt[i+1] == 'V' {
+				i += 3
+			} else {
+				i += 2
+			}
+		default:
+			text = append(text, receipt[i])
+		}
+	}
+	return strings.Split(strings.TrimSuffix(string(text), "\n"), "\n")
+}
+
+func TestRenderESCPOS(t *testing.T) {
+	for name, paper := range map[string]int{"58mm": Paper58, "80mm": Paper80} {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Render(&out, testDocument(), ESCPOS, paper); err != nil {
+				t.Fatal(err)
+			}
+			receipt := out.Bytes()
+			if !bytes.HasPrefix(receipt, []byte{esc, '@'}) || !bytes.HasSuffix(receipt, []byte{gs, 'V', 66, 3}) {
+				t.Errorf("the receipt does not start by resetting and end by cutting")
+			}
+			lines := escposText(receipt)
+			for _, line := range lines {
+				if len(line) > paperColumns[paper] {
+					t.Errorf("%q is wider than %d columns", line, paperColumns[paper])
+				}
+				for _, r := range line {
+					if r < 32 || r > 126 {
+						t.Errorf("%q prints %q outside ASCII", line, r)
+					}
+				}
+			}
+			text := strings.Join(lines, "\n")
+			for _, want := range []string{"INVOICE", "No. INV-0007", "01 Mar 2026 15:04", "karim", "Phone: 1811000000", "42,850.00", "Goods once sold"} {
+				if !strings.Contains(text, want) {
+					t.Errorf("the receipt does not print %q:\n%s", want, text)
+				}
+			}
+			// amounts are right aligned on the line of their label
+			due := "Due" + strings.Repeat(" ", paperColumns[paper]-len("Due")-len("32,850.00")) + "32,850.00"
+			if !strings.Contains(text, "\n"+due+"\n") {
+				t.Errorf("the receipt does not print %q:\n%s", due, text)
+			}
+		})
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	doc := testDocument()
+	logo := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		logo.Set(x, 10, color.Black)
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, logo); err != nil {
+		t.Fatal(err)
+	}
+	doc.Organization.Logo = encoded.Bytes()
+	for i := 0; i < 60; i++ {
+		doc.Lines = append(doc.Lines, models.DocumentLine{Description: "Screen guard", Quantity: 1, Unit_price: 100, Total: 100})
+	}
+
+	var out bytes.Buffer
+	if err := Render(&out, doc, PDF, 0); err != nil {
+		t.Fatal(err)
+	}
+	pdf := out.String()
+	if !strings.HasPrefix(pdf, "%PDF-") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatalf("not a PDF file")
+	}
+	for _, want := range []string{"(INVOICE)", "(karim)", "(INVOICE INV-0007 \\(continued\\))", "(32,850.00)", "/Subtype /Image /Width 40 /Height 20", "/Count 2"} {
+		if !strings.Contains(pdf, want) {
+			t.Errorf("the PDF does not hold %q", want)
+		}
+	}
+}
+
+func TestRenderRejectsUnknownFormats(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		paper  int
+	}{{"html", 0}, {ESCPOS, 0}, {ESCPOS, 76}} {
+		if err := Render(&bytes.Buffer{}, testDocument(), tt.format, tt.paper); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("Render(%s, %d) error = %v, want ErrUnsupportedFormat", tt.format, tt.paper, err)
+		}
+	}
+}
+
+func TestCheckLogo(t *testing.T) {
+	var small bytes.Buffer
+	png.Encode(&small, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	if mediaType, err := CheckLogo(small.Bytes()); err != nil || mediaType != "image/png" {
+		t.Errorf("CheckLogo(png) = %q, %v", mediaType, err)
+	}
+	if _, err := CheckLogo([]byte("GIF89a")); !errors.Is(err, ErrInvalidLogo) {
+		t.Errorf("CheckLogo(gif) error = %v, want ErrInvalidLogo", err)
+	}
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AttachmentFields are the attachment fields lists can be filtered and sorted on
+var AttachmentFields = ListFields{
+	"document_type": {Key: "document_type", Kind: StringField},
+	"owner_type":    {Key: "owner_type", Kind: StringField},
+	"filename":      {Key: "filename", Kind: StringField},
+	"user":          {Key: "user_id", Kind: IDField},
+	"created_at":    {Key: "created_at", Kind: TimeField},
+}
+
+// AttachmentDownloadFields are the download audit fields lists can be filtered and sorted on
+var AttachmentDownloadFields = ListFields{
+	"attachment":    {Key: "attachment_id", Kind: IDField},
+	"customer":      {Key: "customer_id", Kind: IDField},
+	"user":          {Key: "user_id", Kind: IDField},
+	"document_type": {Key: "document_type", Kind: StringField},
+	"date":          {Key: "date", Kind: TimeField},
+}
+
+// AttachmentRepository persists models.Attachment documents
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *models.Attachment) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Attachment, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.Attachment], error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+// AttachmentDownloadRepository keeps the audit of attachment downloads
+type AttachmentDownloadRepository interface {
+	Create(ctx context.Context, download *models.AttachmentDownload) error
+	List(ctx context.Context, query ListQuery) (ListResult[models.AttachmentDownload], error)
+}
+
+var attachmentTenancy = &tenancy[models.Attachment]{
+	key: "organization_id",
+	get: func(doc models.Attachment) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.Attachment, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+var attachmentDownloadTenancy = &tenancy[models.AttachmentDownload]{
+	key: "organization_id",
+	get: func(doc models.AttachmentDownload) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.AttachmentDownload, organizationId primitive.ObjectID) {
+		doc.Organization_id = organizationId
+	},
+}
+
+type mongoAttachmentRepository struct {
+	mongoCollection[models.Attachment]
+}
+
+func newMongoAttachmentRepository(client *mongo.Client) *mongoAttachmentRepository {
+	return &mongoAttachmentRepository{mongoCollection[models.Attachment]{configs.GetCollection(client, "attachments"), attachmentTenancy}}
+}
+
+// ensureIndexes supports listing the attachments of a customer or a sale
+func (r *mongoAttachmentRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func (r *mongoAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	return r.insert(ctx, attachment)
+}
+
+func (r *mongoAttachmentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Attachment, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoAttachmentRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Attachment], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoAttachmentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+type mongoAttachmentDownloadRepository struct {
+	mongoCollection[models.AttachmentDownload]
+}
+
+func newMongoAttachmentDownloadRepository(client *mongo.Client) *mongoAttachmentDownloadRepository {
+	return &mongoAttachmentDownloadRepository{mongoCollection[models.AttachmentDownload]{configs.GetCollection(client, "attachment_downloads"), attachmentDownloadTenancy}}
+}
+
+func (r *mongoAttachmentDownloadRepository) Create(ctx context.Context, download *models.AttachmentDownload) error {
+	return r.insert(ctx, download)
+}
+
+func (r *mongoAttachmentDownloadRepository) List(ctx context.Context, query ListQuery) (ListResult[models.AttachmentDownload], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+type memoryAttachmentRepository struct {
+	docs *memoryCollection[models.Attachment]
+}
+
+func newMemoryAttachmentRepository() *memoryAttachmentRepository {
+	return &memoryAttachmentRepository{newMemoryCollection(func(a models.Attachment) primitive.ObjectID { return a.Id }, attachmentTenancy)}
+}
+
+func (r *memoryAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	r.docs.insert(ctx, attachment)
+	return nil
+}
+
+func (r *memoryAttachmentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Attachment, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryAttachmentRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Attachment], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryAttachmentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
+
+type memoryAttachmentDownloadRepository struct {
+	docs *memoryCollection[models.AttachmentDownload]
+}
+
+func newMemoryAttachmentDownloadRepository() *memoryAttachmentDownloadRepository {
+	return &memoryAttachmentDownloadRepository{newMemoryCollection(func(d models.AttachmentDownload) primitive.ObjectID { return d.Id }, attachmentDownloadTenancy)}
+}
+
+func (r *memoryAttachmentDownloadRepository) Create(ctx context.Context, download *models.AttachmentDownload) error {
+	r.docs.insert(ctx, download)
+	return nil
+}
+
+func (r *memoryAttachmentDownloadRepository) List(ctx context.Context, query ListQuery) (ListResult[models.AttachmentDownload], error) {
+	return r.docs.list(ctx, nil, query)
+}
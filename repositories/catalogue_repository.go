@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CategoryFields are the category fields lists can be filtered and sorted on
+var CategoryFields = ListFields{
+	"name":   {Key: "name", Kind: StringField},
+	"parent": {Key: "parent_id", Kind: IDField},
+}
+
+// CategoryRepository persists models.Category documents
+type CategoryRepository interface {
+	Create(ctx context.Context, category *models.Category) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Category, error)
+	FindAll(ctx context.Context) ([]models.Category, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.Category], error)
+	Update(ctx context.Context, id primitive.ObjectID, category models.Category) (models.Category, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+var categoryTenancy = &tenancy[models.Category]{
+	key: "organization_id",
+	get: func(doc models.Category) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.Category, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+type mongoCategoryRepository struct {
+	mongoCollection[models.Category]
+}
+
+func newMongoCategoryRepository(client *mongo.Client) *mongoCategoryRepository {
+	return &mongoCategoryRepository{mongoCollection[models.Category]{configs.GetCollection(client, "categories"), categoryTenancy}}
+}
+
+func (r *mongoCategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	return r.insert(ctx, category)
+}
+
+func (r *mongoCategoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Category, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoCategoryRepository) FindAll(ctx context.Context) ([]models.Category, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoCategoryRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Category], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoCategoryRepository) Update(ctx context.Context, id primitive.ObjectID, category models.Category) (models.Category, error) {
+	update := bson.M{
+		"name":        category.Name,
+		"parent_id":   category.Parent_id,
+		"description": category.Description,
+	}
+	return r.update(ctx, bson.M{"id": id}, update)
+}
+
+func (r *mongoCategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+type memoryCategoryRepository struct {
+	docs *memoryCollection[models.Category]
+}
+
+func newMemoryCategoryRepository() *memoryCategoryRepository {
+	return &memoryCategoryRepository{newMemoryCollection(func(c models.Category) primitive.ObjectID { return c.Id }, categoryTenancy)}
+}
+
+func (r *memoryCategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	r.docs.insert(ctx, category)
+	return nil
+}
+
+func (r *memoryCategoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Category, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryCategoryRepository) FindAll(ctx context.Context) ([]models.Category, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryCategoryRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Category], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryCategoryRepository) Update(ctx context.Context, id primitive.ObjectID, category models.Category) (models.Category, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Category) {
+		category.Id = doc.Id
+		category.Organization_id = doc.Organization_id
+		*doc = category
+	})
+}
+
+func (r *memoryCategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
+
+// BrandFields are the brand fields lists can be filtered and sorted on
+var BrandFields = ListFields{
+	"name": {Key: "name", Kind: StringField},
+}
+
+// BrandRepository persists models.Brand documents
+type BrandRepository interface {
+	Create(ctx context.Context, brand *models.Brand) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Brand, error)
+	FindAll(ctx context.Context) ([]models.Brand, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.Brand], error)
+	Update(ctx context.Context, id primitive.ObjectID, brand models.Brand) (models.Brand, error)
+	// SetLogo replaces the logo, an empty logo removes it
+	SetLogo(ctx context.Context, id primitive.ObjectID, logo []byte, contentType string) (models.Brand, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+var brandTenancy = &tenancy[models.Brand]{
+	key: "organization_id",
+	get: func(doc models.Brand) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.Brand, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+type mongoBrandRepository struct {
+	mongoCollection[models.Brand]
+}
+
+func newMongoBrandRepository(client *mongo.Client) *mongoBrandRepository {
+	return &mongoBrandRepository{mongoCollection[models.Brand]{configs.GetCollection(client, "brands"), brandTenancy}}
+}
+
+func (r *mongoBrandRepository) Create(ctx context.Context, brand *models.Brand) error {
+	return r.insert(ctx, brand)
+}
+
+func (r *mongoBrandRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Brand, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoBrandRepository) FindAll(ctx context.Context) ([]models.Brand, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoBrandRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Brand], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoBrandRepository) Update(ctx context.Context, id primitive.ObjectID, brand models.Brand) (models.Brand, error) {
+	update := bson.M{
+		"name":        brand.Name,
+		"description": brand.Description,
+	}
+	return r.update(ctx, bson.M{"id": id}, update)
+}
+
+func (r *mongoBrandRepository) SetLogo(ctx context.Context, id primitive.ObjectID, logo []byte, contentType string) (models.Brand, error) {
+	return r.update(ctx, bson.M{"id": id}, bson.M{"logo": logo, "logo_type": contentType})
+}
+
+func (r *mongoBrandRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+type memoryBrandRepository struct {
+	docs *memoryCollection[models.Brand]
+}
+
+func newMemoryBrandRepository() *memoryBrandRepository {
+	return &memoryBrandRepository{newMemoryCollection(func(b models.Brand) primitive.ObjectID { return b.Id }, brandTenancy)}
+}
+
+func (r *memoryBrandRepository) Create(ctx context.Context, brand *models.Brand) error {
+	r.docs.insert(ctx, brand)
+	return nil
+}
+
+func (r *memoryBrandRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Brand, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryBrandRepository) FindAll(ctx context.Context) ([]models.Brand, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryBrandRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Brand], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryBrandRepository) Update(ctx context.Context, id primitive.ObjectID, brand models.Brand) (models.Brand, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Brand) {
+		brand.Id = doc.Id
+		brand.Organization_id = doc.Organization_id
+		brand.Logo, brand.Logo_type = doc.Logo, doc.Logo_type
+		*doc = brand
+	})
+}
+
+func (r *memoryBrandRepository) SetLogo(ctx context.Context, id primitive.ObjectID, logo []byte, contentType string) (models.Brand, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Brand) {
+		doc.Logo, doc.Logo_type = logo, contentType
+	})
+}
+
+func (r *memoryBrandRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
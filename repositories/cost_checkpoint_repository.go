@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CostCheckpointRepository keeps the costing checkpoints of products, one per
+// product, method and date
+type CostCheckpointRepository interface {
+	// Save stores checkpoint in place of the checkpoint of its product and
+	// method at its date, if any
+	Save(ctx context.Context, checkpoint *models.CostCheckpoint) error
+	// FindBefore returns the latest checkpoint of the product under method
+	// dated up to date
+	FindBefore(ctx context.Context, productId primitive.ObjectID, method string, date time.Time) (models.CostCheckpoint, error)
+}
+
+var costCheckpointTenancy = &tenancy[models.CostCheckpoint]{
+	key: "organization_id",
+	get: func(doc models.CostCheckpoint) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.CostCheckpoint, organizationId primitive.ObjectID) {
+		doc.Organization_id = organizationId
+	},
+}
+
+type mongoCostCheckpointRepository struct {
+	mongoCollection[models.CostCheckpoint]
+}
+
+func newMongoCostCheckpointRepository(client *mongo.Client) *mongoCostCheckpointRepository {
+	return &mongoCostCheckpointRepository{mongoCollection[models.CostCheckpoint]{configs.GetCollection(client, "cost_checkpoints"), costCheckpointTenancy}}
+}
+
+// ensureIndexes keeps a single checkpoint per product, method and date, and
+// supports finding the latest checkpoint of a product
+func (r *mongoCostCheckpointRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "organization_id", Value: 1}, {Key: "product_id", Value: 1}, {Key: "method", Value: 1}, {Key: "date", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *mongoCostCheckpointRepository) Save(ctx context.Context, checkpoint *models.CostCheckpoint) error {
+	if organizationId, ok := OrganizationFrom(ctx); ok {
+		checkpoint.Organization_id = organizationId
+	}
+	filter := bson.M{
+		"organization_id": checkpoint.Organization_id,
+		"product_id":      checkpoint.Product_id,
+		"method":          checkpoint.Method,
+		"date":            checkpoint.Date,
+	}
+	update := bson.M{
+		"$set":         bson.M{"layers": checkpoint.Layers, "last_cost": checkpoint.Last_cost, "sales": checkpoint.Sales},
+		"$setOnInsert": bson.M{"id": checkpoint.Id},
+	}
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *mongoCostCheckpointRepository) FindBefore(ctx context.Context, productId primitive.ObjectID, method string, date time.Time) (models.CostCheckpoint, error) {
+	var checkpoint models.CostCheckpoint
+	filter := bson.M{"product_id": productId, "method": method, "date": bson.M{"$lte": primitive.NewDateTimeFromTime(date)}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "date", Value: -1}})
+	err := r.collection.FindOne(ctx, r.scope(ctx, filter), opts).Decode(&checkpoint)
+	if err == mongo.ErrNoDocuments {
+		return checkpoint, ErrNotFound
+	}
+	return checkpoint, err
+}
+
+type memoryCostCheckpointRepository struct {
+	docs *memoryCollection[models.CostCheckpoint]
+	// mu makes the lookup and the insert of a new checkpoint one step
+	mu sync.Mutex
+}
+
+func newMemoryCostCheckpointRepository() *memoryCostCheckpointRepository {
+	return &memoryCostCheckpointRepository{docs: newMemoryCollection(func(c models.CostCheckpoint) primitive.ObjectID { return c.Id }, costCheckpointTenancy)}
+}
+
+func (r *memoryCostCheckpointRepository) Save(ctx context.Context, checkpoint *models.CostCheckpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err := r.docs.update(ctx, func(c models.CostCheckpoint) bool {
+		return c.Product_id == checkpoint.Product_id && c.Method == checkpoint.Method && c.Date == checkpoint.Date
+	}, func(c *models.CostCheckpoint) {
+		c.Layers, c.Last_cost, c.Sales = checkpoint.Layers, checkpoint.Last_cost, checkpoint.Sales
+	})
+	if err == ErrNotFound {
+		r.docs.insert(ctx, checkpoint)
+		return nil
+	}
+	return err
+}
+
+func (r *memoryCostCheckpointRepository) FindBefore(ctx context.Context, productId primitive.ObjectID, method string, date time.Time) (models.CostCheckpoint, error) {
+	limit := primitive.NewDateTimeFromTime(date)
+	var latest models.CostCheckpoint
+	found := false
+	for _, checkpoint := range r.docs.find(ctx, func(c models.CostCheckpoint) bool {
+		return c.Product_id == productId && c.Method == method && c.Date <= limit
+	}) {
+		if !found || checkpoint.Date > latest.Date {
+			latest, found = checkpoint, true
+		}
+	}
+	if !found {
+		return latest, ErrNotFound
+	}
+	return latest, nil
+}
@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CustomerFields are the customer fields lists can be filtered and sorted on
+var CustomerFields = ListFields{
+	"name":     {Key: "name", Kind: StringField},
+	"father":   {Key: "father", Kind: StringField},
+	"village":  {Key: "village", Kind: StringField},
+	"thana":    {Key: "thana", Kind: StringField},
+	"district": {Key: "district", Kind: StringField},
+	"phone":    {Key: "phone", Kind: NumberField},
+	"email":    {Key: "email", Kind: StringField},
+	"due_date": {Key: "duedate", Kind: TimeField},
+}
+
+// CustomerRepository persists models.Customer documents
+type CustomerRepository interface {
+	Create(ctx context.Context, customer *models.Customer) error
+	// CreateMany inserts customers in one batch
+	CreateMany(ctx context.Context, customers []*models.Customer) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Customer, error)
+	FindAll(ctx context.Context) ([]models.Customer, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.Customer], error)
+	// Stream calls fn with every document matching the query, ignoring its pagination
+	Stream(ctx context.Context, query ListQuery, fn func(models.Customer) error) error
+	Update(ctx context.Context, id primitive.ObjectID, customer models.Customer) (models.Customer, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Search ranks the customers matching query by name, father, village,
+	// thana, district and phone, tolerating partial words, misspellings and
+	// Bengali script, and returns the best limit matches
+	Search(ctx context.Context, query string, limit int) ([]models.CustomerMatch, error)
+}
+
+var customerTenancy = &tenancy[models.Customer]{
+	key: "organization_id",
+	get: func(doc models.Customer) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.Customer, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+// customerSearchFields are the fields a customer is found by, by importance
+func customerSearchFields(customer models.Customer) []searchField {
+	return []searchField{
+		{customer.Name, 3},
+		{strconv.Itoa(customer.Phone), 3},
+		{customer.Father, 2},
+		{customer.Village, 2},
+		{customer.Thana, 1},
+		{customer.District, 1},
+	}
+}
+
+// indexCustomer refreshes the search keys of customer
+func indexCustomer(customer *models.Customer) {
+	customer.Search_terms, customer.Search_codes = searchKeys(customerSearchFields(*customer))
+}
+
+func customerMatches(hits []searchHit[models.Customer]) []models.CustomerMatch {
+	matches := make([]models.CustomerMatch, len(hits))
+	for i, hit := range hits {
+		matches[i] = models.CustomerMatch{Customer: hit.Doc, Score: hit.Score}
+	}
+	return matches
+}
+
+type mongoCustomerRepository struct {
+	mongoCollection[models.Customer]
+}
+
+func newMongoCustomerRepository(client *mongo.Client) *mongoCustomerRepository {
+	return &mongoCustomerRepository{mongoCollection[models.Customer]{configs.GetCollection(client, "customers"), customerTenancy}}
+}
+
+// ensureIndexes creates the search indexes and fills in the search keys of
+// customers stored before they existed. Mongo text indexes only match whole
+// words, so prefixes and misspellings go through the search_terms and
+// search_codes arrays instead.
+func (r *mongoCustomerRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "organization_id", Value: 1}, {Key: "search_terms", Value: 1}}},
+		{Keys: bson.D{{Key: "organization_id", Value: 1}, {Key: "search_codes", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	stale, err := r.find(Unscoped(ctx), bson.M{"search_terms": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	for _, customer := range stale {
+		indexCustomer(&customer)
+		set := bson.M{"search_terms": customer.Search_terms, "search_codes": customer.Search_codes}
+		if _, err := r.update(Unscoped(ctx), bson.M{"id": customer.Id}, set); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *mongoCustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	indexCustomer(customer)
+	return r.insert(ctx, customer)
+}
+
+func (r *mongoCustomerRepository) CreateMany(ctx context.Context, customers []*models.Customer) error {
+	for _, customer := range customers {
+		indexCustomer(customer)
+	}
+	return r.insertMany(ctx, customers)
+}
+
+func (r *mongoCustomerRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Customer, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoCustomerRepository) FindAll(ctx context.Context) ([]models.Customer, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoCustomerRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Customer], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoCustomerRepository) Stream(ctx context.Context, query ListQuery, fn func(models.Customer) error) error {
+	return r.stream(ctx, bson.M{}, query, fn)
+}
+
+func (r *mongoCustomerRepository) Update(ctx context.Context, id primitive.ObjectID, customer models.Customer) (models.Customer, error) {
+	update := bson.M{
+		"name":     customer.Name,
+		"father":   customer.Father,
+		"home":     customer.Home,
+		"village":  customer.Village,
+		"thana":    customer.Thana,
+		"district": customer.District,
+		"phone":    customer.Phone,
+		"email":    customer.Email,
+		"duedate":  customer.DueDate,
+	}
+	indexCustomer(&customer)
+	update["search_terms"] = customer.Search_terms
+	update["search_codes"] = customer.Search_codes
+	return r.update(ctx, bson.M{"id": id}, update)
+}
+
+func (r *mongoCustomerRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+func (r *mongoCustomerRepository) Search(ctx context.Context, query string, limit int) ([]models.CustomerMatch, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []models.CustomerMatch{}, nil
+	}
+	// every candidate is ranked, in id order so that ties come out the same way each time
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+	results, err := r.collection.Find(ctx, r.scope(ctx, candidateFilter(terms)), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close(ctx)
+	ranking := newRanking(query, limit, customerSearchFields)
+	for results.Next(ctx) {
+		var candidate models.Customer
+		if err := results.Decode(&candidate); err != nil {
+			return nil, err
+		}
+		ranking.add(candidate)
+	}
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+	return customerMatches(ranking.best()), nil
+}
+
+type memoryCustomerRepository struct {
+	docs *memoryCollection[models.Customer]
+}
+
+func newMemoryCustomerRepository() *memoryCustomerRepository {
+	return &memoryCustomerRepository{newMemoryCollection(func(c models.Customer) primitive.ObjectID { return c.Id }, customerTenancy)}
+}
+
+func (r *memoryCustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	indexCustomer(customer)
+	r.docs.insert(ctx, customer)
+	return nil
+}
+
+func (r *memoryCustomerRepository) CreateMany(ctx context.Context, customers []*models.Customer) error {
+	for _, customer := range customers {
+		if err := r.Create(ctx, customer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryCustomerRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Customer, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryCustomerRepository) FindAll(ctx context.Context) ([]models.Customer, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryCustomerRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Customer], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryCustomerRepository) Stream(ctx context.Context, query ListQuery, fn func(models.Customer) error) error {
+	return r.docs.stream(ctx, nil, query, fn)
+}
+
+func (r *memoryCustomerRepository) Update(ctx context.Context, id primitive.ObjectID, customer models.Customer) (models.Customer, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Customer) {
+		customer.Id = doc.Id
+		*doc = customer
+		indexCustomer(doc)
+	})
+}
+
+func (r *memoryCustomerRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
+
+func (r *memoryCustomerRepository) Search(ctx context.Context, query string, limit int) ([]models.CustomerMatch, error) {
+	terms := searchTerms(query)
+	candidates := r.docs.find(ctx, func(c models.Customer) bool {
+		return len(terms) > 0 && isCandidate(terms, c.Search_terms, c.Search_codes)
+	})
+	return customerMatches(rank(candidates, query, limit, customerSearchFields)), nil
+}
@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GuarantorFields are the guarantor fields lists can be filtered and sorted on
+var GuarantorFields = ListFields{
+	"name":       {Key: "name", Kind: StringField},
+	"phone":      {Key: "phone", Kind: NumberField},
+	"nid":        {Key: "nid", Kind: StringField},
+	"village":    {Key: "village", Kind: StringField},
+	"thana":      {Key: "thana", Kind: StringField},
+	"district":   {Key: "district", Kind: StringField},
+	"created_at": {Key: "created_at", Kind: TimeField},
+}
+
+// GuarantorRepository persists models.Guarantor documents
+type GuarantorRepository interface {
+	Create(ctx context.Context, guarantor *models.Guarantor) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Guarantor, error)
+	FindByNid(ctx context.Context, nid string) (models.Guarantor, error)
+	// FindByCustomer returns the guarantors of the customer's whole balance
+	FindByCustomer(ctx context.Context, customerId primitive.ObjectID) ([]models.Guarantor, error)
+	FindAll(ctx context.Context) ([]models.Guarantor, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.Guarantor], error)
+	// Update changes the personal details of the guarantor, not whom they guarantee
+	Update(ctx context.Context, id primitive.ObjectID, guarantor models.Guarantor) (models.Guarantor, error)
+	// SetCustomers replaces the customers the guarantor answers for
+	SetCustomers(ctx context.Context, id primitive.ObjectID, customerIds []primitive.ObjectID) (models.Guarantor, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+var guarantorTenancy = &tenancy[models.Guarantor]{
+	key: "organization_id",
+	get: func(doc models.Guarantor) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.Guarantor, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+type mongoGuarantorRepository struct {
+	mongoCollection[models.Guarantor]
+}
+
+func newMongoGuarantorRepository(client *mongo.Client) *mongoGuarantorRepository {
+	return &mongoGuarantorRepository{mongoCollection[models.Guarantor]{configs.GetCollection(client, "guarantors"), guarantorTenancy}}
+}
+
+// ensureIndexes keeps one guarantor per national ID and supports looking up
+// the guarantors of a customer
+func (r *mongoGuarantorRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "organization_id", Value: 1}, {Key: "nid", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "customer_ids", Value: 1}}},
+	})
+	return err
+}
+
+func (r *mongoGuarantorRepository) Create(ctx context.Context, guarantor *models.Guarantor) error {
+	return r.insert(ctx, guarantor)
+}
+
+func (r *mongoGuarantorRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Guarantor, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoGuarantorRepository) FindByNid(ctx context.Context, nid string) (models.Guarantor, error) {
+	return r.findOne(ctx, bson.M{"nid": nid})
+}
+
+func (r *mongoGuarantorRepository) FindByCustomer(ctx context.Context, customerId primitive.ObjectID) ([]models.Guarantor, error) {
+	return r.find(ctx, bson.M{"customer_ids": customerId})
+}
+
+func (r *mongoGuarantorRepository) FindAll(ctx context.Context) ([]models.Guarantor, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoGuarantorRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Guarantor], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoGuarantorRepository) Update(ctx context.Context, id primitive.ObjectID, guarantor models.Guarantor) (models.Guarantor, error) {
+	update := bson.M{
+		"name":     guarantor.Name,
+		"relation": guarantor.Relation,
+		"father":   guarantor.Father,
+		"home":     guarantor.Home,
+		"village":  guarantor.Village,
+		"thana":    guarantor.Thana,
+		"district": guarantor.District,
+		"phone":    guarantor.Phone,
+		"nid":      guarantor.Nid,
+	}
+	return r.update(ctx, bson.M{"id": id}, update)
+}
+
+func (r *mongoGuarantorRepository) SetCustomers(ctx context.Context, id primitive.ObjectID, customerIds []primitive.ObjectID) (models.Guarantor, error) {
+	return r.update(ctx, bson.M{"id": id}, bson.M{"customer_ids": customerIds})
+}
+
+func (r *mongoGuarantorRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+type memoryGuarantorRepository struct {
+	docs *memoryCollection[models.Guarantor]
+}
+
+func newMemoryGuarantorRepository() *memoryGuarantorRepository {
+	return &memoryGuarantorRepository{newMemoryCollection(func(g models.Guarantor) primitive.ObjectID { return g.Id }, guarantorTenancy)}
+}
+
+func (r *memoryGuarantorRepository) Create(ctx context.Context, guarantor *models.Guarantor) error {
+	r.docs.insert(ctx, guarantor)
+	return nil
+}
+
+func (r *memoryGuarantorRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Guarantor, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryGuarantorRepository) FindByNid(ctx context.Context, nid string) (models.Guarantor, error) {
+	return r.docs.findOne(ctx, func(g models.Guarantor) bool { return g.Nid == nid })
+}
+
+func (r *memoryGuarantorRepository) FindByCustomer(ctx context.Context, customerId primitive.ObjectID) ([]models.Guarantor, error) {
+	return r.docs.find(ctx, func(g models.Guarantor) bool {
+		for _, id := range g.Customer_ids {
+			if id == customerId {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (r *memoryGuarantorRepository) FindAll(ctx context.Context) ([]models.Guarantor, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryGuarantorRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Guarantor], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryGuarantorRepository) Update(ctx context.Context, id primitive.ObjectID, guarantor models.Guarantor) (models.Guarantor, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Guarantor) {
+		guarantor.Id = doc.Id
+		guarantor.Organization_id = doc.Organization_id
+		guarantor.Customer_ids = doc.Customer_ids
+		guarantor.Created_at = doc.Created_at
+		*doc = guarantor
+	})
+}
+
+func (r *memoryGuarantorRepository) SetCustomers(ctx context.Context, id primitive.ObjectID, customerIds []primitive.ObjectID) (models.Guarantor, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Guarantor) { doc.Customer_ids = customerIds })
+}
+
+func (r *memoryGuarantorRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
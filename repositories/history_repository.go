@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HistoryFields are the history fields lists can be filtered and sorted on
+var HistoryFields = ListFields{
+	"customer": {Key: "customer_id", Kind: IDField},
+	"sell":     {Key: "sell_id", Kind: IDField},
+	"type":     {Key: "type", Kind: StringField},
+	"date":     {Key: "date", Kind: TimeField},
+	"due":      {Key: "due", Kind: NumberField},
+	"paid":     {Key: "paid", Kind: NumberField},
+	"number":   {Key: "number", Kind: StringField},
+}
+
+// HistoryRepository persists models.History documents
+type HistoryRepository interface {
+	Create(ctx context.Context, history *models.History) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.History, error)
+	FindAll(ctx context.Context) ([]models.History, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.History], error)
+	// Stream calls fn with every document matching the query, ignoring its pagination
+	Stream(ctx context.Context, query ListQuery, fn func(models.History) error) error
+	Update(ctx context.Context, id primitive.ObjectID, history models.History) (models.History, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// FindByCustomer returns the customer's entries dated up to until, oldest first
+	FindByCustomer(ctx context.Context, customerId primitive.ObjectID, until time.Time) ([]models.History, error)
+}
+
+var historyTenancy = &tenancy[models.History]{
+	key: "seller_id",
+	get: func(doc models.History) primitive.ObjectID { return doc.Seller_id },
+	set: func(doc *models.History, organizationId primitive.ObjectID) { doc.Seller_id = organizationId },
+}
+
+type mongoHistoryRepository struct {
+	mongoCollection[models.History]
+}
+
+func newMongoHistoryRepository(client *mongo.Client) *mongoHistoryRepository {
+	return &mongoHistoryRepository{mongoCollection[models.History]{configs.GetCollection(client, "historys"), historyTenancy}}
+}
+
+// ensureIndexes supports looking entries up by number
+func (r *mongoHistoryRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "seller_id", Value: 1}, {Key: "number", Value: 1}}})
+	return err
+}
+
+func (r *mongoHistoryRepository) Create(ctx context.Context, history *models.History) error {
+	return r.insert(ctx, history)
+}
+
+func (r *mongoHistoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.History, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoHistoryRepository) FindAll(ctx context.Context) ([]models.History, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoHistoryRepository) List(ctx context.Context, query ListQuery) (ListResult[models.History], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoHistoryRepository) Stream(ctx context.Context, query ListQuery, fn func(models.History) error) error {
+	return r.stream(ctx, bson.M{}, query, fn)
+}
+
+func (r *mongoHistoryRepository) Update(ctx context.Context, id primitive.ObjectID, history models.History) (models.History, error) {
+	update := bson.M{
+		"due":         history.Due,
+		"paid":        history.Paid,
+		"date":        history.Date,
+		"customer_id": history.Customer_id,
+		"seller_id":   history.Seller_id,
+		"type":        history.Type,
+		"note":        history.Note,
+		"voided_by":   history.Voided_by,
+	}
+	return r.update(ctx, bson.M{"id": id}, update)
+}
+
+func (r *mongoHistoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+func (r *mongoHistoryRepository) FindByCustomer(ctx context.Context, customerId primitive.ObjectID, until time.Time) ([]models.History, error) {
+	filter := bson.M{"customer_id": customerId, "date": bson.M{"$lte": primitive.NewDateTimeFromTime(until)}}
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}})
+	results, err := r.collection.Find(ctx, r.scope(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	var historys []models.History
+	if err = results.All(ctx, &historys); err != nil {
+		return nil, err
+	}
+	return historys, nil
+}
+
+type memoryHistoryRepository struct {
+	docs *memoryCollection[models.History]
+}
+
+func newMemoryHistoryRepository() *memoryHistoryRepository {
+	return &memoryHistoryRepository{newMemoryCollection(func(h models.History) primitive.ObjectID { return h.Id }, historyTenancy)}
+}
+
+func (r *memoryHistoryRepository) Create(ctx context.Context, history *models.History) error {
+	r.docs.insert(ctx, history)
+	return nil
+}
+
+func (r *memoryHistoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.History, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryHistoryRepository) FindAll(ctx context.Context) ([]models.History, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryHistoryRepository) List(ctx context.Context, query ListQuery) (ListResult[models.History], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryHistoryRepository) Stream(ctx context.Context, query ListQuery, fn func(models.History) error) error {
+	return r.docs.stream(ctx, nil, query, fn)
+}
+
+func (r *memoryHistoryRepository) Update(ctx context.Context, id primitive.ObjectID, history models.History) (models.History, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.History) {
+		history.Id = doc.Id
+		history.Sell_id = doc.Sell_id
+		history.Number = doc.Number
+		history.Reverses = doc.Reverses
+		*doc = history
+	})
+}
+
+func (r *memoryHistoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
+
+func (r *memoryHistoryRepository) FindByCustomer(ctx context.Context, customerId primitive.ObjectID, until time.Time) ([]models.History, error) {
+	limit := primitive.NewDateTimeFromTime(until)
+	historys := r.docs.find(ctx, func(h models.History) bool { return h.Customer_id == customerId && h.Date <= limit })
+	sort.SliceStable(historys, func(i, j int) bool { return historys[i].Date < historys[j].Date })
+	return historys, nil
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// InstallmentPlanRepository persists models.InstallmentPlan documents
+type InstallmentPlanRepository interface {
+	Create(ctx context.Context, plan *models.InstallmentPlan) error
+	FindBySell(ctx context.Context, sellId primitive.ObjectID) (models.InstallmentPlan, error)
+	FindAll(ctx context.Context) ([]models.InstallmentPlan, error)
+	// FindByCustomer returns the customer's plans, oldest first
+	FindByCustomer(ctx context.Context, customerId primitive.ObjectID) ([]models.InstallmentPlan, error)
+	UpdateSchedule(ctx context.Context, id primitive.ObjectID, schedule []models.Installment, status string) error
+}
+
+var installmentPlanTenancy = &tenancy[models.InstallmentPlan]{
+	key: "organization_id",
+	get: func(doc models.InstallmentPlan) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.InstallmentPlan, organizationId primitive.ObjectID) {
+		doc.Organization_id = organizationId
+	},
+}
+
+type mongoInstallmentPlanRepository struct {
+	mongoCollection[models.InstallmentPlan]
+}
+
+func newMongoInstallmentPlanRepository(client *mongo.Client) *mongoInstallmentPlanRepository {
+	return &mongoInstallmentPlanRepository{mongoCollection[models.InstallmentPlan]{configs.GetCollection(client, "plans"), installmentPlanTenancy}}
+}
+
+func (r *mongoInstallmentPlanRepository) Create(ctx context.Context, plan *models.InstallmentPlan) error {
+	return r.insert(ctx, plan)
+}
+
+func (r *mongoInstallmentPlanRepository) FindBySell(ctx context.Context, sellId primitive.ObjectID) (models.InstallmentPlan, error) {
+	return r.findOne(ctx, bson.M{"sell_id": sellId})
+}
+
+func (r *mongoInstallmentPlanRepository) FindAll(ctx context.Context) ([]models.InstallmentPlan, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoInstallmentPlanRepository) FindByCustomer(ctx context.Context, customerId primitive.ObjectID) ([]models.InstallmentPlan, error) {
+	return r.find(ctx, bson.M{"customer_id": customerId})
+}
+
+func (r *mongoInstallmentPlanRepository) UpdateSchedule(ctx context.Context, id primitive.ObjectID, schedule []models.Installment, status string) error {
+	_, err := r.update(ctx, bson.M{"id": id}, bson.M{"schedule": schedule, "status": status})
+	return err
+}
+
+type memoryInstallmentPlanRepository struct {
+	docs *memoryCollection[models.InstallmentPlan]
+}
+
+func newMemoryInstallmentPlanRepository() *memoryInstallmentPlanRepository {
+	return &memoryInstallmentPlanRepository{newMemoryCollection(func(p models.InstallmentPlan) primitive.ObjectID { return p.Id }, installmentPlanTenancy)}
+}
+
+func (r *memoryInstallmentPlanRepository) Create(ctx context.Context, plan *models.InstallmentPlan) error {
+	r.docs.insert(ctx, plan)
+	return nil
+}
+
+func (r *memoryInstallmentPlanRepository) FindBySell(ctx context.Context, sellId primitive.ObjectID) (models.InstallmentPlan, error) {
+	return r.docs.findOne(ctx, func(p models.InstallmentPlan) bool { return p.Sell_id == sellId })
+}
+
+func (r *memoryInstallmentPlanRepository) FindAll(ctx context.Context) ([]models.InstallmentPlan, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryInstallmentPlanRepository) FindByCustomer(ctx context.Context, customerId primitive.ObjectID) ([]models.InstallmentPlan, error) {
+	return r.docs.find(ctx, func(p models.InstallmentPlan) bool { return p.Customer_id == customerId }), nil
+}
+
+func (r *memoryInstallmentPlanRepository) UpdateSchedule(ctx context.Context, id primitive.ObjectID, schedule []models.Installment, status string) error {
+	_, err := r.docs.updateByID(ctx, id, func(doc *models.InstallmentPlan) {
+		doc.Schedule = append([]models.Installment(nil), schedule...)
+		doc.Status = status
+	})
+	return err
+}
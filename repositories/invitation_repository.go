@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var invitationTenancy = &tenancy[models.Invitation]{
+	key: "organization_id",
+	get: func(doc models.Invitation) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.Invitation, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+// InvitationFields are the invitation fields lists can be filtered and sorted on
+var InvitationFields = ListFields{
+	"email":      {Key: "email", Kind: StringField},
+	"role":       {Key: "role", Kind: StringField},
+	"created_at": {Key: "created_at", Kind: TimeField},
+	"expires_at": {Key: "expires_at", Kind: TimeField},
+}
+
+// InvitationRepository persists models.Invitation documents
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *models.Invitation) error
+	FindByToken(ctx context.Context, token string) (models.Invitation, error)
+	FindAll(ctx context.Context) ([]models.Invitation, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.Invitation], error)
+	MarkAccepted(ctx context.Context, id primitive.ObjectID, userId string, at time.Time) error
+}
+
+type mongoInvitationRepository struct {
+	mongoCollection[models.Invitation]
+}
+
+func newMongoInvitationRepository(client *mongo.Client) *mongoInvitationRepository {
+	return &mongoInvitationRepository{mongoCollection[models.Invitation]{configs.GetCollection(client, "invitations"), invitationTenancy}}
+}
+
+func (r *mongoInvitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	return r.insert(ctx, invitation)
+}
+
+func (r *mongoInvitationRepository) FindByToken(ctx context.Context, token string) (models.Invitation, error) {
+	return r.findOne(ctx, bson.M{"token": token})
+}
+
+func (r *mongoInvitationRepository) FindAll(ctx context.Context) ([]models.Invitation, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoInvitationRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Invitation], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoInvitationRepository) MarkAccepted(ctx context.Context, id primitive.ObjectID, userId string, at time.Time) error {
+	_, err := r.update(ctx, bson.M{"id": id}, bson.M{"accepted_by": userId, "accepted_at": at})
+	return err
+}
+
+type memoryInvitationRepository struct {
+	docs *memoryCollection[models.Invitation]
+}
+
+func newMemoryInvitationRepository() *memoryInvitationRepository {
+	return &memoryInvitationRepository{newMemoryCollection(func(i models.Invitation) primitive.ObjectID { return i.Id }, invitationTenancy)}
+}
+
+func (r *memoryInvitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	r.docs.insert(ctx, invitation)
+	return nil
+}
+
+func (r *memoryInvitationRepository) FindByToken(ctx context.Context, token string) (models.Invitation, error) {
+	return r.docs.findOne(ctx, func(i models.Invitation) bool { return i.Token == token })
+}
+
+func (r *memoryInvitationRepository) FindAll(ctx context.Context) ([]models.Invitation, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryInvitationRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Invitation], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryInvitationRepository) MarkAccepted(ctx context.Context, id primitive.ObjectID, userId string, at time.Time) error {
+	_, err := r.docs.updateByID(ctx, id, func(doc *models.Invitation) {
+		doc.Accepted_by = userId
+		doc.Accepted_at = &at
+	})
+	return err
+}
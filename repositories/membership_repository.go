@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var membershipTenancy = &tenancy[models.Membership]{
+	key: "organization_id",
+	get: func(doc models.Membership) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.Membership, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+// MembershipFields are the membership fields lists can be filtered and sorted on
+var MembershipFields = ListFields{
+	"user":        {Key: "user_id", Kind: StringField},
+	"role":        {Key: "role", Kind: StringField},
+	"designation": {Key: "designation", Kind: StringField},
+	"created_at":  {Key: "created_at", Kind: TimeField},
+}
+
+// MembershipRepository persists models.Membership documents
+type MembershipRepository interface {
+	Create(ctx context.Context, membership *models.Membership) error
+	Find(ctx context.Context, userId string, organizationId primitive.ObjectID) (models.Membership, error)
+	FindAll(ctx context.Context) ([]models.Membership, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.Membership], error)
+	FindByUser(ctx context.Context, userId string) ([]models.Membership, error)
+	CountByOrganization(ctx context.Context, organizationId primitive.ObjectID, role string) (int64, error)
+	SetRole(ctx context.Context, id primitive.ObjectID, role string, assignedBy string) (models.Membership, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type mongoMembershipRepository struct {
+	mongoCollection[models.Membership]
+}
+
+func newMongoMembershipRepository(client *mongo.Client) *mongoMembershipRepository {
+	return &mongoMembershipRepository{mongoCollection[models.Membership]{configs.GetCollection(client, "memberships"), membershipTenancy}}
+}
+
+func (r *mongoMembershipRepository) Create(ctx context.Context, membership *models.Membership) error {
+	return r.insert(ctx, membership)
+}
+
+func (r *mongoMembershipRepository) Find(ctx context.Context, userId string, organizationId primitive.ObjectID) (models.Membership, error) {
+	return r.findOne(ctx, bson.M{"user_id": userId, "organization_id": organizationId})
+}
+
+func (r *mongoMembershipRepository) FindAll(ctx context.Context) ([]models.Membership, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoMembershipRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Membership], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoMembershipRepository) FindByUser(ctx context.Context, userId string) ([]models.Membership, error) {
+	return r.find(ctx, bson.M{"user_id": userId})
+}
+
+// CountByOrganization counts the memberships of an organization, only those with role unless it is empty
+func (r *mongoMembershipRepository) CountByOrganization(ctx context.Context, organizationId primitive.ObjectID, role string) (int64, error) {
+	filter := bson.M{"organization_id": organizationId}
+	if role != "" {
+		filter["role"] = role
+	}
+	return r.count(ctx, filter)
+}
+
+func (r *mongoMembershipRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string, assignedBy string) (models.Membership, error) {
+	return r.update(ctx, bson.M{"id": id}, bson.M{"role": role, "assigned_by": assignedBy, "updated_at": time.Now()})
+}
+
+func (r *mongoMembershipRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+type memoryMembershipRepository struct {
+	docs *memoryCollection[models.Membership]
+}
+
+func newMemoryMembershipRepository() *memoryMembershipRepository {
+	return &memoryMembershipRepository{newMemoryCollection(func(m models.Membership) primitive.ObjectID { return m.Id }, membershipTenancy)}
+}
+
+func (r *memoryMembershipRepository) Create(ctx context.Context, membership *models.Membership) error {
+	r.docs.insert(ctx, membership)
+	return nil
+}
+
+func (r *memoryMembershipRepository) Find(ctx context.Context, userId string, organizationId primitive.ObjectID) (models.Membership, error) {
+	return r.docs.findOne(ctx, func(m models.Membership) bool { return m.User_id == userId && m.Organization_id == organizationId })
+}
+
+func (r *memoryMembershipRepository) FindAll(ctx context.Context) ([]models.Membership, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryMembershipRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Membership], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryMembershipRepository) FindByUser(ctx context.Context, userId string) ([]models.Membership, error) {
+	return r.docs.find(ctx, func(m models.Membership) bool { return m.User_id == userId }), nil
+}
+
+func (r *memoryMembershipRepository) CountByOrganization(ctx context.Context, organizationId primitive.ObjectID, role string) (int64, error) {
+	return r.docs.count(ctx, func(m models.Membership) bool {
+		return m.Organization_id == organizationId && (role == "" || m.Role == role)
+	}), nil
+}
+
+func (r *memoryMembershipRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string, assignedBy string) (models.Membership, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Membership) {
+		doc.Role = role
+		doc.Assigned_by = assignedBy
+		doc.Updated_at = time.Now()
+	})
+}
+
+func (r *memoryMembershipRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
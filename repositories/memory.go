@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryCollection keeps documents in insertion order behind a lock.
+// When tenancy is set every operation is scoped to the organization of the context.
+type memoryCollection[T any] struct {
+	mu      sync.RWMutex
+	docs    []T
+	id      func(T) primitive.ObjectID
+	tenancy *tenancy[T]
+}
+
+func newMemoryCollection[T any](id func(T) primitive.ObjectID, tenancy *tenancy[T]) *memoryCollection[T] {
+	return &memoryCollection[T]{id: id, tenancy: tenancy}
+}
+
+// visible reports whether doc belongs to the organization ctx is scoped to
+func (m *memoryCollection[T]) visible(ctx context.Context, doc T) bool {
+	organizationId, ok := OrganizationFrom(ctx)
+	return !ok || m.tenancy == nil || m.tenancy.get(doc) == organizationId
+}
+
+// insert stores a copy of doc, stamping it with the organization ctx is scoped to
+func (m *memoryCollection[T]) insert(ctx context.Context, doc *T) {
+	if organizationId, ok := OrganizationFrom(ctx); ok && m.tenancy != nil {
+		m.tenancy.set(doc, organizationId)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs = append(m.docs, *doc)
+	id := m.id(*doc)
+	record(ctx, func() { m.remove(id) })
+}
+
+func (m *memoryCollection[T]) findOne(ctx context.Context, match func(T) bool) (T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, doc := range m.docs {
+		if m.visible(ctx, doc) && match(doc) {
+			return doc, nil
+		}
+	}
+	var zero T
+	return zero, ErrNotFound
+}
+
+func (m *memoryCollection[T]) findByID(ctx context.Context, id primitive.ObjectID) (T, error) {
+	return m.findOne(ctx, func(doc T) bool { return m.id(doc) == id })
+}
+
+func (m *memoryCollection[T]) find(ctx context.Context, match func(T) bool) []T {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var docs []T
+	for _, doc := range m.docs {
+		if m.visible(ctx, doc) && (match == nil || match(doc)) {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// list returns the page of documents matching and query
+func (m *memoryCollection[T]) list(ctx context.Context, match func(T) bool, query ListQuery) (ListResult[T], error) {
+	return listDocs(m.find(ctx, match), query)
+}
+
+// stream calls fn with every document matching and the query filters, in the query order
+func (m *memoryCollection[T]) stream(ctx context.Context, match func(T) bool, query ListQuery, fn func(T) error) error {
+	return streamDocs(m.find(ctx, match), query, fn)
+}
+
+// update applies change to the first document matching and returns the result
+func (m *memoryCollection[T]) update(ctx context.Context, match func(T) bool, change func(*T)) (T, error) {
+	return m.tryUpdate(ctx, match, func(doc *T) error {
+		change(doc)
+		return nil
+	})
+}
+
+func (m *memoryCollection[T]) updateByID(ctx context.Context, id primitive.ObjectID, change func(*T)) (T, error) {
+	return m.update(ctx, func(doc T) bool { return m.id(doc) == id }, change)
+}
+
+// tryUpdate applies change to the first document matching, leaving it untouched when change fails
+func (m *memoryCollection[T]) tryUpdate(ctx context.Context, match func(T) bool, change func(*T) error) (T, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var zero T
+	for i := range m.docs {
+		if m.visible(ctx, m.docs[i]) && match(m.docs[i]) {
+			doc := m.docs[i]
+			if err := change(&doc); err != nil {
+				return zero, err
+			}
+			if _, ok := OrganizationFrom(ctx); ok && m.tenancy != nil {
+				// documents never move to another organization
+				m.tenancy.set(&doc, m.tenancy.get(m.docs[i]))
+			}
+			previous := m.docs[i]
+			m.docs[i] = doc
+			record(ctx, func() { m.restore(previous, -1) })
+			return doc, nil
+		}
+	}
+	return zero, ErrNotFound
+}
+
+func (m *memoryCollection[T]) tryUpdateByID(ctx context.Context, id primitive.ObjectID, change func(*T) error) (T, error) {
+	return m.tryUpdate(ctx, func(doc T) bool { return m.id(doc) == id }, change)
+}
+
+func (m *memoryCollection[T]) deleteByID(ctx context.Context, id primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, doc := range m.docs {
+		if m.id(doc) == id && m.visible(ctx, doc) {
+			m.docs = append(m.docs[:i], m.docs[i+1:]...)
+			record(ctx, func() { m.restore(doc, i) })
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *memoryCollection[T]) count(ctx context.Context, match func(T) bool) int64 {
+	return int64(len(m.find(ctx, match)))
+}
+
+// remove drops the document with id whatever its organization, undoing an insert
+func (m *memoryCollection[T]) remove(id primitive.ObjectID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.docs) - 1; i >= 0; i-- {
+		if m.id(m.docs[i]) == id {
+			m.docs = append(m.docs[:i], m.docs[i+1:]...)
+			return
+		}
+	}
+}
+
+// restore puts doc back, over the document with its id when there is one and
+// otherwise at position at, undoing an update or a delete
+func (m *memoryCollection[T]) restore(doc T, at int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.docs {
+		if m.id(m.docs[i]) == m.id(doc) {
+			m.docs[i] = doc
+			return
+		}
+	}
+	if at < 0 || at > len(m.docs) {
+		at = len(m.docs)
+	}
+	m.docs = append(m.docs[:at], append([]T{doc}, m.docs[at:]...)...)
+}
+
+type undoKey struct{}
+
+// undoLog collects how to take back the writes of a transaction, so a failed
+// transaction reverts its own writes and nothing written beside it
+type undoLog struct {
+	mu    sync.Mutex
+	steps []func()
+}
+
+// record adds undo to the log of the transaction ctx belongs to, if any
+func record(ctx context.Context, undo func()) {
+	if log, ok := ctx.Value(undoKey{}).(*undoLog); ok {
+		log.mu.Lock()
+		log.steps = append(log.steps, undo)
+		log.mu.Unlock()
+	}
+}
+
+// rollback undoes the logged writes, latest first
+func (l *undoLog) rollback() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := len(l.steps) - 1; i >= 0; i-- {
+		l.steps[i]()
+	}
+	l.steps = nil
+}
+
+// memoryTransactor serialises transactions and undoes the writes of one that fails
+type memoryTransactor struct {
+	mu sync.Mutex
+}
+
+func (t *memoryTransactor) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	log := &undoLog{}
+	if err := fn(context.WithValue(ctx, undoKey{}, log)); err != nil {
+		log.rollback()
+		return err
+	}
+	return nil
+}
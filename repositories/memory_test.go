@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"appadming/models"
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryTransactionRollsBackItsOwnWrites(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := WithOrganization(context.Background(), primitive.NewObjectID())
+	kept := models.Customer{Id: primitive.NewObjectID(), Name: "kept"}
+	changed := models.Customer{Id: primitive.NewObjectID(), Name: "changed"}
+	deleted := models.Customer{Id: primitive.NewObjectID(), Name: "deleted"}
+	for _, customer := range []*models.Customer{&kept, &changed, &deleted} {
+		if err := store.Customers.Create(ctx, customer); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	failed := errors.New("failed")
+	outside := models.Customer{Id: primitive.NewObjectID(), Name: "outside"}
+	err := store.WithTransaction(ctx, func(txCtx context.Context) error {
+		inserted := models.Customer{Id: primitive.NewObjectID(), Name: "inserted"}
+		if err := store.Customers.Create(txCtx, &inserted); err != nil {
+			return err
+		}
+		if _, err := store.Customers.Update(txCtx, changed.Id, models.Customer{Name: "renamed"}); err != nil {
+			return err
+		}
+		if err := store.Customers.Delete(txCtx, deleted.Id); err != nil {
+			return err
+		}
+		// written by another request while the transaction runs
+		if err := store.Customers.Create(ctx, &outside); err != nil {
+			return err
+		}
+		if _, err := store.Customers.Update(ctx, kept.Id, models.Customer{Name: "kept too"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if err != failed {
+		t.Fatalf("transaction error = %v", err)
+	}
+
+	customers, err := store.Customers.FindAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, customer := range customers {
+		names = append(names, customer.Name)
+	}
+	want := []string{"kept too", "changed", "deleted", "outside"}
+	if len(names) != len(want) {
+		t.Fatalf("customers = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("customers = %v, want %v", names, want)
+			break
+		}
+	}
+}
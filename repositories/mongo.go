@@ -0,0 +1,219 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoCollection implements the operations shared by every Mongo repository.
+// When tenancy is set every operation is scoped to the organization of the context.
+type mongoCollection[T any] struct {
+	collection *mongo.Collection
+	tenancy    *tenancy[T]
+}
+
+// scope restricts filter to the organization ctx is scoped to
+func (m mongoCollection[T]) scope(ctx context.Context, filter bson.M) bson.M {
+	organizationId, ok := OrganizationFrom(ctx)
+	if !ok || m.tenancy == nil {
+		return filter
+	}
+	scoped := bson.M{}
+	for key, value := range filter {
+		scoped[key] = value
+	}
+	scoped[m.tenancy.key] = organizationId
+	return scoped
+}
+
+// insert stores doc, stamping it with the organization ctx is scoped to
+func (m mongoCollection[T]) insert(ctx context.Context, doc *T) error {
+	if organizationId, ok := OrganizationFrom(ctx); ok && m.tenancy != nil {
+		m.tenancy.set(doc, organizationId)
+	}
+	_, err := m.collection.InsertOne(ctx, doc)
+	return err
+}
+
+// insertMany stores docs in one round trip, stamping them like insert
+func (m mongoCollection[T]) insertMany(ctx context.Context, docs []*T) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	batch := make([]interface{}, len(docs))
+	for i, doc := range docs {
+		if organizationId, ok := OrganizationFrom(ctx); ok && m.tenancy != nil {
+			m.tenancy.set(doc, organizationId)
+		}
+		batch[i] = doc
+	}
+	_, err := m.collection.InsertMany(ctx, batch)
+	return err
+}
+
+func (m mongoCollection[T]) findOne(ctx context.Context, filter bson.M) (T, error) {
+	var doc T
+	err := m.collection.FindOne(ctx, m.scope(ctx, filter)).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return doc, ErrNotFound
+	}
+	return doc, err
+}
+
+func (m mongoCollection[T]) find(ctx context.Context, filter bson.M) ([]T, error) {
+	var docs []T
+	results, err := m.collection.Find(ctx, m.scope(ctx, filter))
+	if err != nil {
+		return nil, err
+	}
+
+	//reading from the db in an optimal way
+	defer results.Close(ctx)
+	for results.Next(ctx) {
+		var doc T
+		if err = results.Decode(&doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, results.Err()
+}
+
+// mongoSort is the sort document of order
+func mongoSort(order []SortField) bson.D {
+	sortBy := bson.D{}
+	for _, s := range order {
+		direction := 1
+		if s.Desc {
+			direction = -1
+		}
+		sortBy = append(sortBy, bson.E{Key: s.Key, Value: direction})
+	}
+	return sortBy
+}
+
+// list returns the page of documents matching base and query
+func (m mongoCollection[T]) list(ctx context.Context, base bson.M, query ListQuery) (ListResult[T], error) {
+	query = query.normalize()
+	result := ListResult[T]{Page: query.Page, Limit: query.Limit, Items: []T{}}
+
+	filter := m.scope(ctx, query.filter(base))
+	total, err := m.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return result, err
+	}
+	result.Total = total
+
+	// every Mongo document has an _id, even when the model does not map one
+	order := query.order("_id")
+	opts := options.Find().SetSort(mongoSort(order)).SetLimit(int64(query.Limit) + 1)
+	if query.Cursor != "" {
+		values, err := decodeCursor(query.Cursor, len(order))
+		if err != nil {
+			return result, err
+		}
+		filter = bson.M{"$and": bson.A{filter, after(order, values)}}
+	} else {
+		opts.SetSkip(int64((query.Page - 1) * query.Limit))
+	}
+
+	results, err := m.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return result, err
+	}
+	defer results.Close(ctx)
+	var last bson.M
+	for results.Next(ctx) {
+		if len(result.Items) == query.Limit {
+			cursor, err := encodeCursor(sortKey(last, order))
+			if err != nil {
+				return result, err
+			}
+			result.NextCursor = cursor
+			break
+		}
+		var doc T
+		if err = results.Decode(&doc); err != nil {
+			return result, err
+		}
+		last = bson.M{}
+		if err = bson.Unmarshal(results.Current, &last); err != nil {
+			return result, err
+		}
+		result.Items = append(result.Items, doc)
+	}
+	return result, results.Err()
+}
+
+// stream calls fn with every document matching base and the query filters,
+// in the query order, decoding them one at a time from the cursor
+func (m mongoCollection[T]) stream(ctx context.Context, base bson.M, query ListQuery, fn func(T) error) error {
+	opts := options.Find().SetSort(mongoSort(query.order("_id")))
+	results, err := m.collection.Find(ctx, m.scope(ctx, query.filter(base)), opts)
+	if err != nil {
+		return err
+	}
+	defer results.Close(ctx)
+	for results.Next(ctx) {
+		var doc T
+		if err := results.Decode(&doc); err != nil {
+			return err
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return results.Err()
+}
+
+func (m mongoCollection[T]) update(ctx context.Context, filter bson.M, set bson.M) (T, error) {
+	var doc T
+	if _, ok := OrganizationFrom(ctx); ok && m.tenancy != nil {
+		// documents never move to another organization
+		delete(set, m.tenancy.key)
+	}
+	result, err := m.collection.UpdateOne(ctx, m.scope(ctx, filter), bson.M{"$set": set})
+	if err != nil {
+		return doc, err
+	}
+	if result.MatchedCount < 1 {
+		return doc, ErrNotFound
+	}
+	return m.findOne(ctx, filter)
+}
+
+func (m mongoCollection[T]) delete(ctx context.Context, filter bson.M) error {
+	result, err := m.collection.DeleteOne(ctx, m.scope(ctx, filter))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount < 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoCollection[T]) count(ctx context.Context, filter bson.M) (int64, error) {
+	return m.collection.CountDocuments(ctx, m.scope(ctx, filter))
+}
+
+// mongoTransactor runs functions inside a multi-document transaction
+type mongoTransactor struct {
+	client *mongo.Client
+}
+
+func (t mongoTransactor) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
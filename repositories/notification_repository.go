@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NotificationFields are the notification fields lists can be filtered and sorted on
+var NotificationFields = ListFields{
+	"type":       {Key: "type", Kind: StringField},
+	"product":    {Key: "product_id", Kind: IDField},
+	"created_at": {Key: "created_at", Kind: TimeField},
+}
+
+// NotificationRepository persists models.Notification documents
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	// ListForUser lists the notifications of userId, unread ones only when unread is set
+	ListForUser(ctx context.Context, userId string, unread bool, query ListQuery) (ListResult[models.Notification], error)
+	// MarkRead marks a notification of userId read
+	MarkRead(ctx context.Context, id primitive.ObjectID, userId string, at time.Time) (models.Notification, error)
+}
+
+var notificationTenancy = &tenancy[models.Notification]{
+	key: "organization_id",
+	get: func(doc models.Notification) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.Notification, organizationId primitive.ObjectID) {
+		doc.Organization_id = organizationId
+	},
+}
+
+type mongoNotificationRepository struct {
+	mongoCollection[models.Notification]
+}
+
+func newMongoNotificationRepository(client *mongo.Client) *mongoNotificationRepository {
+	return &mongoNotificationRepository{mongoCollection[models.Notification]{configs.GetCollection(client, "notifications"), notificationTenancy}}
+}
+
+// ensureIndexes supports listing the notifications of a user
+func (r *mongoNotificationRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}})
+	return err
+}
+
+func (r *mongoNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	return r.insert(ctx, notification)
+}
+
+func (r *mongoNotificationRepository) ListForUser(ctx context.Context, userId string, unread bool, query ListQuery) (ListResult[models.Notification], error) {
+	filter := bson.M{"user_id": userId}
+	if unread {
+		filter["read_at"] = nil
+	}
+	return r.list(ctx, filter, query)
+}
+
+func (r *mongoNotificationRepository) MarkRead(ctx context.Context, id primitive.ObjectID, userId string, at time.Time) (models.Notification, error) {
+	return r.update(ctx, bson.M{"id": id, "user_id": userId}, bson.M{"read_at": at})
+}
+
+type memoryNotificationRepository struct {
+	docs *memoryCollection[models.Notification]
+}
+
+func newMemoryNotificationRepository() *memoryNotificationRepository {
+	return &memoryNotificationRepository{newMemoryCollection(func(n models.Notification) primitive.ObjectID { return n.Id }, notificationTenancy)}
+}
+
+func (r *memoryNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	r.docs.insert(ctx, notification)
+	return nil
+}
+
+func (r *memoryNotificationRepository) ListForUser(ctx context.Context, userId string, unread bool, query ListQuery) (ListResult[models.Notification], error) {
+	return r.docs.list(ctx, func(n models.Notification) bool { return n.User_id == userId && (!unread || n.Read_at == nil) }, query)
+}
+
+func (r *memoryNotificationRepository) MarkRead(ctx context.Context, id primitive.ObjectID, userId string, at time.Time) (models.Notification, error) {
+	return r.docs.update(ctx, func(n models.Notification) bool { return n.Id == id && n.User_id == userId }, func(doc *models.Notification) {
+		doc.Read_at = &at
+	})
+}
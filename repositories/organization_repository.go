@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// OrganizationRepository persists models.Orgnization documents
+type OrganizationRepository interface {
+	Create(ctx context.Context, organization *models.Orgnization) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Orgnization, error)
+	FindAll(ctx context.Context) ([]models.Orgnization, error)
+	// Update replaces the organization's settings; its owner, logo and
+	// creation date stay as they are
+	Update(ctx context.Context, id primitive.ObjectID, organization models.Orgnization) (models.Orgnization, error)
+	// SetLogo replaces the logo, an empty logo removes it
+	SetLogo(ctx context.Context, id primitive.ObjectID, logo []byte, contentType string) (models.Orgnization, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type mongoOrganizationRepository struct {
+	mongoCollection[models.Orgnization]
+}
+
+func newMongoOrganizationRepository(client *mongo.Client) *mongoOrganizationRepository {
+	return &mongoOrganizationRepository{mongoCollection[models.Orgnization]{configs.GetCollection(client, "organizations"), nil}}
+}
+
+func (r *mongoOrganizationRepository) Create(ctx context.Context, organization *models.Orgnization) error {
+	return r.insert(ctx, organization)
+}
+
+func (r *mongoOrganizationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Orgnization, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoOrganizationRepository) FindAll(ctx context.Context) ([]models.Orgnization, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoOrganizationRepository) Update(ctx context.Context, id primitive.ObjectID, organization models.Orgnization) (models.Orgnization, error) {
+	update := bson.M{
+		"name":            organization.Name,
+		"address":         organization.Address,
+		"phone":           organization.Phone,
+		"email":           organization.Email,
+		"designation":     organization.Designation,
+		"invoice_footer":  organization.Invoice_footer,
+		"invoice_prefix":  organization.Invoice_prefix,
+		"receipt_prefix":  organization.Receipt_prefix,
+		"costing_method":  organization.Costing_method,
+		"reorder_windows": organization.Reorder_windows,
+		"stockout_days":   organization.Stockout_days,
+
+		"guarantor_threshold": organization.Guarantor_threshold,
+	}
+	return r.update(ctx, bson.M{"id": id}, update)
+}
+
+func (r *mongoOrganizationRepository) SetLogo(ctx context.Context, id primitive.ObjectID, logo []byte, contentType string) (models.Orgnization, error) {
+	return r.update(ctx, bson.M{"id": id}, bson.M{"logo": logo, "logo_type": contentType})
+}
+
+func (r *mongoOrganizationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+type memoryOrganizationRepository struct {
+	docs *memoryCollection[models.Orgnization]
+}
+
+func newMemoryOrganizationRepository() *memoryOrganizationRepository {
+	return &memoryOrganizationRepository{newMemoryCollection(func(o models.Orgnization) primitive.ObjectID { return o.Id }, nil)}
+}
+
+func (r *memoryOrganizationRepository) Create(ctx context.Context, organization *models.Orgnization) error {
+	r.docs.insert(ctx, organization)
+	return nil
+}
+
+func (r *memoryOrganizationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Orgnization, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryOrganizationRepository) FindAll(ctx context.Context) ([]models.Orgnization, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryOrganizationRepository) Update(ctx context.Context, id primitive.ObjectID, organization models.Orgnization) (models.Orgnization, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Orgnization) {
+		organization.Id = doc.Id
+		organization.Created_at = doc.Created_at
+		organization.User_id = doc.User_id
+		organization.Logo, organization.Logo_type = doc.Logo, doc.Logo_type
+		*doc = organization
+	})
+}
+
+func (r *memoryOrganizationRepository) SetLogo(ctx context.Context, id primitive.ObjectID, logo []byte, contentType string) (models.Orgnization, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Orgnization) {
+		doc.Logo, doc.Logo_type = logo, contentType
+	})
+}
+
+func (r *memoryOrganizationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
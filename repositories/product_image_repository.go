@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ProductImageRepository persists models.ProductImage documents
+type ProductImageRepository interface {
+	Create(ctx context.Context, image *models.ProductImage) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.ProductImage, error)
+	// FindByProduct lists the pictures of a product by position
+	FindByProduct(ctx context.Context, productId primitive.ObjectID) ([]models.ProductImage, error)
+	SetPosition(ctx context.Context, id primitive.ObjectID, position int) (models.ProductImage, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+var productImageTenancy = &tenancy[models.ProductImage]{
+	key: "organization_id",
+	get: func(doc models.ProductImage) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.ProductImage, organizationId primitive.ObjectID) {
+		doc.Organization_id = organizationId
+	},
+}
+
+func sortImages(images []models.ProductImage) []models.ProductImage {
+	sort.SliceStable(images, func(i, j int) bool { return images[i].Position < images[j].Position })
+	return images
+}
+
+type mongoProductImageRepository struct {
+	mongoCollection[models.ProductImage]
+}
+
+func newMongoProductImageRepository(client *mongo.Client) *mongoProductImageRepository {
+	return &mongoProductImageRepository{mongoCollection[models.ProductImage]{configs.GetCollection(client, "product_images"), productImageTenancy}}
+}
+
+func (r *mongoProductImageRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "position", Value: 1}},
+	})
+	return err
+}
+
+func (r *mongoProductImageRepository) Create(ctx context.Context, image *models.ProductImage) error {
+	return r.insert(ctx, image)
+}
+
+func (r *mongoProductImageRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.ProductImage, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoProductImageRepository) FindByProduct(ctx context.Context, productId primitive.ObjectID) ([]models.ProductImage, error) {
+	images, err := r.find(ctx, bson.M{"product_id": productId})
+	return sortImages(images), err
+}
+
+func (r *mongoProductImageRepository) SetPosition(ctx context.Context, id primitive.ObjectID, position int) (models.ProductImage, error) {
+	return r.update(ctx, bson.M{"id": id}, bson.M{"position": position})
+}
+
+func (r *mongoProductImageRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+type memoryProductImageRepository struct {
+	docs *memoryCollection[models.ProductImage]
+}
+
+func newMemoryProductImageRepository() *memoryProductImageRepository {
+	return &memoryProductImageRepository{newMemoryCollection(func(i models.ProductImage) primitive.ObjectID { return i.Id }, productImageTenancy)}
+}
+
+func (r *memoryProductImageRepository) Create(ctx context.Context, image *models.ProductImage) error {
+	r.docs.insert(ctx, image)
+	return nil
+}
+
+func (r *memoryProductImageRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.ProductImage, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryProductImageRepository) FindByProduct(ctx context.Context, productId primitive.ObjectID) ([]models.ProductImage, error) {
+	return sortImages(r.docs.find(ctx, func(i models.ProductImage) bool { return i.Product_id == productId })), nil
+}
+
+func (r *memoryProductImageRepository) SetPosition(ctx context.Context, id primitive.ObjectID, position int) (models.ProductImage, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.ProductImage) { doc.Position = position })
+}
+
+func (r *memoryProductImageRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
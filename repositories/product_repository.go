@@ -0,0 +1,226 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrInsufficientStock is returned when a product does not have enough stock to cover a sale
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ProductFields are the product fields lists can be filtered and sorted on
+var ProductFields = ListFields{
+	"model":         {Key: "model", Kind: StringField},
+	"category":      {Key: "category", Kind: StringField},
+	"brand":         {Key: "brand", Kind: NumberField},
+	"price":         {Key: "price", Kind: NumberField},
+	"cost":          {Key: "cost", Kind: NumberField},
+	"stock":         {Key: "stock", Kind: NumberField},
+	"reorder_point": {Key: "reorder_point", Kind: NumberField},
+	"category_id":   {Key: "category_id", Kind: IDField},
+	"brand_id":      {Key: "brand_id", Kind: IDField},
+	"variant_of":    {Key: "variant_of", Kind: IDField},
+	"sku":           {Key: "sku", Kind: StringField},
+}
+
+// ProductRepository persists models.Product documents
+type ProductRepository interface {
+	Create(ctx context.Context, product *models.Product) error
+	// CreateMany inserts products in one batch
+	CreateMany(ctx context.Context, products []*models.Product) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Product, error)
+	FindAll(ctx context.Context) ([]models.Product, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.Product], error)
+	// Stream calls fn with every document matching the query, ignoring its pagination
+	Stream(ctx context.Context, query ListQuery, fn func(models.Product) error) error
+	Update(ctx context.Context, id primitive.ObjectID, product models.Product) (models.Product, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// SetImageURL points the product at the picture shown for it
+	SetImageURL(ctx context.Context, id primitive.ObjectID, url string) (models.Product, error)
+	// DecrementStock removes quantity from the product stock, refusing to go below zero
+	DecrementStock(ctx context.Context, id primitive.ObjectID, quantity int) (models.Product, error)
+	// IncrementStock puts quantity back into the product stock
+	IncrementStock(ctx context.Context, id primitive.ObjectID, quantity int) (models.Product, error)
+}
+
+var productTenancy = &tenancy[models.Product]{
+	key: "seller_id",
+	get: func(doc models.Product) primitive.ObjectID { return doc.Seller_id },
+	set: func(doc *models.Product, organizationId primitive.ObjectID) { doc.Seller_id = organizationId },
+}
+
+type mongoProductRepository struct {
+	mongoCollection[models.Product]
+}
+
+func newMongoProductRepository(client *mongo.Client) *mongoProductRepository {
+	return &mongoProductRepository{mongoCollection[models.Product]{configs.GetCollection(client, "products"), productTenancy}}
+}
+
+// ensureIndexes supports looking products up by SKU, category and variant family
+func (r *mongoProductRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "seller_id", Value: 1}, {Key: "sku", Value: 1}}},
+		{Keys: bson.D{{Key: "seller_id", Value: 1}, {Key: "category_id", Value: 1}}},
+		{Keys: bson.D{{Key: "seller_id", Value: 1}, {Key: "variant_of", Value: 1}}},
+	})
+	return err
+}
+
+func (r *mongoProductRepository) Create(ctx context.Context, product *models.Product) error {
+	return r.insert(ctx, product)
+}
+
+func (r *mongoProductRepository) CreateMany(ctx context.Context, products []*models.Product) error {
+	return r.insertMany(ctx, products)
+}
+
+func (r *mongoProductRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Product, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoProductRepository) FindAll(ctx context.Context) ([]models.Product, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoProductRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Product], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoProductRepository) Stream(ctx context.Context, query ListQuery, fn func(models.Product) error) error {
+	return r.stream(ctx, bson.M{}, query, fn)
+}
+
+func (r *mongoProductRepository) Update(ctx context.Context, id primitive.ObjectID, product models.Product) (models.Product, error) {
+	update := bson.M{
+		"category":      product.Category,
+		"brand":         product.Brand,
+		"cost":          product.Cost,
+		"description":   product.Description,
+		"imageurl":      product.ImageURL,
+		"model":         product.Model,
+		"price":         product.Price,
+		"seller_id":     product.Seller_id,
+		"reorder_point": product.Reorder_point,
+		"category_id":   product.Category_id,
+		"brand_id":      product.Brand_id,
+		"sku":           product.Sku,
+		"attributes":    product.Attributes,
+		"serialized":    product.Serialized,
+		"warranty_days": product.Warranty_days,
+	}
+	return r.update(ctx, bson.M{"id": id}, update)
+}
+
+func (r *mongoProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+func (r *mongoProductRepository) SetImageURL(ctx context.Context, id primitive.ObjectID, url string) (models.Product, error) {
+	return r.update(ctx, bson.M{"id": id}, bson.M{"imageurl": url})
+}
+
+func (r *mongoProductRepository) DecrementStock(ctx context.Context, id primitive.ObjectID, quantity int) (models.Product, error) {
+	filter := bson.M{"id": id, "stock": bson.M{"$gte": quantity}}
+	result, err := r.collection.UpdateOne(ctx, r.scope(ctx, filter), bson.M{"$inc": bson.M{"stock": -quantity}})
+	if err != nil {
+		return models.Product{}, err
+	}
+	if result.MatchedCount < 1 {
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return models.Product{}, err
+		}
+		return models.Product{}, ErrInsufficientStock
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *mongoProductRepository) IncrementStock(ctx context.Context, id primitive.ObjectID, quantity int) (models.Product, error) {
+	result, err := r.collection.UpdateOne(ctx, r.scope(ctx, bson.M{"id": id}), bson.M{"$inc": bson.M{"stock": quantity}})
+	if err != nil {
+		return models.Product{}, err
+	}
+	if result.MatchedCount < 1 {
+		return models.Product{}, ErrNotFound
+	}
+	return r.FindByID(ctx, id)
+}
+
+type memoryProductRepository struct {
+	docs *memoryCollection[models.Product]
+}
+
+func newMemoryProductRepository() *memoryProductRepository {
+	return &memoryProductRepository{newMemoryCollection(func(p models.Product) primitive.ObjectID { return p.Id }, productTenancy)}
+}
+
+func (r *memoryProductRepository) Create(ctx context.Context, product *models.Product) error {
+	r.docs.insert(ctx, product)
+	return nil
+}
+
+func (r *memoryProductRepository) CreateMany(ctx context.Context, products []*models.Product) error {
+	for _, product := range products {
+		if err := r.Create(ctx, product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryProductRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Product, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryProductRepository) FindAll(ctx context.Context) ([]models.Product, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryProductRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Product], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryProductRepository) Stream(ctx context.Context, query ListQuery, fn func(models.Product) error) error {
+	return r.docs.stream(ctx, nil, query, fn)
+}
+
+func (r *memoryProductRepository) Update(ctx context.Context, id primitive.ObjectID, product models.Product) (models.Product, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Product) {
+		product.Id = doc.Id
+		// stock only changes through movements
+		product.Stock = doc.Stock
+		product.Seller_id = doc.Seller_id
+		product.Variant_of = doc.Variant_of
+		*doc = product
+	})
+}
+
+func (r *memoryProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
+
+func (r *memoryProductRepository) SetImageURL(ctx context.Context, id primitive.ObjectID, url string) (models.Product, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Product) { doc.ImageURL = url })
+}
+
+func (r *memoryProductRepository) DecrementStock(ctx context.Context, id primitive.ObjectID, quantity int) (models.Product, error) {
+	return r.docs.tryUpdateByID(ctx, id, func(doc *models.Product) error {
+		if doc.Stock < quantity {
+			return ErrInsufficientStock
+		}
+		doc.Stock -= quantity
+		return nil
+	})
+}
+
+func (r *memoryProductRepository) IncrementStock(ctx context.Context, id primitive.ObjectID, quantity int) (models.Product, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Product) {
+		doc.Stock += quantity
+	})
+}
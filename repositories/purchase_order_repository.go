@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PurchaseOrderFields are the purchase order fields lists can be filtered and sorted on
+var PurchaseOrderFields = ListFields{
+	"supplier": {Key: "supplier_id", Kind: IDField},
+	"status":   {Key: "status", Kind: StringField},
+	"amount":   {Key: "amount", Kind: NumberField},
+	"date":     {Key: "date", Kind: TimeField},
+	"number":   {Key: "number", Kind: StringField},
+}
+
+// PurchaseOrderRepository persists models.PurchaseOrder documents
+type PurchaseOrderRepository interface {
+	Create(ctx context.Context, order *models.PurchaseOrder) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.PurchaseOrder, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.PurchaseOrder], error)
+	// FindOpen finds the orders still waiting on deliveries
+	FindOpen(ctx context.Context) ([]models.PurchaseOrder, error)
+	// Receive replaces the lines of the order with what has now been received,
+	// appends receipt and sets the status
+	Receive(ctx context.Context, id primitive.ObjectID, lines []models.PurchaseLine, receipt models.PurchaseReceipt, status string) (models.PurchaseOrder, error)
+	SetStatus(ctx context.Context, id primitive.ObjectID, status string) (models.PurchaseOrder, error)
+}
+
+var purchaseOrderTenancy = &tenancy[models.PurchaseOrder]{
+	key: "organization_id",
+	get: func(doc models.PurchaseOrder) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.PurchaseOrder, organizationId primitive.ObjectID) {
+		doc.Organization_id = organizationId
+	},
+}
+
+type mongoPurchaseOrderRepository struct {
+	mongoCollection[models.PurchaseOrder]
+}
+
+func newMongoPurchaseOrderRepository(client *mongo.Client) *mongoPurchaseOrderRepository {
+	return &mongoPurchaseOrderRepository{mongoCollection[models.PurchaseOrder]{configs.GetCollection(client, "purchases"), purchaseOrderTenancy}}
+}
+
+// ensureIndexes supports looking purchase orders up by number
+func (r *mongoPurchaseOrderRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "organization_id", Value: 1}, {Key: "number", Value: 1}}})
+	return err
+}
+
+func (r *mongoPurchaseOrderRepository) Create(ctx context.Context, order *models.PurchaseOrder) error {
+	return r.insert(ctx, order)
+}
+
+func (r *mongoPurchaseOrderRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.PurchaseOrder, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoPurchaseOrderRepository) List(ctx context.Context, query ListQuery) (ListResult[models.PurchaseOrder], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoPurchaseOrderRepository) FindOpen(ctx context.Context) ([]models.PurchaseOrder, error) {
+	return r.find(ctx, bson.M{"status": bson.M{"$in": []string{models.PurchaseOpen, models.PurchasePartiallyReceived}}})
+}
+
+func (r *mongoPurchaseOrderRepository) Receive(ctx context.Context, id primitive.ObjectID, lines []models.PurchaseLine, receipt models.PurchaseReceipt, status string) (models.PurchaseOrder, error) {
+	order, err := r.FindByID(ctx, id)
+	if err != nil {
+		return order, err
+	}
+	// receipts is set whole since an order without any holds null
+	return r.update(ctx, bson.M{"id": id}, bson.M{"lines": lines, "receipts": append(order.Receipts, receipt), "status": status})
+}
+
+func (r *mongoPurchaseOrderRepository) SetStatus(ctx context.Context, id primitive.ObjectID, status string) (models.PurchaseOrder, error) {
+	return r.update(ctx, bson.M{"id": id}, bson.M{"status": status})
+}
+
+type memoryPurchaseOrderRepository struct {
+	docs *memoryCollection[models.PurchaseOrder]
+}
+
+func newMemoryPurchaseOrderRepository() *memoryPurchaseOrderRepository {
+	return &memoryPurchaseOrderRepository{newMemoryCollection(func(o models.PurchaseOrder) primitive.ObjectID { return o.Id }, purchaseOrderTenancy)}
+}
+
+func (r *memoryPurchaseOrderRepository) Create(ctx context.Context, order *models.PurchaseOrder) error {
+	r.docs.insert(ctx, order)
+	return nil
+}
+
+func (r *memoryPurchaseOrderRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.PurchaseOrder, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryPurchaseOrderRepository) List(ctx context.Context, query ListQuery) (ListResult[models.PurchaseOrder], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryPurchaseOrderRepository) FindOpen(ctx context.Context) ([]models.PurchaseOrder, error) {
+	return r.docs.find(ctx, func(o models.PurchaseOrder) bool {
+		return o.Status == models.PurchaseOpen || o.Status == models.PurchasePartiallyReceived
+	}), nil
+}
+
+func (r *memoryPurchaseOrderRepository) Receive(ctx context.Context, id primitive.ObjectID, lines []models.PurchaseLine, receipt models.PurchaseReceipt, status string) (models.PurchaseOrder, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.PurchaseOrder) {
+		doc.Lines = lines
+		doc.Receipts = append(append([]models.PurchaseReceipt{}, doc.Receipts...), receipt)
+		doc.Status = status
+	})
+}
+
+func (r *memoryPurchaseOrderRepository) SetStatus(ctx context.Context, id primitive.ObjectID, status string) (models.PurchaseOrder, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.PurchaseOrder) {
+		doc.Status = status
+	})
+}
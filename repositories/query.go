@@ -0,0 +1,424 @@
+package repositories
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidQuery is returned for list queries using unknown fields or malformed values
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Bounds applied to the number of documents returned by List
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// FieldKind tells how the value of a list filter is parsed
+type FieldKind int
+
+const (
+	StringField FieldKind = iota
+	NumberField
+	TimeField
+	IDField
+)
+
+// ListField is a document field clients may filter and sort a list on
+type ListField struct {
+	Key  string
+	Kind FieldKind
+}
+
+// ListFields whitelists the fields of a collection by their query parameter name
+type ListFields map[string]ListField
+
+// Filter compares the document field Key with Value using a Mongo comparison
+// operator. OpIn takes a []interface{} of the values the field may hold.
+type Filter struct {
+	Key   string
+	Op    string
+	Value interface{}
+}
+
+// Filter operators understood by both stores
+const (
+	OpEq  = "$eq"
+	OpGte = "$gte"
+	OpLte = "$lte"
+	OpIn  = "$in"
+)
+
+// SortField orders a list on Key, descending when Desc is set
+type SortField struct {
+	Key  string
+	Desc bool
+}
+
+// ListQuery selects one page of a collection. Cursor, when set, takes
+// precedence over Page and continues right after the last document of the
+// page that returned it.
+type ListQuery struct {
+	Filters []Filter
+	Sort    []SortField
+	Page    int
+	Limit   int
+	Cursor  string
+}
+
+// ListResult is one page of a collection
+type ListResult[T any] struct {
+	Items      []T
+	Total      int64
+	Page       int
+	Limit      int
+	NextCursor string
+}
+
+// normalize fills in the default page and limit
+func (q ListQuery) normalize() ListQuery {
+	if q.Limit < 1 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+	if q.Page < 1 || q.Cursor != "" {
+		q.Page = 1
+	}
+	return q
+}
+
+// filter merges the query filters into base
+func (q ListQuery) filter(base bson.M) bson.M {
+	merged := bson.M{}
+	for key, value := range base {
+		merged[key] = value
+	}
+	for _, f := range q.Filters {
+		ops, ok := merged[f.Key].(bson.M)
+		if !ok {
+			ops = bson.M{}
+			merged[f.Key] = ops
+		}
+		if values, ok := f.Value.([]interface{}); ok {
+			converted := make(bson.A, len(values))
+			for i, value := range values {
+				converted[i] = mongoValue(value)
+			}
+			ops[f.Op] = converted
+			continue
+		}
+		ops[f.Op] = mongoValue(f.Value)
+	}
+	return merged
+}
+
+// order is the sort of the query followed by idKey, which keeps pages stable
+func (q ListQuery) order(idKey string) []SortField {
+	order := append([]SortField(nil), q.Sort...)
+	for _, s := range order {
+		if s.Key == idKey {
+			return order
+		}
+	}
+	return append(order, SortField{Key: idKey})
+}
+
+// mongoValue converts filter values to their stored representation
+func mongoValue(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return primitive.NewDateTimeFromTime(t)
+	}
+	return value
+}
+
+// after builds the Mongo filter selecting documents that sort after values
+func after(order []SortField, values []interface{}) bson.M {
+	var or bson.A
+	for i, s := range order {
+		clause := bson.M{}
+		for j := 0; j < i; j++ {
+			clause[order[j].Key] = values[j]
+		}
+		op := "$gt"
+		if s.Desc {
+			op = "$lt"
+		}
+		clause[s.Key] = bson.M{op: values[i]}
+		or = append(or, clause)
+	}
+	return bson.M{"$or": or}
+}
+
+type cursorValues struct {
+	Values []interface{} `bson:"v"`
+}
+
+func encodeCursor(values []interface{}) (string, error) {
+	raw, err := bson.Marshal(cursorValues{values})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(cursor string, size int) ([]interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	var decoded cursorValues
+	if err := bson.Unmarshal(raw, &decoded); err != nil || len(decoded.Values) != size {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return decoded.Values, nil
+}
+
+// lookup returns the value stored under a dotted key of doc
+func lookup(doc bson.M, key string) interface{} {
+	var value interface{} = doc
+	for _, part := range strings.Split(key, ".") {
+		switch m := value.(type) {
+		case bson.M:
+			value = m[part]
+		case bson.D:
+			value = m.Map()[part]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// compareValues orders BSON values the way Mongo does for the types the models use
+func compareValues(a interface{}, b interface{}) int {
+	a, b = mongoValue(a), mongoValue(b)
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch av := a.(type) {
+	case string:
+		return strings.Compare(av, b.(string))
+	case primitive.ObjectID:
+		bv := b.(primitive.ObjectID)
+		return bytes.Compare(av[:], bv[:])
+	case primitive.DateTime:
+		return compareFloats(float64(av), float64(b.(primitive.DateTime)))
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		}
+		if !av {
+			return -1
+		}
+		return 1
+	}
+	if ra == 1 {
+		return compareFloats(toFloat(a), toFloat(b))
+	}
+	return 0
+}
+
+func typeRank(value interface{}) int {
+	switch value.(type) {
+	case nil:
+		return 0
+	case int, int32, int64, float64:
+		return 1
+	case string:
+		return 2
+	case primitive.ObjectID:
+		return 3
+	case bool:
+		return 4
+	case primitive.DateTime:
+		return 5
+	}
+	return 6
+}
+
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func compareFloats(a float64, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// matches reports whether doc passes every filter of the query
+func (q ListQuery) matches(doc bson.M) bool {
+	for _, f := range q.Filters {
+		if f.Op == OpIn {
+			if !matchesAny(lookup(doc, f.Key), f.Value.([]interface{})) {
+				return false
+			}
+			continue
+		}
+		c := compareValues(lookup(doc, f.Key), f.Value)
+		switch f.Op {
+		case OpEq:
+			if c != 0 {
+				return false
+			}
+		case OpGte:
+			if c < 0 {
+				return false
+			}
+		case OpLte:
+			if c > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchesAny(value interface{}, values []interface{}) bool {
+	for _, candidate := range values {
+		if compareValues(value, candidate) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sortKey reads the values of doc the order sorts on
+func sortKey(doc bson.M, order []SortField) []interface{} {
+	values := make([]interface{}, len(order))
+	for i, s := range order {
+		values[i] = lookup(doc, s.Key)
+	}
+	return values
+}
+
+func compareKeys(order []SortField, a []interface{}, b []interface{}) int {
+	for i, s := range order {
+		if c := compareValues(a[i], b[i]); c != 0 {
+			if s.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// listEntry is a document with its BSON form, which filters and sorts read
+type listEntry[T any] struct {
+	doc T
+	raw bson.M
+}
+
+// selectDocs keeps the docs matching the query filters, in the query order
+func selectDocs[T any](docs []T, query ListQuery) ([]listEntry[T], []SortField, error) {
+	var entries []listEntry[T]
+	for _, doc := range docs {
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			return nil, nil, err
+		}
+		var raw bson.M
+		if err := bson.Unmarshal(data, &raw); err != nil {
+			return nil, nil, err
+		}
+		if query.matches(raw) {
+			entries = append(entries, listEntry[T]{doc, raw})
+		}
+	}
+
+	idKey := "id"
+	if len(entries) > 0 {
+		if _, ok := entries[0].raw["_id"]; ok {
+			idKey = "_id"
+		}
+	}
+	order := query.order(idKey)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return compareKeys(order, sortKey(entries[i].raw, order), sortKey(entries[j].raw, order)) < 0
+	})
+	return entries, order, nil
+}
+
+// listDocs applies query to docs in memory
+func listDocs[T any](docs []T, query ListQuery) (ListResult[T], error) {
+	query = query.normalize()
+	result := ListResult[T]{Page: query.Page, Limit: query.Limit}
+
+	entries, order, err := selectDocs(docs, query)
+	if err != nil {
+		return result, err
+	}
+	result.Total = int64(len(entries))
+
+	start := (query.Page - 1) * query.Limit
+	if query.Cursor != "" {
+		values, err := decodeCursor(query.Cursor, len(order))
+		if err != nil {
+			return result, err
+		}
+		start = sort.Search(len(entries), func(i int) bool {
+			return compareKeys(order, sortKey(entries[i].raw, order), values) > 0
+		})
+	}
+	if start > len(entries) {
+		start = len(entries)
+	}
+	end := start + query.Limit
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	result.Items = []T{}
+	for _, e := range entries[start:end] {
+		result.Items = append(result.Items, e.doc)
+	}
+	if end < len(entries) {
+		cursor, err := encodeCursor(sortKey(entries[end-1].raw, order))
+		if err != nil {
+			return result, err
+		}
+		result.NextCursor = cursor
+	}
+	return result, nil
+}
+
+// streamDocs calls fn with every doc matching the query filters, in the query order
+func streamDocs[T any](docs []T, query ListQuery, fn func(T) error) error {
+	entries, _, err := selectDocs(docs, query)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := fn(e.doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"appadming/models"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func listFixture(t *testing.T) (context.Context, *Store) {
+	t.Helper()
+	store := NewMemoryStore()
+	ctx := WithOrganization(context.Background(), primitive.NewObjectID())
+	customers := []models.Customer{
+		{Name: "Abdul", District: "Dhaka", Phone: 300, DueDate: primitive.NewDateTimeFromTime(time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC))},
+		{Name: "Babul", District: "Khulna", Phone: 100, DueDate: primitive.NewDateTimeFromTime(time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC))},
+		{Name: "Karim", District: "Dhaka", Phone: 200, DueDate: primitive.NewDateTimeFromTime(time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC))},
+		{Name: "Rahim", District: "Dhaka", Phone: 200, DueDate: primitive.NewDateTimeFromTime(time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC))},
+		{Name: "Salam", District: "Sylhet", Phone: 500, DueDate: primitive.NewDateTimeFromTime(time.Date(2024, 3, 25, 12, 0, 0, 0, time.UTC))},
+	}
+	for i := range customers {
+		customers[i].Id = primitive.NewObjectID()
+		if err := store.Customers.Create(ctx, &customers[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ctx, store
+}
+
+func customerNames(customers []models.Customer) []string {
+	names := []string{}
+	for _, customer := range customers {
+		names = append(names, customer.Name)
+	}
+	return names
+}
+
+func TestListFiltersAndSorts(t *testing.T) {
+	ctx, store := listFixture(t)
+	tests := []struct {
+		name  string
+		query ListQuery
+		want  []string
+		total int64
+	}{
+		{
+			name:  "insertion order by default",
+			query: ListQuery{},
+			want:  []string{"Abdul", "Babul", "Karim", "Rahim", "Salam"},
+			total: 5,
+		},
+		{
+			name:  "exact match",
+			query: ListQuery{Filters: []Filter{{Key: "district", Op: OpEq, Value: "Dhaka"}}},
+			want:  []string{"Abdul", "Karim", "Rahim"},
+			total: 3,
+		},
+		{
+			name: "number range",
+			query: ListQuery{Filters: []Filter{
+				{Key: "phone", Op: OpGte, Value: int64(200)},
+				{Key: "phone", Op: OpLte, Value: int64(300)},
+			}},
+			want:  []string{"Abdul", "Karim", "Rahim"},
+			total: 3,
+		},
+		{
+			name: "date range",
+			query: ListQuery{Filters: []Filter{
+				{Key: "duedate", Op: OpGte, Value: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+				{Key: "duedate", Op: OpLte, Value: time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)},
+			}},
+			want:  []string{"Babul", "Karim"},
+			total: 2,
+		},
+		{
+			name:  "one of",
+			query: ListQuery{Filters: []Filter{{Key: "district", Op: OpIn, Value: []interface{}{"Khulna", "Sylhet"}}}},
+			want:  []string{"Babul", "Salam"},
+			total: 2,
+		},
+		{
+			name:  "descending with ties in insertion order",
+			query: ListQuery{Sort: []SortField{{Key: "phone", Desc: true}}},
+			want:  []string{"Salam", "Abdul", "Karim", "Rahim", "Babul"},
+			total: 5,
+		},
+		{
+			name:  "second page",
+			query: ListQuery{Sort: []SortField{{Key: "name"}}, Page: 2, Limit: 2},
+			want:  []string{"Karim", "Rahim"},
+			total: 5,
+		},
+		{
+			name:  "past the last page",
+			query: ListQuery{Page: 4, Limit: 2},
+			want:  []string{},
+			total: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.Customers.List(ctx, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := customerNames(result.Items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items = %v, want %v", got, tt.want)
+			}
+			if result.Total != tt.total {
+				t.Errorf("total = %d, want %d", result.Total, tt.total)
+			}
+		})
+	}
+}
+
+func TestListCursorWalksEveryDocumentOnce(t *testing.T) {
+	ctx, store := listFixture(t)
+	query := ListQuery{Sort: []SortField{{Key: "phone"}}, Limit: 2}
+	var pages [][]string
+	for i := 0; i < 5; i++ {
+		result, err := store.Customers.List(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, customerNames(result.Items))
+		if result.NextCursor == "" {
+			break
+		}
+		query.Cursor = result.NextCursor
+	}
+	// Karim and Rahim share a phone, the id keeps them apart across pages
+	want := [][]string{{"Babul", "Karim"}, {"Rahim", "Abdul"}, {"Salam"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+}
+
+func TestListCursorSkipsDocumentsInsertedBefore(t *testing.T) {
+	ctx, store := listFixture(t)
+	first, err := store.Customers.List(ctx, ListQuery{Sort: []SortField{{Key: "name"}}, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	early := models.Customer{Id: primitive.NewObjectID(), Name: "Aziz"}
+	if err := store.Customers.Create(ctx, &early); err != nil {
+		t.Fatal(err)
+	}
+	next, err := store.Customers.List(ctx, ListQuery{Sort: []SortField{{Key: "name"}}, Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := customerNames(next.Items), []string{"Karim", "Rahim"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("items = %v, want %v", got, want)
+	}
+}
+
+func TestListRejectsMalformedCursors(t *testing.T) {
+	ctx, store := listFixture(t)
+	first, err := store.Customers.List(ctx, ListQuery{Sort: []SortField{{Key: "name"}}, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, query := range map[string]ListQuery{
+		"not base64":     {Cursor: "%%%"},
+		"not bson":       {Cursor: "AAAA"},
+		"other sort key": {Sort: []SortField{{Key: "name"}, {Key: "phone"}}, Cursor: first.NextCursor},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.Customers.List(ctx, query); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("error = %v, want ErrInvalidQuery", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ReorderSuggestionFields are the suggestion fields lists can be filtered and sorted on
+var ReorderSuggestionFields = ListFields{
+	"model":              {Key: "model", Kind: StringField},
+	"stock":              {Key: "stock", Kind: NumberField},
+	"per_day":            {Key: "per_day", Kind: NumberField},
+	"days_left":          {Key: "days_left", Kind: NumberField},
+	"suggested_quantity": {Key: "suggested_quantity", Kind: NumberField},
+}
+
+// ReorderSuggestionRepository keeps the last reorder suggestions of every organization
+type ReorderSuggestionRepository interface {
+	// Replace swaps the suggestions of the organization ctx is scoped to for suggestions
+	Replace(ctx context.Context, suggestions []models.ReorderSuggestion) error
+	FindAll(ctx context.Context) ([]models.ReorderSuggestion, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.ReorderSuggestion], error)
+}
+
+var reorderSuggestionTenancy = &tenancy[models.ReorderSuggestion]{
+	key: "organization_id",
+	get: func(doc models.ReorderSuggestion) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.ReorderSuggestion, organizationId primitive.ObjectID) {
+		doc.Organization_id = organizationId
+	},
+}
+
+type mongoReorderSuggestionRepository struct {
+	mongoCollection[models.ReorderSuggestion]
+}
+
+func newMongoReorderSuggestionRepository(client *mongo.Client) *mongoReorderSuggestionRepository {
+	return &mongoReorderSuggestionRepository{mongoCollection[models.ReorderSuggestion]{configs.GetCollection(client, "reorder_suggestions"), reorderSuggestionTenancy}}
+}
+
+func (r *mongoReorderSuggestionRepository) Replace(ctx context.Context, suggestions []models.ReorderSuggestion) error {
+	if _, err := r.collection.DeleteMany(ctx, r.scope(ctx, bson.M{})); err != nil {
+		return err
+	}
+	if len(suggestions) == 0 {
+		return nil
+	}
+	docs := make([]*models.ReorderSuggestion, len(suggestions))
+	for i := range suggestions {
+		docs[i] = &suggestions[i]
+	}
+	return r.insertMany(ctx, docs)
+}
+
+func (r *mongoReorderSuggestionRepository) FindAll(ctx context.Context) ([]models.ReorderSuggestion, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoReorderSuggestionRepository) List(ctx context.Context, query ListQuery) (ListResult[models.ReorderSuggestion], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+type memoryReorderSuggestionRepository struct {
+	docs *memoryCollection[models.ReorderSuggestion]
+}
+
+func newMemoryReorderSuggestionRepository() *memoryReorderSuggestionRepository {
+	return &memoryReorderSuggestionRepository{newMemoryCollection(func(s models.ReorderSuggestion) primitive.ObjectID { return s.Id }, reorderSuggestionTenancy)}
+}
+
+func (r *memoryReorderSuggestionRepository) Replace(ctx context.Context, suggestions []models.ReorderSuggestion) error {
+	for _, old := range r.docs.find(ctx, nil) {
+		if err := r.docs.deleteByID(ctx, old.Id); err != nil {
+			return err
+		}
+	}
+	for i := range suggestions {
+		r.docs.insert(ctx, &suggestions[i])
+	}
+	return nil
+}
+
+func (r *memoryReorderSuggestionRepository) FindAll(ctx context.Context) ([]models.ReorderSuggestion, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryReorderSuggestionRepository) List(ctx context.Context, query ListQuery) (ListResult[models.ReorderSuggestion], error) {
+	return r.docs.list(ctx, nil, query)
+}
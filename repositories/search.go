@@ -0,0 +1,353 @@
+package repositories
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// bengali transliterates the letters of the Bengali script to Latin
+var bengali = map[rune]string{
+	// independent vowels
+	'অ': "a", 'আ': "a", 'ই': "i", 'ঈ': "i", 'উ': "u", 'ঊ': "u", 'ঋ': "ri",
+	'এ': "e", 'ঐ': "oi", 'ও': "o", 'ঔ': "ou",
+	// consonants
+	'ক': "k", 'খ': "kh", 'গ': "g", 'ঘ': "gh", 'ঙ': "ng",
+	'চ': "ch", 'ছ': "chh", 'জ': "j", 'ঝ': "jh", 'ঞ': "n",
+	'ট': "t", 'ঠ': "th", 'ড': "d", 'ঢ': "dh", 'ণ': "n",
+	'ত': "t", 'থ': "th", 'দ': "d", 'ধ': "dh", 'ন': "n",
+	'প': "p", 'ফ': "ph", 'ব': "b", 'ভ': "bh", 'ম': "m",
+	'য': "j", 'র': "r", 'ল': "l", 'শ': "sh", 'ষ': "sh", 'স': "s", 'হ': "h",
+	'\u09dc': "r", '\u09dd': "rh", '\u09df': "y", 'ৎ': "t",
+	// signs
+	'ং': "ng", 'ঃ': "h", 'ঁ': "",
+	// digits
+	'০': "0", '১': "1", '২': "2", '৩': "3", '৪': "4", '৫': "5", '৬': "6", '৭': "7", '৮': "8", '৯': "9",
+}
+
+// bengaliVowelSigns are the dependent vowels written after a consonant
+var bengaliVowelSigns = map[rune]string{
+	'া': "a", 'ি': "i", 'ী': "i", 'ু': "u", 'ূ': "u", 'ৃ': "ri",
+	'ে': "e", 'ৈ': "oi", 'ো': "o", 'ৌ': "ou",
+}
+
+const (
+	bengaliVirama = '্'
+	bengaliNukta  = '়'
+)
+
+// nukta gives the letters written as a consonant followed by a nukta
+var nukta = map[rune]rune{'ড': '\u09dc', 'ঢ': '\u09dd', 'য': '\u09df'}
+
+func isBengaliConsonant(r rune) bool {
+	return (r >= 'ক' && r <= 'হ') || r == '\u09dc' || r == '\u09dd' || r == '\u09df'
+}
+
+// Transliterate spells Bengali-script text with Latin letters, leaving other
+// text untouched. Consonants carry the inherent vowel "a" unless a vowel sign,
+// a virama or the end of the word follows them.
+func Transliterate(text string) string {
+	runes := []rune(text)
+	var out strings.Builder
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if i+1 < len(runes) && runes[i+1] == bengaliNukta {
+			if composed, ok := nukta[r]; ok {
+				r = composed
+			}
+			i++
+		}
+		if sign, ok := bengaliVowelSigns[r]; ok {
+			out.WriteString(sign)
+			continue
+		}
+		latin, ok := bengali[r]
+		if !ok {
+			if r != bengaliVirama && r != bengaliNukta {
+				out.WriteRune(r)
+			}
+			continue
+		}
+		out.WriteString(latin)
+		if isBengaliConsonant(r) && i+1 < len(runes) && isBengaliConsonant(runes[i+1]) {
+			out.WriteString("a")
+		}
+	}
+	return out.String()
+}
+
+// searchTerms splits text into lower case Latin words. Numbers lose their
+// leading zeros since phone numbers are stored as integers.
+func searchTerms(text string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(Transliterate(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, term := range terms {
+		if trimmed := strings.TrimLeft(term, "0"); trimmed != "" && isNumber(term) {
+			terms[i] = trimmed
+		}
+	}
+	return terms
+}
+
+// phoneticDigraphs fold spellings of the same sound, longest first
+var phoneticDigraphs = []struct{ from, to string }{
+	{"chh", "c"}, {"ch", "c"}, {"sh", "s"}, {"ph", "f"}, {"bh", "b"}, {"kh", "k"},
+	{"gh", "g"}, {"th", "t"}, {"dh", "d"}, {"jh", "j"}, {"ck", "k"}, {"q", "k"},
+	{"z", "j"}, {"v", "b"}, {"x", "ks"}, {"w", "o"},
+}
+
+// phoneticCode keeps the consonant skeleton of a word so that the many Latin
+// spellings of a Bengali name (Rahman, Rohman, Rahaman) share one code
+func phoneticCode(term string) string {
+	if term == "" || unicode.IsDigit([]rune(term)[0]) {
+		return term
+	}
+	for _, d := range phoneticDigraphs {
+		term = strings.ReplaceAll(term, d.from, d.to)
+	}
+	var code []rune
+	for i, r := range term {
+		if i > 0 && strings.ContainsRune("aeiouy", r) {
+			continue
+		}
+		if len(code) > 0 && code[len(code)-1] == r {
+			continue
+		}
+		code = append(code, r)
+	}
+	return string(code)
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// fuzzyLimit is how many typos a query word of that length may contain
+func fuzzyLimit(term string) int {
+	switch n := len([]rune(term)); {
+	case n >= 7:
+		return 2
+	case n >= 4:
+		return 1
+	}
+	return 0
+}
+
+func isNumber(term string) bool {
+	_, err := strconv.ParseInt(term, 10, 64)
+	return err == nil
+}
+
+// matchTerm scores how well a query word matches a document word
+func matchTerm(query string, term string) float64 {
+	switch {
+	case query == term:
+		return 1
+	case strings.HasPrefix(term, query):
+		return 0.8
+	case isNumber(query) && strings.Contains(term, query):
+		// collectors often only know the last digits of a phone number
+		return 0.7
+	}
+	queryCode, termCode := phoneticCode(query), phoneticCode(term)
+	switch {
+	case isNumber(query):
+		return 0
+	case queryCode == termCode:
+		return 0.6
+	case len(queryCode) > 1 && strings.HasPrefix(termCode, queryCode):
+		return 0.5
+	case editDistance(query, term) <= fuzzyLimit(query):
+		return 0.4
+	}
+	return 0
+}
+
+// searchField is one searchable field of a document and its weight in the ranking
+type searchField struct {
+	text   string
+	weight float64
+}
+
+// scoreFields ranks a document against the query words. Every query word must
+// match some field; each contributes its best weighted match.
+func scoreFields(query []string, fields []searchField) float64 {
+	var score float64
+	for _, q := range query {
+		var best float64
+		for _, field := range fields {
+			for _, term := range searchTerms(field.text) {
+				if s := matchTerm(q, term) * field.weight; s > best {
+					best = s
+				}
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		score += best
+	}
+	return score
+}
+
+// searchHit is a document matching a search with its relevance
+type searchHit[T any] struct {
+	Doc   T
+	Score float64
+}
+
+// ranking keeps the best matches of a query among the candidates added to it,
+// so that every candidate can be scored without holding all of them. Matches
+// with the same score keep the order they were added in.
+type ranking[T any] struct {
+	terms  []string
+	limit  int
+	fields func(T) []searchField
+	hits   []searchHit[T]
+}
+
+func newRanking[T any](query string, limit int, fields func(T) []searchField) *ranking[T] {
+	return &ranking[T]{terms: searchTerms(query), limit: limit, fields: fields, hits: []searchHit[T]{}}
+}
+
+// add scores doc and keeps it when it is among the best matches so far
+func (r *ranking[T]) add(doc T) {
+	if len(r.terms) == 0 {
+		return
+	}
+	score := scoreFields(r.terms, r.fields(doc))
+	if score == 0 {
+		return
+	}
+	r.hits = append(r.hits, searchHit[T]{doc, score})
+	if r.limit > 0 && len(r.hits) >= 2*r.limit {
+		r.trim()
+	}
+}
+
+// trim sorts the matches by relevance and drops those past the limit
+func (r *ranking[T]) trim() {
+	sort.SliceStable(r.hits, func(i, j int) bool { return r.hits[i].Score > r.hits[j].Score })
+	if r.limit > 0 && len(r.hits) > r.limit {
+		r.hits = r.hits[:r.limit]
+	}
+}
+
+// best returns the best limit matches first
+func (r *ranking[T]) best() []searchHit[T] {
+	r.trim()
+	return r.hits
+}
+
+// rank scores docs against query and returns the best limit matches first
+func rank[T any](docs []T, query string, limit int, fields func(T) []searchField) []searchHit[T] {
+	r := newRanking(query, limit, fields)
+	for _, doc := range docs {
+		r.add(doc)
+	}
+	return r.best()
+}
+
+// searchKeys are the normalized words and phonetic codes of fields, stored
+// with the document so that Mongo can find candidates through an index
+func searchKeys(fields []searchField) (terms []string, codes []string) {
+	seenTerms, seenCodes := map[string]bool{}, map[string]bool{}
+	for _, field := range fields {
+		for _, term := range searchTerms(field.text) {
+			if !seenTerms[term] {
+				seenTerms[term] = true
+				terms = append(terms, term)
+			}
+			if code := phoneticCode(term); !seenCodes[code] {
+				seenCodes[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	return terms, codes
+}
+
+// candidatePrefix is how much of a query word's phonetic code a stored code
+// must start with to be considered; the ranking then weeds out false positives
+const candidatePrefix = 2
+
+// isCandidate mirrors the Mongo candidate query: every query word must start
+// a stored word, share the start of a stored phonetic code or, for numbers,
+// appear anywhere in a stored word
+func isCandidate(query []string, terms []string, codes []string) bool {
+	for _, q := range query {
+		if !anyTerm(terms, func(term string) bool {
+			return strings.HasPrefix(term, q) || (isNumber(q) && strings.Contains(term, q))
+		}) && (isNumber(q) || !anyTerm(codes, func(code string) bool {
+			return strings.HasPrefix(code, codePrefix(q))
+		})) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyTerm(terms []string, match func(string) bool) bool {
+	for _, term := range terms {
+		if match(term) {
+			return true
+		}
+	}
+	return false
+}
+
+func codePrefix(query string) string {
+	code := []rune(phoneticCode(query))
+	if len(code) > candidatePrefix {
+		code = code[:candidatePrefix]
+	}
+	return string(code)
+}
+
+// candidateFilter is the Mongo query selecting the documents worth ranking
+// through the indexes on search_terms and search_codes
+func candidateFilter(query []string) bson.M {
+	var and bson.A
+	for _, q := range query {
+		if isNumber(q) {
+			and = append(and, bson.M{"search_terms": primitive.Regex{Pattern: regexp.QuoteMeta(q)}})
+			continue
+		}
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"search_terms": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(q)}},
+			bson.M{"search_codes": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(codePrefix(q))}},
+		}})
+	}
+	return bson.M{"$and": and}
+}
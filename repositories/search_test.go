@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"appadming/models"
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"রহিম", "rahim"},
+		{"করিম", "karim"},
+		{"আব্দুর রহমান", "abdur rahaman"},
+		{"ঢাকা", "dhaka"},
+		{"মোড়", "mor"},
+		{"০১৭১১", "01711"},
+		{"Savar 12", "Savar 12"},
+	}
+	for _, tt := range tests {
+		if got := Transliterate(tt.text); got != tt.want {
+			t.Errorf("Transliterate(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPhoneticCodeFoldsSpellings(t *testing.T) {
+	for _, group := range [][]string{
+		{"rahman", "rohman", "rahaman"},
+		{"chowdhury", "choudhury"},
+	} {
+		for _, spelling := range group[1:] {
+			if got, want := phoneticCode(spelling), phoneticCode(group[0]); got != want {
+				t.Errorf("phoneticCode(%q) = %q, want %q like %s", spelling, got, want, group[0])
+			}
+		}
+	}
+}
+
+func searchFixture(t *testing.T, customers ...models.Customer) (context.Context, *Store) {
+	t.Helper()
+	store := NewMemoryStore()
+	ctx := WithOrganization(context.Background(), primitive.NewObjectID())
+	for i := range customers {
+		customers[i].Id = primitive.NewObjectID()
+		if err := store.Customers.Create(ctx, &customers[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ctx, store
+}
+
+func matchNames(matches []models.CustomerMatch) []string {
+	names := []string{}
+	for _, match := range matches {
+		names = append(names, match.Name)
+	}
+	return names
+}
+
+func TestSearchRanksCustomers(t *testing.T) {
+	ctx, store := searchFixture(t,
+		models.Customer{Name: "Abdur Rohman", Village: "Kumarpara", District: "Dhaka", Phone: 1711000001},
+		models.Customer{Name: "Rahmat Ali", Village: "Rahmanpur", District: "Dhaka", Phone: 1711000002},
+		models.Customer{Name: "আব্দুর রহমান", Village: "Savar", District: "Dhaka", Phone: 1811000003},
+		models.Customer{Name: "Karim", Father: "Abdur Rahman", Village: "Savar", District: "Dhaka", Phone: 1911000004},
+		models.Customer{Name: "Salam", Village: "Savar", District: "Khulna", Phone: 1911005678},
+	)
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// the Bengali name transliterates to the exact spelling and beats the
+		// other spelling, which beats a match on the father's name
+		{"abdur rahaman", []string{"আব্দুর রহমান", "Abdur Rohman", "Karim"}},
+		{"আব্দুর রহমান", []string{"আব্দুর রহমান", "Abdur Rohman", "Karim"}},
+		// a prefix of a name beats a prefix of the father's name, which beats
+		// names only sounding alike
+		{"rahm", []string{"Rahmat Ali", "Karim", "Abdur Rohman", "আব্দুর রহমান"}},
+		// typos are forgiven on longer words
+		{"salaam", []string{"Salam"}},
+		// every word must match
+		{"karim khulna", []string{}},
+		// the last digits of a phone number, with or without a leading zero
+		{"5678", []string{"Salam"}},
+		{"01911005678", []string{"Salam"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			matches, err := store.Customers.Search(ctx, tt.query, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matchNames(matches); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+			for i := 1; i < len(matches); i++ {
+				if matches[i].Score > matches[i-1].Score {
+					t.Errorf("match %d scores %v above %v", i, matches[i].Score, matches[i-1].Score)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchRanksEveryCandidate(t *testing.T) {
+	var customers []models.Customer
+	for i := 0; i < 2500; i++ {
+		customers = append(customers, models.Customer{Name: fmt.Sprintf("Karimul %d", i), Village: "Karimpur"})
+	}
+	// the only exact match is found after thousands of weaker candidates
+	customers = append(customers, models.Customer{Name: "Karim", Village: "Savar"})
+	ctx, store := searchFixture(t, customers...)
+
+	matches, err := store.Customers.Search(ctx, "karim", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ties keep the order the candidates were found in
+	want := []string{"Karim", "Karimul 0", "Karimul 1"}
+	if got := matchNames(matches); !reflect.DeepEqual(got, want) {
+		t.Errorf("matches = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SellInfoFields are the sale fields lists can be filtered and sorted on
+var SellInfoFields = ListFields{
+	"customer":  {Key: "customer_id", Kind: IDField},
+	"amount":    {Key: "amount", Kind: NumberField},
+	"paid":      {Key: "paid", Kind: NumberField},
+	"date":      {Key: "date", Kind: TimeField},
+	"number":    {Key: "number", Kind: StringField},
+	"guarantor": {Key: "guarantor_id", Kind: IDField},
+}
+
+// SellInfoRepository persists models.SellInfo documents
+type SellInfoRepository interface {
+	Create(ctx context.Context, sell *models.SellInfo) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.SellInfo, error)
+	FindAll(ctx context.Context) ([]models.SellInfo, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.SellInfo], error)
+	// Stream calls fn with every document matching the query, ignoring its pagination
+	Stream(ctx context.Context, query ListQuery, fn func(models.SellInfo) error) error
+	// StreamBetween calls fn with every sale made or reversed between from and to
+	StreamBetween(ctx context.Context, from time.Time, to time.Time, fn func(models.SellInfo) error) error
+	// SetNote replaces the note of the sale
+	SetNote(ctx context.Context, id primitive.ObjectID, note string) (models.SellInfo, error)
+	// Reverse appends reversal to the sale and sets its status
+	Reverse(ctx context.Context, id primitive.ObjectID, reversal models.SellReversal, status string) (models.SellInfo, error)
+	// SetGuarantor names the guarantor of the sale, none for a zero guarantorId
+	SetGuarantor(ctx context.Context, id primitive.ObjectID, guarantorId primitive.ObjectID) (models.SellInfo, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+var sellInfoTenancy = &tenancy[models.SellInfo]{
+	key: "organization_id",
+	get: func(doc models.SellInfo) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.SellInfo, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+type mongoSellInfoRepository struct {
+	mongoCollection[models.SellInfo]
+}
+
+func newMongoSellInfoRepository(client *mongo.Client) *mongoSellInfoRepository {
+	return &mongoSellInfoRepository{mongoCollection[models.SellInfo]{configs.GetCollection(client, "sells"), sellInfoTenancy}}
+}
+
+// ensureIndexes supports looking sells up by number
+func (r *mongoSellInfoRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "organization_id", Value: 1}, {Key: "number", Value: 1}}})
+	return err
+}
+
+func (r *mongoSellInfoRepository) Create(ctx context.Context, sell *models.SellInfo) error {
+	return r.insert(ctx, sell)
+}
+
+func (r *mongoSellInfoRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.SellInfo, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoSellInfoRepository) FindAll(ctx context.Context) ([]models.SellInfo, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoSellInfoRepository) List(ctx context.Context, query ListQuery) (ListResult[models.SellInfo], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoSellInfoRepository) Stream(ctx context.Context, query ListQuery, fn func(models.SellInfo) error) error {
+	return r.stream(ctx, bson.M{}, query, fn)
+}
+
+func (r *mongoSellInfoRepository) StreamBetween(ctx context.Context, from time.Time, to time.Time, fn func(models.SellInfo) error) error {
+	between := bson.M{"$gte": primitive.NewDateTimeFromTime(from), "$lte": primitive.NewDateTimeFromTime(to)}
+	return r.stream(ctx, bson.M{"$or": bson.A{bson.M{"date": between}, bson.M{"reversals.date": between}}}, ListQuery{}, fn)
+}
+
+func (r *mongoSellInfoRepository) SetNote(ctx context.Context, id primitive.ObjectID, note string) (models.SellInfo, error) {
+	return r.update(ctx, bson.M{"id": id}, bson.M{"note": note})
+}
+
+func (r *mongoSellInfoRepository) Reverse(ctx context.Context, id primitive.ObjectID, reversal models.SellReversal, status string) (models.SellInfo, error) {
+	sell, err := r.FindByID(ctx, id)
+	if err != nil {
+		return sell, err
+	}
+	// reversals is set whole since sales stored before it existed hold null
+	return r.update(ctx, bson.M{"id": id}, bson.M{"reversals": append(sell.Reversals, reversal), "status": status})
+}
+
+func (r *mongoSellInfoRepository) SetGuarantor(ctx context.Context, id primitive.ObjectID, guarantorId primitive.ObjectID) (models.SellInfo, error) {
+	return r.update(ctx, bson.M{"id": id}, bson.M{"guarantor_id": guarantorId})
+}
+
+func (r *mongoSellInfoRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+type memorySellInfoRepository struct {
+	docs *memoryCollection[models.SellInfo]
+}
+
+func newMemorySellInfoRepository() *memorySellInfoRepository {
+	return &memorySellInfoRepository{newMemoryCollection(func(s models.SellInfo) primitive.ObjectID { return s.Id }, sellInfoTenancy)}
+}
+
+func (r *memorySellInfoRepository) Create(ctx context.Context, sell *models.SellInfo) error {
+	r.docs.insert(ctx, sell)
+	return nil
+}
+
+func (r *memorySellInfoRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.SellInfo, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memorySellInfoRepository) FindAll(ctx context.Context) ([]models.SellInfo, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memorySellInfoRepository) List(ctx context.Context, query ListQuery) (ListResult[models.SellInfo], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memorySellInfoRepository) Stream(ctx context.Context, query ListQuery, fn func(models.SellInfo) error) error {
+	return r.docs.stream(ctx, nil, query, fn)
+}
+
+func (r *memorySellInfoRepository) StreamBetween(ctx context.Context, from time.Time, to time.Time, fn func(models.SellInfo) error) error {
+	first, last := primitive.NewDateTimeFromTime(from), primitive.NewDateTimeFromTime(to)
+	between := func(date primitive.DateTime) bool { return date >= first && date <= last }
+	return r.docs.stream(ctx, func(s models.SellInfo) bool {
+		if between(s.Date) {
+			return true
+		}
+		for _, reversal := range s.Reversals {
+			if between(reversal.Date) {
+				return true
+			}
+		}
+		return false
+	}, ListQuery{}, fn)
+}
+
+func (r *memorySellInfoRepository) SetNote(ctx context.Context, id primitive.ObjectID, note string) (models.SellInfo, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.SellInfo) { doc.Note = note })
+}
+
+func (r *memorySellInfoRepository) Reverse(ctx context.Context, id primitive.ObjectID, reversal models.SellReversal, status string) (models.SellInfo, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.SellInfo) {
+		doc.Reversals = append(append([]models.SellReversal{}, doc.Reversals...), reversal)
+		doc.Status = status
+	})
+}
+
+func (r *memorySellInfoRepository) SetGuarantor(ctx context.Context, id primitive.ObjectID, guarantorId primitive.ObjectID) (models.SellInfo, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.SellInfo) { doc.Guarantor_id = guarantorId })
+}
+
+func (r *memorySellInfoRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SequenceRepository hands out document numbers. Numbers taken inside a
+// transaction that fails are given back, so that sequences have no gaps.
+type SequenceRepository interface {
+	// Next increments the sequence of kind documents of the organization in year and returns its new value
+	Next(ctx context.Context, organizationId primitive.ObjectID, kind string, year int) (int, error)
+}
+
+type mongoSequenceRepository struct {
+	mongoCollection[models.Sequence]
+}
+
+func newMongoSequenceRepository(client *mongo.Client) *mongoSequenceRepository {
+	return &mongoSequenceRepository{mongoCollection[models.Sequence]{configs.GetCollection(client, "sequences"), nil}}
+}
+
+// ensureIndexes keeps a single counter per sequence, which concurrent
+// transactions then update in turn
+func (r *mongoSequenceRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "organization_id", Value: 1}, {Key: "type", Value: 1}, {Key: "year", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *mongoSequenceRepository) Next(ctx context.Context, organizationId primitive.ObjectID, kind string, year int) (int, error) {
+	filter := bson.M{"organization_id": organizationId, "type": kind, "year": year}
+	update := bson.M{"$inc": bson.M{"value": 1}, "$setOnInsert": bson.M{"id": primitive.NewObjectID()}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var sequence models.Sequence
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&sequence); err != nil {
+		return 0, err
+	}
+	return sequence.Value, nil
+}
+
+type memorySequenceRepository struct {
+	docs *memoryCollection[models.Sequence]
+	// mu makes the lookup and the insert of a new sequence one step
+	mu sync.Mutex
+}
+
+func newMemorySequenceRepository() *memorySequenceRepository {
+	return &memorySequenceRepository{docs: newMemoryCollection(func(s models.Sequence) primitive.ObjectID { return s.Id }, nil)}
+}
+
+func (r *memorySequenceRepository) Next(ctx context.Context, organizationId primitive.ObjectID, kind string, year int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sequence, err := r.docs.update(ctx, func(s models.Sequence) bool {
+		return s.Organization_id == organizationId && s.Type == kind && s.Year == year
+	}, func(s *models.Sequence) { s.Value++ })
+	if err == ErrNotFound {
+		sequence = models.Sequence{Id: primitive.NewObjectID(), Organization_id: organizationId, Type: kind, Year: year, Value: 1}
+		r.docs.insert(ctx, &sequence)
+		err = nil
+	}
+	return sequence.Value, err
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SerialFields are the serialized unit fields lists can be filtered and sorted on
+var SerialFields = ListFields{
+	"serial":      {Key: "serial", Kind: StringField},
+	"product":     {Key: "product_id", Kind: IDField},
+	"status":      {Key: "status", Kind: StringField},
+	"sell":        {Key: "sell_id", Kind: IDField},
+	"customer":    {Key: "customer_id", Kind: IDField},
+	"received_at": {Key: "received_at", Kind: TimeField},
+}
+
+// SerialRepository persists models.SerialUnit documents
+type SerialRepository interface {
+	Create(ctx context.Context, unit *models.SerialUnit) error
+	FindBySerial(ctx context.Context, serial string) (models.SerialUnit, error)
+	// FindBySell finds the units of productId a sale took out that it still holds
+	FindBySell(ctx context.Context, sellId primitive.ObjectID, productId primitive.ObjectID) ([]models.SerialUnit, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.SerialUnit], error)
+	// Save stores the status, sale, warranty and events of unit
+	Save(ctx context.Context, unit models.SerialUnit) (models.SerialUnit, error)
+}
+
+var serialTenancy = &tenancy[models.SerialUnit]{
+	key: "organization_id",
+	get: func(doc models.SerialUnit) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.SerialUnit, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+type mongoSerialRepository struct {
+	mongoCollection[models.SerialUnit]
+}
+
+func newMongoSerialRepository(client *mongo.Client) *mongoSerialRepository {
+	return &mongoSerialRepository{mongoCollection[models.SerialUnit]{configs.GetCollection(client, "serials"), serialTenancy}}
+}
+
+// ensureIndexes keeps serials unique within an organization and supports
+// finding the units of a sale
+func (r *mongoSerialRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "organization_id", Value: 1}, {Key: "serial", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "sell_id", Value: 1}, {Key: "product_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *mongoSerialRepository) Create(ctx context.Context, unit *models.SerialUnit) error {
+	return r.insert(ctx, unit)
+}
+
+func (r *mongoSerialRepository) FindBySerial(ctx context.Context, serial string) (models.SerialUnit, error) {
+	return r.findOne(ctx, bson.M{"serial": serial})
+}
+
+func (r *mongoSerialRepository) FindBySell(ctx context.Context, sellId primitive.ObjectID, productId primitive.ObjectID) ([]models.SerialUnit, error) {
+	return r.find(ctx, bson.M{"sell_id": sellId, "product_id": productId, "status": models.SerialSold})
+}
+
+func (r *mongoSerialRepository) List(ctx context.Context, query ListQuery) (ListResult[models.SerialUnit], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoSerialRepository) Save(ctx context.Context, unit models.SerialUnit) (models.SerialUnit, error) {
+	update := bson.M{
+		"status":         unit.Status,
+		"sell_id":        unit.Sell_id,
+		"customer_id":    unit.Customer_id,
+		"sold_at":        unit.Sold_at,
+		"warranty_until": unit.Warranty_until,
+		"events":         unit.Events,
+	}
+	return r.update(ctx, bson.M{"id": unit.Id}, update)
+}
+
+type memorySerialRepository struct {
+	docs *memoryCollection[models.SerialUnit]
+}
+
+func newMemorySerialRepository() *memorySerialRepository {
+	return &memorySerialRepository{newMemoryCollection(func(u models.SerialUnit) primitive.ObjectID { return u.Id }, serialTenancy)}
+}
+
+func (r *memorySerialRepository) Create(ctx context.Context, unit *models.SerialUnit) error {
+	r.docs.insert(ctx, unit)
+	return nil
+}
+
+func (r *memorySerialRepository) FindBySerial(ctx context.Context, serial string) (models.SerialUnit, error) {
+	return r.docs.findOne(ctx, func(u models.SerialUnit) bool { return u.Serial == serial })
+}
+
+func (r *memorySerialRepository) FindBySell(ctx context.Context, sellId primitive.ObjectID, productId primitive.ObjectID) ([]models.SerialUnit, error) {
+	return r.docs.find(ctx, func(u models.SerialUnit) bool {
+		return u.Sell_id == sellId && u.Product_id == productId && u.Status == models.SerialSold
+	}), nil
+}
+
+func (r *memorySerialRepository) List(ctx context.Context, query ListQuery) (ListResult[models.SerialUnit], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memorySerialRepository) Save(ctx context.Context, unit models.SerialUnit) (models.SerialUnit, error) {
+	return r.docs.updateByID(ctx, unit.Id, func(doc *models.SerialUnit) {
+		doc.Status = unit.Status
+		doc.Sell_id = unit.Sell_id
+		doc.Customer_id = unit.Customer_id
+		doc.Sold_at = unit.Sold_at
+		doc.Warranty_until = unit.Warranty_until
+		doc.Events = append([]models.SerialEvent{}, unit.Events...)
+	})
+}
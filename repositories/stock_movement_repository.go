@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StockMovementFields are the movement fields lists can be filtered and sorted on
+var StockMovementFields = ListFields{
+	"type":      {Key: "type", Kind: StringField},
+	"quantity":  {Key: "quantity", Kind: NumberField},
+	"date":      {Key: "date", Kind: TimeField},
+	"reference": {Key: "reference_id", Kind: IDField},
+	"user":      {Key: "user_id", Kind: IDField},
+}
+
+var stockMovementTenancy = &tenancy[models.StockMovement]{
+	key: "organization_id",
+	get: func(doc models.StockMovement) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.StockMovement, organizationId primitive.ObjectID) {
+		doc.Organization_id = organizationId
+	},
+}
+
+// StockMovementRepository journals models.StockMovement documents, which are never changed
+type StockMovementRepository interface {
+	Create(ctx context.Context, movement *models.StockMovement) error
+	ListByProduct(ctx context.Context, productId primitive.ObjectID, query ListQuery) (ListResult[models.StockMovement], error)
+	// FindByProduct returns the movements of the product dated from from up to
+	// until in the order they were made
+	FindByProduct(ctx context.Context, productId primitive.ObjectID, from time.Time, until time.Time) ([]models.StockMovement, error)
+}
+
+type mongoStockMovementRepository struct {
+	mongoCollection[models.StockMovement]
+}
+
+func newMongoStockMovementRepository(client *mongo.Client) *mongoStockMovementRepository {
+	return &mongoStockMovementRepository{mongoCollection[models.StockMovement]{configs.GetCollection(client, "movements"), stockMovementTenancy}}
+}
+
+// ensureIndexes supports listing and replaying the movements of a product
+func (r *mongoStockMovementRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "date", Value: 1}}})
+	return err
+}
+
+func (r *mongoStockMovementRepository) Create(ctx context.Context, movement *models.StockMovement) error {
+	return r.insert(ctx, movement)
+}
+
+func (r *mongoStockMovementRepository) ListByProduct(ctx context.Context, productId primitive.ObjectID, query ListQuery) (ListResult[models.StockMovement], error) {
+	return r.list(ctx, bson.M{"product_id": productId}, query)
+}
+
+func (r *mongoStockMovementRepository) FindByProduct(ctx context.Context, productId primitive.ObjectID, from time.Time, until time.Time) ([]models.StockMovement, error) {
+	filter := bson.M{"product_id": productId, "date": bson.M{"$gte": primitive.NewDateTimeFromTime(from), "$lte": primitive.NewDateTimeFromTime(until)}}
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "id", Value: 1}})
+	results, err := r.collection.Find(ctx, r.scope(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	var movements []models.StockMovement
+	if err = results.All(ctx, &movements); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+type memoryStockMovementRepository struct {
+	docs *memoryCollection[models.StockMovement]
+}
+
+func newMemoryStockMovementRepository() *memoryStockMovementRepository {
+	return &memoryStockMovementRepository{newMemoryCollection(func(m models.StockMovement) primitive.ObjectID { return m.Id }, stockMovementTenancy)}
+}
+
+func (r *memoryStockMovementRepository) Create(ctx context.Context, movement *models.StockMovement) error {
+	r.docs.insert(ctx, movement)
+	return nil
+}
+
+func (r *memoryStockMovementRepository) ListByProduct(ctx context.Context, productId primitive.ObjectID, query ListQuery) (ListResult[models.StockMovement], error) {
+	return r.docs.list(ctx, func(m models.StockMovement) bool { return m.Product_id == productId }, query)
+}
+
+func (r *memoryStockMovementRepository) FindByProduct(ctx context.Context, productId primitive.ObjectID, from time.Time, until time.Time) ([]models.StockMovement, error) {
+	first, limit := primitive.NewDateTimeFromTime(from), primitive.NewDateTimeFromTime(until)
+	movements := r.docs.find(ctx, func(m models.StockMovement) bool {
+		return m.Product_id == productId && m.Date >= first && m.Date <= limit
+	})
+	sort.SliceStable(movements, func(i, j int) bool { return movements[i].Date < movements[j].Date })
+	return movements, nil
+}
@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNotFound is returned by every repository when the requested document does not exist
+var ErrNotFound = errors.New("document not found")
+
+// transactor runs a function so that all of its writes are applied or none are
+type transactor interface {
+	run(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Store bundles the repositories the handlers depend on
+type Store struct {
+	Customers     CustomerRepository
+	Products      ProductRepository
+	Sells         SellInfoRepository
+	Historys      HistoryRepository
+	Organizations OrganizationRepository
+	Users         UserRepository
+	Plans         InstallmentPlanRepository
+	Memberships   MembershipRepository
+	Invitations   InvitationRepository
+	Sequences     SequenceRepository
+	Movements     StockMovementRepository
+	Checkpoints   CostCheckpointRepository
+	Suppliers     SupplierRepository
+	Purchases     PurchaseOrderRepository
+	Payables      PayableRepository
+	Reorders      ReorderSuggestionRepository
+	Notifications NotificationRepository
+	Categories    CategoryRepository
+	Brands        BrandRepository
+	Serials       SerialRepository
+	Claims        WarrantyClaimRepository
+	Images        ProductImageRepository
+	Attachments   AttachmentRepository
+	Downloads     AttachmentDownloadRepository
+	Guarantors    GuarantorRepository
+
+	transactor transactor
+}
+
+// WithTransaction calls fn atomically. Repositories must be called with the
+// context handed to fn for their writes to be part of the transaction.
+func (s *Store) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.transactor.run(ctx, fn)
+}
+
+// NewMongoStore returns a Store backed by the AppAdming MongoDB database
+func NewMongoStore(client *mongo.Client) *Store {
+	customers := newMongoCustomerRepository(client)
+	products := newMongoProductRepository(client)
+	sells := newMongoSellInfoRepository(client)
+	historys := newMongoHistoryRepository(client)
+	sequences := newMongoSequenceRepository(client)
+	movements := newMongoStockMovementRepository(client)
+	checkpoints := newMongoCostCheckpointRepository(client)
+	purchases := newMongoPurchaseOrderRepository(client)
+	payables := newMongoPayableRepository(client)
+	notifications := newMongoNotificationRepository(client)
+	serials := newMongoSerialRepository(client)
+	images := newMongoProductImageRepository(client)
+	attachments := newMongoAttachmentRepository(client)
+	guarantors := newMongoGuarantorRepository(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	if err := customers.ensureIndexes(ctx); err != nil {
+		log.Println("could not index customers for searching:", err)
+	}
+	if err := products.ensureIndexes(ctx); err != nil {
+		log.Println("could not index products:", err)
+	}
+	if err := sells.ensureIndexes(ctx); err != nil {
+		log.Println("could not index sells by number:", err)
+	}
+	if err := historys.ensureIndexes(ctx); err != nil {
+		log.Println("could not index historys by number:", err)
+	}
+	if err := sequences.ensureIndexes(ctx); err != nil {
+		log.Println("could not index sequences:", err)
+	}
+	if err := movements.ensureIndexes(ctx); err != nil {
+		log.Println("could not index stock movements:", err)
+	}
+	if err := checkpoints.ensureIndexes(ctx); err != nil {
+		log.Println("could not index cost checkpoints:", err)
+	}
+	if err := purchases.ensureIndexes(ctx); err != nil {
+		log.Println("could not index purchase orders:", err)
+	}
+	if err := payables.ensureIndexes(ctx); err != nil {
+		log.Println("could not index payables:", err)
+	}
+	if err := notifications.ensureIndexes(ctx); err != nil {
+		log.Println("could not index notifications:", err)
+	}
+	if err := serials.ensureIndexes(ctx); err != nil {
+		log.Println("could not index serials:", err)
+	}
+	if err := images.ensureIndexes(ctx); err != nil {
+		log.Println("could not index product images:", err)
+	}
+	if err := attachments.ensureIndexes(ctx); err != nil {
+		log.Println("could not index attachments:", err)
+	}
+	if err := guarantors.ensureIndexes(ctx); err != nil {
+		log.Println("could not index guarantors:", err)
+	}
+
+	return &Store{
+		Customers:     customers,
+		Products:      products,
+		Sells:         sells,
+		Historys:      historys,
+		Organizations: newMongoOrganizationRepository(client),
+		Users:         newMongoUserRepository(client),
+		Plans:         newMongoInstallmentPlanRepository(client),
+		Memberships:   newMongoMembershipRepository(client),
+		Invitations:   newMongoInvitationRepository(client),
+		Sequences:     sequences,
+		Movements:     movements,
+		Checkpoints:   checkpoints,
+		Suppliers:     newMongoSupplierRepository(client),
+		Purchases:     purchases,
+		Payables:      payables,
+		Reorders:      newMongoReorderSuggestionRepository(client),
+		Notifications: notifications,
+		Categories:    newMongoCategoryRepository(client),
+		Brands:        newMongoBrandRepository(client),
+		Serials:       serials,
+		Claims:        newMongoWarrantyClaimRepository(client),
+		Images:        images,
+		Attachments:   attachments,
+		Downloads:     newMongoAttachmentDownloadRepository(client),
+		Guarantors:    guarantors,
+		transactor:    mongoTransactor{client},
+	}
+}
+
+// NewMemoryStore returns a Store that keeps every collection in process memory
+func NewMemoryStore() *Store {
+	customers := newMemoryCustomerRepository()
+	products := newMemoryProductRepository()
+	sells := newMemorySellInfoRepository()
+	historys := newMemoryHistoryRepository()
+	organizations := newMemoryOrganizationRepository()
+	users := newMemoryUserRepository()
+	plans := newMemoryInstallmentPlanRepository()
+	memberships := newMemoryMembershipRepository()
+	invitations := newMemoryInvitationRepository()
+	sequences := newMemorySequenceRepository()
+	movements := newMemoryStockMovementRepository()
+	checkpoints := newMemoryCostCheckpointRepository()
+	suppliers := newMemorySupplierRepository()
+	purchases := newMemoryPurchaseOrderRepository()
+	payables := newMemoryPayableRepository()
+	reorders := newMemoryReorderSuggestionRepository()
+	notifications := newMemoryNotificationRepository()
+	categories := newMemoryCategoryRepository()
+	brands := newMemoryBrandRepository()
+	serials := newMemorySerialRepository()
+	claims := newMemoryWarrantyClaimRepository()
+	images := newMemoryProductImageRepository()
+	attachments := newMemoryAttachmentRepository()
+	downloads := newMemoryAttachmentDownloadRepository()
+	guarantors := newMemoryGuarantorRepository()
+
+	return &Store{
+		Customers:     customers,
+		Products:      products,
+		Sells:         sells,
+		Historys:      historys,
+		Organizations: organizations,
+		Users:         users,
+		Plans:         plans,
+		Memberships:   memberships,
+		Invitations:   invitations,
+		Sequences:     sequences,
+		Movements:     movements,
+		Checkpoints:   checkpoints,
+		Suppliers:     suppliers,
+		Purchases:     purchases,
+		Payables:      payables,
+		Reorders:      reorders,
+		Notifications: notifications,
+		Categories:    categories,
+		Brands:        brands,
+		Serials:       serials,
+		Claims:        claims,
+		Images:        images,
+		Attachments:   attachments,
+		Downloads:     downloads,
+		Guarantors:    guarantors,
+		transactor:    &memoryTransactor{},
+	}
+}
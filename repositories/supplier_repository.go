@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SupplierFields are the supplier fields lists can be filtered and sorted on
+var SupplierFields = ListFields{
+	"name":    {Key: "name", Kind: StringField},
+	"contact": {Key: "contact", Kind: StringField},
+	"phone":   {Key: "phone", Kind: NumberField},
+	"email":   {Key: "email", Kind: StringField},
+}
+
+// SupplierRepository persists models.Supplier documents
+type SupplierRepository interface {
+	Create(ctx context.Context, supplier *models.Supplier) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Supplier, error)
+	FindAll(ctx context.Context) ([]models.Supplier, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.Supplier], error)
+	Update(ctx context.Context, id primitive.ObjectID, supplier models.Supplier) (models.Supplier, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+var supplierTenancy = &tenancy[models.Supplier]{
+	key: "organization_id",
+	get: func(doc models.Supplier) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.Supplier, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+type mongoSupplierRepository struct {
+	mongoCollection[models.Supplier]
+}
+
+func newMongoSupplierRepository(client *mongo.Client) *mongoSupplierRepository {
+	return &mongoSupplierRepository{mongoCollection[models.Supplier]{configs.GetCollection(client, "suppliers"), supplierTenancy}}
+}
+
+func (r *mongoSupplierRepository) Create(ctx context.Context, supplier *models.Supplier) error {
+	return r.insert(ctx, supplier)
+}
+
+func (r *mongoSupplierRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Supplier, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoSupplierRepository) FindAll(ctx context.Context) ([]models.Supplier, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoSupplierRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Supplier], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoSupplierRepository) Update(ctx context.Context, id primitive.ObjectID, supplier models.Supplier) (models.Supplier, error) {
+	update := bson.M{
+		"name":    supplier.Name,
+		"contact": supplier.Contact,
+		"phone":   supplier.Phone,
+		"email":   supplier.Email,
+		"address": supplier.Address,
+		"note":    supplier.Note,
+	}
+	return r.update(ctx, bson.M{"id": id}, update)
+}
+
+func (r *mongoSupplierRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, bson.M{"id": id})
+}
+
+type memorySupplierRepository struct {
+	docs *memoryCollection[models.Supplier]
+}
+
+func newMemorySupplierRepository() *memorySupplierRepository {
+	return &memorySupplierRepository{newMemoryCollection(func(s models.Supplier) primitive.ObjectID { return s.Id }, supplierTenancy)}
+}
+
+func (r *memorySupplierRepository) Create(ctx context.Context, supplier *models.Supplier) error {
+	r.docs.insert(ctx, supplier)
+	return nil
+}
+
+func (r *memorySupplierRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Supplier, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memorySupplierRepository) FindAll(ctx context.Context) ([]models.Supplier, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memorySupplierRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Supplier], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memorySupplierRepository) Update(ctx context.Context, id primitive.ObjectID, supplier models.Supplier) (models.Supplier, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.Supplier) {
+		supplier.Id = doc.Id
+		supplier.Organization_id = doc.Organization_id
+		*doc = supplier
+	})
+}
+
+func (r *memorySupplierRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.docs.deleteByID(ctx, id)
+}
+
+// PayableFields are the payable fields lists can be filtered and sorted on
+var PayableFields = ListFields{
+	"supplier": {Key: "supplier_id", Kind: IDField},
+	"purchase": {Key: "purchase_id", Kind: IDField},
+	"type":     {Key: "type", Kind: StringField},
+	"owed":     {Key: "owed", Kind: NumberField},
+	"paid":     {Key: "paid", Kind: NumberField},
+	"date":     {Key: "date", Kind: TimeField},
+}
+
+// PayableRepository persists models.Payable documents
+type PayableRepository interface {
+	Create(ctx context.Context, payable *models.Payable) error
+	FindAll(ctx context.Context) ([]models.Payable, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.Payable], error)
+	// FindBySupplier returns the supplier's entries dated up to until, oldest first
+	FindBySupplier(ctx context.Context, supplierId primitive.ObjectID, until time.Time) ([]models.Payable, error)
+}
+
+var payableTenancy = &tenancy[models.Payable]{
+	key: "organization_id",
+	get: func(doc models.Payable) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.Payable, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+type mongoPayableRepository struct {
+	mongoCollection[models.Payable]
+}
+
+func newMongoPayableRepository(client *mongo.Client) *mongoPayableRepository {
+	return &mongoPayableRepository{mongoCollection[models.Payable]{configs.GetCollection(client, "payables"), payableTenancy}}
+}
+
+// ensureIndexes supports reading the account of a supplier
+func (r *mongoPayableRepository) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "supplier_id", Value: 1}, {Key: "date", Value: 1}}})
+	return err
+}
+
+func (r *mongoPayableRepository) Create(ctx context.Context, payable *models.Payable) error {
+	return r.insert(ctx, payable)
+}
+
+func (r *mongoPayableRepository) FindAll(ctx context.Context) ([]models.Payable, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoPayableRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Payable], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoPayableRepository) FindBySupplier(ctx context.Context, supplierId primitive.ObjectID, until time.Time) ([]models.Payable, error) {
+	filter := bson.M{"supplier_id": supplierId, "date": bson.M{"$lte": primitive.NewDateTimeFromTime(until)}}
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}})
+	results, err := r.collection.Find(ctx, r.scope(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	var payables []models.Payable
+	if err = results.All(ctx, &payables); err != nil {
+		return nil, err
+	}
+	return payables, nil
+}
+
+type memoryPayableRepository struct {
+	docs *memoryCollection[models.Payable]
+}
+
+func newMemoryPayableRepository() *memoryPayableRepository {
+	return &memoryPayableRepository{newMemoryCollection(func(p models.Payable) primitive.ObjectID { return p.Id }, payableTenancy)}
+}
+
+func (r *memoryPayableRepository) Create(ctx context.Context, payable *models.Payable) error {
+	r.docs.insert(ctx, payable)
+	return nil
+}
+
+func (r *memoryPayableRepository) FindAll(ctx context.Context) ([]models.Payable, error) {
+	return r.docs.find(ctx, nil), nil
+}
+
+func (r *memoryPayableRepository) List(ctx context.Context, query ListQuery) (ListResult[models.Payable], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryPayableRepository) FindBySupplier(ctx context.Context, supplierId primitive.ObjectID, until time.Time) ([]models.Payable, error) {
+	limit := primitive.NewDateTimeFromTime(until)
+	payables := r.docs.find(ctx, func(p models.Payable) bool { return p.Supplier_id == supplierId && p.Date <= limit })
+	sort.SliceStable(payables, func(i, j int) bool { return payables[i].Date < payables[j].Date })
+	return payables, nil
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type organizationKey struct{}
+
+// unscoped marks a context that must see every organization
+type unscoped struct{}
+
+// WithOrganization scopes every repository call made with the returned context
+// to organizationId: reads, updates and deletes only see its documents and new
+// documents are stamped with it
+func WithOrganization(ctx context.Context, organizationId primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, organizationKey{}, organizationId)
+}
+
+// Unscoped lifts the organization scope of ctx, for the few operations that
+// cross organizations such as listing a user's memberships
+func Unscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, organizationKey{}, unscoped{})
+}
+
+// OrganizationFrom returns the organization ctx is scoped to, if any
+func OrganizationFrom(ctx context.Context) (primitive.ObjectID, bool) {
+	organizationId, ok := ctx.Value(organizationKey{}).(primitive.ObjectID)
+	return organizationId, ok
+}
+
+// tenancy describes where the documents of a collection keep their organization
+type tenancy[T any] struct {
+	key string
+	get func(T) primitive.ObjectID
+	set func(*T, primitive.ObjectID)
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"appadming/models"
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryStoreIsScopedToTheOrganization(t *testing.T) {
+	store := NewMemoryStore()
+	ours, theirs := primitive.NewObjectID(), primitive.NewObjectID()
+	ctx := WithOrganization(context.Background(), ours)
+	otherCtx := WithOrganization(context.Background(), theirs)
+
+	// the organization a client claims is replaced by the caller's
+	mine := models.Customer{Id: primitive.NewObjectID(), Name: "Rahima", Organization_id: theirs}
+	if err := store.Customers.Create(ctx, &mine); err != nil {
+		t.Fatal(err)
+	}
+	if mine.Organization_id != ours {
+		t.Errorf("stamped with %s, want %s", mine.Organization_id.Hex(), ours.Hex())
+	}
+	other := models.Customer{Id: primitive.NewObjectID(), Name: "Karim"}
+	if err := store.Customers.Create(otherCtx, &other); err != nil {
+		t.Fatal(err)
+	}
+
+	if customers, _ := store.Customers.FindAll(ctx); len(customers) != 1 || customers[0].Id != mine.Id {
+		t.Errorf("own customers = %+v", customers)
+	}
+	if _, err := store.Customers.FindByID(ctx, other.Id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("reading another organization's customer: %v", err)
+	}
+	if _, err := store.Customers.Update(ctx, other.Id, models.Customer{Name: "taken"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("updating another organization's customer: %v", err)
+	}
+	if err := store.Customers.Delete(ctx, other.Id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting another organization's customer: %v", err)
+	}
+	if stored, err := store.Customers.FindByID(otherCtx, other.Id); err != nil || stored.Name != "Karim" {
+		t.Errorf("other customer = %+v, %v", stored, err)
+	}
+	if customers, _ := store.Customers.FindAll(Unscoped(ctx)); len(customers) != 2 {
+		t.Errorf("unscoped sees %d customers, want 2", len(customers))
+	}
+}
+
+func TestMongoScope(t *testing.T) {
+	organizationId := primitive.NewObjectID()
+	collection := mongoCollection[models.Customer]{tenancy: customerTenancy}
+	filter := bson.M{"id": primitive.NewObjectID()}
+
+	scoped := collection.scope(WithOrganization(context.Background(), organizationId), filter)
+	if scoped["organization_id"] != organizationId || scoped["id"] != filter["id"] {
+		t.Errorf("scoped filter = %v", scoped)
+	}
+	if _, ok := filter["organization_id"]; ok {
+		t.Error("scope changed the filter it was given")
+	}
+	if unscoped := collection.scope(Unscoped(context.Background()), filter); len(unscoped) != 1 {
+		t.Errorf("unscoped filter = %v", unscoped)
+	}
+	if shared := (mongoCollection[models.Orgnization]{}).scope(WithOrganization(context.Background(), organizationId), bson.M{}); len(shared) != 0 {
+		t.Errorf("collections without tenancy are not scoped: %v", shared)
+	}
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var userTenancy = &tenancy[models.User]{
+	key: "organization_id",
+	get: func(doc models.User) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.User, organizationId primitive.ObjectID) { doc.Organization_id = organizationId },
+}
+
+// UserFields are the user fields lists can be filtered and sorted on
+var UserFields = ListFields{
+	"name":       {Key: "name", Kind: StringField},
+	"email":      {Key: "email", Kind: StringField},
+	"phone":      {Key: "phone", Kind: NumberField},
+	"created_at": {Key: "created_at", Kind: TimeField},
+}
+
+// UserRepository persists models.User documents
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	FindByUserID(ctx context.Context, userId string) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	CountByEmail(ctx context.Context, email string) (int64, error)
+	CountByPhone(ctx context.Context, phone int) (int64, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.User], error)
+	UpdateTokens(ctx context.Context, userId string, token string, refreshToken string) error
+	// SetOrganization makes organizationId the user's active organization
+	SetOrganization(ctx context.Context, userId string, organizationId primitive.ObjectID) (models.User, error)
+}
+
+type mongoUserRepository struct {
+	mongoCollection[models.User]
+}
+
+func newMongoUserRepository(client *mongo.Client) *mongoUserRepository {
+	return &mongoUserRepository{mongoCollection[models.User]{configs.GetCollection(client, "users"), userTenancy}}
+}
+
+func (r *mongoUserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.insert(ctx, user)
+}
+
+func (r *mongoUserRepository) FindByUserID(ctx context.Context, userId string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"user_id": userId})
+}
+
+func (r *mongoUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) CountByEmail(ctx context.Context, email string) (int64, error) {
+	return r.count(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) CountByPhone(ctx context.Context, phone int) (int64, error) {
+	return r.count(ctx, bson.M{"phone": phone})
+}
+
+func (r *mongoUserRepository) List(ctx context.Context, query ListQuery) (ListResult[models.User], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoUserRepository) UpdateTokens(ctx context.Context, userId string, token string, refreshToken string) error {
+	updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	update := bson.M{
+		"token":         token,
+		"refresh_token": refreshToken,
+		"updated_at":    updated_at,
+	}
+	_, err := r.update(ctx, bson.M{"user_id": userId}, update)
+	return err
+}
+
+func (r *mongoUserRepository) SetOrganization(ctx context.Context, userId string, organizationId primitive.ObjectID) (models.User, error) {
+	return r.update(Unscoped(ctx), bson.M{"user_id": userId}, bson.M{"organization_id": organizationId, "updated_at": time.Now()})
+}
+
+type memoryUserRepository struct {
+	docs *memoryCollection[models.User]
+}
+
+func newMemoryUserRepository() *memoryUserRepository {
+	return &memoryUserRepository{newMemoryCollection(func(u models.User) primitive.ObjectID { return u.ID }, userTenancy)}
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.docs.insert(ctx, user)
+	return nil
+}
+
+func (r *memoryUserRepository) FindByUserID(ctx context.Context, userId string) (models.User, error) {
+	return r.docs.findOne(ctx, func(u models.User) bool { return u.User_id == userId })
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.docs.findOne(ctx, func(u models.User) bool { return u.Email != nil && *u.Email == email })
+}
+
+func (r *memoryUserRepository) CountByEmail(ctx context.Context, email string) (int64, error) {
+	return r.docs.count(ctx, func(u models.User) bool { return u.Email != nil && *u.Email == email }), nil
+}
+
+func (r *memoryUserRepository) CountByPhone(ctx context.Context, phone int) (int64, error) {
+	return r.docs.count(ctx, func(u models.User) bool { return u.Phone != nil && *u.Phone == phone }), nil
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, query ListQuery) (ListResult[models.User], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryUserRepository) UpdateTokens(ctx context.Context, userId string, token string, refreshToken string) error {
+	updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	_, err := r.docs.update(ctx, func(u models.User) bool { return u.User_id == userId }, func(u *models.User) {
+		u.Token = &token
+		u.Refresh_token = &refreshToken
+		u.Updated_at = updated_at
+	})
+	return err
+}
+
+func (r *memoryUserRepository) SetOrganization(ctx context.Context, userId string, organizationId primitive.ObjectID) (models.User, error) {
+	return r.docs.update(Unscoped(ctx), func(u models.User) bool { return u.User_id == userId }, func(u *models.User) {
+		u.Organization_id = organizationId
+		u.Updated_at = time.Now()
+	})
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"appadming/configs"
+	"appadming/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WarrantyClaimFields are the warranty claim fields lists can be filtered and sorted on
+var WarrantyClaimFields = ListFields{
+	"serial":     {Key: "serial", Kind: StringField},
+	"product":    {Key: "product_id", Kind: IDField},
+	"customer":   {Key: "customer_id", Kind: IDField},
+	"status":     {Key: "status", Kind: StringField},
+	"created_at": {Key: "created_at", Kind: TimeField},
+	"updated_at": {Key: "updated_at", Kind: TimeField},
+}
+
+// WarrantyClaimRepository persists models.WarrantyClaim documents
+type WarrantyClaimRepository interface {
+	Create(ctx context.Context, claim *models.WarrantyClaim) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.WarrantyClaim, error)
+	// FindByUnit finds every claim made on a serialized unit
+	FindByUnit(ctx context.Context, unitId primitive.ObjectID) ([]models.WarrantyClaim, error)
+	List(ctx context.Context, query ListQuery) (ListResult[models.WarrantyClaim], error)
+	// Advance moves a claim to the status of event, recording event
+	Advance(ctx context.Context, id primitive.ObjectID, event models.ClaimEvent, replacementSerial string) (models.WarrantyClaim, error)
+}
+
+var warrantyClaimTenancy = &tenancy[models.WarrantyClaim]{
+	key: "organization_id",
+	get: func(doc models.WarrantyClaim) primitive.ObjectID { return doc.Organization_id },
+	set: func(doc *models.WarrantyClaim, organizationId primitive.ObjectID) {
+		doc.Organization_id = organizationId
+	},
+}
+
+type mongoWarrantyClaimRepository struct {
+	mongoCollection[models.WarrantyClaim]
+}
+
+func newMongoWarrantyClaimRepository(client *mongo.Client) *mongoWarrantyClaimRepository {
+	return &mongoWarrantyClaimRepository{mongoCollection[models.WarrantyClaim]{configs.GetCollection(client, "warranty_claims"), warrantyClaimTenancy}}
+}
+
+func (r *mongoWarrantyClaimRepository) Create(ctx context.Context, claim *models.WarrantyClaim) error {
+	return r.insert(ctx, claim)
+}
+
+func (r *mongoWarrantyClaimRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.WarrantyClaim, error) {
+	return r.findOne(ctx, bson.M{"id": id})
+}
+
+func (r *mongoWarrantyClaimRepository) FindByUnit(ctx context.Context, unitId primitive.ObjectID) ([]models.WarrantyClaim, error) {
+	return r.find(ctx, bson.M{"unit_id": unitId})
+}
+
+func (r *mongoWarrantyClaimRepository) List(ctx context.Context, query ListQuery) (ListResult[models.WarrantyClaim], error) {
+	return r.list(ctx, bson.M{}, query)
+}
+
+func (r *mongoWarrantyClaimRepository) Advance(ctx context.Context, id primitive.ObjectID, event models.ClaimEvent, replacementSerial string) (models.WarrantyClaim, error) {
+	claim, err := r.FindByID(ctx, id)
+	if err != nil {
+		return claim, err
+	}
+	update := bson.M{"status": event.Status, "events": append(claim.Events, event), "updated_at": event.Date}
+	if replacementSerial != "" {
+		update["replacement_serial"] = replacementSerial
+	}
+	return r.update(ctx, bson.M{"id": id}, update)
+}
+
+type memoryWarrantyClaimRepository struct {
+	docs *memoryCollection[models.WarrantyClaim]
+}
+
+func newMemoryWarrantyClaimRepository() *memoryWarrantyClaimRepository {
+	return &memoryWarrantyClaimRepository{newMemoryCollection(func(c models.WarrantyClaim) primitive.ObjectID { return c.Id }, warrantyClaimTenancy)}
+}
+
+func (r *memoryWarrantyClaimRepository) Create(ctx context.Context, claim *models.WarrantyClaim) error {
+	r.docs.insert(ctx, claim)
+	return nil
+}
+
+func (r *memoryWarrantyClaimRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.WarrantyClaim, error) {
+	return r.docs.findByID(ctx, id)
+}
+
+func (r *memoryWarrantyClaimRepository) FindByUnit(ctx context.Context, unitId primitive.ObjectID) ([]models.WarrantyClaim, error) {
+	return r.docs.find(ctx, func(c models.WarrantyClaim) bool { return c.Unit_id == unitId }), nil
+}
+
+func (r *memoryWarrantyClaimRepository) List(ctx context.Context, query ListQuery) (ListResult[models.WarrantyClaim], error) {
+	return r.docs.list(ctx, nil, query)
+}
+
+func (r *memoryWarrantyClaimRepository) Advance(ctx context.Context, id primitive.ObjectID, event models.ClaimEvent, replacementSerial string) (models.WarrantyClaim, error) {
+	return r.docs.updateByID(ctx, id, func(doc *models.WarrantyClaim) {
+		doc.Status = event.Status
+		doc.Events = append(append([]models.ClaimEvent{}, doc.Events...), event)
+		doc.Updated_at = event.Date
+		if replacementSerial != "" {
+			doc.Replacement_serial = replacementSerial
+		}
+	})
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"appadming/blobstore"
+	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachmentRoute serves the documents kept for customers and their sales
+func AttachmentRoute(router *gin.Engine, store *repositories.Store, blobs blobstore.Store) {
+	router.POST("/customers/:customerId/attachments", middleware.Authorize(helper.AttachmentCreate), controllers.UploadACustomerAttachment(store, blobs))
+	router.GET("/customers/:customerId/attachments", middleware.Authorize(helper.AttachmentRead), controllers.GetACustomerAttachments(store))
+	router.POST("/sells/:sellsId/attachments", middleware.Authorize(helper.AttachmentCreate), controllers.UploadASellAttachment(store, blobs))
+	router.GET("/sells/:sellsId/attachments", middleware.Authorize(helper.AttachmentRead), controllers.GetASellAttachments(store))
+	router.GET("/attachments/:attachmentId/download", middleware.Authorize(helper.AttachmentRead), controllers.DownloadAnAttachment(store, blobs))
+	router.DELETE("/attachments/:attachmentId", middleware.Authorize(helper.AttachmentDelete), controllers.DeleteAnAttachment(store, blobs))
+	router.GET("/attachment-downloads", middleware.Authorize(helper.AttachmentAudit), controllers.GetAllAttachmentDownloads(store))
+}
@@ -2,13 +2,14 @@ package routes
 
 import (
 	controller "appadming/controllers"
+	"appadming/repositories"
 
 	"github.com/gin-gonic/gin"
 )
 
 // UserRoutes function
-func AuthRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.POST("/users/signup", controller.SignUp())
-	incomingRoutes.POST("/users/login", controller.Login())
-	incomingRoutes.POST("/refresh", controller.Refresh())
+func AuthRoutes(incomingRoutes *gin.Engine, store *repositories.Store) {
+	incomingRoutes.POST("/users/signup", controller.SignUp(store))
+	incomingRoutes.POST("/users/login", controller.Login(store))
+	incomingRoutes.POST("/refresh", controller.Refresh(store))
 }
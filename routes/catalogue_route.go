@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CatalogueRoute serves the category tree and the brands, which are managed
+// with the product permissions
+func CatalogueRoute(router *gin.Engine, store *repositories.Store) {
+	router.POST("/category", middleware.Authorize(helper.ProductCreate), controllers.CreateCategory(store))
+	router.GET("/categories/:categoryId", middleware.Authorize(helper.ProductRead), controllers.GetACategory(store))
+	router.PUT("/categories/:categoryId", middleware.Authorize(helper.ProductUpdate), controllers.EditACategory(store))
+	router.DELETE("/categories/:categoryId", middleware.Authorize(helper.ProductDelete), controllers.DeleteACategory(store))
+	router.GET("/categories", middleware.Authorize(helper.ProductRead), controllers.GetAllCategories(store))
+
+	router.POST("/brand", middleware.Authorize(helper.ProductCreate), controllers.CreateBrand(store))
+	router.GET("/brands/:brandId", middleware.Authorize(helper.ProductRead), controllers.GetABrand(store))
+	router.PUT("/brands/:brandId", middleware.Authorize(helper.ProductUpdate), controllers.EditABrand(store))
+	router.DELETE("/brands/:brandId", middleware.Authorize(helper.ProductDelete), controllers.DeleteABrand(store))
+	router.GET("/brands", middleware.Authorize(helper.ProductRead), controllers.GetAllBrands(store))
+	router.PUT("/brands/:brandId/logo", middleware.Authorize(helper.ProductUpdate), controllers.UploadABrandLogo(store))
+	router.GET("/brands/:brandId/logo", middleware.Authorize(helper.ProductRead), controllers.GetABrandLogo(store))
+	router.DELETE("/brands/:brandId/logo", middleware.Authorize(helper.ProductUpdate), controllers.DeleteABrandLogo(store))
+}
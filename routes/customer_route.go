@@ -2,15 +2,20 @@ package routes
 
 import (
 	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
 
 	"github.com/gin-gonic/gin"
 )
 
-func CustomerRoute(router *gin.Engine) {
-	router.POST("/customer", controllers.CreateCustomer())
-	router.GET("/customers/:customerId", controllers.GetACustomer())
-	router.PUT("/customers/:customerId", controllers.EditACustomer())
-	router.DELETE("/customers/:customerId", controllers.DeleteACustomer())
-	router.GET("/customers", controllers.GetAllCustomers())
+func CustomerRoute(router *gin.Engine, store *repositories.Store) {
+	router.POST("/customer", middleware.Authorize(helper.CustomerCreate), controllers.CreateCustomer(store))
+	router.GET("/customers/search", middleware.Authorize(helper.CustomerRead), controllers.SearchCustomers(store))
+	router.GET("/customers/:customerId", middleware.Authorize(helper.CustomerRead), controllers.GetACustomer(store))
+	router.PUT("/customers/:customerId", middleware.Authorize(helper.CustomerUpdate), controllers.EditACustomer(store))
+	router.DELETE("/customers/:customerId", middleware.Authorize(helper.CustomerDelete), controllers.DeleteACustomer(store))
+	router.GET("/customers", middleware.Authorize(helper.CustomerRead), controllers.GetAllCustomers(store))
+	router.GET("/customers/:customerId/statement", middleware.Authorize(helper.HistoryRead), controllers.GetACustomerStatement(store))
 
 }
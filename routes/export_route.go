@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ExportRoute(router *gin.Engine, store *repositories.Store) {
+	router.GET("/export/sells", middleware.Authorize(helper.SellRead), controllers.ExportSells(store))
+	router.GET("/export/historys", middleware.Authorize(helper.HistoryRead), controllers.ExportHistorys(store))
+	router.GET("/export/customers", middleware.Authorize(helper.CustomerRead), controllers.ExportCustomers(store))
+}
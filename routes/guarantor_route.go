@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GuarantorRoute serves the guarantors of customers and sales and what they answer for
+func GuarantorRoute(router *gin.Engine, store *repositories.Store) {
+	router.POST("/guarantor", middleware.Authorize(helper.CustomerCreate), controllers.CreateGuarantor(store))
+	router.GET("/guarantors/:guarantorId", middleware.Authorize(helper.CustomerRead), controllers.GetAGuarantor(store))
+	router.PUT("/guarantors/:guarantorId", middleware.Authorize(helper.CustomerUpdate), controllers.EditAGuarantor(store))
+	router.DELETE("/guarantors/:guarantorId", middleware.Authorize(helper.CustomerDelete), controllers.DeleteAGuarantor(store))
+	router.GET("/guarantors", middleware.Authorize(helper.CustomerRead), controllers.GetAllGuarantors(store))
+	router.GET("/guarantors/:guarantorId/exposure", middleware.Authorize(helper.ReportRead), controllers.GetAGuarantorExposure(store))
+	router.POST("/customers/:customerId/guarantors", middleware.Authorize(helper.CustomerUpdate), controllers.LinkACustomerGuarantor(store))
+	router.GET("/customers/:customerId/guarantors", middleware.Authorize(helper.CustomerRead), controllers.GetACustomerGuarantors(store))
+	router.DELETE("/customers/:customerId/guarantors/:guarantorId", middleware.Authorize(helper.CustomerUpdate), controllers.UnlinkACustomerGuarantor(store))
+	router.PUT("/sells/:sellsId/guarantor", middleware.Authorize(helper.SellUpdate), controllers.SetASellGuarantor(store))
+}
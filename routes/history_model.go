@@ -2,14 +2,19 @@ package routes
 
 import (
 	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
 
 	"github.com/gin-gonic/gin"
 )
 
-func HistoryRoute(router *gin.Engine) {
-	router.POST("/history", controllers.CreateHistory())
-	router.GET("/historys/:historyId", controllers.GetAHistory())
-	router.PUT("/historys/:historyId", controllers.EditAHistory())
-	router.DELETE("/historys/:historyId", controllers.DeleteAHistory())
-	router.GET("/historys", controllers.GetAllHistorys())
+func HistoryRoute(router *gin.Engine, store *repositories.Store) {
+	router.POST("/history", middleware.Authorize(helper.HistoryCreate), controllers.CreateHistory(store))
+	router.GET("/historys/:historyId", middleware.Authorize(helper.HistoryRead), controllers.GetAHistory(store))
+	router.PUT("/historys/:historyId", middleware.Authorize(helper.HistoryUpdate), controllers.EditAHistory(store))
+	router.DELETE("/historys/:historyId", middleware.Authorize(helper.HistoryDelete), controllers.DeleteAHistory(store))
+	router.POST("/historys/:historyId/void", middleware.Authorize(helper.HistoryDelete), controllers.VoidAHistory(store))
+	router.GET("/historys/:historyId/receipt", middleware.Authorize(helper.HistoryRead), controllers.GetAHistoryReceipt(store))
+	router.GET("/historys", middleware.Authorize(helper.HistoryRead), controllers.GetAllHistorys(store))
 }
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ImportRoute(router *gin.Engine, store *repositories.Store) {
+	router.POST("/import/customers", middleware.Authorize(helper.CustomerCreate), controllers.ImportCustomers(store))
+	router.POST("/import/products", middleware.Authorize(helper.ProductCreate), controllers.ImportProducts(store))
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"appadming/controllers"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationRoute lets every member read their own notifications
+func NotificationRoute(router *gin.Engine, store *repositories.Store) {
+	router.GET("/notifications", controllers.GetMyNotifications(store))
+	router.POST("/notifications/:notificationId/read", controllers.ReadANotification(store))
+}
@@ -2,14 +2,23 @@ package routes
 
 import (
 	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
 
 	"github.com/gin-gonic/gin"
 )
 
-func OrganizationRoute(router *gin.Engine) {
-	router.POST("/organization", controllers.CreateOrganization())
-	router.GET("/organizations/:organizationId", controllers.GetAOrganization())
-	router.PUT("/organizations/:organizationId", controllers.EditAOrganization())
-	router.DELETE("/organizations/:organizationId", controllers.DeleteAOrganization())
-	router.GET("/organizations", controllers.GetAllOrganizations())
+func OrganizationRoute(router *gin.Engine, store *repositories.Store) {
+	router.POST("/organization", controllers.CreateOrganization(store))
+	router.GET("/organizations/:organizationId", controllers.GetAOrganization(store))
+	router.PUT("/organizations/:organizationId", middleware.Authorize(helper.OrganizationUpdate), controllers.EditAOrganization(store))
+	router.DELETE("/organizations/:organizationId", middleware.Authorize(helper.OrganizationDelete), controllers.DeleteAOrganization(store))
+	router.PUT("/organizations/:organizationId/logo", middleware.Authorize(helper.OrganizationUpdate), controllers.UploadAOrganizationLogo(store))
+	router.GET("/organizations/:organizationId/logo", controllers.GetAOrganizationLogo(store))
+	router.DELETE("/organizations/:organizationId/logo", middleware.Authorize(helper.OrganizationUpdate), controllers.DeleteAOrganizationLogo(store))
+	router.GET("/organizations", controllers.GetAllOrganizations(store))
+	router.POST("/organizations/:organizationId/switch", controllers.SwitchAOrganization(store))
+	router.POST("/organizations/:organizationId/invitations", middleware.Authorize(helper.MemberInvite), controllers.CreateAInvitation(store))
+	router.GET("/organizations/:organizationId/invitations", middleware.Authorize(helper.MemberInvite), controllers.GetAllInvitations(store))
 }
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"appadming/blobstore"
+	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProductImageRoute serves the pictures of products kept in blobs
+func ProductImageRoute(router *gin.Engine, store *repositories.Store, blobs blobstore.Store) {
+	router.POST("/products/:productId/images", middleware.Authorize(helper.ProductUpdate), controllers.UploadProductImages(store, blobs))
+	router.GET("/products/:productId/images", middleware.Authorize(helper.ProductRead), controllers.GetAProductImages(store))
+	router.PUT("/products/:productId/images/order", middleware.Authorize(helper.ProductUpdate), controllers.OrderAProductImages(store))
+	router.GET("/products/:productId/images/:imageId", middleware.Authorize(helper.ProductRead), controllers.GetAProductImage(store, blobs))
+	router.GET("/products/:productId/images/:imageId/thumbnail", middleware.Authorize(helper.ProductRead), controllers.GetAProductImageThumbnail(store, blobs))
+	router.DELETE("/products/:productId/images/:imageId", middleware.Authorize(helper.ProductUpdate), controllers.DeleteAProductImage(store, blobs))
+}
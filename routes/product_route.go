@@ -1,15 +1,27 @@
 package routes
 
 import (
+	"appadming/blobstore"
 	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ProductRoute(router *gin.Engine) {
-	router.POST("/product", controllers.CreateProduct())
-	router.GET("/products/:productId", controllers.GetAProduct())
-	router.PUT("/products/:productId", controllers.EditAProduct())
-	router.DELETE("/products/:productId", controllers.DeleteAProduct())
-	router.GET("/products", controllers.GetAllProducts())
+func ProductRoute(router *gin.Engine, store *repositories.Store, blobs blobstore.Store) {
+	router.POST("/product", middleware.Authorize(helper.ProductCreate), controllers.CreateProduct(store))
+	router.GET("/products/:productId", middleware.Authorize(helper.ProductRead), controllers.GetAProduct(store))
+	router.PUT("/products/:productId", middleware.Authorize(helper.ProductUpdate), controllers.EditAProduct(store))
+	router.DELETE("/products/:productId", middleware.Authorize(helper.ProductDelete), controllers.DeleteAProduct(store, blobs))
+	router.GET("/products", middleware.Authorize(helper.ProductRead), controllers.GetAllProducts(store))
+	router.GET("/products/:productId/variants", middleware.Authorize(helper.ProductRead), controllers.GetAProductVariants(store))
+	router.POST("/products/:productId/variants", middleware.Authorize(helper.ProductCreate), controllers.CreateAProductVariant(store))
+	router.GET("/products/:productId/movements", middleware.Authorize(helper.ProductRead), controllers.GetAProductMovements(store))
+	router.POST("/products/:productId/movements", middleware.Authorize(helper.StockAdjust), controllers.CreateAProductMovement(store))
+	router.POST("/products/:productId/transfers", middleware.Authorize(helper.StockAdjust), controllers.TransferAProductStock(store))
+	router.GET("/inventory/reorder-suggestions", middleware.Authorize(helper.ProductRead), controllers.GetReorderSuggestions(store))
+	router.POST("/inventory/reorder-suggestions/refresh", middleware.Authorize(helper.StockAdjust), controllers.RefreshReorderSuggestions(store))
+	router.POST("/stock-counts", middleware.Authorize(helper.StockAdjust), controllers.CountStock(store))
 }
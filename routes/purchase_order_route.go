@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PurchaseOrderRoute(router *gin.Engine, store *repositories.Store) {
+	router.POST("/purchase", middleware.Authorize(helper.PurchaseCreate), controllers.CreatePurchaseOrder(store))
+	router.GET("/purchases/:purchaseId", middleware.Authorize(helper.PurchaseRead), controllers.GetAPurchaseOrder(store))
+	router.GET("/purchases", middleware.Authorize(helper.PurchaseRead), controllers.GetAllPurchaseOrders(store))
+	router.POST("/purchases/:purchaseId/receipts", middleware.Authorize(helper.PurchaseReceive), controllers.ReceiveAPurchaseOrder(store))
+	router.POST("/purchases/:purchaseId/cancel", middleware.Authorize(helper.PurchaseCreate), controllers.CancelAPurchaseOrder(store))
+}
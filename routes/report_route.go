@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReportRoute(router *gin.Engine, store *repositories.Store) {
+	router.GET("/reports/aging", middleware.Authorize(helper.ReportRead), controllers.GetAgingReport(store))
+	router.GET("/reports/payables", middleware.Authorize(helper.ReportRead), controllers.GetPayablesReport(store))
+	router.GET("/reports/inventory-valuation", middleware.Authorize(helper.ReportRead), controllers.GetInventoryValuation(store))
+	router.GET("/reports/profit", middleware.Authorize(helper.ReportRead), controllers.GetProfitReport(store))
+	router.GET("/reports/guarantor-exposure", middleware.Authorize(helper.ReportRead), controllers.GetGuarantorExposureReport(store))
+}
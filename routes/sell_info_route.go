@@ -2,14 +2,22 @@ package routes
 
 import (
 	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SellsRoute(router *gin.Engine) {
-	router.POST("/sell", controllers.CreateSell())
-	router.GET("/sells/:sellsId", controllers.GetASell())
-	router.PUT("/sells/:sellsId", controllers.EditASell())
-	router.DELETE("/sells/:sellsId", controllers.DeleteASell())
-	router.GET("/sells", controllers.GetAllSells())
+func SellsRoute(router *gin.Engine, store *repositories.Store) {
+	router.POST("/sell", middleware.Authorize(helper.SellCreate), controllers.CreateSell(store))
+	router.GET("/sells/:sellsId", middleware.Authorize(helper.SellRead), controllers.GetASell(store))
+	router.PUT("/sells/:sellsId", middleware.Authorize(helper.SellUpdate), controllers.EditASell(store))
+	router.DELETE("/sells/:sellsId", middleware.Authorize(helper.SellVoid), controllers.DeleteASell(store))
+	router.POST("/sells/:sellsId/void", middleware.Authorize(helper.SellVoid), controllers.VoidASell(store))
+	router.POST("/sells/:sellsId/returns", middleware.Authorize(helper.SellReturn), controllers.ReturnASell(store))
+	router.GET("/sells/:sellsId/invoice", middleware.Authorize(helper.SellRead), controllers.GetASellInvoice(store))
+	router.GET("/sells", middleware.Authorize(helper.SellRead), controllers.GetAllSells(store))
+	router.POST("/sells/:sellsId/plan", middleware.Authorize(helper.PlanCreate), controllers.CreateASellPlan(store))
+	router.GET("/sells/:sellsId/plan", middleware.Authorize(helper.PlanRead), controllers.GetASellPlan(store))
 }
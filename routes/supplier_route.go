@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SupplierRoute(router *gin.Engine, store *repositories.Store) {
+	router.POST("/supplier", middleware.Authorize(helper.SupplierCreate), controllers.CreateSupplier(store))
+	router.GET("/suppliers/:supplierId", middleware.Authorize(helper.SupplierRead), controllers.GetASupplier(store))
+	router.PUT("/suppliers/:supplierId", middleware.Authorize(helper.SupplierUpdate), controllers.EditASupplier(store))
+	router.DELETE("/suppliers/:supplierId", middleware.Authorize(helper.SupplierDelete), controllers.DeleteASupplier(store))
+	router.GET("/suppliers", middleware.Authorize(helper.SupplierRead), controllers.GetAllSuppliers(store))
+	router.GET("/suppliers/:supplierId/account", middleware.Authorize(helper.SupplierRead), controllers.GetASupplierAccount(store))
+	router.POST("/suppliers/:supplierId/payments", middleware.Authorize(helper.SupplierPay), controllers.PayASupplier(store))
+}
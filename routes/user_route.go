@@ -2,12 +2,18 @@ package routes
 
 import (
 	controller "appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
 
 	"github.com/gin-gonic/gin"
 )
 
 // UserRoutes function
-func UserRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/users", controller.GetUsers())
-	incomingRoutes.GET("/users/:user_id", controller.GetUser())
+func UserRoutes(incomingRoutes *gin.Engine, store *repositories.Store) {
+	incomingRoutes.GET("/users", middleware.Authorize(helper.UserRead), controller.GetUsers(store))
+	incomingRoutes.GET("/users/:user_id", controller.GetUser(store))
+	incomingRoutes.GET("/roles", middleware.Authorize(helper.UserRead), controller.GetAllRoles(store))
+	incomingRoutes.PUT("/users/:user_id/role", middleware.Authorize(helper.RoleAssign), controller.AssignARole(store))
+	incomingRoutes.DELETE("/users/:user_id/role", middleware.Authorize(helper.RoleAssign), controller.RevokeARole(store))
 }
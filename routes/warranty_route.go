@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"appadming/controllers"
+	helper "appadming/helpers"
+	middleware "appadming/middlewares"
+	"appadming/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WarrantyRoute serves the serialized units and their warranty claims
+func WarrantyRoute(router *gin.Engine, store *repositories.Store) {
+	router.GET("/serials/:serial", middleware.Authorize(helper.ProductRead), controllers.GetASerial(store))
+	router.GET("/serials", middleware.Authorize(helper.ProductRead), controllers.GetAllSerials(store))
+
+	router.POST("/warranty-claim", middleware.Authorize(helper.WarrantyCreate), controllers.CreateWarrantyClaim(store))
+	router.GET("/warranty-claims/:claimId", middleware.Authorize(helper.WarrantyRead), controllers.GetAWarrantyClaim(store))
+	router.GET("/warranty-claims", middleware.Authorize(helper.WarrantyRead), controllers.GetAllWarrantyClaims(store))
+	router.POST("/warranty-claims/:claimId/status", middleware.Authorize(helper.WarrantyUpdate), controllers.UpdateAWarrantyClaim(store))
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"appadming/blobstore"
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidAttachment is returned for uploads that are not PDF, PNG or JPEG documents of a reasonable size
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+// MaxAttachmentSize is the largest document accepted, in bytes
+const MaxAttachmentSize = 10 << 20
+
+// attachmentTypes are the media types documents may have, told by their content
+var attachmentTypes = map[string]bool{"application/pdf": true, "image/png": true, "image/jpeg": true}
+
+// attachmentOwner finds the customer an attachment of ownerType ownerId
+// belongs to, in the organization ctx is scoped to
+func attachmentOwner(ctx context.Context, store *repositories.Store, ownerType string, ownerId primitive.ObjectID) (primitive.ObjectID, error) {
+	switch ownerType {
+	case models.AttachedToCustomer:
+		customer, err := store.Customers.FindByID(ctx, ownerId)
+		return customer.Id, err
+	case models.AttachedToSell:
+		sell, err := store.Sells.FindByID(ctx, ownerId)
+		return sell.Customer_id, err
+	}
+	return primitive.NilObjectID, fmt.Errorf("%w: cannot attach to %q", ErrInvalidAttachment, ownerType)
+}
+
+// AddAttachment keeps a document uploaded by userId for a customer or a sale
+func AddAttachment(ctx context.Context, store *repositories.Store, blobs blobstore.Store, ownerType string, ownerId primitive.ObjectID, upload models.AttachmentUpload, filename string, data []byte, userId primitive.ObjectID, now time.Time) (models.Attachment, error) {
+	var attachment models.Attachment
+
+	if len(data) == 0 || len(data) > MaxAttachmentSize {
+		return attachment, fmt.Errorf("%w: documents must hold up to %d MB", ErrInvalidAttachment, MaxAttachmentSize>>20)
+	}
+	contentType := http.DetectContentType(data)
+	if !attachmentTypes[contentType] {
+		return attachment, fmt.Errorf("%w: %s is not a PDF, PNG or JPEG document", ErrInvalidAttachment, contentType)
+	}
+	customerId, err := attachmentOwner(ctx, store, ownerType, ownerId)
+	if err != nil {
+		return attachment, err
+	}
+	organizationId, _ := repositories.OrganizationFrom(ctx)
+
+	attachment = models.Attachment{
+		Id:              primitive.NewObjectID(),
+		Organization_id: organizationId,
+		Owner_type:      ownerType,
+		Owner_id:        ownerId,
+		Customer_id:     customerId,
+		Document_type:   upload.Document_type,
+		Filename:        cleanFilename(filename),
+		Content_type:    contentType,
+		Size:            len(data),
+		Note:            strings.TrimSpace(upload.Note),
+		User_id:         userId,
+		Created_at:      now,
+	}
+	attachment.Key = fmt.Sprintf("attachments/%s/%s", organizationId.Hex(), attachment.Id.Hex())
+	if err := blobs.Put(ctx, attachment.Key, data, contentType); err != nil {
+		return attachment, err
+	}
+	if err := store.Attachments.Create(ctx, &attachment); err != nil {
+		deleteBlobs(blobs, []string{attachment.Key})
+		return attachment, err
+	}
+	return attachment, nil
+}
+
+// ListAttachments lists the documents of a sale, or of a customer and all
+// their sales
+func ListAttachments(ctx context.Context, store *repositories.Store, ownerType string, ownerId primitive.ObjectID, query repositories.ListQuery) (repositories.ListResult[models.Attachment], error) {
+	if _, err := attachmentOwner(ctx, store, ownerType, ownerId); err != nil {
+		return repositories.ListResult[models.Attachment]{}, err
+	}
+	key := "owner_id"
+	if ownerType == models.AttachedToCustomer {
+		key = "customer_id"
+	}
+	query.Filters = append(query.Filters, repositories.Filter{Key: key, Op: repositories.OpEq, Value: ownerId})
+	return store.Attachments.List(ctx, query)
+}
+
+// DownloadAttachment reads a document for userId, recording the download.
+// Nothing is handed out unless the download could be recorded.
+func DownloadAttachment(ctx context.Context, store *repositories.Store, blobs blobstore.Store, attachmentId primitive.ObjectID, userId primitive.ObjectID, ip string, now time.Time) (models.Attachment, []byte, error) {
+	attachment, err := store.Attachments.FindByID(ctx, attachmentId)
+	if err != nil {
+		return attachment, nil, err
+	}
+	data, err := blobs.Get(ctx, attachment.Key)
+	if err != nil {
+		return attachment, nil, err
+	}
+	download := models.AttachmentDownload{
+		Id:              primitive.NewObjectID(),
+		Organization_id: attachment.Organization_id,
+		Attachment_id:   attachment.Id,
+		Customer_id:     attachment.Customer_id,
+		Document_type:   attachment.Document_type,
+		Filename:        attachment.Filename,
+		User_id:         userId,
+		Ip:              ip,
+		Date:            now,
+	}
+	if err := store.Downloads.Create(ctx, &download); err != nil {
+		return attachment, nil, err
+	}
+	return attachment, data, nil
+}
+
+// DeleteAttachment removes a document and then its file. Its downloads stay in the audit.
+func DeleteAttachment(ctx context.Context, store *repositories.Store, blobs blobstore.Store, attachmentId primitive.ObjectID) error {
+	attachment, err := store.Attachments.FindByID(ctx, attachmentId)
+	if err != nil {
+		return err
+	}
+	if err := store.Attachments.Delete(ctx, attachment.Id); err != nil {
+		return err
+	}
+	deleteBlobs(blobs, []string{attachment.Key})
+	return nil
+}
+
+// cleanFilename keeps the base name of an uploaded file without control characters
+func cleanFilename(filename string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' || r == '\\' {
+			return -1
+		}
+		return r
+	}, filepath.Base(strings.ReplaceAll(filename, "\\", "/")))
+	if runes := []rune(name); len(runes) > 200 {
+		name = string(runes[:200])
+	}
+	if name == "" || name == "." || name == "/" {
+		return "document"
+	}
+	return name
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"appadming/blobstore"
+	"appadming/models"
+	"appadming/repositories"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	pdfDocument = []byte("%PDF-1.4\n1 0 obj << >> endobj\n%%EOF\n")
+	pngDocument = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+)
+
+func TestAddAttachment(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	blobs, err := blobstore.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone := f.addProduct(t, "Phone", 1000, 700, 5)
+	sell, _, err := CreateSell(f.ctx, f.store, models.SellInfo{Customer_id: f.customer.Id, Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 1}}}, primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	userId := primitive.NewObjectID()
+	now := time.Now()
+
+	nid, err := AddAttachment(f.ctx, f.store, blobs, models.AttachedToCustomer, f.customer.Id, models.AttachmentUpload{Document_type: models.DocumentNID, Note: " front and back "}, `C:\scans\nid "new".pdf`, pdfDocument, userId, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nid.Customer_id != f.customer.Id || nid.Content_type != "application/pdf" || nid.Filename != "nid new.pdf" || nid.Note != "front and back" || nid.Size != len(pdfDocument) || nid.Organization_id != f.organization.Id {
+		t.Errorf("attachment = %+v", nid)
+	}
+	if stored, err := blobs.Get(f.ctx, nid.Key); err != nil || !bytes.Equal(stored, pdfDocument) {
+		t.Errorf("stored document = %q, %v", stored, err)
+	}
+	contract, err := AddAttachment(f.ctx, f.store, blobs, models.AttachedToSell, sell.Id, models.AttachmentUpload{Document_type: models.DocumentContract}, "contract.png", pngDocument, userId, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contract.Owner_id != sell.Id || contract.Customer_id != f.customer.Id {
+		t.Errorf("attachment of the sale = %+v, want it kept for the customer of the sale", contract)
+	}
+
+	tests := []struct {
+		name      string
+		ownerType string
+		ownerId   primitive.ObjectID
+		data      []byte
+		wantErr   error
+	}{
+		{name: "empty", ownerType: models.AttachedToCustomer, ownerId: f.customer.Id, data: nil, wantErr: ErrInvalidAttachment},
+		{name: "too large", ownerType: models.AttachedToCustomer, ownerId: f.customer.Id, data: append(append([]byte{}, pdfDocument...), make([]byte, MaxAttachmentSize)...), wantErr: ErrInvalidAttachment},
+		{name: "not a document", ownerType: models.AttachedToCustomer, ownerId: f.customer.Id, data: []byte("<html><script>"), wantErr: ErrInvalidAttachment},
+		{name: "unknown owner type", ownerType: "supplier", ownerId: f.customer.Id, data: pdfDocument, wantErr: ErrInvalidAttachment},
+		{name: "missing customer", ownerType: models.AttachedToCustomer, ownerId: primitive.NewObjectID(), data: pdfDocument, wantErr: repositories.ErrNotFound},
+		{name: "missing sale", ownerType: models.AttachedToSell, ownerId: primitive.NewObjectID(), data: pdfDocument, wantErr: repositories.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := AddAttachment(f.ctx, f.store, blobs, tt.ownerType, tt.ownerId, models.AttachmentUpload{Document_type: models.DocumentOther}, "scan.pdf", tt.data, userId, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	list := func(ownerType string, ownerId primitive.ObjectID) []primitive.ObjectID {
+		t.Helper()
+		page, err := ListAttachments(f.ctx, f.store, ownerType, ownerId, repositories.ListQuery{Sort: []repositories.SortField{{Key: "document_type"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []primitive.ObjectID
+		for _, attachment := range page.Items {
+			ids = append(ids, attachment.Id)
+		}
+		return ids
+	}
+	if got := list(models.AttachedToCustomer, f.customer.Id); len(got) != 2 || got[0] != contract.Id || got[1] != nid.Id {
+		t.Errorf("documents of the customer = %v, want the contract of their sale and their NID", got)
+	}
+	if got := list(models.AttachedToSell, sell.Id); len(got) != 1 || got[0] != contract.Id {
+		t.Errorf("documents of the sale = %v, want its contract", got)
+	}
+}
+
+func TestDownloadAndDeleteAttachment(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	blobs, err := blobstore.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachment, err := AddAttachment(f.ctx, f.store, blobs, models.AttachedToCustomer, f.customer.Id, models.AttachmentUpload{Document_type: models.DocumentGuarantor}, "guarantor.pdf", pdfDocument, primitive.NewObjectID(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	userId := primitive.NewObjectID()
+
+	_, data, err := DownloadAttachment(f.ctx, f.store, blobs, attachment.Id, userId, "10.0.0.7", date(2024, 3, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, pdfDocument) {
+		t.Errorf("downloaded %q", data)
+	}
+	other := repositories.WithOrganization(context.Background(), primitive.NewObjectID())
+	if _, _, err := DownloadAttachment(other, f.store, blobs, attachment.Id, userId, "10.0.0.8", date(2024, 3, 1)); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("download from another organization = %v, want ErrNotFound", err)
+	}
+
+	if err := DeleteAttachment(f.ctx, f.store, blobs, attachment.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blobs.Get(f.ctx, attachment.Key); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Errorf("file of the deleted attachment = %v, want ErrNotFound", err)
+	}
+	if _, _, err := DownloadAttachment(f.ctx, f.store, blobs, attachment.Id, userId, "10.0.0.7", date(2024, 3, 2)); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("download of the deleted attachment = %v, want ErrNotFound", err)
+	}
+
+	downloads, err := f.store.Downloads.List(f.ctx, repositories.ListQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloads.Total != 1 {
+		t.Fatalf("%d downloads recorded, want only the one handed out", downloads.Total)
+	}
+	download := downloads.Items[0]
+	if download.Attachment_id != attachment.Id || download.Customer_id != f.customer.Id || download.User_id != userId || download.Ip != "10.0.0.7" || download.Filename != "guarantor.pdf" {
+		t.Errorf("download = %+v", download)
+	}
+	// the customer can go once their documents are gone
+	if err := DeleteCustomer(f.ctx, f.store, f.customer.Id); err != nil {
+		t.Errorf("deleting the customer after their documents = %v", err)
+	}
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCatalogue is returned for categories, brands and variants that
+// do not fit the catalogue
+var ErrInvalidCatalogue = errors.New("invalid catalogue entry")
+
+// ErrCatalogueInUse is returned when deleting a category or brand products or
+// subcategories still point at
+var ErrCatalogueInUse = errors.New("catalogue entry is in use")
+
+// ErrDuplicateSku is returned when a SKU is already taken by another product
+var ErrDuplicateSku = errors.New("sku is already taken")
+
+// attributeName is what a variant attribute may be called, so it can be
+// filtered on as attr.<name>
+var attributeName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// CreateCategory adds a category under category.Parent_id, or at the top of
+// the tree when it is zero
+func CreateCategory(ctx context.Context, store *repositories.Store, category models.Category) (models.Category, error) {
+	if !category.Parent_id.IsZero() {
+		if _, err := store.Categories.FindByID(ctx, category.Parent_id); err != nil {
+			return category, fmt.Errorf("parent category %s: %w", category.Parent_id.Hex(), err)
+		}
+	}
+	category.Id = primitive.NewObjectID()
+	err := store.Categories.Create(ctx, &category)
+	return category, err
+}
+
+// UpdateCategory renames or moves a category, refusing to move it under
+// itself, and renames the category of its products with it
+func UpdateCategory(ctx context.Context, store *repositories.Store, id primitive.ObjectID, category models.Category) (models.Category, error) {
+	var updated models.Category
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := store.Categories.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !category.Parent_id.IsZero() {
+			categories, err := store.Categories.FindAll(ctx)
+			if err != nil {
+				return err
+			}
+			for _, descendant := range categorySubtree(categories, id) {
+				if descendant == category.Parent_id {
+					return fmt.Errorf("%w: a category cannot sit under itself", ErrInvalidCatalogue)
+				}
+			}
+			if _, err := store.Categories.FindByID(ctx, category.Parent_id); err != nil {
+				return fmt.Errorf("parent category %s: %w", category.Parent_id.Hex(), err)
+			}
+		}
+		if updated, err = store.Categories.Update(ctx, id, category); err != nil {
+			return err
+		}
+		if current.Name == updated.Name {
+			return nil
+		}
+		query := repositories.ListQuery{Filters: []repositories.Filter{{Key: "category_id", Op: repositories.OpEq, Value: id}}}
+		var products []models.Product
+		if err := store.Products.Stream(ctx, query, func(product models.Product) error {
+			products = append(products, product)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, product := range products {
+			product.Category = updated.Name
+			if _, err := store.Products.Update(ctx, product.Id, product); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// DeleteCategory deletes a category no product or subcategory uses
+func DeleteCategory(ctx context.Context, store *repositories.Store, id primitive.ObjectID) error {
+	return store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.Categories.FindByID(ctx, id); err != nil {
+			return err
+		}
+		children, err := store.Categories.List(ctx, repositories.ListQuery{Limit: 1, Filters: []repositories.Filter{{Key: "parent_id", Op: repositories.OpEq, Value: id}}})
+		if err != nil {
+			return err
+		}
+		if children.Total > 0 {
+			return fmt.Errorf("%w: the category has %d subcategories", ErrCatalogueInUse, children.Total)
+		}
+		if err := checkUnused(ctx, store, "category_id", id); err != nil {
+			return err
+		}
+		return store.Categories.Delete(ctx, id)
+	})
+}
+
+// DeleteBrand deletes a brand no product uses
+func DeleteBrand(ctx context.Context, store *repositories.Store, id primitive.ObjectID) error {
+	return store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.Brands.FindByID(ctx, id); err != nil {
+			return err
+		}
+		if err := checkUnused(ctx, store, "brand_id", id); err != nil {
+			return err
+		}
+		return store.Brands.Delete(ctx, id)
+	})
+}
+
+func checkUnused(ctx context.Context, store *repositories.Store, key string, id primitive.ObjectID) error {
+	products, err := store.Products.List(ctx, repositories.ListQuery{Limit: 1, Filters: []repositories.Filter{{Key: key, Op: repositories.OpEq, Value: id}}})
+	if err != nil {
+		return err
+	}
+	if products.Total > 0 {
+		return fmt.Errorf("%w: %d products use it", ErrCatalogueInUse, products.Total)
+	}
+	return nil
+}
+
+// categorySubtree lists id and every category below it
+func categorySubtree(categories []models.Category, id primitive.ObjectID) []primitive.ObjectID {
+	children := map[primitive.ObjectID][]primitive.ObjectID{}
+	for _, category := range categories {
+		children[category.Parent_id] = append(children[category.Parent_id], category.Id)
+	}
+	subtree := []primitive.ObjectID{id}
+	for i := 0; i < len(subtree); i++ {
+		subtree = append(subtree, children[subtree[i]]...)
+	}
+	return subtree
+}
+
+// resolveCatalogue checks the category, brand, SKU and attributes of product
+// before it is stored and names its category after the category it points at
+func resolveCatalogue(ctx context.Context, store *repositories.Store, product *models.Product) error {
+	if !product.Category_id.IsZero() {
+		category, err := store.Categories.FindByID(ctx, product.Category_id)
+		if err != nil {
+			return fmt.Errorf("category %s: %w", product.Category_id.Hex(), err)
+		}
+		product.Category = category.Name
+	}
+	if !product.Brand_id.IsZero() {
+		if _, err := store.Brands.FindByID(ctx, product.Brand_id); err != nil {
+			return fmt.Errorf("brand %s: %w", product.Brand_id.Hex(), err)
+		}
+	}
+	for name := range product.Attributes {
+		if !attributeName.MatchString(name) {
+			return fmt.Errorf("%w: attribute %q may only hold letters, digits, _ and -", ErrInvalidCatalogue, name)
+		}
+	}
+	product.Sku = strings.TrimSpace(product.Sku)
+	if product.Sku == "" {
+		return nil
+	}
+	taken, err := store.Products.List(ctx, repositories.ListQuery{Limit: 2, Filters: []repositories.Filter{{Key: "sku", Op: repositories.OpEq, Value: product.Sku}}})
+	if err != nil {
+		return err
+	}
+	for _, other := range taken.Items {
+		if other.Id != product.Id {
+			return fmt.Errorf("%w: %s", ErrDuplicateSku, product.Sku)
+		}
+	}
+	return nil
+}
+
+// UpdateProduct edits a product after checking where it sits in the catalogue
+func UpdateProduct(ctx context.Context, store *repositories.Store, id primitive.ObjectID, product models.Product) (models.Product, error) {
+	var updated models.Product
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		product.Id = id
+		if err := resolveCatalogue(ctx, store, &product); err != nil {
+			return err
+		}
+		var err error
+		updated, err = store.Products.Update(ctx, id, product)
+		return err
+	})
+	return updated, err
+}
+
+// CreateVariant adds a variant to the family of productId. The variant takes
+// the model, description, category and brand of the product the family
+// starts from and brings its own SKU, attributes, price, cost and stock.
+func CreateVariant(ctx context.Context, store *repositories.Store, productId primitive.ObjectID, variant models.Variant, userId primitive.ObjectID) (models.Product, error) {
+	root, err := variantRoot(ctx, store, productId)
+	if err != nil {
+		return root, err
+	}
+	imageURL := variant.ImageURL
+	if imageURL == "" {
+		imageURL = root.ImageURL
+	}
+	newProduct := models.Product{
+		Id:            primitive.NewObjectID(),
+		Model:         root.Model,
+		Price:         variant.Price,
+		Cost:          variant.Cost,
+		Description:   root.Description,
+		Category:      root.Category,
+		ImageURL:      imageURL,
+		Stock:         variant.Stock,
+		Brand:         root.Brand,
+		Reorder_point: variant.Reorder_point,
+		Category_id:   root.Category_id,
+		Brand_id:      root.Brand_id,
+		Variant_of:    root.Id,
+		Sku:           variant.Sku,
+		Attributes:    variant.Attributes,
+		Serialized:    root.Serialized,
+		Warranty_days: root.Warranty_days,
+	}
+	err = CreateProduct(ctx, store, &newProduct, userId)
+	return newProduct, err
+}
+
+// ProductVariants lists the family of productId, starting with the product
+// the others are variants of
+func ProductVariants(ctx context.Context, store *repositories.Store, productId primitive.ObjectID) ([]models.Product, error) {
+	root, err := variantRoot(ctx, store, productId)
+	if err != nil {
+		return nil, err
+	}
+	family := []models.Product{root}
+	query := repositories.ListQuery{Filters: []repositories.Filter{{Key: "variant_of", Op: repositories.OpEq, Value: root.Id}}}
+	err = store.Products.Stream(ctx, query, func(product models.Product) error {
+		family = append(family, product)
+		return nil
+	})
+	return family, err
+}
+
+func variantRoot(ctx context.Context, store *repositories.Store, productId primitive.ObjectID) (models.Product, error) {
+	product, err := store.Products.FindByID(ctx, productId)
+	if err != nil || product.Variant_of.IsZero() {
+		return product, err
+	}
+	return store.Products.FindByID(ctx, product.Variant_of)
+}
+
+// ProductQuery widens a category_id filter of query to the whole subtree of
+// that category and adds a filter for every variant attribute asked for
+func ProductQuery(ctx context.Context, store *repositories.Store, query repositories.ListQuery, attributes map[string]string) (repositories.ListQuery, error) {
+	var categories []models.Category
+	for i, filter := range query.Filters {
+		if filter.Key != "category_id" || filter.Op != repositories.OpEq {
+			continue
+		}
+		if categories == nil {
+			var err error
+			if categories, err = store.Categories.FindAll(ctx); err != nil {
+				return query, err
+			}
+		}
+		var subtree []interface{}
+		for _, id := range categorySubtree(categories, filter.Value.(primitive.ObjectID)) {
+			subtree = append(subtree, id)
+		}
+		query.Filters[i] = repositories.Filter{Key: filter.Key, Op: repositories.OpIn, Value: subtree}
+	}
+	for name, value := range attributes {
+		if !attributeName.MatchString(name) {
+			return query, fmt.Errorf("%w: unknown attribute %q", repositories.ErrInvalidQuery, name)
+		}
+		query.Filters = append(query.Filters, repositories.Filter{Key: "attributes." + name, Op: repositories.OpEq, Value: value})
+	}
+	return query, nil
+}
+
+// ProductFacets counts every product matching query by category, counting
+// products towards every category above theirs too, by brand and by the
+// values of every variant attribute
+func ProductFacets(ctx context.Context, store *repositories.Store, query repositories.ListQuery) (models.ProductFacets, error) {
+	facets := models.ProductFacets{Categories: []models.CategoryCount{}, Brands: []models.BrandCount{}, Attributes: map[string][]models.AttributeCount{}}
+
+	categories, err := store.Categories.FindAll(ctx)
+	if err != nil {
+		return facets, err
+	}
+	brands, err := store.Brands.FindAll(ctx)
+	if err != nil {
+		return facets, err
+	}
+	parentOf := map[primitive.ObjectID]primitive.ObjectID{}
+	for _, category := range categories {
+		parentOf[category.Id] = category.Parent_id
+	}
+
+	categoryCounts := map[primitive.ObjectID]int{}
+	brandCounts := map[primitive.ObjectID]int{}
+	attributeCounts := map[string]map[string]int{}
+	err = store.Products.Stream(ctx, query, func(product models.Product) error {
+		// the depth bound stops at parent loops left behind by concurrent moves
+		for id, depth := product.Category_id, 0; !id.IsZero() && depth <= len(categories); id, depth = parentOf[id], depth+1 {
+			categoryCounts[id]++
+		}
+		if !product.Brand_id.IsZero() {
+			brandCounts[product.Brand_id]++
+		}
+		for name, value := range product.Attributes {
+			if attributeCounts[name] == nil {
+				attributeCounts[name] = map[string]int{}
+			}
+			attributeCounts[name][value]++
+		}
+		return nil
+	})
+	if err != nil {
+		return facets, err
+	}
+
+	for _, category := range categories {
+		if count := categoryCounts[category.Id]; count > 0 {
+			facets.Categories = append(facets.Categories, models.CategoryCount{Id: category.Id, Name: category.Name, Parent_id: category.Parent_id, Count: count})
+		}
+	}
+	sort.Slice(facets.Categories, func(i, j int) bool { return facets.Categories[i].Name < facets.Categories[j].Name })
+	for _, brand := range brands {
+		if count := brandCounts[brand.Id]; count > 0 {
+			facets.Brands = append(facets.Brands, models.BrandCount{Id: brand.Id, Name: brand.Name, Count: count})
+		}
+	}
+	sort.Slice(facets.Brands, func(i, j int) bool { return facets.Brands[i].Name < facets.Brands[j].Name })
+	for name, values := range attributeCounts {
+		counts := []models.AttributeCount{}
+		for value, count := range values {
+			counts = append(counts, models.AttributeCount{Value: value, Count: count})
+		}
+		sort.Slice(counts, func(i, j int) bool {
+			if counts[i].Count != counts[j].Count {
+				return counts[i].Count > counts[j].Count
+			}
+			return counts[i].Value < counts[j].Value
+		})
+		facets.Attributes[name] = counts
+	}
+	return facets, nil
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (f *fixture) addCategory(t *testing.T, name string, parent models.Category) models.Category {
+	t.Helper()
+	category, err := CreateCategory(f.ctx, f.store, models.Category{Name: name, Parent_id: parent.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return category
+}
+
+func (f *fixture) addBrand(t *testing.T, name string) models.Brand {
+	t.Helper()
+	brand := models.Brand{Id: primitive.NewObjectID(), Name: name}
+	if err := f.store.Brands.Create(f.ctx, &brand); err != nil {
+		t.Fatal(err)
+	}
+	return brand
+}
+
+func (f *fixture) addCatalogued(t *testing.T, product models.Product) models.Product {
+	t.Helper()
+	product.Id = primitive.NewObjectID()
+	if err := CreateProduct(f.ctx, f.store, &product, primitive.NewObjectID()); err != nil {
+		t.Fatal(err)
+	}
+	return product
+}
+
+func productSkus(t *testing.T, f *fixture, query repositories.ListQuery) []string {
+	t.Helper()
+	query.Sort = []repositories.SortField{{Key: "sku"}}
+	page, err := f.store.Products.List(f.ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skus := []string{}
+	for _, product := range page.Items {
+		skus = append(skus, product.Sku)
+	}
+	return skus
+}
+
+func TestCategoryTree(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phones := f.addCategory(t, "Phones", models.Category{})
+	smart := f.addCategory(t, "Smartphones", phones)
+	android := f.addCategory(t, "Android", smart)
+
+	if _, err := CreateCategory(f.ctx, f.store, models.Category{Name: "Lost", Parent_id: primitive.NewObjectID()}); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("creating under a missing parent = %v, want ErrNotFound", err)
+	}
+	for _, parent := range []models.Category{smart, android} {
+		if _, err := UpdateCategory(f.ctx, f.store, smart.Id, models.Category{Name: "Smartphones", Parent_id: parent.Id}); !errors.Is(err, ErrInvalidCatalogue) {
+			t.Errorf("moving Smartphones under %s = %v, want ErrInvalidCatalogue", parent.Name, err)
+		}
+	}
+	if parent, _ := f.store.Categories.FindByID(f.ctx, smart.Id); parent.Parent_id != phones.Id {
+		t.Errorf("refused move left Smartphones under %s", parent.Parent_id.Hex())
+	}
+
+	product := f.addCatalogued(t, models.Product{Model: "A15", Price: 100, Category_id: android.Id})
+	if product.Category != "Android" {
+		t.Errorf("product category = %q, want the name of its category", product.Category)
+	}
+	tablets := f.addCategory(t, "Tablets", models.Category{})
+	moved, err := UpdateCategory(f.ctx, f.store, android.Id, models.Category{Name: "Android tablets", Parent_id: tablets.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.Parent_id != tablets.Id {
+		t.Errorf("moved parent = %s, want Tablets", moved.Parent_id.Hex())
+	}
+	if stored, _ := f.store.Products.FindByID(f.ctx, product.Id); stored.Category != "Android tablets" {
+		t.Errorf("product category after rename = %q", stored.Category)
+	}
+}
+
+func TestDeleteCatalogueInUse(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phones := f.addCategory(t, "Phones", models.Category{})
+	smart := f.addCategory(t, "Smartphones", phones)
+	samsung := f.addBrand(t, "Samsung")
+	product := f.addCatalogued(t, models.Product{Model: "A15", Price: 100, Category_id: smart.Id, Brand_id: samsung.Id})
+
+	if err := DeleteCategory(f.ctx, f.store, phones.Id); !errors.Is(err, ErrCatalogueInUse) {
+		t.Errorf("deleting a category with subcategories = %v, want ErrCatalogueInUse", err)
+	}
+	if err := DeleteCategory(f.ctx, f.store, smart.Id); !errors.Is(err, ErrCatalogueInUse) {
+		t.Errorf("deleting a category with products = %v, want ErrCatalogueInUse", err)
+	}
+	if err := DeleteBrand(f.ctx, f.store, samsung.Id); !errors.Is(err, ErrCatalogueInUse) {
+		t.Errorf("deleting a brand with products = %v, want ErrCatalogueInUse", err)
+	}
+
+	if err := f.store.Products.Delete(f.ctx, product.Id); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range []error{
+		DeleteCategory(f.ctx, f.store, smart.Id),
+		DeleteCategory(f.ctx, f.store, phones.Id),
+		DeleteBrand(f.ctx, f.store, samsung.Id),
+	} {
+		if err != nil {
+			t.Errorf("deleting an unused entry = %v", err)
+		}
+	}
+	if err := DeleteBrand(f.ctx, f.store, samsung.Id); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("deleting a deleted brand = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCreateVariant(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phones := f.addCategory(t, "Phones", models.Category{})
+	samsung := f.addBrand(t, "Samsung")
+	root := f.addCatalogued(t, models.Product{Model: "A15", Price: 100, Description: "6.5 inch", Category_id: phones.Id, Brand_id: samsung.Id, Sku: "A15-BLK", Attributes: map[string]string{"colour": "black"}, ImageURL: "/a15.png", Serialized: true, Warranty_days: 365})
+
+	blue, err := CreateVariant(f.ctx, f.store, root.Id, models.Variant{Sku: " A15-BLU ", Attributes: map[string]string{"colour": "blue"}, Price: 110, Cost: 80}, primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a variant of a variant joins the family of the root
+	green, err := CreateVariant(f.ctx, f.store, blue.Id, models.Variant{Sku: "A15-GRN", Attributes: map[string]string{"colour": "green"}, Price: 120, Cost: 85, ImageURL: "/green.png"}, primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := models.Product{Id: blue.Id, Model: "A15", Price: 110, Cost: 80, Description: "6.5 inch", Category: "Phones", ImageURL: "/a15.png", Category_id: phones.Id, Brand_id: samsung.Id, Variant_of: root.Id, Sku: "A15-BLU", Attributes: map[string]string{"colour": "blue"}, Serialized: true, Warranty_days: 365}
+	stored, err := f.store.Products.FindByID(f.ctx, blue.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored.Seller_id = primitive.NilObjectID
+	if !reflect.DeepEqual(stored, want) {
+		t.Errorf("variant = %+v, want %+v", stored, want)
+	}
+	if green.Variant_of != root.Id || green.ImageURL != "/green.png" {
+		t.Errorf("variant of a variant = %s with image %q, want the root and its own image", green.Variant_of.Hex(), green.ImageURL)
+	}
+
+	family, err := ProductVariants(f.ctx, f.store, green.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var skus []string
+	for _, product := range family {
+		skus = append(skus, product.Sku)
+	}
+	if len(skus) != 3 || skus[0] != "A15-BLK" {
+		t.Errorf("family = %v, want the root first and both variants", skus)
+	}
+
+	if _, err := CreateVariant(f.ctx, f.store, root.Id, models.Variant{Sku: "X", Attributes: map[string]string{"screen size": "6"}, Price: 1, Cost: 1}, primitive.NewObjectID()); !errors.Is(err, ErrInvalidCatalogue) {
+		t.Errorf("attribute with a space = %v, want ErrInvalidCatalogue", err)
+	}
+	if _, err := CreateVariant(f.ctx, f.store, primitive.NewObjectID(), models.Variant{Sku: "Y", Attributes: map[string]string{"colour": "red"}, Price: 1, Cost: 1}, primitive.NewObjectID()); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("variant of a missing product = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDuplicateSku(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	black := f.addCatalogued(t, models.Product{Model: "A15", Price: 100, Sku: "A15-BLK"})
+	blue := f.addCatalogued(t, models.Product{Model: "A15", Price: 100, Sku: "A15-BLU"})
+
+	if _, err := CreateVariant(f.ctx, f.store, black.Id, models.Variant{Sku: "A15-BLU", Attributes: map[string]string{"colour": "blue"}, Price: 1, Cost: 1}, primitive.NewObjectID()); !errors.Is(err, ErrDuplicateSku) {
+		t.Errorf("variant with a taken SKU = %v, want ErrDuplicateSku", err)
+	}
+	batch := []*models.Product{
+		{Id: primitive.NewObjectID(), Model: "A25", Price: 100, Sku: "A25"},
+		{Id: primitive.NewObjectID(), Model: "A25", Price: 100, Sku: "A25"},
+	}
+	if err := CreateProducts(f.ctx, f.store, batch, primitive.NewObjectID()); !errors.Is(err, ErrDuplicateSku) {
+		t.Errorf("batch repeating a SKU = %v, want ErrDuplicateSku", err)
+	}
+	if _, err := f.store.Products.FindByID(f.ctx, batch[0].Id); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("refused batch stored its first product: %v", err)
+	}
+
+	blue.Sku = "A15-BLK"
+	if _, err := UpdateProduct(f.ctx, f.store, blue.Id, blue); !errors.Is(err, ErrDuplicateSku) {
+		t.Errorf("updating to a taken SKU = %v, want ErrDuplicateSku", err)
+	}
+	// a product keeps its own SKU through an update
+	black.Price = 90
+	if _, err := UpdateProduct(f.ctx, f.store, black.Id, black); err != nil {
+		t.Errorf("updating a product keeping its SKU = %v", err)
+	}
+}
+
+func TestProductQuery(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phones := f.addCategory(t, "Phones", models.Category{})
+	smart := f.addCategory(t, "Smartphones", phones)
+	android := f.addCategory(t, "Android", smart)
+	tablets := f.addCategory(t, "Tablets", models.Category{})
+	f.addCatalogued(t, models.Product{Model: "Nokia 105", Price: 20, Sku: "1", Category_id: phones.Id, Attributes: map[string]string{"colour": "black"}})
+	f.addCatalogued(t, models.Product{Model: "A15", Price: 100, Sku: "2", Category_id: android.Id, Attributes: map[string]string{"colour": "black", "storage": "128"}})
+	f.addCatalogued(t, models.Product{Model: "A15", Price: 120, Sku: "3", Category_id: android.Id, Attributes: map[string]string{"colour": "blue", "storage": "256"}})
+	f.addCatalogued(t, models.Product{Model: "Tab A9", Price: 150, Sku: "4", Category_id: tablets.Id, Attributes: map[string]string{"colour": "black"}})
+
+	tests := []struct {
+		name       string
+		category   models.Category
+		attributes map[string]string
+		want       []string
+	}{
+		{name: "top category with its subtree", category: phones, want: []string{"1", "2", "3"}},
+		{name: "middle category", category: smart, want: []string{"2", "3"}},
+		{name: "leaf category", category: android, want: []string{"2", "3"}},
+		{name: "attribute", attributes: map[string]string{"colour": "black"}, want: []string{"1", "2", "4"}},
+		{name: "category and attributes", category: phones, attributes: map[string]string{"colour": "black", "storage": "128"}, want: []string{"2"}},
+		{name: "no match", category: tablets, attributes: map[string]string{"colour": "blue"}, want: []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := repositories.ListQuery{}
+			if !test.category.Id.IsZero() {
+				query.Filters = []repositories.Filter{{Key: "category_id", Op: repositories.OpEq, Value: test.category.Id}}
+			}
+			query, err := ProductQuery(f.ctx, f.store, query, test.attributes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := productSkus(t, f, query); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("products = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if _, err := ProductQuery(f.ctx, f.store, repositories.ListQuery{}, map[string]string{"colour.$ne": "x"}); !errors.Is(err, repositories.ErrInvalidQuery) {
+		t.Errorf("malformed attribute = %v, want ErrInvalidQuery", err)
+	}
+}
+
+func TestProductFacets(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phones := f.addCategory(t, "Phones", models.Category{})
+	android := f.addCategory(t, "Android", phones)
+	tablets := f.addCategory(t, "Tablets", models.Category{})
+	f.addCategory(t, "Empty", models.Category{})
+	samsung := f.addBrand(t, "Samsung")
+	nokia := f.addBrand(t, "Nokia")
+	f.addBrand(t, "Unused")
+	f.addCatalogued(t, models.Product{Model: "Nokia 105", Price: 20, Category_id: phones.Id, Brand_id: nokia.Id, Attributes: map[string]string{"colour": "black"}})
+	f.addCatalogued(t, models.Product{Model: "A15", Price: 100, Category_id: android.Id, Brand_id: samsung.Id, Attributes: map[string]string{"colour": "blue", "storage": "128"}})
+	f.addCatalogued(t, models.Product{Model: "A15", Price: 120, Category_id: android.Id, Brand_id: samsung.Id, Attributes: map[string]string{"colour": "black", "storage": "256"}})
+	f.addCatalogued(t, models.Product{Model: "Tab A9", Price: 150, Category_id: tablets.Id, Brand_id: samsung.Id})
+	f.addProduct(t, "Charger", 5, 3, 10)
+
+	facets, err := ProductFacets(f.ctx, f.store, repositories.ListQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.ProductFacets{
+		Categories: []models.CategoryCount{
+			{Id: android.Id, Name: "Android", Parent_id: phones.Id, Count: 2},
+			{Id: phones.Id, Name: "Phones", Count: 3},
+			{Id: tablets.Id, Name: "Tablets", Count: 1},
+		},
+		Brands: []models.BrandCount{
+			{Id: nokia.Id, Name: "Nokia", Count: 1},
+			{Id: samsung.Id, Name: "Samsung", Count: 3},
+		},
+		Attributes: map[string][]models.AttributeCount{
+			"colour":  {{Value: "black", Count: 2}, {Value: "blue", Count: 1}},
+			"storage": {{Value: "128", Count: 1}, {Value: "256", Count: 1}},
+		},
+	}
+	if !reflect.DeepEqual(facets, want) {
+		t.Errorf("facets = %+v, want %+v", facets, want)
+	}
+
+	query, err := ProductQuery(f.ctx, f.store, repositories.ListQuery{}, map[string]string{"colour": "black"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	facets, err = ProductFacets(f.ctx, f.store, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(facets.Brands) != 2 || facets.Brands[1].Count != 1 || len(facets.Categories) != 2 || facets.Categories[1].Count != 2 {
+		t.Errorf("facets of black products = %+v", facets)
+	}
+}
@@ -0,0 +1,631 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidReport is returned for report parameters that cannot be honoured
+var ErrInvalidReport = errors.New("invalid report")
+
+// Groupings and periods of the profit report
+const (
+	ProfitByProduct  = "product"
+	ProfitByCategory = "category"
+	ProfitByBrand    = "brand"
+	ProfitByPeriod   = "period"
+)
+
+// ProfitFilter chooses the sales of the profit report and how they are grouped
+type ProfitFilter struct {
+	From     time.Time
+	To       time.Time
+	Group_by string
+	// Interval is the length of a period: day, week, month or year
+	Interval string
+}
+
+// costLayer is a quantity of a product brought in at one unit cost
+type costLayer struct {
+	quantity int
+	unitCost float64
+}
+
+// productCost is the stock of a product as the costing method sees it: one
+// layer at the weighted average, or the layers in the order they came in
+type productCost struct {
+	fifo     bool
+	layers   []costLayer
+	lastCost float64
+}
+
+func (p *productCost) onHand() (int, float64) {
+	var quantity int
+	var value float64
+	for _, layer := range p.layers {
+		quantity += layer.quantity
+		value += float64(layer.quantity) * layer.unitCost
+	}
+	return quantity, value
+}
+
+// unitCost is what a unit on hand is worth, or the last known cost once none is left
+func (p *productCost) unitCost() float64 {
+	if quantity, value := p.onHand(); quantity > 0 {
+		return value / float64(quantity)
+	}
+	return p.lastCost
+}
+
+func (p *productCost) receive(quantity int, unitCost float64) {
+	p.lastCost = unitCost
+	if p.fifo || len(p.layers) == 0 {
+		p.layers = append(p.layers, costLayer{quantity, unitCost})
+		return
+	}
+	onHand, value := p.onHand()
+	onHand += quantity
+	value += float64(quantity) * unitCost
+	p.layers = []costLayer{{onHand, value / float64(onHand)}}
+}
+
+// issue takes quantity out of stock and returns what it cost. Units the
+// journal never brought in are costed at the last known cost.
+func (p *productCost) issue(quantity int) float64 {
+	var cost float64
+	for quantity > 0 && len(p.layers) > 0 {
+		layer := &p.layers[0]
+		take := layer.quantity
+		if take > quantity {
+			take = quantity
+		}
+		cost += float64(take) * layer.unitCost
+		p.lastCost = layer.unitCost
+		layer.quantity -= take
+		quantity -= take
+		if layer.quantity == 0 {
+			p.layers = p.layers[1:]
+		}
+	}
+	return cost + float64(quantity)*p.lastCost
+}
+
+// ReturnCostingDays is how long after a sale checkpoints remember what it
+// took out, so that its returns come back at that cost. Later returns come
+// back at the cost of the units on hand.
+const ReturnCostingDays = 90
+
+// saleCost is what the units of one product taken out by one sale on date
+// cost and how many of them came back
+type saleCost struct {
+	quantity int
+	cost     float64
+	returned int
+	date     time.Time
+}
+
+func (s saleCost) unitCost() float64 {
+	if s.quantity == 0 {
+		return 0
+	}
+	return s.cost / float64(s.quantity)
+}
+
+type saleKey struct {
+	sellId    primitive.ObjectID
+	productId primitive.ObjectID
+}
+
+// costing is the journal of an organization replayed by its costing method
+type costing struct {
+	method   string
+	products map[primitive.ObjectID]models.Product
+	stock    map[primitive.ObjectID]*productCost
+	sales    map[saleKey]saleCost
+	// brands names the brands products point at, loaded for grouping by brand
+	brands map[primitive.ObjectID]string
+}
+
+// CostingMethod is the costing method of the organization ctx is scoped to
+func CostingMethod(ctx context.Context, store *repositories.Store) (string, error) {
+	organizationId, ok := repositories.OrganizationFrom(ctx)
+	if !ok {
+		return models.CostingAverage, nil
+	}
+	organization, err := store.Organizations.FindByID(ctx, organizationId)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return models.CostingAverage, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if organization.Costing_method == models.CostingFIFO {
+		return models.CostingFIFO, nil
+	}
+	return models.CostingAverage, nil
+}
+
+// newCosting starts replaying the journal of the organization ctx is scoped
+// to by its costing method
+func newCosting(ctx context.Context, store *repositories.Store) (*costing, error) {
+	method, err := CostingMethod(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return &costing{
+		method:   method,
+		products: map[primitive.ObjectID]models.Product{},
+		stock:    map[primitive.ObjectID]*productCost{},
+		sales:    map[saleKey]saleCost{},
+	}, nil
+}
+
+// replayCosts values the stock movements of every product dated up to until
+func replayCosts(ctx context.Context, store *repositories.Store, until time.Time) (*costing, error) {
+	c, err := newCosting(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	products, err := store.Products.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		c.products[product.Id] = product
+	}
+	for _, product := range products {
+		if err := c.replay(ctx, store, product.Id, until, until); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// replay values the stock movements of a product dated up to until, starting
+// from its latest checkpoint up to from
+func (c *costing) replay(ctx context.Context, store *repositories.Store, productId primitive.ObjectID, from time.Time, until time.Time) error {
+	stock := c.productCost(productId)
+	var start time.Time
+	checkpoint, err := store.Checkpoints.FindBefore(ctx, productId, c.method, from)
+	switch {
+	case err == nil:
+		start = checkpoint.Date.Time()
+		c.restore(stock, checkpoint)
+	case !errors.Is(err, repositories.ErrNotFound):
+		return err
+	}
+
+	movements, err := store.Movements.FindByProduct(ctx, productId, start, until)
+	if err != nil {
+		return err
+	}
+	for _, movement := range movements {
+		c.apply(stock, movement)
+	}
+	return nil
+}
+
+// apply values one stock movement. Units come in at the cost their movement
+// carries; returns at what the sale took them out for; anything else at the
+// cost of the units on hand, or the catalogue cost of the product when there
+// are none.
+func (c *costing) apply(stock *productCost, movement models.StockMovement) {
+	key := saleKey{movement.Reference_id, movement.Product_id}
+	if movement.Quantity < 0 {
+		cost := stock.issue(-movement.Quantity)
+		if movement.Type == models.MovementSale {
+			sale, ok := c.sales[key]
+			if !ok {
+				sale.date = movement.Date.Time()
+			}
+			sale.quantity -= movement.Quantity
+			sale.cost += cost
+			c.sales[key] = sale
+		}
+		return
+	}
+	unitCost := movement.Unit_cost
+	if sale, ok := c.sales[key]; ok && movement.Type == models.MovementReturn {
+		sale.returned += movement.Quantity
+		c.sales[key] = sale
+		if unitCost == 0 {
+			unitCost = sale.unitCost()
+		}
+	}
+	if unitCost == 0 {
+		unitCost = stock.unitCost()
+	}
+	stock.receive(movement.Quantity, unitCost)
+}
+
+// checkpoint records stock and the sales of productId still open to returns
+// as of date
+func (c *costing) checkpoint(productId primitive.ObjectID, stock *productCost, date time.Time) *models.CostCheckpoint {
+	checkpoint := &models.CostCheckpoint{
+		Id:         primitive.NewObjectID(),
+		Product_id: productId,
+		Method:     c.method,
+		Date:       primitive.NewDateTimeFromTime(date),
+		Layers:     []models.CostLayer{},
+		Last_cost:  stock.lastCost,
+		Sales:      []models.SaleCost{},
+	}
+	for _, layer := range stock.layers {
+		if layer.quantity > 0 {
+			checkpoint.Layers = append(checkpoint.Layers, models.CostLayer{Quantity: layer.quantity, Unit_cost: layer.unitCost})
+		}
+	}
+	returnable := date.AddDate(0, 0, -ReturnCostingDays)
+	for key, sale := range c.sales {
+		if key.productId == productId && sale.returned < sale.quantity && !sale.date.Before(returnable) {
+			checkpoint.Sales = append(checkpoint.Sales, models.SaleCost{
+				Sell_id:  key.sellId,
+				Quantity: sale.quantity,
+				Returned: sale.returned,
+				Cost:     sale.cost,
+				Date:     primitive.NewDateTimeFromTime(sale.date),
+			})
+		}
+	}
+	sort.Slice(checkpoint.Sales, func(i, j int) bool { return checkpoint.Sales[i].Date < checkpoint.Sales[j].Date })
+	return checkpoint
+}
+
+// restore picks the replay of a product up where checkpoint left it
+func (c *costing) restore(stock *productCost, checkpoint models.CostCheckpoint) {
+	stock.layers = nil
+	for _, layer := range checkpoint.Layers {
+		stock.layers = append(stock.layers, costLayer{layer.Quantity, layer.Unit_cost})
+	}
+	stock.lastCost = checkpoint.Last_cost
+	for _, sale := range checkpoint.Sales {
+		c.sales[saleKey{sale.Sell_id, checkpoint.Product_id}] = saleCost{sale.Quantity, sale.Cost, sale.Returned, sale.Date.Time()}
+	}
+}
+
+// CheckpointCosts brings the costing checkpoints of the products of the
+// organization ctx is scoped to up to the start of the month of now. Every
+// month that ended and saw movements since the latest checkpoint of a
+// product gets one, saved in one transaction per product.
+func CheckpointCosts(ctx context.Context, store *repositories.Store, now time.Time) error {
+	method, err := CostingMethod(ctx, store)
+	if err != nil {
+		return err
+	}
+	products, err := store.Products.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	closed := monthStart(now)
+	for _, product := range products {
+		err := store.WithTransaction(ctx, func(ctx context.Context) error {
+			return checkpointProduct(ctx, store, method, product, closed)
+		})
+		if err != nil {
+			return fmt.Errorf("product %s: %w", product.Id.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// checkpointProduct replays the movements of product made since its latest
+// checkpoint and before closed, saving a checkpoint at the start of every
+// month that follows one with movements
+func checkpointProduct(ctx context.Context, store *repositories.Store, method string, product models.Product, closed time.Time) error {
+	c := &costing{
+		method:   method,
+		products: map[primitive.ObjectID]models.Product{product.Id: product},
+		stock:    map[primitive.ObjectID]*productCost{},
+		sales:    map[saleKey]saleCost{},
+	}
+	stock := c.productCost(product.Id)
+	var start time.Time
+	latest, err := store.Checkpoints.FindBefore(ctx, product.Id, method, closed)
+	switch {
+	case err == nil:
+		start = latest.Date.Time()
+		c.restore(stock, latest)
+	case !errors.Is(err, repositories.ErrNotFound):
+		return err
+	}
+	if !start.Before(closed) {
+		return nil
+	}
+
+	// a checkpoint covers the movements dated before it
+	movements, err := store.Movements.FindByProduct(ctx, product.Id, start, closed.Add(-time.Millisecond))
+	if err != nil {
+		return err
+	}
+	var last time.Time
+	save := func(boundary time.Time) error {
+		if last.IsZero() || !last.Before(boundary) {
+			return nil
+		}
+		last = time.Time{}
+		return store.Checkpoints.Save(ctx, c.checkpoint(product.Id, stock, boundary))
+	}
+	for _, movement := range movements {
+		if err := save(monthStart(movement.Date.Time())); err != nil {
+			return err
+		}
+		c.apply(stock, movement)
+		last = movement.Date.Time()
+	}
+	return save(closed)
+}
+
+// WatchCosts checkpoints the costs of every organization now and then every
+// interval, for as long as the process runs
+func WatchCosts(store *repositories.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		checkpointAllCosts(store)
+		<-ticker.C
+	}
+}
+
+func checkpointAllCosts(store *repositories.Store) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	organizations, err := store.Organizations.FindAll(ctx)
+	if err != nil {
+		log.Println("could not list organizations for cost checkpoints:", err)
+		return
+	}
+	for _, organization := range organizations {
+		orgCtx := repositories.WithOrganization(ctx, organization.Id)
+		if err := CheckpointCosts(orgCtx, store, time.Now()); err != nil {
+			log.Printf("could not checkpoint the costs of %s: %v", organization.Id.Hex(), err)
+		}
+	}
+}
+
+// monthStart is the first instant of the month date falls in
+func monthStart(date time.Time) time.Time {
+	date = date.UTC()
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (c *costing) productCost(productId primitive.ObjectID) *productCost {
+	stock, ok := c.stock[productId]
+	if !ok {
+		stock = &productCost{fifo: c.method == models.CostingFIFO, lastCost: c.products[productId].Cost}
+		c.stock[productId] = stock
+	}
+	return stock
+}
+
+// lineUnitCost is what a unit sold on line cost. Sales made before stock was
+// journaled fall back to the cost snapshotted on the line.
+func (c *costing) lineUnitCost(sell models.SellInfo, productId primitive.ObjectID, snapshot float64) float64 {
+	if sale, ok := c.sales[saleKey{sell.Id, productId}]; ok {
+		return sale.unitCost()
+	}
+	return snapshot
+}
+
+// UnitCost is what a unit of product on hand is worth now by the costing
+// method of the organization ctx is scoped to
+func UnitCost(ctx context.Context, store *repositories.Store, productId primitive.ObjectID) (float64, error) {
+	c, err := newCosting(ctx, store)
+	if err != nil {
+		return 0, err
+	}
+	product, err := store.Products.FindByID(ctx, productId)
+	if err != nil {
+		return 0, fmt.Errorf("product %s: %w", productId.Hex(), err)
+	}
+	c.products[productId] = product
+	now := time.Now()
+	if err := c.replay(ctx, store, productId, now, now); err != nil {
+		return 0, err
+	}
+	return c.productCost(productId).unitCost(), nil
+}
+
+// InventoryValuation values the stock on hand at asOf
+func InventoryValuation(ctx context.Context, store *repositories.Store, asOf time.Time) (models.ValuationReport, error) {
+	report := models.ValuationReport{As_of: asOf, Rows: []models.ValuationRow{}}
+
+	c, err := replayCosts(ctx, store, asOf)
+	if err != nil {
+		return report, err
+	}
+	report.Method = c.method
+	for productId, stock := range c.stock {
+		quantity, value := stock.onHand()
+		if quantity == 0 {
+			continue
+		}
+		product := c.products[productId]
+		report.Rows = append(report.Rows, models.ValuationRow{
+			Product_id: productId,
+			Model:      product.Model,
+			Category:   product.Category,
+			Quantity:   quantity,
+			Unit_cost:  roundCents(value / float64(quantity)),
+			Value:      roundCents(value),
+		})
+		report.Value += value
+	}
+	report.Value = roundCents(report.Value)
+	sort.Slice(report.Rows, func(i, j int) bool { return report.Rows[i].Model < report.Rows[j].Model })
+	return report, nil
+}
+
+// ProfitReport sets the revenue of the sales made between filter.From and
+// filter.To against the cost of the goods sold, less what was returned
+// in that time
+func ProfitReport(ctx context.Context, store *repositories.Store, filter ProfitFilter) (models.ProfitReport, error) {
+	report := models.ProfitReport{From: filter.From, To: filter.To, Group_by: filter.Group_by, Rows: []models.ProfitRow{}}
+
+	switch filter.Group_by {
+	case ProfitByProduct, ProfitByCategory, ProfitByBrand:
+	case ProfitByPeriod:
+		if periodKey(time.Time{}, filter.Interval) == "" {
+			return report, fmt.Errorf("%w: interval must be day, week, month or year", ErrInvalidReport)
+		}
+	default:
+		return report, fmt.Errorf("%w: group_by must be product, category, brand or period", ErrInvalidReport)
+	}
+
+	c, err := newCosting(ctx, store)
+	if err != nil {
+		return report, err
+	}
+	report.Method = c.method
+	products, err := store.Products.FindAll(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, product := range products {
+		c.products[product.Id] = product
+	}
+	if filter.Group_by == ProfitByBrand {
+		brands, err := store.Brands.FindAll(ctx)
+		if err != nil {
+			return report, err
+		}
+		c.brands = map[primitive.ObjectID]string{}
+		for _, brand := range brands {
+			c.brands[brand.Id] = brand.Name
+		}
+	}
+	var sells []models.SellInfo
+	sold := map[primitive.ObjectID]bool{}
+	err = store.Sells.StreamBetween(ctx, filter.From, filter.To, func(sell models.SellInfo) error {
+		sells = append(sells, sell)
+		for _, line := range sell.Lines {
+			sold[line.Product_id] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	// sales before filter.From reversed since are remembered by its checkpoint
+	// for ReturnCostingDays
+	for productId := range sold {
+		if err := c.replay(ctx, store, productId, filter.From, filter.To); err != nil {
+			return report, err
+		}
+	}
+
+	rows := map[string]*models.ProfitRow{}
+	add := func(productId primitive.ObjectID, model string, date time.Time, quantity int, revenue float64, cogs float64) {
+		if date.Before(filter.From) || date.After(filter.To) {
+			return
+		}
+		key, label := c.group(filter, productId, model, date)
+		row, ok := rows[key]
+		if !ok {
+			row = &models.ProfitRow{Key: key, Label: label}
+			rows[key] = row
+		}
+		row.Quantity += quantity
+		row.Revenue += revenue
+		row.Cogs += cogs
+	}
+	for _, sell := range sells {
+		modelOf := map[primitive.ObjectID]string{}
+		snapshotOf := map[primitive.ObjectID]float64{}
+		for _, line := range sell.Lines {
+			modelOf[line.Product_id] = line.Model
+			snapshotOf[line.Product_id] = line.Unit_cost
+			unitCost := c.lineUnitCost(sell, line.Product_id, line.Unit_cost)
+			add(line.Product_id, line.Model, sell.Date.Time(), line.Quantity, line.Line_total, unitCost*float64(line.Quantity))
+		}
+		for _, reversal := range sell.Reversals {
+			for _, line := range reversal.Lines {
+				unitCost := c.lineUnitCost(sell, line.Product_id, snapshotOf[line.Product_id])
+				add(line.Product_id, modelOf[line.Product_id], reversal.Date.Time(), -line.Quantity, -line.Amount, -unitCost*float64(line.Quantity))
+			}
+		}
+	}
+
+	for _, row := range rows {
+		finishProfitRow(row)
+		report.Rows = append(report.Rows, *row)
+		report.Totals.Quantity += row.Quantity
+		report.Totals.Revenue += row.Revenue
+		report.Totals.Cogs += row.Cogs
+	}
+	finishProfitRow(&report.Totals)
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if filter.Group_by == ProfitByPeriod {
+			return report.Rows[i].Key < report.Rows[j].Key
+		}
+		return report.Rows[i].Revenue > report.Rows[j].Revenue
+	})
+	return report, nil
+}
+
+// group is the key and label of the row a sale of productId on date belongs to
+func (c *costing) group(filter ProfitFilter, productId primitive.ObjectID, model string, date time.Time) (string, string) {
+	product, ok := c.products[productId]
+	switch filter.Group_by {
+	case ProfitByCategory:
+		if !ok || product.Category == "" {
+			return "", "uncategorized"
+		}
+		return strings.ToLower(product.Category), product.Category
+	case ProfitByBrand:
+		if name, ok := c.brands[product.Brand_id]; ok {
+			return product.Brand_id.Hex(), name
+		}
+		brand := strconv.Itoa(product.Brand)
+		return brand, brand
+	case ProfitByPeriod:
+		key := periodKey(date, filter.Interval)
+		return key, key
+	}
+	if ok {
+		model = product.Model
+	}
+	return productId.Hex(), model
+}
+
+// periodKey names the period of interval date falls in so that names sort
+// in time order, empty for unknown intervals
+func periodKey(date time.Time, interval string) string {
+	date = date.Local()
+	switch interval {
+	case "day":
+		return date.Format("2006-01-02")
+	case "week":
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "month", "":
+		return date.Format("2006-01")
+	case "year":
+		return date.Format("2006")
+	}
+	return ""
+}
+
+func finishProfitRow(row *models.ProfitRow) {
+	row.Revenue = roundCents(row.Revenue)
+	row.Cogs = roundCents(row.Cogs)
+	row.Gross_margin = roundCents(row.Revenue - row.Cogs)
+	if row.Revenue != 0 {
+		row.Margin_percent = roundCents(row.Gross_margin / row.Revenue * 100)
+	}
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
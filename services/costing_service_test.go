@@ -0,0 +1,228 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReplayCosts(t *testing.T) {
+	sellId := primitive.NewObjectID()
+	journal := []models.StockMovement{
+		{Type: models.MovementPurchase, Quantity: 10, Unit_cost: 100, Date: dateTime(2026, time.January, 1)},
+		{Type: models.MovementPurchase, Quantity: 10, Unit_cost: 200, Date: dateTime(2026, time.January, 2)},
+		{Type: models.MovementSale, Quantity: -15, Reference_id: sellId, Date: dateTime(2026, time.January, 3)},
+		{Type: models.MovementReturn, Quantity: 5, Reference_id: sellId, Date: dateTime(2026, time.January, 4)},
+		{Type: models.MovementPurchase, Quantity: 10, Unit_cost: 300, Date: dateTime(2026, time.January, 5)},
+	}
+	tests := []struct {
+		method string
+		until  time.Time
+		// saleCost is what the 15 units sold cost, onHand what is left in stock
+		saleCost     float64
+		onHand       int
+		onHandValue  float64
+		unitCostLeft float64
+	}{
+		// 10 at 100 and 5 at 200 go out, the return comes back at their average
+		{models.CostingFIFO, date(2026, time.January, 5), 2000, 20, 5*200 + 5*2000.0/15 + 10*300, 5*200/20.0 + 5*2000.0/15/20 + 10*300/20.0},
+		// every unit goes out and comes back at the average of 150
+		{models.CostingAverage, date(2026, time.January, 5), 2250, 20, 10*150 + 10*300, 225},
+		{models.CostingFIFO, date(2026, time.January, 3), 2000, 5, 1000, 200},
+		{models.CostingAverage, date(2026, time.January, 3), 2250, 5, 750, 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" until "+tt.until.Format("Jan 2"), func(t *testing.T) {
+			f := newFixture(t, models.Orgnization{Costing_method: tt.method})
+			product := f.addProduct(t, "Phone", 400, 90, 0)
+			for _, movement := range journal {
+				movement.Id = primitive.NewObjectID()
+				movement.Product_id = product.Id
+				if err := f.store.Movements.Create(f.ctx, &movement); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c, err := replayCosts(f.ctx, f.store, tt.until)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.method != tt.method {
+				t.Errorf("method = %s", c.method)
+			}
+			sale := c.sales[saleKey{sellId, product.Id}]
+			if sale.quantity != 15 || !near(sale.cost, tt.saleCost) {
+				t.Errorf("sale = %d units costing %v, want 15 costing %v", sale.quantity, sale.cost, tt.saleCost)
+			}
+			quantity, value := c.productCost(product.Id).onHand()
+			if quantity != tt.onHand || !near(value, tt.onHandValue) {
+				t.Errorf("on hand = %d worth %v, want %d worth %v", quantity, value, tt.onHand, tt.onHandValue)
+			}
+			if got := c.productCost(product.Id).unitCost(); !near(got, tt.unitCostLeft) {
+				t.Errorf("unit cost = %v, want %v", got, tt.unitCostLeft)
+			}
+		})
+	}
+}
+
+func TestReplayCostsWithoutReceipts(t *testing.T) {
+	f := newFixture(t, models.Orgnization{Costing_method: models.CostingFIFO})
+	product := f.addProduct(t, "Phone", 400, 90, 0)
+	sellId := primitive.NewObjectID()
+	movement := models.StockMovement{Id: primitive.NewObjectID(), Product_id: product.Id, Type: models.MovementSale, Quantity: -2, Reference_id: sellId, Date: dateTime(2026, time.January, 3)}
+	if err := f.store.Movements.Create(f.ctx, &movement); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := replayCosts(f.ctx, f.store, date(2026, time.February, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// units never brought in are costed at the catalogue cost
+	if sale := c.sales[saleKey{sellId, product.Id}]; sale.cost != 180 {
+		t.Errorf("sale cost = %v, want 180", sale.cost)
+	}
+}
+
+// addMovements journals movements of productId
+func (f *fixture) addMovements(t *testing.T, productId primitive.ObjectID, movements ...models.StockMovement) {
+	t.Helper()
+	for _, movement := range movements {
+		movement.Id = primitive.NewObjectID()
+		movement.Product_id = productId
+		if err := f.store.Movements.Create(f.ctx, &movement); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReplayCostsFromCheckpoints(t *testing.T) {
+	f := newFixture(t, models.Orgnization{Costing_method: models.CostingFIFO})
+	product := f.addProduct(t, "Phone", 400, 90, 0)
+	sold, later := primitive.NewObjectID(), primitive.NewObjectID()
+	f.addMovements(t, product.Id,
+		models.StockMovement{Type: models.MovementPurchase, Quantity: 10, Unit_cost: 100, Date: dateTime(2026, time.January, 10)},
+		models.StockMovement{Type: models.MovementSale, Quantity: -4, Reference_id: sold, Date: dateTime(2026, time.January, 20)},
+		models.StockMovement{Type: models.MovementPurchase, Quantity: 10, Unit_cost: 200, Date: dateTime(2026, time.February, 10)},
+		models.StockMovement{Type: models.MovementReturn, Quantity: 2, Reference_id: sold, Date: dateTime(2026, time.February, 15)},
+		models.StockMovement{Type: models.MovementSale, Quantity: -3, Reference_id: later, Date: dateTime(2026, time.March, 5)},
+	)
+
+	// reports only read checkpoints
+	if _, err := replayCosts(f.ctx, f.store, date(2026, time.March, 31)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.store.Checkpoints.FindBefore(f.ctx, product.Id, models.CostingFIFO, date(2026, time.April, 30)); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("a report saved a checkpoint: %v", err)
+	}
+
+	// the months that ended after a movement are checkpointed, with the sales
+	// that can still be returned
+	for i := 0; i < 2; i++ {
+		if err := CheckpointCosts(f.ctx, f.store, date(2026, time.April, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, month := range []time.Month{time.February, time.March, time.April} {
+		checkpoint, err := f.store.Checkpoints.FindBefore(f.ctx, product.Id, models.CostingFIFO, time.Date(2026, month, 1, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := checkpoint.Date.Time(); !got.Equal(time.Date(2026, month, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("checkpoint of %s dated %v", month, got)
+		}
+	}
+	checkpoint, err := f.store.Checkpoints.FindBefore(f.ctx, product.Id, models.CostingFIFO, date(2026, time.April, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLayers := []models.CostLayer{{Quantity: 3, Unit_cost: 100}, {Quantity: 10, Unit_cost: 200}, {Quantity: 2, Unit_cost: 100}}
+	if !reflect.DeepEqual(checkpoint.Layers, wantLayers) {
+		t.Errorf("layers = %+v, want %+v", checkpoint.Layers, wantLayers)
+	}
+	wantSales := []models.SaleCost{
+		{Sell_id: sold, Quantity: 4, Returned: 2, Cost: 400, Date: dateTime(2026, time.January, 20)},
+		{Sell_id: later, Quantity: 3, Cost: 300, Date: dateTime(2026, time.March, 5)},
+	}
+	if !reflect.DeepEqual(checkpoint.Sales, wantSales) {
+		t.Errorf("sales = %+v, want %+v", checkpoint.Sales, wantSales)
+	}
+
+	// the return of a sale made before the report is costed from the checkpoint
+	sell := models.SellInfo{Id: sold, Customer_id: f.customer.Id, Date: dateTime(2026, time.January, 20),
+		Lines:     []models.SellLine{{Product_id: product.Id, Model: "Phone", Quantity: 4, Line_total: 1600, Unit_cost: 90}},
+		Reversals: []models.SellReversal{{Type: models.ReversalReturn, Date: dateTime(2026, time.February, 15), Lines: []models.ReturnLine{{Product_id: product.Id, Quantity: 2, Amount: 800}}}},
+	}
+	if err := f.store.Sells.Create(f.ctx, &sell); err != nil {
+		t.Fatal(err)
+	}
+	report, err := ProfitReport(f.ctx, f.store, ProfitFilter{From: date(2026, time.February, 1), To: date(2026, time.February, 28), Group_by: ProfitByProduct})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Totals.Quantity != -2 || report.Totals.Revenue != -800 || report.Totals.Cogs != -200 {
+		t.Errorf("totals = %+v", report.Totals)
+	}
+
+	// a later replay starts from the latest checkpoint instead of the first
+	// movement, and saving a checkpoint again replaces it
+	checkpoint.Layers = []models.CostLayer{{Quantity: 1, Unit_cost: 999}}
+	if err := f.store.Checkpoints.Save(f.ctx, &checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	if cost, err := UnitCost(f.ctx, f.store, product.Id); err != nil || cost != 999 {
+		t.Errorf("unit cost = %v, %v, want 999 from the checkpoint", cost, err)
+	}
+}
+
+func TestCheckpointsForgetClosedSales(t *testing.T) {
+	f := newFixture(t, models.Orgnization{Costing_method: models.CostingFIFO})
+	product := f.addProduct(t, "Phone", 400, 90, 0)
+	old, returned, recent := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	f.addMovements(t, product.Id,
+		models.StockMovement{Type: models.MovementPurchase, Quantity: 10, Unit_cost: 100, Date: dateTime(2026, time.January, 10)},
+		models.StockMovement{Type: models.MovementSale, Quantity: -4, Reference_id: old, Date: dateTime(2026, time.January, 20)},
+		models.StockMovement{Type: models.MovementSale, Quantity: -1, Reference_id: returned, Date: dateTime(2026, time.May, 15)},
+		models.StockMovement{Type: models.MovementReturn, Quantity: 1, Reference_id: returned, Date: dateTime(2026, time.May, 20)},
+		models.StockMovement{Type: models.MovementSale, Quantity: -1, Reference_id: recent, Date: dateTime(2026, time.June, 1)},
+		models.StockMovement{Type: models.MovementPurchase, Quantity: 5, Unit_cost: 200, Date: dateTime(2026, time.July, 1)},
+	)
+	if err := CheckpointCosts(f.ctx, f.store, date(2026, time.August, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint, err := f.store.Checkpoints.FindBefore(f.ctx, product.Id, models.CostingFIFO, date(2026, time.August, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLayers := []models.CostLayer{{Quantity: 4, Unit_cost: 100}, {Quantity: 1, Unit_cost: 100}, {Quantity: 5, Unit_cost: 200}}
+	if !reflect.DeepEqual(checkpoint.Layers, wantLayers) {
+		t.Errorf("layers = %+v, want %+v", checkpoint.Layers, wantLayers)
+	}
+	// the sale of January is past the return window and the one of May came back
+	wantSales := []models.SaleCost{{Sell_id: recent, Quantity: 1, Cost: 100, Date: dateTime(2026, time.June, 1)}}
+	if !reflect.DeepEqual(checkpoint.Sales, wantSales) {
+		t.Errorf("sales = %+v, want %+v", checkpoint.Sales, wantSales)
+	}
+
+	// a late return comes back at the cost of the units on hand
+	f.addMovements(t, product.Id, models.StockMovement{Type: models.MovementReturn, Quantity: 2, Reference_id: old, Date: dateTime(2026, time.August, 12)})
+	c, err := replayCosts(f.ctx, f.store, date(2026, time.August, 31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	quantity, value := c.productCost(product.Id).onHand()
+	if quantity != 12 || !near(value, 1500+2*150) {
+		t.Errorf("on hand = %d worth %v, want 12 worth %v", quantity, value, 1500+2*150)
+	}
+}
+
+func near(got float64, want float64) bool {
+	return math.Abs(got-want) < 0.001
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrCustomerInUse is returned when deleting a customer ledger entries,
+// installment plans, documents or guarantors still point at
+var ErrCustomerInUse = errors.New("customer is in use")
+
+// DeleteCustomer deletes a customer nothing else refers to, so no balance,
+// plan, document or guarantee is left without its customer
+func DeleteCustomer(ctx context.Context, store *repositories.Store, id primitive.ObjectID) error {
+	return store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.Customers.FindByID(ctx, id); err != nil {
+			return err
+		}
+		byCustomer := repositories.ListQuery{Limit: 1, Filters: []repositories.Filter{{Key: "customer_id", Op: repositories.OpEq, Value: id}}}
+		historys, err := store.Historys.List(ctx, byCustomer)
+		if err != nil {
+			return err
+		}
+		if historys.Total > 0 {
+			return fmt.Errorf("%w: the customer has %d ledger entries", ErrCustomerInUse, historys.Total)
+		}
+		plans, err := store.Plans.FindByCustomer(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(plans) > 0 {
+			return fmt.Errorf("%w: the customer has %d installment plans", ErrCustomerInUse, len(plans))
+		}
+		attachments, err := store.Attachments.List(ctx, byCustomer)
+		if err != nil {
+			return err
+		}
+		if attachments.Total > 0 {
+			return fmt.Errorf("%w: the customer has %d documents", ErrCustomerInUse, attachments.Total)
+		}
+		guarantors, err := store.Guarantors.FindByCustomer(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(guarantors) > 0 {
+			return fmt.Errorf("%w: %d guarantors answer for the customer", ErrCustomerInUse, len(guarantors))
+		}
+		return store.Customers.Delete(ctx, id)
+	})
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"appadming/blobstore"
+	"appadming/models"
+	"appadming/repositories"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDeleteCustomerInUse(t *testing.T) {
+	tests := []struct {
+		name string
+		use  func(t *testing.T, f *fixture, customer models.Customer)
+	}{
+		{
+			name: "ledger entries",
+			use: func(t *testing.T, f *fixture, customer models.Customer) {
+				f.addHistory(t, models.History{Customer_id: customer.Id, Due: 100, Date: dateTime(2024, 1, 1)})
+			},
+		},
+		{
+			name: "installment plan",
+			use: func(t *testing.T, f *fixture, customer models.Customer) {
+				plan := models.InstallmentPlan{Id: primitive.NewObjectID(), Customer_id: customer.Id, Sell_id: primitive.NewObjectID()}
+				if err := f.store.Plans.Create(f.ctx, &plan); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "documents",
+			use: func(t *testing.T, f *fixture, customer models.Customer) {
+				blobs, err := blobstore.NewLocal(t.TempDir())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := AddAttachment(f.ctx, f.store, blobs, models.AttachedToCustomer, customer.Id, models.AttachmentUpload{Document_type: models.DocumentNID}, "nid.pdf", []byte("%PDF-1.4 nid"), primitive.NewObjectID(), time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "guarantor",
+			use: func(t *testing.T, f *fixture, customer models.Customer) {
+				guarantor, err := CreateGuarantor(f.ctx, f.store, models.Guarantor{Name: "Karim", Nid: "1990"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := LinkGuarantor(f.ctx, f.store, customer.Id, guarantor.Id); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, models.Orgnization{})
+			tt.use(t, f, f.customer)
+
+			if err := DeleteCustomer(f.ctx, f.store, f.customer.Id); !errors.Is(err, ErrCustomerInUse) {
+				t.Fatalf("error = %v, want ErrCustomerInUse", err)
+			}
+			if _, err := f.store.Customers.FindByID(f.ctx, f.customer.Id); err != nil {
+				t.Errorf("refused delete removed the customer: %v", err)
+			}
+		})
+	}
+}
+
+func TestDeleteCustomer(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	// what other customers have does not keep this one
+	other := f.addCustomer(t, "Karim", time.Time{})
+	f.addHistory(t, models.History{Customer_id: other.Id, Due: 100, Date: dateTime(2024, 1, 1)})
+
+	if err := DeleteCustomer(f.ctx, f.store, f.customer.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.store.Customers.FindByID(f.ctx, f.customer.Id); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("deleted customer = %v, want ErrNotFound", err)
+	}
+	if err := DeleteCustomer(f.ctx, f.store, f.customer.Id); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("deleting a deleted customer = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SellInvoice gathers the invoice of a sale: its lines, what was paid and what is still due
+func SellInvoice(ctx context.Context, store *repositories.Store, sellId primitive.ObjectID) (models.Document, error) {
+	var invoice models.Document
+
+	sell, err := store.Sells.FindByID(ctx, sellId)
+	if err != nil {
+		return invoice, err
+	}
+	customer, err := store.Customers.FindByID(ctx, sell.Customer_id)
+	if err != nil {
+		return invoice, fmt.Errorf("customer %s: %w", sell.Customer_id.Hex(), err)
+	}
+	organization, err := store.Organizations.FindByID(ctx, sell.Organization_id)
+	if err != nil {
+		return invoice, fmt.Errorf("organization %s: %w", sell.Organization_id.Hex(), err)
+	}
+
+	invoice = models.Document{
+		Title:        "Invoice",
+		Number:       documentNumber(sell.Number, sell.Id),
+		Date:         sell.Date.Time(),
+		Organization: organization,
+		Customer:     customer,
+		Lines:        make([]models.DocumentLine, len(sell.Lines)),
+	}
+	var subtotal, discount float64
+	for i, line := range sell.Lines {
+		invoice.Lines[i] = models.DocumentLine{
+			Description: line.Model,
+			Quantity:    line.Quantity,
+			Unit_price:  line.Unit_price,
+			Discount:    line.Discount,
+			Total:       line.Line_total,
+		}
+		subtotal += line.Unit_price * float64(line.Quantity)
+		discount += line.Discount
+	}
+	if discount > 0 {
+		invoice.Totals = append(invoice.Totals,
+			models.DocumentTotal{Label: "Subtotal", Amount: subtotal},
+			models.DocumentTotal{Label: "Discount", Amount: -discount},
+		)
+	}
+	invoice.Totals = append(invoice.Totals,
+		models.DocumentTotal{Label: "Total", Amount: float64(sell.Amount)},
+		models.DocumentTotal{Label: "Paid", Amount: float64(sell.Paid)},
+	)
+	due := sell.Amount - sell.Paid
+	for _, reversal := range sell.Reversals {
+		label := "Returned"
+		if reversal.Type == models.ReversalVoid {
+			label = "Voided"
+		}
+		invoice.Totals = append(invoice.Totals, models.DocumentTotal{Label: label, Amount: -float64(reversal.Amount)})
+		if reversal.Refund > 0 {
+			invoice.Totals = append(invoice.Totals, models.DocumentTotal{Label: "Refunded", Amount: float64(reversal.Refund)})
+		}
+		due += reversal.Refund - reversal.Amount
+	}
+	invoice.Totals = append(invoice.Totals, models.DocumentTotal{Label: "Due", Amount: float64(due)})
+	if sell.Status != "" && sell.Status != models.SellCompleted {
+		invoice.Note = "Status: " + strings.ReplaceAll(sell.Status, "_", " ")
+	}
+	return invoice, nil
+}
+
+// HistoryReceipt gathers the receipt of a history entry with the customer
+// balance once it was posted. The receipt is issued by the organization ctx
+// is scoped to or else the entry's seller.
+func HistoryReceipt(ctx context.Context, store *repositories.Store, historyId primitive.ObjectID) (models.Document, error) {
+	var receipt models.Document
+
+	entry, err := store.Historys.FindByID(ctx, historyId)
+	if err != nil {
+		return receipt, err
+	}
+	customer, err := store.Customers.FindByID(ctx, entry.Customer_id)
+	if err != nil {
+		return receipt, fmt.Errorf("customer %s: %w", entry.Customer_id.Hex(), err)
+	}
+	organizationId, ok := repositories.OrganizationFrom(ctx)
+	if !ok {
+		organizationId = entry.Seller_id
+	}
+	organization, err := store.Organizations.FindByID(ctx, organizationId)
+	if err != nil {
+		return receipt, fmt.Errorf("organization %s: %w", organizationId.Hex(), err)
+	}
+	balance, err := CustomerBalance(ctx, store, entry.Customer_id, entry.Date.Time())
+	if err != nil {
+		return receipt, err
+	}
+
+	title, charged, received := "Receipt", "Charged", "Received"
+	switch entry.Type {
+	case models.HistoryReturn:
+		title, received = "Credit note", "Credited"
+	case models.HistoryRefund:
+		title, charged = "Refund", "Refunded"
+	}
+	receipt = models.Document{
+		Title:        title,
+		Number:       documentNumber(entry.Number, entry.Id),
+		Date:         entry.Date.Time(),
+		Organization: organization,
+		Customer:     customer,
+		Note:         strings.TrimSpace(entry.Type + " " + entry.Note),
+	}
+	if entry.Due > 0 {
+		receipt.Totals = append(receipt.Totals, models.DocumentTotal{Label: charged, Amount: float64(entry.Due)})
+	}
+	if entry.Paid > 0 || entry.Due == 0 {
+		receipt.Totals = append(receipt.Totals, models.DocumentTotal{Label: received, Amount: float64(entry.Paid)})
+	}
+	receipt.Totals = append(receipt.Totals, models.DocumentTotal{Label: "Balance due", Amount: float64(balance)})
+	return receipt, nil
+}
+
+// documentNumber falls back to the id of documents stored before they were numbered
+func documentNumber(number string, id primitive.ObjectID) string {
+	if number == "" {
+		return id.Hex()
+	}
+	return number
+}
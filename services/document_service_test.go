@@ -0,0 +1,101 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSellInvoice(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	sell := models.SellInfo{
+		Id:          primitive.NewObjectID(),
+		Number:      "INV-0007",
+		Customer_id: f.customer.Id,
+		Date:        dateTime(2026, time.March, 1),
+		Lines: []models.SellLine{
+			{Model: "Phone", Quantity: 2, Unit_price: 1000, Discount: 100, Line_total: 1900},
+			{Model: "Charger", Quantity: 1, Unit_price: 300, Line_total: 300},
+		},
+		Amount: 2200,
+		Paid:   500,
+		Status: models.SellPartiallyReturned,
+		Reversals: []models.SellReversal{
+			{Type: models.ReversalReturn, Amount: 300, Refund: 100},
+		},
+	}
+	if err := f.store.Sells.Create(f.ctx, &sell); err != nil {
+		t.Fatal(err)
+	}
+
+	invoice, err := SellInvoice(f.ctx, f.store, sell.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Title != "Invoice" || invoice.Number != "INV-0007" || invoice.Customer.Name != "Rahima" || invoice.Organization.Name != "Shop" {
+		t.Errorf("invoice = %+v", invoice)
+	}
+	if len(invoice.Lines) != 2 || invoice.Lines[0] != (models.DocumentLine{Description: "Phone", Quantity: 2, Unit_price: 1000, Discount: 100, Total: 1900}) {
+		t.Errorf("lines = %+v", invoice.Lines)
+	}
+	want := []models.DocumentTotal{
+		{Label: "Subtotal", Amount: 2300},
+		{Label: "Discount", Amount: -100},
+		{Label: "Total", Amount: 2200},
+		{Label: "Paid", Amount: 500},
+		{Label: "Returned", Amount: -300},
+		{Label: "Refunded", Amount: 100},
+		{Label: "Due", Amount: 1500},
+	}
+	if !reflect.DeepEqual(invoice.Totals, want) {
+		t.Errorf("totals = %+v, want %+v", invoice.Totals, want)
+	}
+	if invoice.Note != "Status: partially returned" {
+		t.Errorf("note = %q", invoice.Note)
+	}
+
+	other := repositories.WithOrganization(f.ctx, primitive.NewObjectID())
+	if _, err := SellInvoice(other, f.store, sell.Id); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("invoice of another organization: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestHistoryReceipt(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 1000, Date: dateTime(2026, time.March, 1)})
+	payment := f.addHistory(t, models.History{Type: models.HistoryPayment, Number: "RCT-0003", Paid: 300, Note: "cash", Date: dateTime(2026, time.March, 5)})
+	refund := f.addHistory(t, models.History{Type: models.HistoryRefund, Due: 50, Date: dateTime(2026, time.March, 9)})
+	// posted after the receipts and left out of their balance
+	f.addHistory(t, models.History{Type: models.HistoryPayment, Paid: 200, Date: dateTime(2026, time.March, 20)})
+
+	tests := []struct {
+		entry  models.History
+		title  string
+		number string
+		note   string
+		totals []models.DocumentTotal
+	}{
+		{payment, "Receipt", "RCT-0003", "payment cash", []models.DocumentTotal{{Label: "Received", Amount: 300}, {Label: "Balance due", Amount: 700}}},
+		// entries stored before numbering print their id
+		{refund, "Refund", refund.Id.Hex(), "refund", []models.DocumentTotal{{Label: "Refunded", Amount: 50}, {Label: "Balance due", Amount: 750}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			receipt, err := HistoryReceipt(f.ctx, f.store, tt.entry.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if receipt.Title != tt.title || receipt.Number != tt.number || receipt.Note != tt.note {
+				t.Errorf("receipt = %q %q %q, want %q %q %q", receipt.Title, receipt.Number, receipt.Note, tt.title, tt.number, tt.note)
+			}
+			if !reflect.DeepEqual(receipt.Totals, tt.totals) {
+				t.Errorf("totals = %+v, want %+v", receipt.Totals, tt.totals)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fixture is an organization in a memory store with one customer
+type fixture struct {
+	ctx          context.Context
+	store        *repositories.Store
+	organization models.Orgnization
+	customer     models.Customer
+}
+
+func newFixture(t *testing.T, organization models.Orgnization) *fixture {
+	t.Helper()
+	store := repositories.NewMemoryStore()
+	organization.Id = primitive.NewObjectID()
+	organization.Name = "Shop"
+	ctx := repositories.WithOrganization(context.Background(), organization.Id)
+	if err := store.Organizations.Create(ctx, &organization); err != nil {
+		t.Fatal(err)
+	}
+	f := &fixture{ctx: ctx, store: store, organization: organization}
+	f.customer = f.addCustomer(t, "Rahima", time.Time{})
+	return f
+}
+
+func (f *fixture) addCustomer(t *testing.T, name string, dueDate time.Time) models.Customer {
+	t.Helper()
+	customer := models.Customer{Id: primitive.NewObjectID(), Name: name, District: "Dhaka", Thana: "Savar", Phone: 171}
+	if !dueDate.IsZero() {
+		customer.DueDate = primitive.NewDateTimeFromTime(dueDate)
+	}
+	if err := f.store.Customers.Create(f.ctx, &customer); err != nil {
+		t.Fatal(err)
+	}
+	return customer
+}
+
+func (f *fixture) addProduct(t *testing.T, model string, price float64, cost float64, stock int) models.Product {
+	t.Helper()
+	product := models.Product{Id: primitive.NewObjectID(), Model: model, Price: price, Cost: cost, Stock: stock}
+	if err := f.store.Products.Create(f.ctx, &product); err != nil {
+		t.Fatal(err)
+	}
+	return product
+}
+
+func (f *fixture) addHistory(t *testing.T, entry models.History) models.History {
+	t.Helper()
+	entry.Id = primitive.NewObjectID()
+	if entry.Customer_id.IsZero() {
+		entry.Customer_id = f.customer.Id
+	}
+	if err := f.store.Historys.Create(f.ctx, &entry); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 12, 0, 0, 0, time.Local)
+}
+
+func dateTime(year int, month time.Month, day int) primitive.DateTime {
+	return primitive.NewDateTimeFromTime(date(year, month, day))
+}
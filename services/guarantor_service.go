@@ -0,0 +1,383 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrDuplicateGuarantor is returned when a national ID already belongs to another guarantor
+var ErrDuplicateGuarantor = errors.New("guarantor nid is already taken")
+
+// ErrGuarantorInUse is returned when deleting a guarantor customers or sales still point at
+var ErrGuarantorInUse = errors.New("guarantor is in use")
+
+// CreateGuarantor records a guarantor, one per national ID
+func CreateGuarantor(ctx context.Context, store *repositories.Store, guarantor models.Guarantor) (models.Guarantor, error) {
+	guarantor.Nid = strings.TrimSpace(guarantor.Nid)
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := checkNid(ctx, store, guarantor); err != nil {
+			return err
+		}
+		guarantor.Id = primitive.NewObjectID()
+		guarantor.Customer_ids = []primitive.ObjectID{}
+		guarantor.Created_at = time.Now()
+		return store.Guarantors.Create(ctx, &guarantor)
+	})
+	return guarantor, err
+}
+
+// UpdateGuarantor changes the personal details of a guarantor
+func UpdateGuarantor(ctx context.Context, store *repositories.Store, id primitive.ObjectID, guarantor models.Guarantor) (models.Guarantor, error) {
+	var updated models.Guarantor
+	guarantor.Id = id
+	guarantor.Nid = strings.TrimSpace(guarantor.Nid)
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.Guarantors.FindByID(ctx, id); err != nil {
+			return err
+		}
+		if err := checkNid(ctx, store, guarantor); err != nil {
+			return err
+		}
+		var err error
+		updated, err = store.Guarantors.Update(ctx, id, guarantor)
+		return err
+	})
+	return updated, err
+}
+
+func checkNid(ctx context.Context, store *repositories.Store, guarantor models.Guarantor) error {
+	other, err := store.Guarantors.FindByNid(ctx, guarantor.Nid)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if other.Id != guarantor.Id {
+		return fmt.Errorf("%w: %s", ErrDuplicateGuarantor, guarantor.Nid)
+	}
+	return nil
+}
+
+// DeleteGuarantor deletes a guarantor who answers for no customer or sale
+func DeleteGuarantor(ctx context.Context, store *repositories.Store, id primitive.ObjectID) error {
+	return store.WithTransaction(ctx, func(ctx context.Context) error {
+		guarantor, err := store.Guarantors.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(guarantor.Customer_ids) > 0 {
+			return fmt.Errorf("%w: the guarantor answers for %d customers", ErrGuarantorInUse, len(guarantor.Customer_ids))
+		}
+		sells, err := store.Sells.List(ctx, repositories.ListQuery{Limit: 1, Filters: []repositories.Filter{{Key: "guarantor_id", Op: repositories.OpEq, Value: id}}})
+		if err != nil {
+			return err
+		}
+		if sells.Total > 0 {
+			return fmt.Errorf("%w: the guarantor answers for %d sales", ErrGuarantorInUse, sells.Total)
+		}
+		return store.Guarantors.Delete(ctx, id)
+	})
+}
+
+// LinkGuarantor makes the guarantor answer for everything the customer owes
+func LinkGuarantor(ctx context.Context, store *repositories.Store, customerId primitive.ObjectID, guarantorId primitive.ObjectID) (models.Guarantor, error) {
+	var guarantor models.Guarantor
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.Customers.FindByID(ctx, customerId); err != nil {
+			return fmt.Errorf("customer %s: %w", customerId.Hex(), err)
+		}
+		var err error
+		if guarantor, err = store.Guarantors.FindByID(ctx, guarantorId); err != nil {
+			return fmt.Errorf("guarantor %s: %w", guarantorId.Hex(), err)
+		}
+		for _, id := range guarantor.Customer_ids {
+			if id == customerId {
+				return nil
+			}
+		}
+		guarantor, err = store.Guarantors.SetCustomers(ctx, guarantorId, append(guarantor.Customer_ids, customerId))
+		return err
+	})
+	return guarantor, err
+}
+
+// UnlinkGuarantor stops the guarantor answering for the customer. Sales the
+// guarantor was named on keep their guarantor.
+func UnlinkGuarantor(ctx context.Context, store *repositories.Store, customerId primitive.ObjectID, guarantorId primitive.ObjectID) (models.Guarantor, error) {
+	var guarantor models.Guarantor
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if guarantor, err = store.Guarantors.FindByID(ctx, guarantorId); err != nil {
+			return err
+		}
+		customerIds := []primitive.ObjectID{}
+		for _, id := range guarantor.Customer_ids {
+			if id != customerId {
+				customerIds = append(customerIds, id)
+			}
+		}
+		if len(customerIds) == len(guarantor.Customer_ids) {
+			return fmt.Errorf("customer %s of guarantor %s: %w", customerId.Hex(), guarantorId.Hex(), repositories.ErrNotFound)
+		}
+		guarantor, err = store.Guarantors.SetCustomers(ctx, guarantorId, customerIds)
+		return err
+	})
+	return guarantor, err
+}
+
+// CustomerGuarantors returns the guarantors of the customer's whole balance
+func CustomerGuarantors(ctx context.Context, store *repositories.Store, customerId primitive.ObjectID) ([]models.Guarantor, error) {
+	if _, err := store.Customers.FindByID(ctx, customerId); err != nil {
+		return nil, err
+	}
+	guarantors, err := store.Guarantors.FindByCustomer(ctx, customerId)
+	if guarantors == nil {
+		guarantors = []models.Guarantor{}
+	}
+	return guarantors, err
+}
+
+// SetSellGuarantor names the guarantor of a sale, replacing any before
+func SetSellGuarantor(ctx context.Context, store *repositories.Store, sellId primitive.ObjectID, guarantorId primitive.ObjectID) (models.SellInfo, error) {
+	var sell models.SellInfo
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.Sells.FindByID(ctx, sellId); err != nil {
+			return err
+		}
+		if _, err := store.Guarantors.FindByID(ctx, guarantorId); err != nil {
+			return fmt.Errorf("guarantor %s: %w", guarantorId.Hex(), err)
+		}
+		var err error
+		sell, err = store.Sells.SetGuarantor(ctx, sellId, guarantorId)
+		return err
+	})
+	return sell, err
+}
+
+// checkGuarantor refuses credit above the organization's guarantor threshold
+// unless the sale or the customer has a guarantor
+func checkGuarantor(ctx context.Context, store *repositories.Store, organization models.Orgnization, sell models.SellInfo, credit int) error {
+	if organization.Guarantor_threshold == 0 || credit <= organization.Guarantor_threshold || !sell.Guarantor_id.IsZero() {
+		return nil
+	}
+	guarantors, err := store.Guarantors.FindByCustomer(ctx, sell.Customer_id)
+	if err != nil {
+		return err
+	}
+	if len(guarantors) == 0 {
+		return fmt.Errorf("%w: credit of %d exceeds %d and needs a guarantor", ErrInvalidSell, credit, organization.Guarantor_threshold)
+	}
+	return nil
+}
+
+// exposures computes guarantor exposures, loading every customer and ledger
+// once however many guarantors share them
+type exposures struct {
+	store     *repositories.Store
+	asOf      time.Time
+	customers map[primitive.ObjectID]models.Customer
+	ledgers   map[primitive.ObjectID]ledger
+}
+
+// ledger is what a customer owes in all and on each of their sales
+type ledger struct {
+	balance int
+	sells   map[primitive.ObjectID]int
+}
+
+func newExposures(store *repositories.Store, asOf time.Time) *exposures {
+	return &exposures{store: store, asOf: asOf, customers: map[primitive.ObjectID]models.Customer{}, ledgers: map[primitive.ObjectID]ledger{}}
+}
+
+func (e *exposures) customer(ctx context.Context, id primitive.ObjectID) (models.Customer, ledger, error) {
+	if customer, ok := e.customers[id]; ok {
+		return customer, e.ledgers[id], nil
+	}
+	customer, err := e.store.Customers.FindByID(ctx, id)
+	if err != nil {
+		return customer, ledger{}, fmt.Errorf("customer %s: %w", id.Hex(), err)
+	}
+	historys, err := e.store.Historys.FindByCustomer(ctx, id, e.asOf)
+	if err != nil {
+		return customer, ledger{}, err
+	}
+	e.customers[id] = customer
+	e.ledgers[id] = ledger{balance: sumEntries(historys), sells: sellBalances(historys)}
+	return customer, e.ledgers[id], nil
+}
+
+func sumEntries(historys []models.History) int {
+	balance := 0
+	for _, entry := range historys {
+		balance += EntryAmount(entry)
+	}
+	return balance
+}
+
+// sellBalances is what is left to pay of each sale. Entries naming a sale
+// settle that sale first; payments on account and any surplus settle the
+// oldest debts, sales or otherwise, first.
+func sellBalances(historys []models.History) map[primitive.ObjectID]int {
+	var debits []receivable
+	var owners []primitive.ObjectID
+	credited := map[primitive.ObjectID]int{}
+	credit := 0
+	for _, entry := range historys {
+		if voided(entry) {
+			continue
+		}
+		if entry.Due > 0 {
+			debits = append(debits, receivable{entry.Date.Time(), entry.Due})
+			owners = append(owners, entry.Sell_id)
+		}
+		if entry.Sell_id.IsZero() {
+			credit += entry.Paid
+		} else {
+			credited[entry.Sell_id] += entry.Paid
+		}
+	}
+
+	open := make([]int, len(debits))
+	for i, debit := range debits {
+		open[i] = debit.amount
+		if owners[i].IsZero() {
+			continue
+		}
+		settled := credited[owners[i]]
+		if settled > open[i] {
+			settled = open[i]
+		}
+		open[i] -= settled
+		credited[owners[i]] -= settled
+	}
+	for _, surplus := range credited {
+		credit += surplus
+	}
+
+	balances := map[primitive.ObjectID]int{}
+	for i := range debits {
+		settled := credit
+		if settled > open[i] {
+			settled = open[i]
+		}
+		open[i] -= settled
+		credit -= settled
+		if !owners[i].IsZero() {
+			balances[owners[i]] += open[i]
+		}
+	}
+	return balances
+}
+
+// exposure is everything guarantor answers for. Sales of customers the
+// guarantor already answers for whole are listed but not counted twice.
+func (e *exposures) exposure(ctx context.Context, guarantor models.Guarantor) (models.GuarantorExposure, error) {
+	exposure := models.GuarantorExposure{
+		Guarantor_id: guarantor.Id,
+		Name:         guarantor.Name,
+		Phone:        guarantor.Phone,
+		Nid:          guarantor.Nid,
+		Customers:    []models.GuaranteedCustomer{},
+		Sells:        []models.GuaranteedSell{},
+	}
+
+	covered := map[primitive.ObjectID]bool{}
+	for _, customerId := range guarantor.Customer_ids {
+		customer, ledger, err := e.customer(ctx, customerId)
+		if errors.Is(err, repositories.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return exposure, err
+		}
+		covered[customerId] = true
+		balance := ledger.balance
+		if balance < 0 {
+			balance = 0
+		}
+		exposure.Customers = append(exposure.Customers, models.GuaranteedCustomer{Customer_id: customerId, Name: customer.Name, Balance: balance})
+		exposure.Total += balance
+	}
+
+	var sells []models.SellInfo
+	query := repositories.ListQuery{Filters: []repositories.Filter{{Key: "guarantor_id", Op: repositories.OpEq, Value: guarantor.Id}}}
+	if err := e.store.Sells.Stream(ctx, query, func(sell models.SellInfo) error {
+		sells = append(sells, sell)
+		return nil
+	}); err != nil {
+		return exposure, err
+	}
+	sort.SliceStable(sells, func(i, j int) bool { return sells[i].Date < sells[j].Date })
+	for _, sell := range sells {
+		if sell.Date.Time().After(e.asOf) {
+			continue
+		}
+		customer, ledger, err := e.customer(ctx, sell.Customer_id)
+		if errors.Is(err, repositories.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return exposure, err
+		}
+		guaranteed := models.GuaranteedSell{
+			Sell_id:     sell.Id,
+			Number:      sell.Number,
+			Customer_id: sell.Customer_id,
+			Name:        customer.Name,
+			Amount:      sell.Amount,
+			Balance:     ledger.sells[sell.Id],
+			Covered:     covered[sell.Customer_id],
+		}
+		exposure.Sells = append(exposure.Sells, guaranteed)
+		if !guaranteed.Covered {
+			exposure.Total += guaranteed.Balance
+		}
+	}
+	return exposure, nil
+}
+
+// GuarantorExposure is what the guarantor answers for as of asOf: the whole
+// balance of the customers they guarantee and what is left to pay of the
+// sales they are named on
+func GuarantorExposure(ctx context.Context, store *repositories.Store, id primitive.ObjectID, asOf time.Time) (models.GuarantorExposure, error) {
+	guarantor, err := store.Guarantors.FindByID(ctx, id)
+	if err != nil {
+		return models.GuarantorExposure{}, err
+	}
+	return newExposures(store, asOf).exposure(ctx, guarantor)
+}
+
+// GuarantorExposureReport lists the exposure of every guarantor of the
+// organization ctx is scoped to, largest first
+func GuarantorExposureReport(ctx context.Context, store *repositories.Store, asOf time.Time) (models.ExposureReport, error) {
+	report := models.ExposureReport{Rows: []models.GuarantorExposure{}}
+	guarantors, err := store.Guarantors.FindAll(ctx)
+	if err != nil {
+		return report, err
+	}
+	e := newExposures(store, asOf)
+	for _, guarantor := range guarantors {
+		exposure, err := e.exposure(ctx, guarantor)
+		if err != nil {
+			return report, err
+		}
+		report.Rows = append(report.Rows, exposure)
+		report.Total += exposure.Total
+	}
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Total != report.Rows[j].Total {
+			return report.Rows[i].Total > report.Rows[j].Total
+		}
+		return report.Rows[i].Name < report.Rows[j].Name
+	})
+	return report, nil
+}
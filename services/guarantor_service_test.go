@@ -0,0 +1,168 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (f *fixture) addGuarantor(t *testing.T, name string, nid string, customers ...models.Customer) models.Guarantor {
+	t.Helper()
+	guarantor, err := CreateGuarantor(f.ctx, f.store, models.Guarantor{Name: name, Nid: nid, Phone: 181})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, customer := range customers {
+		if guarantor, err = LinkGuarantor(f.ctx, f.store, customer.Id, guarantor.Id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return guarantor
+}
+
+// addGuaranteedSell stores a sale of customer named on guarantor with the
+// entry it posts
+func (f *fixture) addGuaranteedSell(t *testing.T, customer models.Customer, guarantor models.Guarantor, number string, amount int, paid int, on primitive.DateTime) models.SellInfo {
+	t.Helper()
+	sell := models.SellInfo{Id: primitive.NewObjectID(), Number: number, Customer_id: customer.Id, Amount: amount, Paid: paid, Date: on, Status: models.SellCompleted, Guarantor_id: guarantor.Id}
+	if err := f.store.Sells.Create(f.ctx, &sell); err != nil {
+		t.Fatal(err)
+	}
+	f.addHistory(t, models.History{Customer_id: customer.Id, Sell_id: sell.Id, Due: amount, Paid: paid, Date: on, Type: models.HistorySale})
+	return sell
+}
+
+// exposureFixture has Rahima guaranteed whole by Karim, who is also named on
+// a sale of Babul, and Salam overpaid and guaranteed by Abul
+type exposureFixture struct {
+	*fixture
+	karim, abul          models.Guarantor
+	babul, salam         models.Customer
+	babulSell, rahimaNew models.SellInfo
+}
+
+func newExposureFixture(t *testing.T) *exposureFixture {
+	f := &exposureFixture{fixture: newFixture(t, models.Orgnization{})}
+	f.babul = f.addCustomer(t, "Babul", time.Time{})
+	f.salam = f.addCustomer(t, "Salam", time.Time{})
+	f.karim = f.addGuarantor(t, "Karim", "1001", f.customer)
+	f.abul = f.addGuarantor(t, "Abul", "1002", f.salam)
+	f.addGuarantor(t, "Zahid", "1003")
+
+	// Rahima owes 1000 - 200 - 300 by March, and 500 more on a sale Karim is also named on
+	f.addHistory(t, models.History{Sell_id: primitive.NewObjectID(), Due: 1000, Paid: 200, Date: dateTime(2024, 1, 1), Type: models.HistorySale})
+	f.addHistory(t, models.History{Paid: 300, Date: dateTime(2024, 2, 1), Type: models.HistoryPayment})
+	f.rahimaNew = f.addGuaranteedSell(t, f.customer, f.karim, "INV-3", 500, 0, dateTime(2024, 3, 1))
+
+	// Babul's payment on account settles their older debt before the guaranteed sale
+	f.addHistory(t, models.History{Customer_id: f.babul.Id, Due: 200, Date: dateTime(2024, 1, 5), Type: models.HistoryAdjustment})
+	f.babulSell = f.addGuaranteedSell(t, f.babul, f.karim, "INV-2", 800, 100, dateTime(2024, 1, 10))
+	f.addHistory(t, models.History{Customer_id: f.babul.Id, Paid: 400, Date: dateTime(2024, 2, 10), Type: models.HistoryPayment})
+	// a payment taken back settles nothing
+	payment := f.addHistory(t, models.History{Customer_id: f.babul.Id, Paid: 1000, Date: dateTime(2024, 2, 20), Type: models.HistoryPayment})
+	reversal := f.addHistory(t, models.History{Customer_id: f.babul.Id, Due: 1000, Date: dateTime(2024, 2, 21), Type: models.HistoryVoid, Reverses: payment.Id})
+	payment.Voided_by = reversal.Id
+	if _, err := f.store.Historys.Update(f.ctx, payment.Id, payment); err != nil {
+		t.Fatal(err)
+	}
+	// sales without a guarantor count only through a guaranteed customer
+	f.addHistory(t, models.History{Customer_id: f.babul.Id, Sell_id: primitive.NewObjectID(), Due: 300, Date: dateTime(2024, 3, 5), Type: models.HistorySale})
+
+	f.addHistory(t, models.History{Customer_id: f.salam.Id, Due: 100, Paid: 250, Date: dateTime(2024, 1, 1), Type: models.HistorySale})
+	return f
+}
+
+func TestGuarantorExposure(t *testing.T) {
+	f := newExposureFixture(t)
+	tests := []struct {
+		name string
+		asOf time.Time
+		want models.GuarantorExposure
+	}{
+		{
+			name: "every entry",
+			asOf: date(2024, 12, 31),
+			want: models.GuarantorExposure{
+				Customers: []models.GuaranteedCustomer{{Customer_id: f.customer.Id, Name: "Rahima", Balance: 1000}},
+				Sells: []models.GuaranteedSell{
+					{Sell_id: f.babulSell.Id, Number: "INV-2", Customer_id: f.babul.Id, Name: "Babul", Amount: 800, Balance: 500},
+					{Sell_id: f.rahimaNew.Id, Number: "INV-3", Customer_id: f.customer.Id, Name: "Rahima", Amount: 500, Balance: 500, Covered: true},
+				},
+				Total: 1500,
+			},
+		},
+		{
+			name: "as of mid February",
+			asOf: date(2024, 2, 15),
+			want: models.GuarantorExposure{
+				Customers: []models.GuaranteedCustomer{{Customer_id: f.customer.Id, Name: "Rahima", Balance: 500}},
+				Sells: []models.GuaranteedSell{
+					{Sell_id: f.babulSell.Id, Number: "INV-2", Customer_id: f.babul.Id, Name: "Babul", Amount: 800, Balance: 500},
+				},
+				Total: 1000,
+			},
+		},
+		{
+			name: "before the guaranteed sale",
+			asOf: date(2024, 1, 7),
+			want: models.GuarantorExposure{
+				Customers: []models.GuaranteedCustomer{{Customer_id: f.customer.Id, Name: "Rahima", Balance: 800}},
+				Sells:     []models.GuaranteedSell{},
+				Total:     800,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exposure, err := GuarantorExposure(f.ctx, f.store, f.karim.Id, tt.asOf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.Guarantor_id, tt.want.Name, tt.want.Phone, tt.want.Nid = f.karim.Id, "Karim", 181, "1001"
+			if !reflect.DeepEqual(exposure, tt.want) {
+				t.Errorf("exposure = %+v, want %+v", exposure, tt.want)
+			}
+		})
+	}
+
+	exposure, err := GuarantorExposure(f.ctx, f.store, f.abul.Id, date(2024, 12, 31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exposure.Total != 0 || len(exposure.Customers) != 1 || exposure.Customers[0].Balance != 0 {
+		t.Errorf("exposure for an overpaid customer = %+v, want nothing owed", exposure)
+	}
+	if _, err := GuarantorExposure(f.ctx, f.store, primitive.NewObjectID(), date(2024, 12, 31)); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("exposure of a missing guarantor = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGuarantorExposureReport(t *testing.T) {
+	f := newExposureFixture(t)
+	report, err := GuarantorExposureReport(f.ctx, f.store, date(2024, 12, 31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for _, row := range report.Rows {
+		rows = append(rows, row.Name)
+	}
+	// guarantors owing nothing come by name after the largest
+	if want := []string{"Karim", "Abul", "Zahid"}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+	if report.Total != 1500 || report.Rows[0].Total != 1500 {
+		t.Errorf("report total = %d with Karim at %d, want 1500", report.Total, report.Rows[0].Total)
+	}
+
+	other := repositories.WithOrganization(context.Background(), primitive.NewObjectID())
+	if report, err := GuarantorExposureReport(other, f.store, date(2024, 12, 31)); err != nil || len(report.Rows) != 0 {
+		t.Errorf("report of another organization = %+v, %v, want no rows", report, err)
+	}
+}
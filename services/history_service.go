@@ -0,0 +1,180 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidHistory is returned for entries that cannot be posted by hand
+var ErrInvalidHistory = errors.New("invalid history entry")
+
+// ErrHistoryLocked is returned when changing or deleting an entry the ledger
+// and the installment plans depend on
+var ErrHistoryLocked = errors.New("history entry cannot be changed")
+
+// CreateHistory records a payment, adjustment or write-off under the next
+// receipt number and allocates it to open installments in one transaction.
+// The customer, and the sale the entry is posted on, must be the
+// organization's and the sale the customer's; adjustments are not posted on
+// sales, those belong to installment plans. The receipt is numbered by the
+// organization ctx is scoped to or else the entry's seller.
+func CreateHistory(ctx context.Context, store *repositories.Store, entry *models.History) error {
+	switch entry.Type {
+	case models.HistoryPayment, models.HistoryWriteOff:
+	case models.HistoryAdjustment:
+		if !entry.Sell_id.IsZero() {
+			return fmt.Errorf("%w: adjustments are not posted on a sale", ErrInvalidHistory)
+		}
+	default:
+		return fmt.Errorf("%w: type must be payment, adjustment or writeoff", ErrInvalidHistory)
+	}
+	organizationId, ok := repositories.OrganizationFrom(ctx)
+	if !ok {
+		organizationId = entry.Seller_id
+	}
+	return store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.Customers.FindByID(ctx, entry.Customer_id); err != nil {
+			return fmt.Errorf("customer %s: %w", entry.Customer_id.Hex(), err)
+		}
+		if !entry.Sell_id.IsZero() {
+			sell, err := store.Sells.FindByID(ctx, entry.Sell_id)
+			if err != nil {
+				return fmt.Errorf("sell %s: %w", entry.Sell_id.Hex(), err)
+			}
+			if sell.Customer_id != entry.Customer_id {
+				return fmt.Errorf("%w: sell %s is not the customer's", ErrInvalidHistory, entry.Sell_id.Hex())
+			}
+		}
+		number, err := NextNumber(ctx, store, organizationId, models.SequenceReceipt, time.Now())
+		if err != nil {
+			return err
+		}
+		entry.Number = number
+		if err := store.Historys.Create(ctx, entry); err != nil {
+			return err
+		}
+		return AllocatePayment(ctx, store, *entry)
+	})
+}
+
+// EditHistory changes the note of an entry. What an entry moves, when, for
+// whom and on which sale stays as recorded: a wrong entry is voided and
+// posted again.
+func EditHistory(ctx context.Context, store *repositories.Store, id primitive.ObjectID, history models.History) (models.History, error) {
+	var updated models.History
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := store.Historys.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if history.Type != "" && history.Type != current.Type || history.Due != current.Due || history.Paid != current.Paid ||
+			history.Date != current.Date || history.Customer_id != current.Customer_id ||
+			!history.Sell_id.IsZero() && history.Sell_id != current.Sell_id {
+			return fmt.Errorf("%w: only the note can change, void the entry and post it again instead", ErrHistoryLocked)
+		}
+		current.Note = history.Note
+		updated, err = store.Historys.Update(ctx, id, current)
+		return err
+	})
+	return updated, err
+}
+
+// DeleteHistory deletes an unnumbered manual adjustment or write-off. Sales,
+// returns, refunds, payments and anything issued a receipt stay in the ledger
+// and are taken back by voiding.
+func DeleteHistory(ctx context.Context, store *repositories.Store, id primitive.ObjectID) error {
+	return store.WithTransaction(ctx, func(ctx context.Context) error {
+		entry, err := store.Historys.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkVoidable(entry); err != nil {
+			return err
+		}
+		if entry.Type == models.HistoryPayment {
+			return fmt.Errorf("%w: payments are voided, not deleted", ErrHistoryLocked)
+		}
+		if entry.Number != "" {
+			return fmt.Errorf("%w: receipt %s is voided, not deleted", ErrHistoryLocked, entry.Number)
+		}
+		return store.Historys.Delete(ctx, id)
+	})
+}
+
+// VoidHistory takes back a payment, adjustment or write-off with an entry
+// moving the balance the other way under the next receipt number, then
+// allocates the payments the customer still has over their installment
+// plans again
+func VoidHistory(ctx context.Context, store *repositories.Store, id primitive.ObjectID, reason string) (models.History, error) {
+	var void models.History
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		entry, err := store.Historys.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkVoidable(entry); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		number, err := NextNumber(ctx, store, entry.Seller_id, models.SequenceReceipt, now)
+		if err != nil {
+			return err
+		}
+		note := "void of " + documentNumber(entry.Number, entry.Id)
+		if reason = strings.TrimSpace(reason); reason != "" {
+			note += ": " + reason
+		}
+		void = models.History{
+			Id:          primitive.NewObjectID(),
+			Number:      number,
+			Type:        models.HistoryVoid,
+			Due:         entry.Paid,
+			Paid:        entry.Due,
+			Date:        primitive.NewDateTimeFromTime(now),
+			Customer_id: entry.Customer_id,
+			Seller_id:   entry.Seller_id,
+			Sell_id:     entry.Sell_id,
+			Note:        note,
+			Reverses:    entry.Id,
+		}
+		if err := store.Historys.Create(ctx, &void); err != nil {
+			return err
+		}
+		entry.Voided_by = void.Id
+		if _, err := store.Historys.Update(ctx, entry.Id, entry); err != nil {
+			return err
+		}
+		if entry.Type != models.HistoryPayment {
+			return nil
+		}
+		return reallocatePlans(ctx, store, entry.Customer_id, now)
+	})
+	return void, err
+}
+
+// checkVoidable refuses entries only their sale or plan may take back
+func checkVoidable(entry models.History) error {
+	switch {
+	case voided(entry):
+		return fmt.Errorf("%w: the entry is voided or voids another", ErrHistoryLocked)
+	case entry.Type == models.HistorySale || entry.Type == models.HistoryReturn || entry.Type == models.HistoryRefund:
+		return fmt.Errorf("%w: %s entries are taken back by returning or voiding the sale", ErrHistoryLocked, entry.Type)
+	case entry.Type == models.HistoryAdjustment && !entry.Sell_id.IsZero():
+		return fmt.Errorf("%w: the adjustment belongs to the installment plan of its sale", ErrHistoryLocked)
+	}
+	return nil
+}
+
+// voided reports whether entry was voided or is the void of another; the two
+// cancel out and neither is owed or settles anything
+func voided(entry models.History) bool {
+	return !entry.Voided_by.IsZero() || !entry.Reverses.IsZero()
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVoidHistoryAllocatesPaymentsAgain(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	plan := models.InstallmentPlan{Id: primitive.NewObjectID(), Sell_id: primitive.NewObjectID(), Customer_id: f.customer.Id, Created_at: date(2026, time.January, 1),
+		Schedule: buildSchedule(300, 3, models.PlanMonthly, date(2100, time.February, 1))}
+	if err := f.store.Plans.Create(f.ctx, &plan); err != nil {
+		t.Fatal(err)
+	}
+	var payments []models.History
+	for _, paid := range []int{150, 100} {
+		payment := models.History{Id: primitive.NewObjectID(), Type: models.HistoryPayment, Paid: paid, Date: dateTime(2026, time.February, 1), Customer_id: f.customer.Id}
+		if err := CreateHistory(f.ctx, f.store, &payment); err != nil {
+			t.Fatal(err)
+		}
+		payments = append(payments, payment)
+	}
+
+	void, err := VoidHistory(f.ctx, f.store, payments[0].Id, "bounced")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if void.Type != models.HistoryVoid || void.Due != 150 || void.Reverses != payments[0].Id || void.Number == payments[0].Number {
+		t.Errorf("void = %+v", void)
+	}
+	stored, err := f.store.Plans.FindBySell(f.ctx, plan.Sell_id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{100, 0, 0} {
+		if stored.Schedule[i].Paid != want {
+			t.Errorf("installment %d paid %d, want %d", i+1, stored.Schedule[i].Paid, want)
+		}
+	}
+	if balance, _ := CustomerBalance(f.ctx, f.store, f.customer.Id, time.Now()); balance != -100 {
+		t.Errorf("balance = %d, want -100", balance)
+	}
+	if _, err := VoidHistory(f.ctx, f.store, payments[0].Id, "again"); !errors.Is(err, ErrHistoryLocked) {
+		t.Errorf("voiding twice: %v", err)
+	}
+	if _, err := VoidHistory(f.ctx, f.store, void.Id, "undo"); !errors.Is(err, ErrHistoryLocked) {
+		t.Errorf("voiding a void: %v", err)
+	}
+}
+
+func TestHistoryIsLocked(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	sale := f.addHistory(t, models.History{Type: models.HistorySale, Due: 500, Date: dateTime(2026, time.January, 1), Sell_id: primitive.NewObjectID()})
+	payment := f.addHistory(t, models.History{Type: models.HistoryPayment, Number: "RCPT-2026-000001", Paid: 100, Date: dateTime(2026, time.January, 2)})
+	adjustment := f.addHistory(t, models.History{Type: models.HistoryAdjustment, Due: 20, Date: dateTime(2026, time.January, 3)})
+	receipted := f.addHistory(t, models.History{Type: models.HistoryAdjustment, Number: "RCPT-2026-000002", Due: 30, Date: dateTime(2026, time.January, 4)})
+
+	tests := []struct {
+		name    string
+		change  func() error
+		wantErr error
+	}{
+		{"edit the amount of a payment", func() error {
+			changed := payment
+			changed.Paid = 90
+			_, err := EditHistory(f.ctx, f.store, payment.Id, changed)
+			return err
+		}, ErrHistoryLocked},
+		{"move a payment to another customer", func() error {
+			changed := payment
+			changed.Customer_id = primitive.NewObjectID()
+			_, err := EditHistory(f.ctx, f.store, payment.Id, changed)
+			return err
+		}, ErrHistoryLocked},
+		{"edit the note of a payment", func() error {
+			changed := payment
+			changed.Note = "paid by bKash"
+			_, err := EditHistory(f.ctx, f.store, payment.Id, changed)
+			return err
+		}, nil},
+		{"delete a payment", func() error { return DeleteHistory(f.ctx, f.store, payment.Id) }, ErrHistoryLocked},
+		{"delete a sale entry", func() error { return DeleteHistory(f.ctx, f.store, sale.Id) }, ErrHistoryLocked},
+		{"void a sale entry", func() error {
+			_, err := VoidHistory(f.ctx, f.store, sale.Id, "mistake")
+			return err
+		}, ErrHistoryLocked},
+		{"delete a receipted adjustment", func() error { return DeleteHistory(f.ctx, f.store, receipted.Id) }, ErrHistoryLocked},
+		{"delete a manual adjustment", func() error { return DeleteHistory(f.ctx, f.store, adjustment.Id) }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	stored, err := f.store.Historys.FindByID(f.ctx, payment.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Paid != 100 || stored.Note != "paid by bKash" {
+		t.Errorf("payment = %+v", stored)
+	}
+}
+
+func TestCreateHistoryChecksTheEntry(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phone := f.addProduct(t, "Phone", 1000, 700, 5)
+	sold, _, err := CreateSell(f.ctx, f.store, models.SellInfo{Customer_id: f.customer.Id, Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 1}}}, primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	neighbour := f.addCustomer(t, "Karim", time.Time{})
+	stranger := models.Customer{Id: primitive.NewObjectID(), Name: "Selim"}
+	if err := f.store.Customers.Create(repositories.WithOrganization(f.ctx, primitive.NewObjectID()), &stranger); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		entry   models.History
+		wantErr error
+	}{
+		{"a sale by hand", models.History{Type: models.HistorySale, Due: 100, Customer_id: f.customer.Id}, ErrInvalidHistory},
+		{"a void by hand", models.History{Type: models.HistoryVoid, Due: 100, Customer_id: f.customer.Id}, ErrInvalidHistory},
+		{"an adjustment on a sale", models.History{Type: models.HistoryAdjustment, Due: 100, Customer_id: f.customer.Id, Sell_id: sold.Id}, ErrInvalidHistory},
+		{"an unknown customer", models.History{Type: models.HistoryPayment, Paid: 100, Customer_id: primitive.NewObjectID()}, repositories.ErrNotFound},
+		{"a customer of another organization", models.History{Type: models.HistoryPayment, Paid: 100, Customer_id: stranger.Id}, repositories.ErrNotFound},
+		{"an unknown sale", models.History{Type: models.HistoryPayment, Paid: 100, Customer_id: f.customer.Id, Sell_id: primitive.NewObjectID()}, repositories.ErrNotFound},
+		{"another customer's sale", models.History{Type: models.HistoryPayment, Paid: 100, Customer_id: neighbour.Id, Sell_id: sold.Id}, ErrInvalidHistory},
+		{"a payment on the customer's sale", models.History{Type: models.HistoryPayment, Paid: 100, Customer_id: f.customer.Id, Sell_id: sold.Id}, nil},
+		{"a write-off", models.History{Type: models.HistoryWriteOff, Paid: 50, Customer_id: f.customer.Id}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := tt.entry
+			entry.Id = primitive.NewObjectID()
+			entry.Date = dateTime(2026, time.March, 1)
+			if err := CreateHistory(f.ctx, f.store, &entry); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := f.store.Historys.FindByID(f.ctx, entry.Id); (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("stored: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidImport is returned for files that cannot be imported at all, such
+// as an empty file or a column mapping naming a missing column
+var ErrInvalidImport = errors.New("invalid import")
+
+// ImportBatchSize is how many rows a committed import inserts at a time
+const ImportBatchSize = 100
+
+var importValidate = validator.New()
+
+// importColumn parses one spreadsheet column into a field of T
+type importColumn[T any] struct {
+	field string
+	set   func(doc *T, value string) error
+}
+
+// importer describes how rows become documents of one collection
+type importer[T any] struct {
+	columns []importColumn[T]
+	// keys identify duplicates, within the file and against stored
+	// documents; a document sharing any of them with another is a duplicate
+	keys     func(doc T) []string
+	existing func(ctx context.Context) ([]T, error)
+	create   func(ctx context.Context, docs []*T) error
+	id       func(doc *T) *primitive.ObjectID
+}
+
+var customerImporter = importer[models.Customer]{
+	columns: []importColumn[models.Customer]{
+		{"name", func(c *models.Customer, v string) error { c.Name = v; return nil }},
+		{"father", func(c *models.Customer, v string) error { c.Father = v; return nil }},
+		{"home", func(c *models.Customer, v string) error { c.Home = v; return nil }},
+		{"village", func(c *models.Customer, v string) error { c.Village = v; return nil }},
+		{"thana", func(c *models.Customer, v string) error { c.Thana = v; return nil }},
+		{"district", func(c *models.Customer, v string) error { c.District = v; return nil }},
+		{"phone", func(c *models.Customer, v string) (err error) { c.Phone, err = parseImportInt(v); return }},
+		{"email", func(c *models.Customer, v string) error { c.Email = v; return nil }},
+		{"dueDate", func(c *models.Customer, v string) error {
+			date, err := parseImportDate(v)
+			c.DueDate = primitive.NewDateTimeFromTime(date)
+			return err
+		}},
+	},
+	keys: func(c models.Customer) []string { return []string{strconv.Itoa(c.Phone)} },
+}
+
+var productImporter = importer[models.Product]{
+	columns: []importColumn[models.Product]{
+		{"model", func(p *models.Product, v string) error { p.Model = v; return nil }},
+		{"price", func(p *models.Product, v string) (err error) { p.Price, err = parseImportFloat(v); return }},
+		{"cost", func(p *models.Product, v string) (err error) { p.Cost, err = parseImportFloat(v); return }},
+		{"description", func(p *models.Product, v string) error { p.Description = v; return nil }},
+		{"category", func(p *models.Product, v string) error { p.Category = v; return nil }},
+		{"image_url", func(p *models.Product, v string) error { p.ImageURL = v; return nil }},
+		{"stock", func(p *models.Product, v string) (err error) { p.Stock, err = parseImportInt(v); return }},
+		{"brand", func(p *models.Product, v string) (err error) { p.Brand, err = parseImportInt(v); return }},
+		{"reorder_point", func(p *models.Product, v string) (err error) { p.Reorder_point, err = parseImportInt(v); return }},
+		{"sku", func(p *models.Product, v string) error { p.Sku = v; return nil }},
+		{"attributes", func(p *models.Product, v string) (err error) { p.Attributes, err = parseImportAttributes(v); return }},
+	},
+	keys: productImportKeys,
+}
+
+// productImportKeys tell products apart by their variant, the model with its
+// attributes, and by their SKU when they have one
+func productImportKeys(p models.Product) []string {
+	names := make([]string, 0, len(p.Attributes))
+	for name := range p.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	variant := strings.ToLower(strings.TrimSpace(p.Model))
+	if len(names) > 0 {
+		pairs := make([]string, len(names))
+		for i, name := range names {
+			pairs[i] = strings.ToLower(name + "=" + strings.TrimSpace(p.Attributes[name]))
+		}
+		variant += " (" + strings.Join(pairs, ", ") + ")"
+	}
+	keys := []string{variant}
+	if sku := strings.TrimSpace(p.Sku); sku != "" {
+		keys = append(keys, "sku "+sku)
+	}
+	return keys
+}
+
+// ImportCustomers validates rows, the first of which holds the column headers,
+// as customers and stores them unless dryRun is set. Customers whose phone is
+// already taken are skipped. mapping renames the column read for a field.
+func ImportCustomers(ctx context.Context, store *repositories.Store, rows [][]string, mapping map[string]string, dryRun bool) (models.ImportReport, error) {
+	imp := customerImporter
+	imp.existing = store.Customers.FindAll
+	imp.create = store.Customers.CreateMany
+	imp.id = func(c *models.Customer) *primitive.ObjectID { return &c.Id }
+	return runImport(ctx, imp, rows, mapping, dryRun)
+}
+
+// ImportProducts is ImportCustomers for products, which are told apart by
+// model and attributes or by SKU. Their stock is journaled as opening stock brought in by userId.
+func ImportProducts(ctx context.Context, store *repositories.Store, rows [][]string, mapping map[string]string, dryRun bool, userId primitive.ObjectID) (models.ImportReport, error) {
+	imp := productImporter
+	imp.existing = store.Products.FindAll
+	imp.create = func(ctx context.Context, products []*models.Product) error {
+		return CreateProducts(ctx, store, products, userId)
+	}
+	imp.id = func(p *models.Product) *primitive.ObjectID { return &p.Id }
+	return runImport(ctx, imp, rows, mapping, dryRun)
+}
+
+func runImport[T any](ctx context.Context, imp importer[T], rows [][]string, mapping map[string]string, dryRun bool) (models.ImportReport, error) {
+	report := models.ImportReport{Dry_run: dryRun, Rows: []models.ImportRow{}}
+	if len(rows) == 0 {
+		return report, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+	indexes, columns, err := mapColumns(imp.columns, rows[0], mapping)
+	if err != nil {
+		return report, err
+	}
+	report.Columns = columns
+
+	stored, err := imp.existing(ctx)
+	if err != nil {
+		return report, err
+	}
+	taken := map[string]int{}
+	for _, doc := range stored {
+		for _, key := range imp.keys(doc) {
+			taken[key] = 0
+		}
+	}
+
+	var pending []*T
+	var pendingRows []int
+	for i, values := range rows[1:] {
+		if blank(values) {
+			continue
+		}
+		// spreadsheet rows are numbered from 1 and the header is the first
+		row := models.ImportRow{Row: i + 2}
+		var doc T
+		for c, column := range imp.columns {
+			index, ok := indexes[c]
+			if !ok || index >= len(values) || strings.TrimSpace(values[index]) == "" {
+				continue
+			}
+			if err := column.set(&doc, strings.TrimSpace(values[index])); err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("%s: %v", column.field, err))
+			}
+		}
+		if len(row.Errors) == 0 {
+			row.Errors = validationMessages(importValidate.Struct(&doc))
+		}
+
+		key, first, duplicate := takenKey(taken, imp.keys(doc))
+		switch {
+		case len(row.Errors) > 0:
+			row.Status = models.ImportFailed
+		case duplicate && first == 0:
+			row.Status = models.ImportSkipped
+			row.Errors = []string{fmt.Sprintf("%s is already stored", key)}
+		case duplicate:
+			row.Status = models.ImportSkipped
+			row.Errors = []string{fmt.Sprintf("%s duplicates row %d", key, first)}
+		default:
+			for _, key := range imp.keys(doc) {
+				taken[key] = row.Row
+			}
+			row.Status = models.ImportValid
+			*imp.id(&doc) = primitive.NewObjectID()
+			pending = append(pending, &doc)
+			pendingRows = append(pendingRows, len(report.Rows))
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	if !dryRun {
+		for start := 0; start < len(pending); start += ImportBatchSize {
+			end := start + ImportBatchSize
+			if end > len(pending) {
+				end = len(pending)
+			}
+			err := imp.create(ctx, pending[start:end])
+			for i := start; i < end; i++ {
+				row := &report.Rows[pendingRows[i]]
+				if err != nil {
+					row.Status = models.ImportFailed
+					row.Errors = []string{err.Error()}
+					continue
+				}
+				row.Status = models.ImportCreated
+				row.Id = imp.id(pending[i])
+			}
+		}
+	}
+
+	report.Total = len(report.Rows)
+	for _, row := range report.Rows {
+		switch row.Status {
+		case models.ImportValid:
+			report.Valid++
+		case models.ImportCreated:
+			report.Created++
+		case models.ImportSkipped:
+			report.Skipped++
+		case models.ImportFailed:
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+// takenKey returns the first of keys already taken and the row taking it,
+// zero for stored documents
+func takenKey(taken map[string]int, keys []string) (string, int, bool) {
+	for _, key := range keys {
+		if first, ok := taken[key]; ok {
+			return key, first, true
+		}
+	}
+	return "", 0, false
+}
+
+// mapColumns finds the position of every field's column in header. A field is
+// read from the column mapping names or, by default, the column named like it.
+func mapColumns[T any](columns []importColumn[T], header []string, mapping map[string]string) (map[int]int, map[string]string, error) {
+	known := map[string]bool{}
+	for _, column := range columns {
+		known[column.field] = true
+	}
+	for field := range mapping {
+		if !known[field] {
+			return nil, nil, fmt.Errorf("%w: unknown field %q in the column mapping", ErrInvalidImport, field)
+		}
+	}
+
+	indexes, mapped := map[int]int{}, map[string]string{}
+	for c, column := range columns {
+		name, explicit := mapping[column.field]
+		if !explicit {
+			name = column.field
+		}
+		found := false
+		for i, title := range header {
+			if headerKey(title) == headerKey(name) {
+				indexes[c], mapped[column.field] = i, strings.TrimSpace(title)
+				found = true
+				break
+			}
+		}
+		if explicit && !found {
+			return nil, nil, fmt.Errorf("%w: there is no column %q", ErrInvalidImport, name)
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, nil, fmt.Errorf("%w: no column matches a field, map them explicitly", ErrInvalidImport)
+	}
+	return indexes, mapped, nil
+}
+
+// headerKey compares column titles ignoring case, spaces and underscores
+func headerKey(title string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(title)))
+}
+
+func blank(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// validationMessages lists the failed validate tags of err
+func validationMessages(err error) []string {
+	var failures validator.ValidationErrors
+	if !errors.As(err, &failures) {
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	}
+	messages := make([]string, len(failures))
+	for i, failure := range failures {
+		messages[i] = fmt.Sprintf("%s failed on %s", failure.Field(), failure.Tag())
+	}
+	return messages
+}
+
+// parseImportInt also accepts the integral floats spreadsheets store numbers as
+func parseImportInt(value string) (int, error) {
+	if n, err := strconv.Atoi(value); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f != math.Trunc(f) {
+		return 0, fmt.Errorf("%q is not a whole number", value)
+	}
+	return int(f), nil
+}
+
+func parseImportFloat(value string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+	return f, nil
+}
+
+// parseImportAttributes reads attributes written as name=value pairs
+// separated by semicolons, such as "colour=Black; storage=128GB"
+func parseImportAttributes(value string) (map[string]string, error) {
+	attributes := map[string]string{}
+	for _, pair := range strings.Split(value, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(pair, "=")
+		name, val = strings.TrimSpace(name), strings.TrimSpace(val)
+		if !ok || !attributeName.MatchString(name) || val == "" {
+			return nil, fmt.Errorf("%q is not a name=value pair", strings.TrimSpace(pair))
+		}
+		attributes[name] = val
+	}
+	return attributes, nil
+}
+
+// parseImportDate reads ISO dates, day/month/year dates and spreadsheet date serials
+func parseImportDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "02/01/2006"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+		return epoch.Add(time.Duration(serial * float64(24*time.Hour))), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date", value)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"appadming/models"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// importStatuses lists the status of every row of report
+func importStatuses(report models.ImportReport) []string {
+	statuses := []string{}
+	for _, row := range report.Rows {
+		statuses = append(statuses, row.Status)
+	}
+	return statuses
+}
+
+func TestImportCustomersDryRunStoresNothing(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	rows := [][]string{
+		{"Name", "Father", "Home", "Village", "Thana", "District", "Mobile"},
+		{"Karim", "Rahim", "12", "Kumarpara", "Savar", "Dhaka", "01711000001"},
+		{"", "", "", "", "", "", ""},
+		{"Salam", "Jalal", "3", "Bhatpara", "Savar", "Dhaka", "171"},
+		{"Babul", "Kamal", "7", "Bhatpara", "Savar", "Dhaka", "1711000001"},
+		{"Abdul", "", "7", "Bhatpara", "Savar", "Dhaka", "1811"},
+		{"Aziz", "Kamal", "7", "Bhatpara", "Savar", "Dhaka", "18a"},
+	}
+	mapping := map[string]string{"phone": "Mobile"}
+
+	for _, dryRun := range []bool{true, false} {
+		report, err := ImportCustomers(f.ctx, f.store, rows, mapping, dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dryRun {
+			// the fixture customer already has phone 171 and the blank row is left out
+			want := []string{models.ImportValid, models.ImportSkipped, models.ImportSkipped, models.ImportFailed, models.ImportFailed}
+			if got := importStatuses(report); !reflect.DeepEqual(got, want) {
+				t.Errorf("dry run statuses = %v, want %v", got, want)
+			}
+			if report.Rows[2].Row != 5 || report.Rows[2].Errors[0] != "1711000001 duplicates row 2" {
+				t.Errorf("duplicate row = %+v", report.Rows[2])
+			}
+			if report.Columns["phone"] != "Mobile" || report.Valid != 1 || report.Skipped != 2 || report.Failed != 2 {
+				t.Errorf("report = %+v", report)
+			}
+			customers, err := f.store.Customers.FindAll(f.ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(customers) != 1 {
+				t.Fatalf("a dry run stored %d customers", len(customers)-1)
+			}
+			continue
+		}
+		if report.Created != 1 || report.Rows[0].Status != models.ImportCreated || report.Rows[0].Id == nil {
+			t.Fatalf("committed report = %+v", report)
+		}
+		created, err := f.store.Customers.FindByID(f.ctx, *report.Rows[0].Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created.Name != "Karim" || created.Phone != 1711000001 {
+			t.Errorf("created customer = %+v", created)
+		}
+	}
+}
+
+func TestImportProductsByVariant(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	stored := models.Product{
+		Id: primitive.NewObjectID(), Model: "Galaxy A15", Price: 20000, Cost: 17000, Category: "Phone", Brand: 1,
+		Sku: "A15-BLK", Attributes: map[string]string{"colour": "Black"},
+	}
+	if err := f.store.Products.Create(f.ctx, &stored); err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]string{
+		{"model", "price", "cost", "category", "brand", "stock", "sku", "attributes"},
+		// another colour of a stored model
+		{"Galaxy A15", "20000", "17000", "Phone", "1", "3", "A15-BLU", "colour=Blue"},
+		// the stored variant, with its attributes in another order and case
+		{"galaxy a15", "20000", "17000", "Phone", "1", "3", "", "Colour = black"},
+		// a new variant under a stored SKU
+		{"Galaxy A15", "20000", "17000", "Phone", "1", "3", "A15-BLK", "colour=Green"},
+		// the same variant twice in the file
+		{"Galaxy A15", "21000", "18000", "Phone", "1", "2", "A15-BLU-2", "colour=Blue"},
+		{"Galaxy A15", "24000", "20000", "Phone", "1", "2", "", "colour=Blue; storage=256GB"},
+		{"Galaxy A15", "24000", "20000", "Phone", "1", "2", "", "colour"},
+	}
+	report, err := ImportProducts(f.ctx, f.store, rows, nil, false, primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{models.ImportCreated, models.ImportSkipped, models.ImportSkipped, models.ImportSkipped, models.ImportCreated, models.ImportFailed}
+	if got := importStatuses(report); !reflect.DeepEqual(got, want) {
+		t.Fatalf("statuses = %v, want %v", got, want)
+	}
+	messages := []string{
+		"galaxy a15 (colour=black) is already stored",
+		"sku A15-BLK is already stored",
+		"galaxy a15 (colour=blue) duplicates row 2",
+	}
+	for i, message := range messages {
+		if errs := report.Rows[i+1].Errors; len(errs) != 1 || errs[0] != message {
+			t.Errorf("row %d errors = %v, want %q", report.Rows[i+1].Row, errs, message)
+		}
+	}
+
+	created, err := f.store.Products.FindByID(f.ctx, *report.Rows[4].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(created.Attributes, map[string]string{"colour": "Blue", "storage": "256GB"}) || created.Stock != 2 {
+		t.Errorf("created product = %+v", created)
+	}
+}
+
+func TestImportRejectsUnusableFiles(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	tests := []struct {
+		name    string
+		rows    [][]string
+		mapping map[string]string
+	}{
+		{"empty", nil, nil},
+		{"no known column", [][]string{{"colour", "size"}}, nil},
+		{"unknown field", [][]string{{"name"}}, map[string]string{"nickname": "name"}},
+		{"missing column", [][]string{{"name"}}, map[string]string{"phone": "Mobile"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ImportCustomers(f.ctx, f.store, tt.rows, tt.mapping, true); !errors.Is(err, ErrInvalidImport) {
+				t.Errorf("error = %v, want ErrInvalidImport", err)
+			}
+		})
+	}
+}
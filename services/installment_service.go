@@ -0,0 +1,290 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidPlan is returned when an installment plan cannot be created as submitted
+var ErrInvalidPlan = errors.New("invalid installment plan")
+
+// ErrPlanExists is returned when the sale already has an installment plan
+var ErrPlanExists = errors.New("sell already has an installment plan")
+
+// MaxInstallments bounds how many installments a plan is split into
+const MaxInstallments = 120
+
+// CreateInstallmentPlan attaches a plan to a sale still standing and
+// generates its schedule over what is left to pay on it, net of returns. The
+// down payment is what was paid on the sale; any markup is posted to the
+// customer's history as an adjustment so the ledger and the schedule agree.
+func CreateInstallmentPlan(ctx context.Context, store *repositories.Store, sellId primitive.ObjectID, plan models.InstallmentPlan) (models.InstallmentPlan, error) {
+	var newPlan models.InstallmentPlan
+
+	if plan.Installments < 1 || plan.Installments > MaxInstallments {
+		return newPlan, fmt.Errorf("%w: between 1 and %d installments are required", ErrInvalidPlan, MaxInstallments)
+	}
+	if plan.Frequency != models.PlanWeekly && plan.Frequency != models.PlanMonthly {
+		return newPlan, fmt.Errorf("%w: frequency must be weekly or monthly", ErrInvalidPlan)
+	}
+	if plan.Markup_percent < 0 {
+		return newPlan, fmt.Errorf("%w: markup is negative", ErrInvalidPlan)
+	}
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		sell, err := store.Sells.FindByID(ctx, sellId)
+		if err != nil {
+			return fmt.Errorf("sell %s: %w", sellId.Hex(), err)
+		}
+		if sell.Status == models.SellReturned || sell.Status == models.SellVoided {
+			return ErrSellReversed
+		}
+		if _, err := store.Plans.FindBySell(ctx, sellId); err == nil {
+			return ErrPlanExists
+		} else if !errors.Is(err, repositories.ErrNotFound) {
+			return err
+		}
+		if plan.Down_payment != 0 && plan.Down_payment != sell.Paid {
+			return fmt.Errorf("%w: down payment must match the %d paid on the sell", ErrInvalidPlan, sell.Paid)
+		}
+		financed, err := sellBalance(ctx, store, sell)
+		if err != nil {
+			return err
+		}
+		if financed <= 0 {
+			return fmt.Errorf("%w: nothing left to pay on the sell", ErrInvalidPlan)
+		}
+
+		now := time.Now()
+		start := plan.Start_date.Time()
+		if plan.Start_date == 0 {
+			start = nextDueDate(now, plan.Frequency, 1)
+		}
+		markup := int(math.Round(float64(financed) * plan.Markup_percent / 100))
+
+		newPlan = models.InstallmentPlan{
+			Id:              primitive.NewObjectID(),
+			Sell_id:         sell.Id,
+			Customer_id:     sell.Customer_id,
+			Organization_id: sell.Organization_id,
+			Down_payment:    sell.Paid,
+			Installments:    plan.Installments,
+			Frequency:       plan.Frequency,
+			Markup_percent:  plan.Markup_percent,
+			Start_date:      primitive.NewDateTimeFromTime(start),
+			Financed:        financed,
+			Markup:          markup,
+			Schedule:        buildSchedule(financed+markup, plan.Installments, plan.Frequency, start),
+			Created_at:      now,
+		}
+		newPlan.Status = PlanStatus(newPlan, now)
+		if err := store.Plans.Create(ctx, &newPlan); err != nil {
+			return err
+		}
+
+		if markup > 0 {
+			return store.Historys.Create(ctx, &models.History{
+				Id:          primitive.NewObjectID(),
+				Type:        models.HistoryAdjustment,
+				Due:         markup,
+				Date:        primitive.NewDateTimeFromTime(now),
+				Customer_id: sell.Customer_id,
+				Seller_id:   sell.Organization_id,
+				Sell_id:     sell.Id,
+				Note:        "installment plan markup",
+			})
+		}
+		return nil
+	})
+	return newPlan, err
+}
+
+// sellBalance is what is left to pay on the sale by the entries naming it:
+// its own due and down payment, return credits, refunds and payments
+func sellBalance(ctx context.Context, store *repositories.Store, sell models.SellInfo) (int, error) {
+	historys, err := store.Historys.FindByCustomer(ctx, sell.Customer_id, allTime)
+	if err != nil {
+		return 0, err
+	}
+	balance := 0
+	for _, entry := range historys {
+		if entry.Sell_id == sell.Id && !voided(entry) {
+			balance += EntryAmount(entry)
+		}
+	}
+	return balance, nil
+}
+
+// GetInstallmentPlan returns the plan of a sale with its status as of now
+func GetInstallmentPlan(ctx context.Context, store *repositories.Store, sellId primitive.ObjectID) (models.InstallmentPlan, error) {
+	plan, err := store.Plans.FindBySell(ctx, sellId)
+	if err != nil {
+		return plan, err
+	}
+	plan.Status = PlanStatus(plan, time.Now())
+	return plan, nil
+}
+
+// PlanStatus is completed once every installment is paid, overdue when an
+// installment due before now is still open, and current otherwise
+func PlanStatus(plan models.InstallmentPlan, now time.Time) string {
+	status := models.PlanCompleted
+	for _, installment := range plan.Schedule {
+		if installment.Paid >= installment.Amount {
+			continue
+		}
+		if installment.Due_date.Time().Before(now) {
+			return models.PlanOverdue
+		}
+		status = models.PlanCurrent
+	}
+	return status
+}
+
+// AllocatePayment spreads a payment over the oldest open installments of the
+// sale it names, or of every plan the customer has when it names no sale.
+// Whatever is left after the last open installment stays unallocated.
+func AllocatePayment(ctx context.Context, store *repositories.Store, entry models.History) error {
+	remaining := entry.Paid
+	if entry.Type != models.HistoryPayment || remaining <= 0 {
+		return nil
+	}
+
+	var plans []models.InstallmentPlan
+	if !entry.Sell_id.IsZero() {
+		plan, err := store.Plans.FindBySell(ctx, entry.Sell_id)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		plans = append(plans, plan)
+	} else {
+		var err error
+		if plans, err = store.Plans.FindByCustomer(ctx, entry.Customer_id); err != nil {
+			return err
+		}
+		sort.SliceStable(plans, func(i, j int) bool { return plans[i].Created_at.Before(plans[j].Created_at) })
+	}
+
+	now := time.Now()
+	for i := range plans {
+		plans[i].Schedule = append([]models.Installment(nil), plans[i].Schedule...)
+	}
+	for i, changed := range allocate(plans, remaining) {
+		if changed {
+			if err := store.Plans.UpdateSchedule(ctx, plans[i].Id, plans[i].Schedule, PlanStatus(plans[i], now)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// allocate pays amount into the open installments of plans in order and
+// reports which plans it changed
+func allocate(plans []models.InstallmentPlan, amount int) []bool {
+	changed := make([]bool, len(plans))
+	for i := range plans {
+		schedule := plans[i].Schedule
+		for j := range schedule {
+			if amount == 0 {
+				return changed
+			}
+			open := schedule[j].Amount - schedule[j].Paid
+			if open <= 0 {
+				continue
+			}
+			if open > amount {
+				open = amount
+			}
+			schedule[j].Paid += open
+			amount -= open
+			changed[i] = true
+		}
+	}
+	return changed
+}
+
+// reallocatePlans clears what was paid on the customer's installment plans
+// and allocates every payment still standing again in date order, as
+// AllocatePayment did when each was posted
+func reallocatePlans(ctx context.Context, store *repositories.Store, customerId primitive.ObjectID, now time.Time) error {
+	plans, err := store.Plans.FindByCustomer(ctx, customerId)
+	if err != nil || len(plans) == 0 {
+		return err
+	}
+	sort.SliceStable(plans, func(i, j int) bool { return plans[i].Created_at.Before(plans[j].Created_at) })
+	for i := range plans {
+		schedule := make([]models.Installment, len(plans[i].Schedule))
+		for j, installment := range plans[i].Schedule {
+			installment.Paid = 0
+			schedule[j] = installment
+		}
+		plans[i].Schedule = schedule
+	}
+
+	historys, err := store.Historys.FindByCustomer(ctx, customerId, allTime)
+	if err != nil {
+		return err
+	}
+	for _, entry := range historys {
+		if entry.Type != models.HistoryPayment || entry.Paid <= 0 || voided(entry) {
+			continue
+		}
+		if entry.Sell_id.IsZero() {
+			allocate(plans, entry.Paid)
+			continue
+		}
+		for i := range plans {
+			if plans[i].Sell_id == entry.Sell_id {
+				allocate(plans[i:i+1], entry.Paid)
+			}
+		}
+	}
+
+	for _, plan := range plans {
+		if err := store.Plans.UpdateSchedule(ctx, plan.Id, plan.Schedule, PlanStatus(plan, now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildSchedule splits total into count installments, the remainder going to the last one
+func buildSchedule(total int, count int, frequency string, start time.Time) []models.Installment {
+	schedule := make([]models.Installment, count)
+	for i := range schedule {
+		schedule[i] = models.Installment{
+			Number:   i + 1,
+			Due_date: primitive.NewDateTimeFromTime(nextDueDate(start, frequency, i)),
+			Amount:   total / count,
+		}
+	}
+	schedule[count-1].Amount += total % count
+	return schedule
+}
+
+// nextDueDate is the due date periods installments after start. Monthly
+// installments fall on the day of the month of start, or the last day of
+// shorter months.
+func nextDueDate(start time.Time, frequency string, periods int) time.Time {
+	if frequency == models.PlanWeekly {
+		return start.AddDate(0, 0, 7*periods)
+	}
+	month := time.Date(start.Year(), start.Month()+time.Month(periods), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	day := start.Day()
+	if last := month.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return month.AddDate(0, 0, day-1)
+}
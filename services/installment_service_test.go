@@ -0,0 +1,198 @@
+package services
+
+import (
+	"appadming/models"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPlanStatus(t *testing.T) {
+	now := date(2026, time.March, 15)
+	schedule := func(paid ...int) []models.Installment {
+		installments := make([]models.Installment, len(paid))
+		for i := range paid {
+			installments[i] = models.Installment{Number: i + 1, Amount: 100, Paid: paid[i], Due_date: dateTime(2026, time.Month(2+i), 1)}
+		}
+		return installments
+	}
+	tests := []struct {
+		name string
+		paid []int
+		want string
+	}{
+		{"everything paid", []int{100, 100, 100}, models.PlanCompleted},
+		{"paid up to now", []int{100, 100, 0}, models.PlanCurrent},
+		{"paid ahead", []int{100, 100, 50}, models.PlanCurrent},
+		{"past installment short", []int{100, 99, 0}, models.PlanOverdue},
+		{"nothing paid", []int{0, 0, 0}, models.PlanOverdue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlanStatus(models.InstallmentPlan{Schedule: schedule(tt.paid...)}, now); got != tt.want {
+				t.Errorf("status = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocatePayment(t *testing.T) {
+	tests := []struct {
+		name string
+		// payment is the entry allocated, sale names the first plan's sale
+		payment func(first primitive.ObjectID) models.History
+		want    [2][]int
+	}{
+		{
+			name: "on account fills the oldest plan first",
+			payment: func(first primitive.ObjectID) models.History {
+				return models.History{Type: models.HistoryPayment, Paid: 250}
+			},
+			want: [2][]int{{100, 100, 50}, {0, 0}},
+		},
+		{
+			name: "on account spills into the next plan",
+			payment: func(first primitive.ObjectID) models.History {
+				return models.History{Type: models.HistoryPayment, Paid: 350}
+			},
+			want: [2][]int{{100, 100, 100}, {50, 0}},
+		},
+		{
+			name: "surplus stays unallocated",
+			payment: func(first primitive.ObjectID) models.History {
+				return models.History{Type: models.HistoryPayment, Paid: 1000}
+			},
+			want: [2][]int{{100, 100, 100}, {100, 100}},
+		},
+		{
+			name: "naming a sale pays only its plan",
+			payment: func(first primitive.ObjectID) models.History {
+				return models.History{Type: models.HistoryPayment, Paid: 500, Sell_id: first}
+			},
+			want: [2][]int{{100, 100, 100}, {0, 0}},
+		},
+		{
+			name: "other entries allocate nothing",
+			payment: func(first primitive.ObjectID) models.History {
+				return models.History{Type: models.HistoryAdjustment, Paid: 100}
+			},
+			want: [2][]int{{0, 0, 0}, {0, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, models.Orgnization{})
+			plans := []models.InstallmentPlan{
+				{Id: primitive.NewObjectID(), Sell_id: primitive.NewObjectID(), Customer_id: f.customer.Id, Created_at: date(2026, time.January, 1),
+					Schedule: buildSchedule(300, 3, models.PlanMonthly, date(2026, time.February, 1))},
+				{Id: primitive.NewObjectID(), Sell_id: primitive.NewObjectID(), Customer_id: f.customer.Id, Created_at: date(2026, time.January, 2),
+					Schedule: buildSchedule(200, 2, models.PlanMonthly, date(2026, time.February, 1))},
+			}
+			// stored newest first, allocation still starts from the oldest
+			for i := len(plans) - 1; i >= 0; i-- {
+				if err := f.store.Plans.Create(f.ctx, &plans[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			payment := tt.payment(plans[0].Sell_id)
+			payment.Customer_id = f.customer.Id
+			if err := AllocatePayment(f.ctx, f.store, payment); err != nil {
+				t.Fatal(err)
+			}
+			for i, plan := range plans {
+				stored, err := f.store.Plans.FindBySell(f.ctx, plan.Sell_id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for j, installment := range stored.Schedule {
+					if installment.Paid != tt.want[i][j] {
+						t.Errorf("plan %d installment %d paid %d, want %d", i+1, j+1, installment.Paid, tt.want[i][j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSchedule(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency string
+		start     time.Time
+		due       []time.Time
+	}{
+		{"weekly", models.PlanWeekly, date(2026, time.January, 1),
+			[]time.Time{date(2026, time.January, 1), date(2026, time.January, 8), date(2026, time.January, 15)}},
+		{"monthly", models.PlanMonthly, date(2026, time.January, 15),
+			[]time.Time{date(2026, time.January, 15), date(2026, time.February, 15), date(2026, time.March, 15)}},
+		{"monthly from a month end", models.PlanMonthly, date(2026, time.January, 31),
+			[]time.Time{date(2026, time.January, 31), date(2026, time.February, 28), date(2026, time.March, 31)}},
+		{"monthly from a month end in a leap year", models.PlanMonthly, date(2028, time.January, 30),
+			[]time.Time{date(2028, time.January, 30), date(2028, time.February, 29), date(2028, time.March, 30)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := buildSchedule(1000, 3, tt.frequency, tt.start)
+			amounts := []int{333, 333, 334}
+			for i, installment := range schedule {
+				if installment.Amount != amounts[i] {
+					t.Errorf("installment %d = %d, want %d", i+1, installment.Amount, amounts[i])
+				}
+				if !installment.Due_date.Time().Equal(tt.due[i]) {
+					t.Errorf("installment %d due %v, want %v", i+1, installment.Due_date.Time(), tt.due[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCreateInstallmentPlan(t *testing.T) {
+	tests := []struct {
+		name string
+		// reverse takes products back from the sale of two phones before the plan
+		reverse      func(f *fixture, sell models.SellInfo) error
+		installments int
+		wantErr      error
+		wantFinanced int
+	}{
+		{name: "financed what is left to pay", installments: 4, wantFinanced: 1500},
+		{name: "return credit is not financed", installments: 4, wantFinanced: 500,
+			reverse: func(f *fixture, sell models.SellInfo) error {
+				request := models.ReturnRequest{Lines: []models.ReturnLine{{Product_id: sell.Lines[0].Product_id, Quantity: 1}}, Reason: "faulty"}
+				_, err := ReturnSell(f.ctx, f.store, sell.Id, request, primitive.NewObjectID())
+				return err
+			}},
+		{name: "voided sale", installments: 4, wantErr: ErrSellReversed,
+			reverse: func(f *fixture, sell models.SellInfo) error {
+				_, err := VoidSell(f.ctx, f.store, sell.Id, models.ReturnRequest{Reason: "mistake"}, primitive.NewObjectID())
+				return err
+			}},
+		{name: "too many installments", installments: MaxInstallments + 1, wantErr: ErrInvalidPlan},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, models.Orgnization{})
+			phone := f.addProduct(t, "Phone", 1000, 700, 5)
+			sell, _, err := CreateSell(f.ctx, f.store, models.SellInfo{Customer_id: f.customer.Id, Paid: 500, Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 2}}}, primitive.NewObjectID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.reverse != nil {
+				if err := tt.reverse(f, sell); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			plan, err := CreateInstallmentPlan(f.ctx, f.store, sell.Id, models.InstallmentPlan{Installments: tt.installments, Frequency: models.PlanMonthly})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && plan.Financed != tt.wantFinanced {
+				t.Errorf("financed = %d, want %d", plan.Financed, tt.wantFinanced)
+			}
+		})
+	}
+}
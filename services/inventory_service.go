@@ -0,0 +1,256 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidMovement is returned when a stock movement, count or transfer cannot be applied
+var ErrInvalidMovement = errors.New("invalid stock movement")
+
+// OpeningStockReason is the reason of the movement that brings a new product's stock in
+const OpeningStockReason = "opening stock"
+
+// moveStock applies movement to the stock of its product and journals it with
+// the balance it leaves, returning the product as moved. Stock never goes
+// below zero and the units of serialized products move with it. It must run
+// inside a transaction so the balance and the journal agree.
+func moveStock(ctx context.Context, store *repositories.Store, movement models.StockMovement) (models.Product, models.StockMovement, error) {
+	var product models.Product
+	var err error
+	switch {
+	case movement.Quantity < 0:
+		product, err = store.Products.DecrementStock(ctx, movement.Product_id, -movement.Quantity)
+	case movement.Quantity > 0:
+		product, err = store.Products.IncrementStock(ctx, movement.Product_id, movement.Quantity)
+	default:
+		return product, movement, fmt.Errorf("%w: quantity is zero", ErrInvalidMovement)
+	}
+	if err != nil {
+		return product, movement, fmt.Errorf("product %s: %w", movement.Product_id.Hex(), err)
+	}
+
+	movement.Id = primitive.NewObjectID()
+	movement.Organization_id = product.Seller_id
+	movement.Balance = product.Stock
+	if movement.Date == 0 {
+		movement.Date = primitive.NewDateTimeFromTime(time.Now())
+	}
+	if movement.Serials, err = trackSerials(ctx, store, product, movement); err != nil {
+		return product, movement, err
+	}
+	return product, movement, store.Movements.Create(ctx, &movement)
+}
+
+// RecordMovement applies a purchase receipt, damage or adjustment posted by
+// userId. Purchases bring stock in at their unit cost and damage takes it out
+// whatever the sign of the quantity; adjustments are signed. Only purchases
+// carry a unit cost, the others move stock at its current value.
+func RecordMovement(ctx context.Context, store *repositories.Store, productId primitive.ObjectID, movement models.StockMovement, userId primitive.ObjectID) (models.StockMovement, error) {
+	var recorded models.StockMovement
+
+	reason := strings.TrimSpace(movement.Reason)
+	if reason == "" {
+		return recorded, fmt.Errorf("%w: a reason is required", ErrInvalidMovement)
+	}
+	quantity := movement.Quantity
+	if quantity < 0 {
+		quantity = -quantity
+	}
+	if movement.Unit_cost < 0 {
+		return recorded, fmt.Errorf("%w: unit cost is negative", ErrInvalidMovement)
+	}
+	if movement.Unit_cost != 0 && movement.Type != models.MovementPurchase {
+		return recorded, fmt.Errorf("%w: only purchases carry a unit cost", ErrInvalidMovement)
+	}
+	switch movement.Type {
+	case models.MovementPurchase:
+		movement.Quantity = quantity
+	case models.MovementDamage:
+		movement.Quantity = -quantity
+	case models.MovementAdjustment:
+	default:
+		return recorded, fmt.Errorf("%w: type must be purchase, damage or adjustment", ErrInvalidMovement)
+	}
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		_, recorded, err = moveStock(ctx, store, models.StockMovement{
+			Product_id: productId,
+			Type:       movement.Type,
+			Quantity:   movement.Quantity,
+			Reason:     reason,
+			User_id:    userId,
+			Unit_cost:  movement.Unit_cost,
+			Serials:    movement.Serials,
+		})
+		return err
+	})
+	return recorded, err
+}
+
+// CountStock reconciles a physical count with the stock of the counted
+// products. Unless dryRun is set the differences are posted as adjustments
+// in one transaction.
+func CountStock(ctx context.Context, store *repositories.Store, count models.StockCount, userId primitive.ObjectID, dryRun bool) (models.CountReport, error) {
+	report := models.CountReport{Dry_run: dryRun}
+
+	reason := strings.TrimSpace(count.Reason)
+	if reason == "" {
+		reason = "stock count"
+	}
+	seen := map[primitive.ObjectID]bool{}
+	for _, counted := range count.Counts {
+		if counted.Counted < 0 {
+			return report, fmt.Errorf("%w: count of %s is negative", ErrInvalidMovement, counted.Product_id.Hex())
+		}
+		if seen[counted.Product_id] {
+			return report, fmt.Errorf("%w: product %s is counted twice", ErrInvalidMovement, counted.Product_id.Hex())
+		}
+		seen[counted.Product_id] = true
+	}
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		report.Lines = make([]models.CountLine, len(count.Counts))
+		for i, counted := range count.Counts {
+			product, err := store.Products.FindByID(ctx, counted.Product_id)
+			if err != nil {
+				return fmt.Errorf("product %s: %w", counted.Product_id.Hex(), err)
+			}
+			line := models.CountLine{
+				Product_id: product.Id,
+				Model:      product.Model,
+				Expected:   product.Stock,
+				Counted:    counted.Counted,
+				Difference: counted.Counted - product.Stock,
+			}
+			if !dryRun && line.Difference != 0 {
+				_, movement, err := moveStock(ctx, store, models.StockMovement{
+					Product_id: product.Id,
+					Type:       models.MovementAdjustment,
+					Quantity:   line.Difference,
+					Reason:     reason,
+					User_id:    userId,
+				})
+				if err != nil {
+					return err
+				}
+				line.Movement_id = &movement.Id
+			}
+			report.Lines[i] = line
+		}
+		return nil
+	})
+	return report, err
+}
+
+// TransferStock moves units of a product of the organization ctx is scoped to
+// into a product of the transfer's organization. Both movements share a
+// reference so either side can find the other. The caller must be allowed
+// to change the stock of the destination.
+func TransferStock(ctx context.Context, store *repositories.Store, productId primitive.ObjectID, transfer models.StockTransfer, userId primitive.ObjectID) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+
+	reason := strings.TrimSpace(transfer.Reason)
+	if reason == "" {
+		return movements, fmt.Errorf("%w: a reason is required", ErrInvalidMovement)
+	}
+	if transfer.Quantity < 1 {
+		return movements, fmt.Errorf("%w: quantity must be positive", ErrInvalidMovement)
+	}
+	if transfer.Product_id == productId {
+		return movements, fmt.Errorf("%w: a product cannot be transferred to itself", ErrInvalidMovement)
+	}
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		movements = nil
+		// the units arrive at what they are worth where they come from
+		unitCost, err := UnitCost(ctx, store, productId)
+		if err != nil {
+			return err
+		}
+		reference := primitive.NewObjectID()
+		date := primitive.NewDateTimeFromTime(time.Now())
+		_, out, err := moveStock(ctx, store, models.StockMovement{
+			Product_id:   productId,
+			Type:         models.MovementTransfer,
+			Quantity:     -transfer.Quantity,
+			Reason:       reason,
+			User_id:      userId,
+			Reference_id: reference,
+			Date:         date,
+			Serials:      transfer.Serials,
+		})
+		if err != nil {
+			return err
+		}
+		_, in, err := moveStock(repositories.WithOrganization(ctx, transfer.Organization_id), store, models.StockMovement{
+			Product_id:   transfer.Product_id,
+			Type:         models.MovementTransfer,
+			Quantity:     transfer.Quantity,
+			Reason:       reason,
+			User_id:      userId,
+			Reference_id: reference,
+			Date:         date,
+			Unit_cost:    unitCost,
+			Serials:      transfer.Serials,
+		})
+		if err != nil {
+			return err
+		}
+		movements = []models.StockMovement{out, in}
+		return nil
+	})
+	return movements, err
+}
+
+// CreateProduct stores product and journals its opening stock as made by userId
+func CreateProduct(ctx context.Context, store *repositories.Store, product *models.Product, userId primitive.ObjectID) error {
+	return CreateProducts(ctx, store, []*models.Product{product}, userId)
+}
+
+// CreateProducts stores products in one batch with their opening stock
+func CreateProducts(ctx context.Context, store *repositories.Store, products []*models.Product, userId primitive.ObjectID) error {
+	return store.WithTransaction(ctx, func(ctx context.Context) error {
+		opening := make([]int, len(products))
+		skus := map[string]bool{}
+		for i, product := range products {
+			if err := resolveCatalogue(ctx, store, product); err != nil {
+				return err
+			}
+			if product.Sku != "" && skus[product.Sku] {
+				return fmt.Errorf("%w: %s", ErrDuplicateSku, product.Sku)
+			}
+			skus[product.Sku] = true
+			// the stock is brought in by the opening movement
+			opening[i], product.Stock = product.Stock, 0
+		}
+		if err := store.Products.CreateMany(ctx, products); err != nil {
+			return err
+		}
+		for i, product := range products {
+			if opening[i] == 0 {
+				continue
+			}
+			moved, _, err := moveStock(ctx, store, models.StockMovement{
+				Product_id: product.Id,
+				Type:       models.MovementAdjustment,
+				Quantity:   opening[i],
+				Reason:     OpeningStockReason,
+				User_id:    userId,
+			})
+			if err != nil {
+				return err
+			}
+			product.Stock = moved.Stock
+		}
+		return nil
+	})
+}
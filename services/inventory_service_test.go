@@ -0,0 +1,49 @@
+package services
+
+import (
+	"appadming/models"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRecordMovementUnitCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		movement models.StockMovement
+		wantErr  error
+		wantCost float64
+	}{
+		{"purchase at its cost", models.StockMovement{Type: models.MovementPurchase, Quantity: 5, Unit_cost: 400}, nil, 500},
+		{"purchase without a cost", models.StockMovement{Type: models.MovementPurchase, Quantity: 5}, nil, 600},
+		{"purchase at a negative cost", models.StockMovement{Type: models.MovementPurchase, Quantity: 5, Unit_cost: -1}, ErrInvalidMovement, 600},
+		{"damage with a cost", models.StockMovement{Type: models.MovementDamage, Quantity: 1, Unit_cost: 400}, ErrInvalidMovement, 600},
+		{"adjustment with a cost", models.StockMovement{Type: models.MovementAdjustment, Quantity: 2, Unit_cost: 400}, ErrInvalidMovement, 600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, models.Orgnization{})
+			product := f.addProduct(t, "Charger", 900, 600, 0)
+			opening := models.StockMovement{Type: models.MovementPurchase, Quantity: 5, Unit_cost: 600, Reason: "opening stock"}
+			if _, err := RecordMovement(f.ctx, f.store, product.Id, opening, primitive.NewObjectID()); err != nil {
+				t.Fatal(err)
+			}
+			tt.movement.Reason = "delivery"
+			recorded, err := RecordMovement(f.ctx, f.store, product.Id, tt.movement, primitive.NewObjectID())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && recorded.Unit_cost != tt.movement.Unit_cost {
+				t.Errorf("recorded unit cost %v, want %v", recorded.Unit_cost, tt.movement.Unit_cost)
+			}
+			cost, err := UnitCost(f.ctx, f.store, product.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !near(cost, tt.wantCost) {
+				t.Errorf("unit cost = %v, want %v", cost, tt.wantCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// allTime is later than any entry, for reading a whole ledger
+var allTime = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// EntryAmount is how much a history entry moves the customer balance
+func EntryAmount(entry models.History) int {
+	return entry.Due - entry.Paid
+}
+
+// CustomerBalance is what the customer owes after every entry dated up to asOf
+func CustomerBalance(ctx context.Context, store *repositories.Store, customerId primitive.ObjectID, asOf time.Time) (int, error) {
+	historys, err := store.Historys.FindByCustomer(ctx, customerId, asOf)
+	if err != nil {
+		return 0, err
+	}
+	balance := 0
+	for _, entry := range historys {
+		balance += EntryAmount(entry)
+	}
+	return balance, nil
+}
+
+// CustomerStatement returns the opening balance at from, every movement up to
+// to with its running balance, and the closing balance
+func CustomerStatement(ctx context.Context, store *repositories.Store, customerId primitive.ObjectID, from time.Time, to time.Time) (models.Statement, error) {
+	statement := models.Statement{Customer_id: customerId, From: from, To: to, Movements: []models.LedgerMovement{}}
+
+	if _, err := store.Customers.FindByID(ctx, customerId); err != nil {
+		return statement, fmt.Errorf("customer %s: %w", customerId.Hex(), err)
+	}
+	historys, err := store.Historys.FindByCustomer(ctx, customerId, to)
+	if err != nil {
+		return statement, err
+	}
+
+	start := primitive.NewDateTimeFromTime(from)
+	balance := 0
+	for _, entry := range historys {
+		balance += EntryAmount(entry)
+		if entry.Date < start {
+			statement.Opening = balance
+			continue
+		}
+		statement.Movements = append(statement.Movements, models.LedgerMovement{History: entry, Amount: EntryAmount(entry), Balance: balance})
+	}
+	statement.Closing = balance
+	return statement, nil
+}
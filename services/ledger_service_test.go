@@ -0,0 +1,46 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCustomerStatement(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	other := f.addCustomer(t, "Karim", time.Time{})
+	// posted out of date order, and another customer's entry in between
+	f.addHistory(t, models.History{Type: models.HistoryPayment, Paid: 300, Date: dateTime(2026, time.February, 10)})
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 1000, Paid: 200, Date: dateTime(2026, time.January, 5)})
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 5000, Date: dateTime(2026, time.February, 1), Customer_id: other.Id})
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 400, Date: dateTime(2026, time.February, 20)})
+	f.addHistory(t, models.History{Type: models.HistoryPayment, Paid: 100, Date: dateTime(2026, time.March, 5)})
+
+	statement, err := CustomerStatement(f.ctx, f.store, f.customer.Id, date(2026, time.February, 1), date(2026, time.February, 28))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statement.Opening != 800 || statement.Closing != 900 {
+		t.Errorf("opening %d, closing %d, want 800 and 900", statement.Opening, statement.Closing)
+	}
+	want := []struct{ amount, balance int }{{-300, 500}, {400, 900}}
+	if len(statement.Movements) != len(want) {
+		t.Fatalf("%d movements, want %d", len(statement.Movements), len(want))
+	}
+	for i, movement := range statement.Movements {
+		if movement.Amount != want[i].amount || movement.Balance != want[i].balance {
+			t.Errorf("movement %d moved %d to %d, want %d to %d", i+1, movement.Amount, movement.Balance, want[i].amount, want[i].balance)
+		}
+	}
+
+	if balance, err := CustomerBalance(f.ctx, f.store, f.customer.Id, date(2026, time.March, 31)); err != nil || balance != 800 {
+		t.Errorf("balance = %d, %v, want 800", balance, err)
+	}
+	if _, err := CustomerStatement(f.ctx, f.store, primitive.NewObjectID(), date(2026, time.January, 1), date(2026, time.March, 1)); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("statement of an unknown customer: %v", err)
+	}
+}
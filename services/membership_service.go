@@ -0,0 +1,78 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrLastOwner is returned when a change would leave an organization without an owner
+var ErrLastOwner = errors.New("organization must keep at least one owner")
+
+// MemberRole is the role userId holds in organizationId, empty when it holds none
+func MemberRole(ctx context.Context, store *repositories.Store, userId string, organizationId primitive.ObjectID) (string, error) {
+	if organizationId.IsZero() {
+		return "", nil
+	}
+	membership, err := store.Memberships.Find(repositories.Unscoped(ctx), userId, organizationId)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return "", nil
+	}
+	return membership.Role, err
+}
+
+// AssignRole changes the role userId holds in the organization ctx is scoped
+// to. Users join an organization through an invitation.
+func AssignRole(ctx context.Context, store *repositories.Store, userId string, role string, assignedBy string) (models.Membership, error) {
+	var membership models.Membership
+	organizationId, _ := repositories.OrganizationFrom(ctx)
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		existing, err := store.Memberships.Find(ctx, userId, organizationId)
+		if err != nil {
+			return fmt.Errorf("membership of %s: %w", userId, err)
+		}
+
+		if err := keepAnOwner(ctx, store, existing, role); err != nil {
+			return err
+		}
+		membership, err = store.Memberships.SetRole(ctx, existing.Id, role, assignedBy)
+		return err
+	})
+	return membership, err
+}
+
+// RevokeRole removes the role userId holds in the organization ctx is scoped to
+func RevokeRole(ctx context.Context, store *repositories.Store, userId string) error {
+	organizationId, _ := repositories.OrganizationFrom(ctx)
+
+	return store.WithTransaction(ctx, func(ctx context.Context) error {
+		existing, err := store.Memberships.Find(ctx, userId, organizationId)
+		if err != nil {
+			return fmt.Errorf("membership of %s: %w", userId, err)
+		}
+		if err := keepAnOwner(ctx, store, existing, ""); err != nil {
+			return err
+		}
+		return store.Memberships.Delete(ctx, existing.Id)
+	})
+}
+
+// keepAnOwner refuses to take the owner role away from the last owner of an organization
+func keepAnOwner(ctx context.Context, store *repositories.Store, membership models.Membership, role string) error {
+	if membership.Role != models.RoleOwner || role == models.RoleOwner {
+		return nil
+	}
+	owners, err := store.Memberships.CountByOrganization(ctx, membership.Organization_id, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
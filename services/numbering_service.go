@@ -0,0 +1,54 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Prefixes of document numbers for organizations that did not choose their own
+const (
+	DefaultInvoicePrefix = "INV"
+	DefaultReceiptPrefix = "RCPT"
+)
+
+// PurchasePrefix heads the numbers of purchase orders
+const PurchasePrefix = "PO"
+
+// NextNumber hands out the next number of a kind of document of the
+// organization, such as INV-2026-000123, counting from 1 again every year.
+// It must be called inside the transaction storing the document: a failed
+// transaction gives the number back, and concurrent ones take turns.
+func NextNumber(ctx context.Context, store *repositories.Store, organizationId primitive.ObjectID, kind string, issued time.Time) (string, error) {
+	organization, err := store.Organizations.FindByID(ctx, organizationId)
+	// entries recorded without an organization are numbered with the default prefixes
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return "", err
+	}
+
+	prefix := DefaultReceiptPrefix
+	if organization.Receipt_prefix != "" {
+		prefix = organization.Receipt_prefix
+	}
+	switch kind {
+	case models.SequenceInvoice:
+		prefix = DefaultInvoicePrefix
+		if organization.Invoice_prefix != "" {
+			prefix = organization.Invoice_prefix
+		}
+	case models.SequencePurchase:
+		prefix = PurchasePrefix
+	}
+
+	year := issued.Local().Year()
+	value, err := store.Sequences.Next(ctx, organizationId, kind, year)
+	if err != nil {
+		return "", fmt.Errorf("%s number: %w", kind, err)
+	}
+	return fmt.Sprintf("%s-%d-%06d", prefix, year, value), nil
+}
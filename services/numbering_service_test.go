@@ -0,0 +1,88 @@
+package services
+
+import (
+	"appadming/models"
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNextNumber(t *testing.T) {
+	tests := []struct {
+		name         string
+		organization models.Orgnization
+		issues       []string
+		issued       []time.Time
+		want         []string
+	}{
+		{
+			name:   "default prefixes count per kind",
+			issues: []string{models.SequenceInvoice, models.SequenceInvoice, models.SequenceReceipt, models.SequencePurchase},
+			want:   []string{"INV-2026-000001", "INV-2026-000002", "RCPT-2026-000001", "PO-2026-000001"},
+		},
+		{
+			name:         "organization prefixes",
+			organization: models.Orgnization{Invoice_prefix: "SH", Receipt_prefix: "MR"},
+			issues:       []string{models.SequenceInvoice, models.SequenceReceipt, models.SequencePurchase},
+			want:         []string{"SH-2026-000001", "MR-2026-000001", "PO-2026-000001"},
+		},
+		{
+			name:   "every year starts from one",
+			issues: []string{models.SequenceInvoice, models.SequenceInvoice, models.SequenceInvoice},
+			issued: []time.Time{date(2026, time.December, 31), date(2027, time.January, 1), date(2027, time.January, 2)},
+			want:   []string{"INV-2026-000001", "INV-2027-000001", "INV-2027-000002"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, tt.organization)
+			for i, kind := range tt.issues {
+				issued := date(2026, time.March, 1)
+				if tt.issued != nil {
+					issued = tt.issued[i]
+				}
+				got, err := NextNumber(f.ctx, f.store, f.organization.Id, kind, issued)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want[i] {
+					t.Errorf("number %d = %s, want %s", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNextNumberOfUnknownOrganization(t *testing.T) {
+	f := newFixture(t, models.Orgnization{Invoice_prefix: "SH"})
+	got, err := NextNumber(f.ctx, f.store, primitive.NewObjectID(), models.SequenceInvoice, date(2026, time.March, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "INV-2026-000001" {
+		t.Errorf("number = %s, want INV-2026-000001", got)
+	}
+}
+
+func TestNextNumberIsGivenBackByAFailedTransaction(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	issued := date(2026, time.March, 1)
+	err := f.store.WithTransaction(f.ctx, func(ctx context.Context) error {
+		if _, err := NextNumber(ctx, f.store, f.organization.Id, models.SequenceReceipt, issued); err != nil {
+			return err
+		}
+		return ErrInvalidSell
+	})
+	if err != ErrInvalidSell {
+		t.Fatalf("transaction error = %v", err)
+	}
+	got, err := NextNumber(f.ctx, f.store, f.organization.Id, models.SequenceReceipt, issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "RCPT-2026-000001" {
+		t.Errorf("number after rollback = %s, want RCPT-2026-000001", got)
+	}
+}
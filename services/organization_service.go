@@ -0,0 +1,182 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidInvitation is returned for unknown, expired, used or misaddressed invitation tokens
+var ErrInvalidInvitation = errors.New("invalid invitation")
+
+// InvitationLifetime is how long an invitation token can be accepted
+const InvitationLifetime = 7 * 24 * time.Hour
+
+// CreateOrganization registers an organization owned by userId
+func CreateOrganization(ctx context.Context, store *repositories.Store, organization models.Orgnization, userId string) (models.Orgnization, error) {
+	creator, _ := primitive.ObjectIDFromHex(userId)
+	newOrganization := models.Orgnization{
+		Id:              primitive.NewObjectID(),
+		Name:            organization.Name,
+		Address:         organization.Address,
+		Phone:           organization.Phone,
+		Email:           organization.Email,
+		User_id:         creator,
+		Designation:     organization.Designation,
+		Invoice_prefix:  organization.Invoice_prefix,
+		Receipt_prefix:  organization.Receipt_prefix,
+		Invoice_footer:  organization.Invoice_footer,
+		Costing_method:  organization.Costing_method,
+		Reorder_windows: organization.Reorder_windows,
+		Stockout_days:   organization.Stockout_days,
+
+		Guarantor_threshold: organization.Guarantor_threshold,
+	}
+	newOrganization.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	// the owner membership belongs to the new organization, not to the caller's active one
+	ctx = repositories.Unscoped(ctx)
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := store.Organizations.Create(ctx, &newOrganization); err != nil {
+			return err
+		}
+		return store.Memberships.Create(ctx, &models.Membership{
+			Id:              primitive.NewObjectID(),
+			User_id:         userId,
+			Organization_id: newOrganization.Id,
+			Role:            models.RoleOwner,
+			Designation:     organization.Designation,
+			Created_at:      newOrganization.Created_at,
+			Updated_at:      newOrganization.Created_at,
+		})
+	})
+	return newOrganization, err
+}
+
+// IsMember reports whether userId belongs to organizationId
+func IsMember(ctx context.Context, store *repositories.Store, userId string, organizationId primitive.ObjectID) (bool, error) {
+	_, err := store.Memberships.Find(repositories.Unscoped(ctx), userId, organizationId)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// UserOrganizations lists every organization userId belongs to
+func UserOrganizations(ctx context.Context, store *repositories.Store, userId string) ([]models.Orgnization, error) {
+	ctx = repositories.Unscoped(ctx)
+	memberships, err := store.Memberships.FindByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	organizations := []models.Orgnization{}
+	for _, membership := range memberships {
+		organization, err := store.Organizations.FindByID(ctx, membership.Organization_id)
+		if errors.Is(err, repositories.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		organizations = append(organizations, organization)
+	}
+	return organizations, nil
+}
+
+// SwitchOrganization makes organizationId the active organization of userId
+// and returns the role it holds there
+func SwitchOrganization(ctx context.Context, store *repositories.Store, userId string, organizationId primitive.ObjectID) (models.Membership, error) {
+	membership, err := store.Memberships.Find(repositories.Unscoped(ctx), userId, organizationId)
+	if err != nil {
+		return membership, fmt.Errorf("membership of %s: %w", organizationId.Hex(), err)
+	}
+	_, err = store.Users.SetOrganization(ctx, userId, organizationId)
+	return membership, err
+}
+
+// CreateInvitation issues a token inviting email into the organization ctx is scoped to
+func CreateInvitation(ctx context.Context, store *repositories.Store, invitation models.Invitation, invitedBy string) (models.Invitation, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return invitation, err
+	}
+
+	now := time.Now()
+	newInvitation := models.Invitation{
+		Id:          primitive.NewObjectID(),
+		Email:       strings.ToLower(invitation.Email),
+		Role:        invitation.Role,
+		Designation: invitation.Designation,
+		Token:       hex.EncodeToString(secret),
+		Invited_by:  invitedBy,
+		Created_at:  now,
+		Expires_at:  now.Add(InvitationLifetime),
+	}
+	err := store.Invitations.Create(ctx, &newInvitation)
+	return newInvitation, err
+}
+
+// CheckInvitation returns the invitation behind token if email can still accept it
+func CheckInvitation(ctx context.Context, store *repositories.Store, token string, email *string) (models.Invitation, error) {
+	invitation, err := store.Invitations.FindByToken(repositories.Unscoped(ctx), token)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return invitation, ErrInvalidInvitation
+	}
+	if err != nil {
+		return invitation, err
+	}
+	if invitation.Accepted_at != nil || time.Now().After(invitation.Expires_at) {
+		return invitation, fmt.Errorf("%w: the invitation was used or has expired", ErrInvalidInvitation)
+	}
+	if email == nil || !strings.EqualFold(*email, invitation.Email) {
+		return invitation, fmt.Errorf("%w: the invitation was sent to another email", ErrInvalidInvitation)
+	}
+	return invitation, nil
+}
+
+// AcceptInvitation adds user to the organization the token invites them to
+// and makes it their active organization
+func AcceptInvitation(ctx context.Context, store *repositories.Store, token string, user models.User) (models.Membership, error) {
+	var membership models.Membership
+	ctx = repositories.Unscoped(ctx)
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		invitation, err := CheckInvitation(ctx, store, token, user.Email)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+
+		membership, err = store.Memberships.Find(ctx, user.User_id, invitation.Organization_id)
+		if errors.Is(err, repositories.ErrNotFound) {
+			membership = models.Membership{
+				Id:              primitive.NewObjectID(),
+				User_id:         user.User_id,
+				Organization_id: invitation.Organization_id,
+				Role:            invitation.Role,
+				Designation:     invitation.Designation,
+				Assigned_by:     invitation.Invited_by,
+				Created_at:      now,
+				Updated_at:      now,
+			}
+			err = store.Memberships.Create(ctx, &membership)
+		}
+		if err != nil {
+			return err
+		}
+		if err := store.Invitations.MarkAccepted(ctx, invitation.Id, user.User_id, now); err != nil {
+			return err
+		}
+		_, err = store.Users.SetOrganization(ctx, user.User_id, invitation.Organization_id)
+		return err
+	})
+	return membership, err
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"appadming/blobstore"
+	"appadming/imaging"
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxProductImages is how many pictures a product can have
+const MaxProductImages = 12
+
+// AddProductImages checks every upload is a picture, stores it with its
+// thumbnail in blobs and appends it to the pictures of the product in the
+// order given. Nothing is kept unless every upload is.
+func AddProductImages(ctx context.Context, store *repositories.Store, blobs blobstore.Store, productId primitive.ObjectID, uploads [][]byte, userId primitive.ObjectID, now time.Time) ([]models.ProductImage, error) {
+	if len(uploads) == 0 {
+		return nil, fmt.Errorf("%w: no images", imaging.ErrInvalidImage)
+	}
+	product, err := store.Products.FindByID(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := store.Images.FindByProduct(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing)+len(uploads) > MaxProductImages {
+		return nil, fmt.Errorf("%w: a product takes at most %d images", imaging.ErrInvalidImage, MaxProductImages)
+	}
+
+	added := make([]models.ProductImage, len(uploads))
+	var stored []string
+	for i, data := range uploads {
+		info, err := imaging.Check(data)
+		if err == nil {
+			added[i], err = storeImage(ctx, blobs, product, data, info, &stored)
+		}
+		if err != nil {
+			deleteBlobs(blobs, stored)
+			return nil, fmt.Errorf("image %d: %w", i+1, err)
+		}
+		added[i].Position = len(existing) + i
+		added[i].User_id = userId
+		added[i].Created_at = now
+	}
+
+	err = store.WithTransaction(ctx, func(ctx context.Context) error {
+		for i := range added {
+			if err := store.Images.Create(ctx, &added[i]); err != nil {
+				return err
+			}
+		}
+		return syncImageURL(ctx, store, product, append(existing, added...))
+	})
+	if err != nil {
+		deleteBlobs(blobs, stored)
+		return nil, err
+	}
+	return added, nil
+}
+
+// storeImage puts a checked picture and its thumbnail into blobs, recording
+// their keys in stored
+func storeImage(ctx context.Context, blobs blobstore.Store, product models.Product, data []byte, info imaging.Info, stored *[]string) (models.ProductImage, error) {
+	thumbnail, thumbnailType, err := imaging.Thumbnail(data, imaging.ThumbnailSize)
+	if err != nil {
+		return models.ProductImage{}, err
+	}
+	id := primitive.NewObjectID()
+	key := fmt.Sprintf("products/%s/%s/%s", product.Seller_id.Hex(), product.Id.Hex(), id.Hex())
+	url := fmt.Sprintf("/products/%s/images/%s", product.Id.Hex(), id.Hex())
+	image := models.ProductImage{
+		Id:             id,
+		Product_id:     product.Id,
+		Content_type:   info.ContentType,
+		Size:           len(data),
+		Width:          info.Width,
+		Height:         info.Height,
+		Url:            url,
+		Thumbnail_url:  url + "/thumbnail",
+		Key:            key,
+		Thumbnail_key:  key + "-thumbnail",
+		Thumbnail_type: thumbnailType,
+	}
+	if err := blobs.Put(ctx, image.Key, data, image.Content_type); err != nil {
+		return image, err
+	}
+	*stored = append(*stored, image.Key)
+	if err := blobs.Put(ctx, image.Thumbnail_key, thumbnail, image.Thumbnail_type); err != nil {
+		return image, err
+	}
+	*stored = append(*stored, image.Thumbnail_key)
+	return image, nil
+}
+
+// FindProductImage finds a picture of productId
+func FindProductImage(ctx context.Context, store *repositories.Store, productId primitive.ObjectID, imageId primitive.ObjectID) (models.ProductImage, error) {
+	image, err := store.Images.FindByID(ctx, imageId)
+	if err == nil && image.Product_id != productId {
+		err = repositories.ErrNotFound
+	}
+	return image, err
+}
+
+// OrderProductImages puts the pictures of a product in the order of ids,
+// which must name each of them once
+func OrderProductImages(ctx context.Context, store *repositories.Store, productId primitive.ObjectID, ids []primitive.ObjectID) ([]models.ProductImage, error) {
+	var ordered []models.ProductImage
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		product, err := store.Products.FindByID(ctx, productId)
+		if err != nil {
+			return err
+		}
+		images, err := store.Images.FindByProduct(ctx, productId)
+		if err != nil {
+			return err
+		}
+		position := map[primitive.ObjectID]int{}
+		for i, id := range ids {
+			position[id] = i
+		}
+		if len(ids) != len(images) || len(position) != len(images) {
+			return fmt.Errorf("%w: the order must name each of the %d images once", imaging.ErrInvalidImage, len(images))
+		}
+		ordered = make([]models.ProductImage, len(images))
+		for _, image := range images {
+			i, ok := position[image.Id]
+			if !ok {
+				return fmt.Errorf("%w: %s is not an image of the product", imaging.ErrInvalidImage, image.Id.Hex())
+			}
+			if ordered[i], err = store.Images.SetPosition(ctx, image.Id, i); err != nil {
+				return err
+			}
+		}
+		return syncImageURL(ctx, store, product, ordered)
+	})
+	return ordered, err
+}
+
+// DeleteProductImage removes a picture of a product, closing the gap it
+// leaves in the order, and then its blobs
+func DeleteProductImage(ctx context.Context, store *repositories.Store, blobs blobstore.Store, productId primitive.ObjectID, imageId primitive.ObjectID) error {
+	var deleted models.ProductImage
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		product, err := store.Products.FindByID(ctx, productId)
+		if err != nil {
+			return err
+		}
+		if deleted, err = FindProductImage(ctx, store, productId, imageId); err != nil {
+			return err
+		}
+		if err := store.Images.Delete(ctx, imageId); err != nil {
+			return err
+		}
+		images, err := store.Images.FindByProduct(ctx, productId)
+		if err != nil {
+			return err
+		}
+		for i := range images {
+			if images[i].Position != i {
+				if images[i], err = store.Images.SetPosition(ctx, images[i].Id, i); err != nil {
+					return err
+				}
+			}
+		}
+		return syncImageURL(ctx, store, product, images)
+	})
+	if err != nil {
+		return err
+	}
+	deleteBlobs(blobs, []string{deleted.Key, deleted.Thumbnail_key})
+	return nil
+}
+
+// DeleteProduct deletes a product with its pictures, and then their blobs
+func DeleteProduct(ctx context.Context, store *repositories.Store, blobs blobstore.Store, productId primitive.ObjectID) error {
+	var keys []string
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		keys = nil
+		images, err := store.Images.FindByProduct(ctx, productId)
+		if err != nil {
+			return err
+		}
+		for _, image := range images {
+			if err := store.Images.Delete(ctx, image.Id); err != nil {
+				return err
+			}
+			keys = append(keys, image.Key, image.Thumbnail_key)
+		}
+		return store.Products.Delete(ctx, productId)
+	})
+	if err != nil {
+		return err
+	}
+	deleteBlobs(blobs, keys)
+	return nil
+}
+
+// syncImageURL points the product at its first picture. A product left
+// without pictures keeps an image URL hosted elsewhere.
+func syncImageURL(ctx context.Context, store *repositories.Store, product models.Product, images []models.ProductImage) error {
+	url := ""
+	if len(images) > 0 {
+		url = images[0].Url
+	} else if !strings.HasPrefix(product.ImageURL, "/products/") {
+		return nil
+	}
+	if product.ImageURL == url {
+		return nil
+	}
+	_, err := store.Products.SetImageURL(ctx, product.Id, url)
+	return err
+}
+
+// deleteBlobs removes blobs that are no longer referenced. Failures only
+// leave unreachable files behind, so they are logged rather than returned.
+func deleteBlobs(blobs blobstore.Store, keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, key := range keys {
+		if err := blobs.Delete(ctx, key); err != nil {
+			log.Printf("could not delete blob %s: %v", key, err)
+		}
+	}
+}
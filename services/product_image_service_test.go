@@ -0,0 +1,64 @@
+package services
+
+import (
+	"appadming/blobstore"
+	"appadming/models"
+	"appadming/repositories"
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDeleteProductDeletesItsImages(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	blobs, err := blobstore.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var picture bytes.Buffer
+	if err := png.Encode(&picture, image.NewRGBA(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatal(err)
+	}
+	phone := f.addProduct(t, "Phone", 1000, 700, 5)
+	charger := f.addProduct(t, "Charger", 100, 40, 3)
+	images, err := AddProductImages(f.ctx, f.store, blobs, phone.Id, [][]byte{picture.Bytes(), picture.Bytes()}, primitive.NewObjectID(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := AddProductImages(f.ctx, f.store, blobs, charger.Id, [][]byte{picture.Bytes()}, primitive.NewObjectID(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteProduct(f.ctx, f.store, blobs, phone.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.store.Products.FindByID(f.ctx, phone.Id); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("deleted product = %v, want ErrNotFound", err)
+	}
+	for _, deleted := range images {
+		if _, err := f.store.Images.FindByID(f.ctx, deleted.Id); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("image of the deleted product = %v, want ErrNotFound", err)
+		}
+		for _, key := range []string{deleted.Key, deleted.Thumbnail_key} {
+			if _, err := blobs.Get(f.ctx, key); !errors.Is(err, blobstore.ErrNotFound) {
+				t.Errorf("blob %s = %v, want ErrNotFound", key, err)
+			}
+		}
+	}
+	if _, err := blobs.Get(f.ctx, kept[0].Key); err != nil {
+		t.Errorf("image of another product = %v", err)
+	}
+	if left, _ := f.store.Images.FindByProduct(f.ctx, charger.Id); len(left) != 1 {
+		t.Errorf("%d images left on the other product, want 1", len(left))
+	}
+
+	if err := DeleteProduct(f.ctx, f.store, blobs, phone.Id); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("deleting a deleted product = %v, want ErrNotFound", err)
+	}
+}
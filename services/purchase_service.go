@@ -0,0 +1,338 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidPurchase is returned when a purchase order, delivery or supplier
+// payment cannot be recorded as submitted
+var ErrInvalidPurchase = errors.New("invalid purchase")
+
+// ErrPurchaseClosed is returned for purchase orders that were fully received or cancelled
+var ErrPurchaseClosed = errors.New("purchase order is closed")
+
+// ErrSupplierOwed is returned when deleting a supplier that is still owed money
+var ErrSupplierOwed = errors.New("supplier account is not settled")
+
+// CreatePurchaseOrder orders lines from a supplier of the organization ctx is
+// scoped to, snapshotting the model of every product. Nothing reaches stock
+// until it is received.
+func CreatePurchaseOrder(ctx context.Context, store *repositories.Store, order models.PurchaseOrder, userId primitive.ObjectID) (models.PurchaseOrder, error) {
+	var newOrder models.PurchaseOrder
+
+	if len(order.Lines) == 0 {
+		return newOrder, fmt.Errorf("%w: no lines", ErrInvalidPurchase)
+	}
+	for _, line := range order.Lines {
+		if line.Product_id.IsZero() || line.Quantity < 1 || line.Unit_cost < 0 {
+			return newOrder, fmt.Errorf("%w: every line needs a product, a positive quantity and a non-negative unit cost", ErrInvalidPurchase)
+		}
+	}
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.Suppliers.FindByID(ctx, order.Supplier_id); err != nil {
+			return fmt.Errorf("supplier %s: %w", order.Supplier_id.Hex(), err)
+		}
+		lines := make([]models.PurchaseLine, len(order.Lines))
+		var total float64
+		for i, line := range order.Lines {
+			product, err := store.Products.FindByID(ctx, line.Product_id)
+			if err != nil {
+				return fmt.Errorf("product %s: %w", line.Product_id.Hex(), err)
+			}
+			lines[i] = models.PurchaseLine{Product_id: product.Id, Model: product.Model, Quantity: line.Quantity, Unit_cost: line.Unit_cost}
+			total += line.Unit_cost * float64(line.Quantity)
+		}
+
+		now := time.Now()
+		organizationId, _ := repositories.OrganizationFrom(ctx)
+		number, err := NextNumber(ctx, store, organizationId, models.SequencePurchase, now)
+		if err != nil {
+			return err
+		}
+		newOrder = models.PurchaseOrder{
+			Id:          primitive.NewObjectID(),
+			Number:      number,
+			Supplier_id: order.Supplier_id,
+			Lines:       lines,
+			Amount:      int(math.Round(total)),
+			Status:      models.PurchaseOpen,
+			Note:        order.Note,
+			User_id:     userId,
+			Date:        primitive.NewDateTimeFromTime(now),
+		}
+		return store.Purchases.Create(ctx, &newOrder)
+	})
+	return newOrder, err
+}
+
+// ReceivePurchaseOrder records a delivery against an order in one
+// transaction: every unit goes into stock through a purchase movement at the
+// unit cost ordered, unless the delivery states another, and the supplier
+// bills what was delivered. Lines of a product are filled in order and no
+// more than what is outstanding can be received. Deliveries of serialized
+// products register every unit by its serial.
+func ReceivePurchaseOrder(ctx context.Context, store *repositories.Store, orderId primitive.ObjectID, request models.ReceiveRequest, userId primitive.ObjectID) (models.PurchaseOrder, error) {
+	var received models.PurchaseOrder
+
+	if len(request.Lines) == 0 {
+		return received, fmt.Errorf("%w: no lines", ErrInvalidPurchase)
+	}
+	for _, line := range request.Lines {
+		if line.Product_id.IsZero() || line.Quantity < 1 || line.Unit_cost < 0 {
+			return received, fmt.Errorf("%w: every line needs a product, a positive quantity and a non-negative unit cost", ErrInvalidPurchase)
+		}
+	}
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		order, err := store.Purchases.FindByID(ctx, orderId)
+		if err != nil {
+			return err
+		}
+		if order.Status == models.PurchaseReceived || order.Status == models.PurchaseCancelled {
+			return ErrPurchaseClosed
+		}
+
+		now := time.Now()
+		date := primitive.NewDateTimeFromTime(now)
+		lines := append([]models.PurchaseLine{}, order.Lines...)
+		var delivered []models.ReceiptLine
+		var value float64
+		for _, want := range request.Lines {
+			left := want.Quantity
+			serials := want.Serials
+			for i := range lines {
+				line := &lines[i]
+				if left == 0 {
+					break
+				}
+				if line.Product_id != want.Product_id || line.Received >= line.Quantity {
+					continue
+				}
+				take := line.Quantity - line.Received
+				if take > left {
+					take = left
+				}
+				cost := line.Unit_cost
+				if want.Unit_cost > 0 {
+					cost = want.Unit_cost
+				}
+				named := take
+				if named > len(serials) {
+					named = len(serials)
+				}
+				_, moved, err := moveStock(ctx, store, models.StockMovement{
+					Product_id:   line.Product_id,
+					Type:         models.MovementPurchase,
+					Quantity:     take,
+					Reason:       "purchase " + order.Number,
+					User_id:      userId,
+					Reference_id: order.Id,
+					Date:         date,
+					Unit_cost:    cost,
+					Serials:      serials[:named],
+				})
+				if err != nil {
+					return err
+				}
+				serials = serials[named:]
+				line.Received += take
+				left -= take
+				delivered = append(delivered, models.ReceiptLine{Product_id: line.Product_id, Quantity: take, Unit_cost: cost, Serials: moved.Serials})
+				value += cost * float64(take)
+			}
+			if left > 0 {
+				return fmt.Errorf("%w: %d more of product %s than is outstanding", ErrInvalidPurchase, left, want.Product_id.Hex())
+			}
+			if len(serials) > 0 {
+				return fmt.Errorf("%w: more serials of product %s than units received", ErrInvalidPurchase, want.Product_id.Hex())
+			}
+		}
+
+		receipt := models.PurchaseReceipt{
+			Id:      primitive.NewObjectID(),
+			Lines:   delivered,
+			Amount:  int(math.Round(value)),
+			User_id: userId,
+			Date:    date,
+			Note:    strings.TrimSpace(request.Note),
+		}
+		if receipt.Amount > 0 {
+			bill := models.Payable{
+				Id:          primitive.NewObjectID(),
+				Type:        models.PayableBill,
+				Owed:        receipt.Amount,
+				Date:        date,
+				Supplier_id: order.Supplier_id,
+				Purchase_id: order.Id,
+				User_id:     userId,
+				Note:        order.Number,
+			}
+			if err := store.Payables.Create(ctx, &bill); err != nil {
+				return err
+			}
+			receipt.Payable_id = bill.Id
+		}
+
+		status := models.PurchaseReceived
+		for _, line := range lines {
+			if line.Received < line.Quantity {
+				status = models.PurchasePartiallyReceived
+			}
+		}
+		received, err = store.Purchases.Receive(ctx, order.Id, lines, receipt, status)
+		return err
+	})
+	return received, err
+}
+
+// CancelPurchaseOrder closes an order so that nothing more is received
+// against it. What was already received stays in stock and on the bill.
+func CancelPurchaseOrder(ctx context.Context, store *repositories.Store, orderId primitive.ObjectID) (models.PurchaseOrder, error) {
+	var cancelled models.PurchaseOrder
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		order, err := store.Purchases.FindByID(ctx, orderId)
+		if err != nil {
+			return err
+		}
+		if order.Status == models.PurchaseReceived || order.Status == models.PurchaseCancelled {
+			return ErrPurchaseClosed
+		}
+		cancelled, err = store.Purchases.SetStatus(ctx, order.Id, models.PurchaseCancelled)
+		return err
+	})
+	return cancelled, err
+}
+
+// PaySupplier records a payment made to a supplier by userId
+func PaySupplier(ctx context.Context, store *repositories.Store, supplierId primitive.ObjectID, payment models.Payable, userId primitive.ObjectID) (models.Payable, error) {
+	var newPayment models.Payable
+
+	if payment.Paid < 1 {
+		return newPayment, fmt.Errorf("%w: paid amount must be positive", ErrInvalidPurchase)
+	}
+	date := payment.Date
+	if date == 0 {
+		date = primitive.NewDateTimeFromTime(time.Now())
+	}
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.Suppliers.FindByID(ctx, supplierId); err != nil {
+			return fmt.Errorf("supplier %s: %w", supplierId.Hex(), err)
+		}
+		if !payment.Purchase_id.IsZero() {
+			order, err := store.Purchases.FindByID(ctx, payment.Purchase_id)
+			if err != nil {
+				return fmt.Errorf("purchase order %s: %w", payment.Purchase_id.Hex(), err)
+			}
+			if order.Supplier_id != supplierId {
+				return fmt.Errorf("%w: purchase order %s is not from this supplier", ErrInvalidPurchase, order.Number)
+			}
+		}
+		newPayment = models.Payable{
+			Id:          primitive.NewObjectID(),
+			Type:        models.PayablePayment,
+			Paid:        payment.Paid,
+			Date:        date,
+			Supplier_id: supplierId,
+			Purchase_id: payment.Purchase_id,
+			User_id:     userId,
+			Note:        payment.Note,
+		}
+		return store.Payables.Create(ctx, &newPayment)
+	})
+	return newPayment, err
+}
+
+// DeleteSupplier removes a supplier once nothing is owed to it, so that the
+// payables report never loses an open balance
+func DeleteSupplier(ctx context.Context, store *repositories.Store, supplierId primitive.ObjectID) error {
+	return store.WithTransaction(ctx, func(ctx context.Context) error {
+		account, err := SupplierAccount(ctx, store, supplierId, time.Now())
+		if err != nil {
+			return err
+		}
+		if account.Balance != 0 {
+			return fmt.Errorf("%w: %d is owed", ErrSupplierOwed, account.Balance)
+		}
+		return store.Suppliers.Delete(ctx, supplierId)
+	})
+}
+
+// PayableAmount is how much a payable entry moves what is owed to the supplier
+func PayableAmount(payable models.Payable) int {
+	return payable.Owed - payable.Paid
+}
+
+// SupplierAccount returns what is owed to a supplier after every entry dated
+// up to asOf, with the entries oldest first
+func SupplierAccount(ctx context.Context, store *repositories.Store, supplierId primitive.ObjectID, asOf time.Time) (models.SupplierAccount, error) {
+	account := models.SupplierAccount{Payables: []models.Payable{}}
+
+	supplier, err := store.Suppliers.FindByID(ctx, supplierId)
+	if err != nil {
+		return account, err
+	}
+	account.Supplier = supplier
+	payables, err := store.Payables.FindBySupplier(ctx, supplierId, asOf)
+	if err != nil {
+		return account, err
+	}
+	for _, payable := range payables {
+		account.Balance += PayableAmount(payable)
+	}
+	if payables != nil {
+		account.Payables = payables
+	}
+	return account, nil
+}
+
+// PayablesReport lists what is owed to every supplier with a balance and sets
+// the total against what customers owe, both as of asOf
+func PayablesReport(ctx context.Context, store *repositories.Store, asOf time.Time) (models.PayablesReport, error) {
+	report := models.PayablesReport{As_of: asOf, Rows: []models.PayablesRow{}}
+
+	suppliers, err := store.Suppliers.FindAll(ctx)
+	if err != nil {
+		return report, err
+	}
+	payables, err := store.Payables.FindAll(ctx)
+	if err != nil {
+		return report, err
+	}
+	historys, err := store.Historys.FindAll(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	limit := primitive.NewDateTimeFromTime(asOf)
+	balances := map[primitive.ObjectID]int{}
+	for _, payable := range payables {
+		if payable.Date <= limit {
+			balances[payable.Supplier_id] += PayableAmount(payable)
+		}
+	}
+	for _, supplier := range suppliers {
+		if balances[supplier.Id] == 0 {
+			continue
+		}
+		report.Rows = append(report.Rows, models.PayablesRow{Supplier_id: supplier.Id, Name: supplier.Name, Balance: balances[supplier.Id]})
+		report.Payables += balances[supplier.Id]
+	}
+	for _, entry := range historys {
+		if entry.Date <= limit {
+			report.Receivables += EntryAmount(entry)
+		}
+	}
+	report.Net = report.Receivables - report.Payables
+	return report, nil
+}
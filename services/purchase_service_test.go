@@ -0,0 +1,190 @@
+package services
+
+import (
+	"appadming/models"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (f *fixture) addSupplier(t *testing.T, name string) models.Supplier {
+	t.Helper()
+	supplier := models.Supplier{Id: primitive.NewObjectID(), Name: name}
+	if err := f.store.Suppliers.Create(f.ctx, &supplier); err != nil {
+		t.Fatal(err)
+	}
+	return supplier
+}
+
+// stock is what is in stock of the product now
+func stock(t *testing.T, f *fixture, productId primitive.ObjectID) int {
+	t.Helper()
+	product, err := f.store.Products.FindByID(f.ctx, productId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return product.Stock
+}
+
+func TestReceivePurchaseOrderInParts(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	supplier := f.addSupplier(t, "Wholesaler")
+	phone := f.addProduct(t, "Phone", 1000, 700, 0)
+	charger := f.addProduct(t, "Charger", 300, 100, 0)
+	userId := primitive.NewObjectID()
+
+	order, err := CreatePurchaseOrder(f.ctx, f.store, models.PurchaseOrder{
+		Supplier_id: supplier.Id,
+		Lines: []models.PurchaseLine{
+			{Product_id: phone.Id, Quantity: 3, Unit_cost: 700},
+			{Product_id: phone.Id, Quantity: 2, Unit_cost: 650},
+			{Product_id: charger.Id, Quantity: 5, Unit_cost: 100},
+		},
+	}, userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Number == "" || order.Amount != 3900 || order.Status != models.PurchaseOpen || order.Lines[0].Model != "Phone" {
+		t.Fatalf("order = %+v", order)
+	}
+	if stock(t, f, phone.Id) != 0 {
+		t.Fatal("ordering brought stock in")
+	}
+
+	// the first line of a product fills up before the next
+	order, err = ReceivePurchaseOrder(f.ctx, f.store, order.Id, models.ReceiveRequest{Lines: []models.ReceiptLine{{Product_id: phone.Id, Quantity: 4}}}, userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != models.PurchasePartiallyReceived || order.Lines[0].Received != 3 || order.Lines[1].Received != 1 {
+		t.Errorf("order after the first delivery = %+v", order)
+	}
+	if len(order.Receipts) != 1 || order.Receipts[0].Amount != 2750 {
+		t.Errorf("receipts = %+v", order.Receipts)
+	}
+	if got := stock(t, f, phone.Id); got != 4 {
+		t.Errorf("phone stock = %d, want 4", got)
+	}
+
+	// a delivery larger than what is outstanding leaves everything as it was
+	_, err = ReceivePurchaseOrder(f.ctx, f.store, order.Id, models.ReceiveRequest{Lines: []models.ReceiptLine{
+		{Product_id: charger.Id, Quantity: 5},
+		{Product_id: phone.Id, Quantity: 2},
+	}}, userId)
+	if !errors.Is(err, ErrInvalidPurchase) {
+		t.Fatalf("over delivery error = %v, want ErrInvalidPurchase", err)
+	}
+	if got := stock(t, f, charger.Id); got != 0 {
+		t.Errorf("charger stock after a failed delivery = %d, want 0", got)
+	}
+
+	// the delivery may be billed at another cost than ordered
+	order, err = ReceivePurchaseOrder(f.ctx, f.store, order.Id, models.ReceiveRequest{Lines: []models.ReceiptLine{
+		{Product_id: phone.Id, Quantity: 1, Unit_cost: 680},
+		{Product_id: charger.Id, Quantity: 5},
+	}}, userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != models.PurchaseReceived || len(order.Receipts) != 2 || order.Receipts[1].Amount != 1180 {
+		t.Errorf("order after the last delivery = %+v", order)
+	}
+	movements, err := f.store.Movements.FindByProduct(f.ctx, phone.Id, time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != 3 || movements[2].Unit_cost != 680 || movements[2].Reference_id != order.Id {
+		t.Errorf("phone movements = %+v", movements)
+	}
+
+	account, err := SupplierAccount(f.ctx, f.store, supplier.Id, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance != 3930 || len(account.Payables) != 2 {
+		t.Errorf("supplier account = %+v, want 3930 owed on two bills", account)
+	}
+
+	if _, err := ReceivePurchaseOrder(f.ctx, f.store, order.Id, models.ReceiveRequest{Lines: []models.ReceiptLine{{Product_id: phone.Id, Quantity: 1}}}, userId); !errors.Is(err, ErrPurchaseClosed) {
+		t.Errorf("receiving a received order: error = %v, want ErrPurchaseClosed", err)
+	}
+	if _, err := CancelPurchaseOrder(f.ctx, f.store, order.Id); !errors.Is(err, ErrPurchaseClosed) {
+		t.Errorf("cancelling a received order: error = %v, want ErrPurchaseClosed", err)
+	}
+}
+
+func TestCancelledPurchaseOrderKeepsWhatArrived(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	supplier := f.addSupplier(t, "Wholesaler")
+	phone := f.addProduct(t, "Phone", 1000, 700, 0)
+	userId := primitive.NewObjectID()
+
+	order, err := CreatePurchaseOrder(f.ctx, f.store, models.PurchaseOrder{Supplier_id: supplier.Id, Lines: []models.PurchaseLine{{Product_id: phone.Id, Quantity: 5, Unit_cost: 700}}}, userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReceivePurchaseOrder(f.ctx, f.store, order.Id, models.ReceiveRequest{Lines: []models.ReceiptLine{{Product_id: phone.Id, Quantity: 2}}}, userId); err != nil {
+		t.Fatal(err)
+	}
+	order, err = CancelPurchaseOrder(f.ctx, f.store, order.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != models.PurchaseCancelled {
+		t.Errorf("status = %s, want cancelled", order.Status)
+	}
+	if _, err := ReceivePurchaseOrder(f.ctx, f.store, order.Id, models.ReceiveRequest{Lines: []models.ReceiptLine{{Product_id: phone.Id, Quantity: 1}}}, userId); !errors.Is(err, ErrPurchaseClosed) {
+		t.Errorf("receiving a cancelled order: error = %v, want ErrPurchaseClosed", err)
+	}
+	if got := stock(t, f, phone.Id); got != 2 {
+		t.Errorf("stock = %d, want the 2 received", got)
+	}
+}
+
+func TestSupplierPayables(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	wholesaler := f.addSupplier(t, "Wholesaler")
+	importer := f.addSupplier(t, "Importer")
+	phone := f.addProduct(t, "Phone", 1000, 700, 0)
+	userId := primitive.NewObjectID()
+
+	order, err := CreatePurchaseOrder(f.ctx, f.store, models.PurchaseOrder{Supplier_id: wholesaler.Id, Lines: []models.PurchaseLine{{Product_id: phone.Id, Quantity: 5, Unit_cost: 700}}}, userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReceivePurchaseOrder(f.ctx, f.store, order.Id, models.ReceiveRequest{Lines: []models.ReceiptLine{{Product_id: phone.Id, Quantity: 5}}}, userId); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PaySupplier(f.ctx, f.store, wholesaler.Id, models.Payable{Paid: 2000, Purchase_id: order.Id}, userId); err != nil {
+		t.Fatal(err)
+	}
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 1000, Date: dateTime(2026, time.January, 5)})
+
+	if _, err := PaySupplier(f.ctx, f.store, importer.Id, models.Payable{Paid: 100, Purchase_id: order.Id}, userId); !errors.Is(err, ErrInvalidPurchase) {
+		t.Errorf("paying for another supplier's order: error = %v, want ErrInvalidPurchase", err)
+	}
+	if _, err := PaySupplier(f.ctx, f.store, importer.Id, models.Payable{}, userId); !errors.Is(err, ErrInvalidPurchase) {
+		t.Errorf("paying nothing: error = %v, want ErrInvalidPurchase", err)
+	}
+
+	report, err := PayablesReport(f.ctx, f.store, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// suppliers without a balance are left out
+	if len(report.Rows) != 1 || report.Rows[0].Name != "Wholesaler" || report.Payables != 1500 || report.Receivables != 1000 || report.Net != -500 {
+		t.Errorf("report = %+v", report)
+	}
+
+	if err := DeleteSupplier(f.ctx, f.store, wholesaler.Id); !errors.Is(err, ErrSupplierOwed) {
+		t.Errorf("deleting an owed supplier: error = %v, want ErrSupplierOwed", err)
+	}
+	if _, err := PaySupplier(f.ctx, f.store, wholesaler.Id, models.Payable{Paid: 1500}, userId); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteSupplier(f.ctx, f.store, wholesaler.Id); err != nil {
+		t.Errorf("deleting a settled supplier: %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Defaults for organizations that did not configure reorder suggestions
+var (
+	DefaultReorderWindows = []int{7, 30, 90}
+	DefaultStockoutDays   = 14
+)
+
+// reorderSettings are the velocity windows and stockout horizon of an organization
+func reorderSettings(ctx context.Context, store *repositories.Store) ([]int, int, error) {
+	windows, days := DefaultReorderWindows, DefaultStockoutDays
+	organizationId, ok := repositories.OrganizationFrom(ctx)
+	if !ok {
+		return windows, days, nil
+	}
+	organization, err := store.Organizations.FindByID(ctx, organizationId)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return windows, days, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(organization.Reorder_windows) > 0 {
+		windows = append([]int{}, organization.Reorder_windows...)
+		sort.Ints(windows)
+	}
+	if organization.Stockout_days > 0 {
+		days = organization.Stockout_days
+	}
+	return windows, days, nil
+}
+
+// ReorderSuggestions computes which products of the organization ctx is
+// scoped to should be ordered again. Sales velocity is measured over every
+// window, net of returns, and the fastest is projected forward so a recent
+// rush is not averaged away. A product is flagged once its stock is at its
+// reorder point or it is projected to run out within the stockout horizon;
+// the suggested quantity brings it back to the reorder point plus the
+// horizon's demand, less what open purchase orders still bring in.
+func ReorderSuggestions(ctx context.Context, store *repositories.Store, now time.Time) ([]models.ReorderSuggestion, error) {
+	windows, horizon, err := reorderSettings(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	products, err := store.Products.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := store.Purchases.FindOpen(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	longest := now.AddDate(0, 0, -windows[len(windows)-1])
+	sold := map[primitive.ObjectID][]int{}
+	count := func(productId primitive.ObjectID, date time.Time, quantity int) {
+		if date.Before(longest) || date.After(now) {
+			return
+		}
+		if sold[productId] == nil {
+			sold[productId] = make([]int, len(windows))
+		}
+		for i, days := range windows {
+			if !date.Before(now.AddDate(0, 0, -days)) {
+				sold[productId][i] += quantity
+			}
+		}
+	}
+	// older sales still count the products returned from them within the windows
+	err = store.Sells.StreamBetween(ctx, longest, now, func(sell models.SellInfo) error {
+		for _, line := range sell.Lines {
+			count(line.Product_id, sell.Date.Time(), line.Quantity)
+		}
+		for _, reversal := range sell.Reversals {
+			for _, line := range reversal.Lines {
+				count(line.Product_id, reversal.Date.Time(), -line.Quantity)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	incoming := map[primitive.ObjectID]int{}
+	for _, order := range orders {
+		for _, line := range order.Lines {
+			incoming[line.Product_id] += line.Quantity - line.Received
+		}
+	}
+
+	suggestions := []models.ReorderSuggestion{}
+	for _, product := range products {
+		suggestion := models.ReorderSuggestion{
+			Id:              primitive.NewObjectID(),
+			Organization_id: product.Seller_id,
+			Product_id:      product.Id,
+			Model:           product.Model,
+			Stock:           product.Stock,
+			Reorder_point:   product.Reorder_point,
+			Incoming:        incoming[product.Id],
+			Velocities:      make([]models.Velocity, len(windows)),
+			Computed_at:     now,
+		}
+		for i, days := range windows {
+			var quantity int
+			if sold[product.Id] != nil {
+				quantity = sold[product.Id][i]
+			}
+			perDay := math.Max(float64(quantity)/float64(days), 0)
+			suggestion.Velocities[i] = models.Velocity{Days: days, Sold: quantity, Per_day: roundCents(perDay)}
+			suggestion.Per_day = math.Max(suggestion.Per_day, perDay)
+		}
+		if suggestion.Per_day > 0 {
+			daysLeft := math.Max(float64(product.Stock), 0) / suggestion.Per_day
+			stockout := now.Add(time.Duration(daysLeft * float64(24*time.Hour)))
+			suggestion.Stockout_date = &stockout
+			suggestion.Projected_stockout = daysLeft <= float64(horizon)
+			daysLeft = roundCents(daysLeft)
+			suggestion.Days_left = &daysLeft
+		}
+		suggestion.Below_reorder_point = product.Reorder_point > 0 && product.Stock <= product.Reorder_point
+		if !suggestion.Below_reorder_point && !suggestion.Projected_stockout {
+			continue
+		}
+		demand := int(math.Ceil(suggestion.Per_day * float64(horizon)))
+		if quantity := product.Reorder_point + demand - product.Stock - suggestion.Incoming; quantity > 0 {
+			suggestion.Suggested_quantity = quantity
+		}
+		suggestion.Per_day = roundCents(suggestion.Per_day)
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, nil
+}
+
+// RefreshReorderSuggestions recomputes the reorder suggestions of the
+// organization ctx is scoped to, keeps them for GET
+// /inventory/reorder-suggestions and notifies the owners and managers about
+// every product that was not flagged the last time
+func RefreshReorderSuggestions(ctx context.Context, store *repositories.Store, now time.Time) ([]models.ReorderSuggestion, error) {
+	suggestions, err := ReorderSuggestions(ctx, store, now)
+	if err != nil {
+		return nil, err
+	}
+	err = store.WithTransaction(ctx, func(ctx context.Context) error {
+		previous, err := store.Reorders.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+		flagged := map[primitive.ObjectID]bool{}
+		for _, suggestion := range previous {
+			flagged[suggestion.Product_id] = true
+		}
+		if err := store.Reorders.Replace(ctx, suggestions); err != nil {
+			return err
+		}
+
+		var recipients []string
+		for _, suggestion := range suggestions {
+			if flagged[suggestion.Product_id] {
+				continue
+			}
+			if recipients == nil {
+				if recipients, err = stockManagers(ctx, store); err != nil {
+					return err
+				}
+			}
+			for _, userId := range recipients {
+				notification := models.Notification{
+					Id:         primitive.NewObjectID(),
+					User_id:    userId,
+					Type:       models.NotificationLowStock,
+					Product_id: suggestion.Product_id,
+					Message:    lowStockMessage(suggestion),
+					Created_at: now,
+				}
+				if err := store.Notifications.Create(ctx, &notification); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return suggestions, err
+}
+
+// stockManagers are the users told about products running low
+func stockManagers(ctx context.Context, store *repositories.Store) ([]string, error) {
+	memberships, err := store.Memberships.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users := []string{}
+	for _, membership := range memberships {
+		if membership.Role == models.RoleOwner || membership.Role == models.RoleManager {
+			users = append(users, membership.User_id)
+		}
+	}
+	return users, nil
+}
+
+func lowStockMessage(suggestion models.ReorderSuggestion) string {
+	if suggestion.Below_reorder_point {
+		return fmt.Sprintf("%s is at %d in stock, reorder point %d: order %d", suggestion.Model, suggestion.Stock, suggestion.Reorder_point, suggestion.Suggested_quantity)
+	}
+	return fmt.Sprintf("%s is projected to run out by %s: order %d", suggestion.Model, suggestion.Stockout_date.Format("2006-01-02"), suggestion.Suggested_quantity)
+}
+
+// WatchStock refreshes the reorder suggestions of every organization now and
+// then every interval, for as long as the process runs
+func WatchStock(store *repositories.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		refreshAllReorderSuggestions(store)
+		<-ticker.C
+	}
+}
+
+func refreshAllReorderSuggestions(store *repositories.Store) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	organizations, err := store.Organizations.FindAll(ctx)
+	if err != nil {
+		log.Println("could not list organizations for reorder suggestions:", err)
+		return
+	}
+	for _, organization := range organizations {
+		orgCtx := repositories.WithOrganization(ctx, organization.Id)
+		if _, err := RefreshReorderSuggestions(orgCtx, store, time.Now()); err != nil {
+			log.Printf("could not refresh reorder suggestions of %s: %v", organization.Id.Hex(), err)
+		}
+	}
+}
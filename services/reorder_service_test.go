@@ -0,0 +1,47 @@
+package services
+
+import (
+	"appadming/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReorderSuggestionsCountTheWindows(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phone := f.addProduct(t, "Phone", 1000, 700, 2)
+	now := date(2026, time.June, 30)
+	daysAgo := func(days int) primitive.DateTime { return primitive.NewDateTimeFromTime(now.AddDate(0, 0, -days)) }
+
+	for _, sell := range []models.SellInfo{
+		// before every window, but one unit comes back within them
+		{Date: daysAgo(100), Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 5}},
+			Reversals: []models.SellReversal{{Type: models.ReversalReturn, Date: daysAgo(5), Lines: []models.ReturnLine{{Product_id: phone.Id, Quantity: 1}}}}},
+		{Date: daysAgo(200), Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 50}}},
+		{Date: daysAgo(3), Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 4}}},
+		{Date: daysAgo(20), Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 6}}},
+	} {
+		sell.Id = primitive.NewObjectID()
+		sell.Customer_id = f.customer.Id
+		if err := f.store.Sells.Create(f.ctx, &sell); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	suggestions, err := ReorderSuggestions(f.ctx, f.store, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 {
+		t.Fatalf("%d suggestions, want 1", len(suggestions))
+	}
+	for i, want := range []int{3, 9, 9} {
+		if got := suggestions[0].Velocities[i]; got.Sold != want {
+			t.Errorf("sold over %d days = %d, want %d", got.Days, got.Sold, want)
+		}
+	}
+	if !suggestions[0].Projected_stockout {
+		t.Errorf("suggestion = %+v, want a projected stockout", suggestions[0])
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AgingFilter narrows the aging report, zero fields match everything
+type AgingFilter struct {
+	Organization_id primitive.ObjectID
+	District        string
+	Thana           string
+	As_of           time.Time
+}
+
+// receivable is an amount the customer owes from a due date on
+type receivable struct {
+	due    time.Time
+	amount int
+}
+
+// AgingReport buckets every customer's outstanding balance by days past due.
+// Payments settle the oldest receivables first. A sale with an installment
+// plan is owed per installment, less payments and return credits posted on it
+// since, while refunds of it are owed like any other entry: from the entry
+// date or the customer's due date, whichever is later.
+func AgingReport(ctx context.Context, store *repositories.Store, filter AgingFilter) (models.AgingReport, error) {
+	report := models.AgingReport{As_of: filter.As_of, Rows: []models.AgingRow{}}
+
+	customers, err := store.Customers.FindAll(ctx)
+	if err != nil {
+		return report, err
+	}
+	historys, err := store.Historys.FindAll(ctx)
+	if err != nil {
+		return report, err
+	}
+	plans, err := store.Plans.FindAll(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	dueDates := map[primitive.ObjectID]time.Time{}
+	for _, customer := range customers {
+		dueDates[customer.Id] = customer.DueDate.Time()
+	}
+
+	debits := map[primitive.ObjectID][]receivable{}
+	credits := map[primitive.ObjectID]int{}
+	planned := map[primitive.ObjectID]time.Time{}
+	for _, plan := range plans {
+		if plan.Created_at.After(filter.As_of) || !matchesOrganization(filter, plan.Organization_id) {
+			continue
+		}
+		planned[plan.Sell_id] = plan.Created_at
+		for _, installment := range plan.Schedule {
+			debits[plan.Customer_id] = append(debits[plan.Customer_id], receivable{installment.Due_date.Time(), installment.Amount})
+		}
+	}
+	for _, entry := range historys {
+		if entry.Date.Time().After(filter.As_of) || !matchesOrganization(filter, entry.Seller_id) || voided(entry) {
+			continue
+		}
+		if created, ok := planned[entry.Sell_id]; ok && (entry.Type == models.HistorySale || entry.Type == models.HistoryAdjustment || entry.Date.Time().Before(created)) {
+			// the plan schedule carries the sale and its markup, net of
+			// whatever was posted on it before the plan
+			continue
+		}
+		if entry.Due > 0 {
+			due := entry.Date.Time()
+			if dueDates[entry.Customer_id].After(due) {
+				due = dueDates[entry.Customer_id]
+			}
+			debits[entry.Customer_id] = append(debits[entry.Customer_id], receivable{due, entry.Due})
+		}
+		credits[entry.Customer_id] += entry.Paid
+	}
+
+	for _, customer := range customers {
+		if filter.District != "" && !strings.EqualFold(customer.District, filter.District) {
+			continue
+		}
+		if filter.Thana != "" && !strings.EqualFold(customer.Thana, filter.Thana) {
+			continue
+		}
+		buckets := ageReceivables(debits[customer.Id], credits[customer.Id], filter.As_of)
+		if buckets.Total <= 0 {
+			continue
+		}
+		report.Rows = append(report.Rows, models.AgingRow{
+			Customer_id:  customer.Id,
+			Name:         customer.Name,
+			District:     customer.District,
+			Thana:        customer.Thana,
+			AgingBuckets: buckets,
+		})
+		addBuckets(&report.Totals, buckets)
+	}
+	return report, nil
+}
+
+func matchesOrganization(filter AgingFilter, organizationId primitive.ObjectID) bool {
+	return filter.Organization_id.IsZero() || filter.Organization_id == organizationId
+}
+
+// ageReceivables settles credit against the oldest receivables and buckets what remains
+func ageReceivables(debits []receivable, credit int, asOf time.Time) models.AgingBuckets {
+	var buckets models.AgingBuckets
+	sort.SliceStable(debits, func(i, j int) bool { return debits[i].due.Before(debits[j].due) })
+	for _, debit := range debits {
+		open := debit.amount
+		settled := credit
+		if settled > open {
+			settled = open
+		}
+		open -= settled
+		credit -= settled
+		if open == 0 {
+			continue
+		}
+
+		days := int(asOf.Sub(debit.due).Hours() / 24)
+		switch {
+		case days < 1:
+			buckets.Current += open
+		case days <= 30:
+			buckets.Days1_30 += open
+		case days <= 60:
+			buckets.Days31_60 += open
+		case days <= 90:
+			buckets.Days61_90 += open
+		default:
+			buckets.Over90 += open
+		}
+		buckets.Total += open
+	}
+	return buckets
+}
+
+func addBuckets(total *models.AgingBuckets, buckets models.AgingBuckets) {
+	total.Current += buckets.Current
+	total.Days1_30 += buckets.Days1_30
+	total.Days31_60 += buckets.Days31_60
+	total.Days61_90 += buckets.Days61_90
+	total.Over90 += buckets.Over90
+	total.Total += buckets.Total
+}
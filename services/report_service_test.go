@@ -0,0 +1,87 @@
+package services
+
+import (
+	"appadming/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAgingReport(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	asOf := date(2026, time.March, 15)
+
+	// pays on account against the oldest sale; a sale made today is current
+	paying := f.customer
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 1000, Paid: 200, Date: dateTime(2026, time.January, 1), Sell_id: primitive.NewObjectID()})
+	f.addHistory(t, models.History{Type: models.HistoryPayment, Paid: 300, Date: dateTime(2026, time.February, 1)})
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 400, Date: dateTime(2026, time.March, 15), Sell_id: primitive.NewObjectID()})
+
+	// owes from the customer's due date, later than the sale
+	late := f.addCustomer(t, "Karim", date(2026, time.March, 1))
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 600, Date: dateTime(2025, time.December, 1), Customer_id: late.Id})
+
+	settled := f.addCustomer(t, "Jamal", time.Time{})
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 600, Paid: 600, Date: dateTime(2026, time.January, 5), Customer_id: settled.Id})
+
+	old := f.addCustomer(t, "Selim", time.Time{})
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 500, Date: dateTime(2025, time.November, 1), Customer_id: old.Id})
+	// entries after the report date are left out
+	f.addHistory(t, models.History{Type: models.HistoryPayment, Paid: 500, Date: dateTime(2026, time.March, 20), Customer_id: old.Id})
+
+	// a planned sale is owed per installment, less payments and returns on it;
+	// its ledger balance agrees with the buckets
+	planned := f.addCustomer(t, "Nasrin", time.Time{})
+	sellId := primitive.NewObjectID()
+	f.addHistory(t, models.History{Type: models.HistorySale, Due: 1200, Date: dateTime(2026, time.January, 1), Customer_id: planned.Id, Sell_id: sellId})
+	f.addHistory(t, models.History{Type: models.HistoryPayment, Paid: 400, Date: dateTime(2026, time.February, 1), Customer_id: planned.Id, Sell_id: sellId})
+	f.addHistory(t, models.History{Type: models.HistoryReturn, Paid: 300, Date: dateTime(2026, time.March, 1), Customer_id: planned.Id, Sell_id: sellId})
+	// cash handed back for the return is owed from the day it was refunded
+	f.addHistory(t, models.History{Type: models.HistoryRefund, Due: 200, Date: dateTime(2026, time.March, 10), Customer_id: planned.Id, Sell_id: sellId})
+	plan := models.InstallmentPlan{Id: primitive.NewObjectID(), Sell_id: sellId, Customer_id: planned.Id, Created_at: date(2026, time.January, 1),
+		Schedule: buildSchedule(1200, 3, models.PlanMonthly, date(2026, time.February, 1))}
+	if err := f.store.Plans.Create(f.ctx, &plan); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := AgingReport(f.ctx, f.store, AgingFilter{As_of: asOf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[primitive.ObjectID]models.AgingBuckets{
+		paying.Id:  {Current: 400, Days61_90: 500, Total: 900},
+		late.Id:    {Days1_30: 600, Total: 600},
+		old.Id:     {Over90: 500, Total: 500},
+		planned.Id: {Current: 400, Days1_30: 300, Total: 700},
+	}
+	if len(report.Rows) != len(want) {
+		t.Fatalf("%d rows, want %d", len(report.Rows), len(want))
+	}
+	for _, row := range report.Rows {
+		if row.AgingBuckets != want[row.Customer_id] {
+			t.Errorf("%s buckets = %+v, want %+v", row.Name, row.AgingBuckets, want[row.Customer_id])
+		}
+	}
+	if balance, err := CustomerBalance(f.ctx, f.store, planned.Id, asOf); err != nil || balance != want[planned.Id].Total {
+		t.Errorf("ledger balance of the planned sale = %d, %v, want %d", balance, err, want[planned.Id].Total)
+	}
+	if total := (models.AgingBuckets{Current: 800, Days1_30: 900, Days61_90: 500, Over90: 500, Total: 2700}); report.Totals != total {
+		t.Errorf("totals = %+v, want %+v", report.Totals, total)
+	}
+
+	report, err = AgingReport(f.ctx, f.store, AgingFilter{As_of: asOf, Thana: "savar", District: "DHAKA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rows) != len(want) {
+		t.Errorf("filtering on the shared thana left %d rows", len(report.Rows))
+	}
+	report, err = AgingReport(f.ctx, f.store, AgingFilter{As_of: asOf, Thana: "Mirpur"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rows) != 0 {
+		t.Errorf("filtering on another thana left %d rows", len(report.Rows))
+	}
+}
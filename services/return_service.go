@@ -0,0 +1,237 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidReturn is returned when a return or void request cannot be applied to the sale
+var ErrInvalidReturn = errors.New("invalid return")
+
+// ErrSellReversed is returned for sales that can no longer be changed because
+// products were taken back from them, or returned from again once nothing is left
+var ErrSellReversed = errors.New("sell has been returned or voided")
+
+// ReturnSell takes the request lines back from a sale in one transaction: the
+// products go back into stock through return movements, the customer is credited with what they were
+// sold for and charged the refund handed back in cash. The sale keeps its
+// lines and records the return with userId and the reason. Lines of
+// serialized products name the units brought back.
+func ReturnSell(ctx context.Context, store *repositories.Store, sellId primitive.ObjectID, request models.ReturnRequest, userId primitive.ObjectID) (models.SellInfo, error) {
+	if len(request.Lines) == 0 {
+		return models.SellInfo{}, fmt.Errorf("%w: no lines", ErrInvalidReturn)
+	}
+	return reverseSell(ctx, store, sellId, models.ReversalReturn, request, userId)
+}
+
+// VoidSell returns everything left on a sale and marks it voided, with every
+// serialized unit the customer still holds from it
+func VoidSell(ctx context.Context, store *repositories.Store, sellId primitive.ObjectID, request models.ReturnRequest, userId primitive.ObjectID) (models.SellInfo, error) {
+	request.Lines = nil
+	return reverseSell(ctx, store, sellId, models.ReversalVoid, request, userId)
+}
+
+func reverseSell(ctx context.Context, store *repositories.Store, sellId primitive.ObjectID, kind string, request models.ReturnRequest, userId primitive.ObjectID) (models.SellInfo, error) {
+	var reversed models.SellInfo
+
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return reversed, fmt.Errorf("%w: a reason is required", ErrInvalidReturn)
+	}
+	if request.Refund < 0 {
+		return reversed, fmt.Errorf("%w: refund is negative", ErrInvalidReturn)
+	}
+	for _, line := range request.Lines {
+		if line.Product_id.IsZero() || line.Quantity < 1 {
+			return reversed, fmt.Errorf("%w: every line needs a product and a positive quantity", ErrInvalidReturn)
+		}
+	}
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		sell, err := store.Sells.FindByID(ctx, sellId)
+		if err != nil {
+			return err
+		}
+		if sell.Status == models.SellVoided || sell.Status == models.SellReturned {
+			return ErrSellReversed
+		}
+
+		remaining := RemainingQuantities(sell)
+		wanted := map[primitive.ObjectID]int{}
+		if kind == models.ReversalVoid {
+			for i, line := range sell.Lines {
+				wanted[line.Product_id] += remaining[i]
+			}
+		}
+		for _, line := range request.Lines {
+			wanted[line.Product_id] += line.Quantity
+		}
+		serials, err := returnedSerials(ctx, store, sell, kind, request)
+		if err != nil {
+			return err
+		}
+
+		// lines are taken back in the order they were sold
+		var lines []models.ReturnLine
+		var value float64
+		for i, line := range sell.Lines {
+			take := wanted[line.Product_id]
+			if take > remaining[i] {
+				take = remaining[i]
+			}
+			if take == 0 {
+				continue
+			}
+			wanted[line.Product_id] -= take
+			remaining[i] -= take
+			amount := line.Line_total * float64(take) / float64(line.Quantity)
+			named := take
+			if named > len(serials[line.Product_id]) {
+				named = len(serials[line.Product_id])
+			}
+			returned := models.ReturnLine{Product_id: line.Product_id, Model: line.Model, Quantity: take, Amount: amount}
+			returned.Serials, serials[line.Product_id] = serials[line.Product_id][:named], serials[line.Product_id][named:]
+			lines = append(lines, returned)
+			value += amount
+		}
+		for productId, left := range wanted {
+			if left > 0 {
+				return fmt.Errorf("%w: %d more of product %s than can be returned", ErrInvalidReturn, left, productId.Hex())
+			}
+		}
+		for productId, left := range serials {
+			if len(left) > 0 {
+				return fmt.Errorf("%w: more serials of product %s than units returned", ErrInvalidReturn, productId.Hex())
+			}
+		}
+		if len(lines) == 0 {
+			return ErrSellReversed
+		}
+		credit := int(math.Round(value))
+		if request.Refund > credit {
+			return fmt.Errorf("%w: refund %d exceeds the %d returned", ErrInvalidReturn, request.Refund, credit)
+		}
+
+		now := time.Now()
+		for _, line := range lines {
+			if _, _, err := moveStock(ctx, store, models.StockMovement{
+				Product_id:   line.Product_id,
+				Type:         models.MovementReturn,
+				Quantity:     line.Quantity,
+				Reason:       reason,
+				User_id:      userId,
+				Reference_id: sell.Id,
+				Date:         primitive.NewDateTimeFromTime(now),
+				Serials:      line.Serials,
+			}); err != nil {
+				return err
+			}
+		}
+		reversal := models.SellReversal{
+			Id:      primitive.NewObjectID(),
+			Type:    kind,
+			Lines:   lines,
+			Amount:  credit,
+			Refund:  request.Refund,
+			Reason:  reason,
+			User_id: userId,
+			Date:    primitive.NewDateTimeFromTime(now),
+		}
+		if credit > 0 {
+			entry, err := postReversal(ctx, store, sell, models.History{Type: models.HistoryReturn, Paid: credit, Date: reversal.Date, Note: reason}, now)
+			if err != nil {
+				return err
+			}
+			reversal.Credit_id = entry.Id
+		}
+		if request.Refund > 0 {
+			entry, err := postReversal(ctx, store, sell, models.History{Type: models.HistoryRefund, Due: request.Refund, Date: reversal.Date, Note: reason}, now)
+			if err != nil {
+				return err
+			}
+			reversal.Refund_id = entry.Id
+		}
+
+		status := models.SellReturned
+		for _, left := range remaining {
+			if left > 0 {
+				status = models.SellPartiallyReturned
+			}
+		}
+		if kind == models.ReversalVoid {
+			status = models.SellVoided
+		}
+		reversed, err = store.Sells.Reverse(ctx, sell.Id, reversal, status)
+		return err
+	})
+	return reversed, err
+}
+
+// returnedSerials gathers the serials of the units taken back from sell by
+// product: those named by the request, or every unit a void finds still sold
+func returnedSerials(ctx context.Context, store *repositories.Store, sell models.SellInfo, kind string, request models.ReturnRequest) (map[primitive.ObjectID][]string, error) {
+	serials := map[primitive.ObjectID][]string{}
+	for _, line := range request.Lines {
+		serials[line.Product_id] = append(serials[line.Product_id], line.Serials...)
+	}
+	if kind != models.ReversalVoid {
+		return serials, nil
+	}
+	for _, line := range sell.Lines {
+		if _, ok := serials[line.Product_id]; ok {
+			continue
+		}
+		units, err := store.Serials.FindBySell(ctx, sell.Id, line.Product_id)
+		if err != nil {
+			return nil, err
+		}
+		serials[line.Product_id] = []string{}
+		for _, unit := range units {
+			serials[line.Product_id] = append(serials[line.Product_id], unit.Serial)
+		}
+	}
+	return serials, nil
+}
+
+// postReversal records a credit or refund entry of sell under the next receipt number
+func postReversal(ctx context.Context, store *repositories.Store, sell models.SellInfo, entry models.History, now time.Time) (models.History, error) {
+	number, err := NextNumber(ctx, store, sell.Organization_id, models.SequenceReceipt, now)
+	if err != nil {
+		return entry, err
+	}
+	entry.Id = primitive.NewObjectID()
+	entry.Number = number
+	entry.Customer_id = sell.Customer_id
+	entry.Seller_id = sell.Organization_id
+	entry.Sell_id = sell.Id
+	return entry, store.Historys.Create(ctx, &entry)
+}
+
+// RemainingQuantities lists, for every line of sell, how many units have not
+// been taken back. Returns use up the lines of a product in order.
+func RemainingQuantities(sell models.SellInfo) []int {
+	returned := map[primitive.ObjectID]int{}
+	for _, reversal := range sell.Reversals {
+		for _, line := range reversal.Lines {
+			returned[line.Product_id] += line.Quantity
+		}
+	}
+	remaining := make([]int, len(sell.Lines))
+	for i, line := range sell.Lines {
+		taken := returned[line.Product_id]
+		if taken > line.Quantity {
+			taken = line.Quantity
+		}
+		returned[line.Product_id] -= taken
+		remaining[i] = line.Quantity - taken
+	}
+	return remaining
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidSell is returned when a sale request cannot be recorded as submitted
+var ErrInvalidSell = errors.New("invalid sell")
+
+// ErrSellLocked is returned when an edit changes what a sale sold, to whom or
+// for how much
+var ErrSellLocked = errors.New("sell cannot be changed")
+
+// CreateSell records a sale in one transaction: it checks the customer and
+// organization exist, takes every line out of stock, snapshots the catalogue
+// price and cost onto the lines and posts the matching due/paid entry to the
+// history. A non-zero sell.Amount must agree with the total of the lines.
+// When ctx is scoped to an organization the sale is made by it. The sale
+// carries the next invoice number of the organization; its entry has none and
+// refers to it by Sell_id. Every line is
+// journaled as a sale movement made by userId; lines of serialized products
+// name the units sold, which start their warranty with the sale. Credit
+// above the organization's guarantor threshold needs a guarantor, of the sale
+// or of the customer.
+func CreateSell(ctx context.Context, store *repositories.Store, sell models.SellInfo, userId primitive.ObjectID) (models.SellInfo, models.History, error) {
+	var newSell models.SellInfo
+	var entry models.History
+
+	if organizationId, ok := repositories.OrganizationFrom(ctx); ok {
+		sell.Organization_id = organizationId
+	}
+	if len(sell.Lines) == 0 {
+		return newSell, entry, fmt.Errorf("%w: no lines", ErrInvalidSell)
+	}
+	if sell.Paid < 0 {
+		return newSell, entry, fmt.Errorf("%w: paid amount is negative", ErrInvalidSell)
+	}
+	for _, line := range sell.Lines {
+		if line.Product_id.IsZero() || line.Quantity < 1 || line.Discount < 0 {
+			return newSell, entry, fmt.Errorf("%w: every line needs a product, a positive quantity and a non-negative discount", ErrInvalidSell)
+		}
+	}
+
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.Customers.FindByID(ctx, sell.Customer_id); err != nil {
+			return fmt.Errorf("customer %s: %w", sell.Customer_id.Hex(), err)
+		}
+		organization, err := store.Organizations.FindByID(ctx, sell.Organization_id)
+		if err != nil {
+			return fmt.Errorf("organization %s: %w", sell.Organization_id.Hex(), err)
+		}
+		if !sell.Guarantor_id.IsZero() {
+			if _, err := store.Guarantors.FindByID(ctx, sell.Guarantor_id); err != nil {
+				return fmt.Errorf("guarantor %s: %w", sell.Guarantor_id.Hex(), err)
+			}
+		}
+
+		now := time.Now()
+		sellId := primitive.NewObjectID()
+		lines := make([]models.SellLine, len(sell.Lines))
+		products := make([]models.Product, len(sell.Lines))
+		for i, line := range sell.Lines {
+			product, moved, err := moveStock(ctx, store, models.StockMovement{
+				Product_id:   line.Product_id,
+				Type:         models.MovementSale,
+				Quantity:     -line.Quantity,
+				Reason:       "sale",
+				User_id:      userId,
+				Reference_id: sellId,
+				Date:         primitive.NewDateTimeFromTime(now),
+				Serials:      line.Serials,
+			})
+			if err != nil {
+				return err
+			}
+			lines[i], err = PriceLine(product, line.Quantity, line.Discount)
+			if err != nil {
+				return err
+			}
+			lines[i].Serials = moved.Serials
+			products[i] = product
+		}
+
+		amount := SellAmount(lines)
+		if sell.Amount != 0 && sell.Amount != amount {
+			return fmt.Errorf("%w: amount %d does not match the lines total %d", ErrInvalidSell, sell.Amount, amount)
+		}
+		if sell.Paid > amount {
+			return fmt.Errorf("%w: paid %d exceeds amount %d", ErrInvalidSell, sell.Paid, amount)
+		}
+		if err := checkGuarantor(ctx, store, organization, sell, amount-sell.Paid); err != nil {
+			return err
+		}
+
+		number, err := NextNumber(ctx, store, sell.Organization_id, models.SequenceInvoice, now)
+		if err != nil {
+			return err
+		}
+		newSell = models.SellInfo{
+			Id:              sellId,
+			Number:          number,
+			Lines:           lines,
+			Customer_id:     sell.Customer_id,
+			Organization_id: sell.Organization_id,
+			Amount:          amount,
+			Paid:            sell.Paid,
+			Date:            primitive.NewDateTimeFromTime(now),
+			Status:          models.SellCompleted,
+			Guarantor_id:    sell.Guarantor_id,
+		}
+		if err := store.Sells.Create(ctx, &newSell); err != nil {
+			return err
+		}
+		for i, line := range lines {
+			if err := handOver(ctx, store, line.Serials, products[i], sellId, sell.Customer_id, now); err != nil {
+				return err
+			}
+		}
+
+		entry = models.History{
+			Id:          primitive.NewObjectID(),
+			Due:         amount,
+			Paid:        sell.Paid,
+			Date:        newSell.Date,
+			Customer_id: sell.Customer_id,
+			Seller_id:   sell.Organization_id,
+			Sell_id:     newSell.Id,
+			Type:        models.HistorySale,
+		}
+		return store.Historys.Create(ctx, &entry)
+	})
+	return newSell, entry, err
+}
+
+// PriceLine snapshots the current price and cost of product onto a sale line
+func PriceLine(product models.Product, quantity int, discount float64) (models.SellLine, error) {
+	gross := product.Price * float64(quantity)
+	if discount > gross {
+		return models.SellLine{}, fmt.Errorf("%w: discount on %s exceeds its price", ErrInvalidSell, product.Model)
+	}
+	return models.SellLine{
+		Product_id: product.Id,
+		Model:      product.Model,
+		Quantity:   quantity,
+		Unit_price: product.Price,
+		Unit_cost:  product.Cost,
+		Discount:   discount,
+		Line_total: gross - discount,
+	}, nil
+}
+
+// SellAmount is the whole-unit amount owed for lines
+func SellAmount(lines []models.SellLine) int {
+	var total float64
+	for _, line := range lines {
+		total += line.Line_total
+	}
+	return int(math.Round(total))
+}
+
+// EditSell changes the note of a sale. Its lines, customer and amounts stay as
+// sold: products are taken back by returning or voiding the sale. A zero
+// amount or paid in the edit leaves it out.
+func EditSell(ctx context.Context, store *repositories.Store, id primitive.ObjectID, sell models.SellInfo) (models.SellInfo, error) {
+	var updated models.SellInfo
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := store.Sells.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !sameLines(sell.Lines, current.Lines) || sell.Customer_id != current.Customer_id ||
+			sell.Amount != 0 && sell.Amount != current.Amount || sell.Paid != 0 && sell.Paid != current.Paid {
+			return fmt.Errorf("%w: only the note can change, return or void the sale instead", ErrSellLocked)
+		}
+		updated, err = store.Sells.SetNote(ctx, id, sell.Note)
+		return err
+	})
+	return updated, err
+}
+
+// sameLines reports whether edited sells the same quantities of the same
+// products at the same discounts as sold
+func sameLines(edited, sold []models.SellLine) bool {
+	if len(edited) != len(sold) {
+		return false
+	}
+	for i, line := range edited {
+		if line.Product_id != sold[i].Product_id || line.Quantity != sold[i].Quantity || line.Discount != sold[i].Discount {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCreateSellRollsBack(t *testing.T) {
+	tests := []struct {
+		name    string
+		sell    func(phone, charger models.Product) models.SellInfo
+		wantErr error
+	}{
+		{
+			name: "second line out of stock",
+			sell: func(phone, charger models.Product) models.SellInfo {
+				return models.SellInfo{Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 2}, {Product_id: charger.Id, Quantity: 4}}}
+			},
+			wantErr: repositories.ErrInsufficientStock,
+		},
+		{
+			name: "paid more than the amount",
+			sell: func(phone, charger models.Product) models.SellInfo {
+				return models.SellInfo{Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 1}}, Paid: 1001}
+			},
+			wantErr: ErrInvalidSell,
+		},
+		{
+			name: "amount disagrees with the lines",
+			sell: func(phone, charger models.Product) models.SellInfo {
+				return models.SellInfo{Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 1}, {Product_id: charger.Id, Quantity: 1}}, Amount: 1000}
+			},
+			wantErr: ErrInvalidSell,
+		},
+		{
+			name: "unknown product",
+			sell: func(phone, charger models.Product) models.SellInfo {
+				return models.SellInfo{Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 1}, {Product_id: primitive.NewObjectID(), Quantity: 1}}}
+			},
+			wantErr: repositories.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, models.Orgnization{})
+			phone := f.addProduct(t, "Phone", 1000, 700, 5)
+			charger := f.addProduct(t, "Charger", 100, 40, 3)
+			sell := tt.sell(phone, charger)
+			sell.Customer_id = f.customer.Id
+
+			if _, _, err := CreateSell(f.ctx, f.store, sell, primitive.NewObjectID()); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			for _, want := range []models.Product{phone, charger} {
+				product, err := f.store.Products.FindByID(f.ctx, want.Id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if product.Stock != want.Stock {
+					t.Errorf("stock of %s = %d, want %d", want.Model, product.Stock, want.Stock)
+				}
+			}
+			if sells, _ := f.store.Sells.FindAll(f.ctx); len(sells) != 0 {
+				t.Errorf("%d sells stored", len(sells))
+			}
+			if historys, _ := f.store.Historys.FindAll(f.ctx); len(historys) != 0 {
+				t.Errorf("%d history entries stored", len(historys))
+			}
+			for _, product := range []models.Product{phone, charger} {
+				if movements, _ := f.store.Movements.FindByProduct(f.ctx, product.Id, time.Time{}, date(2100, 1, 1)); len(movements) != 0 {
+					t.Errorf("%d stock movements of %s stored", len(movements), product.Model)
+				}
+			}
+
+			// the invoice number of the failed sale is handed out again
+			sell = models.SellInfo{Customer_id: f.customer.Id, Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 1}}}
+			created, entry, err := CreateSell(f.ctx, f.store, sell, primitive.NewObjectID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created.Number[len(created.Number)-6:] != "000001" {
+				t.Errorf("number after rollback = %s", created.Number)
+			}
+			if entry.Due != 1000 || created.Lines[0].Unit_cost != 700 {
+				t.Errorf("sale entry due %d, unit cost %v", entry.Due, created.Lines[0].Unit_cost)
+			}
+			if entry.Number != "" {
+				t.Errorf("sale entry numbered %s, the invoice number belongs to the sale", entry.Number)
+			}
+		})
+	}
+}
+
+func TestEditSellOnlyChangesTheNote(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phone := f.addProduct(t, "Phone", 1000, 700, 5)
+	sold, _, err := CreateSell(f.ctx, f.store, models.SellInfo{Customer_id: f.customer.Id, Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 2, Discount: 100}}, Paid: 500}, primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := f.addCustomer(t, "Karim", time.Time{})
+
+	tests := []struct {
+		name    string
+		edit    func(sell *models.SellInfo)
+		wantErr error
+	}{
+		{"more units", func(sell *models.SellInfo) { sell.Lines[0].Quantity = 3 }, ErrSellLocked},
+		{"a bigger discount", func(sell *models.SellInfo) { sell.Lines[0].Discount = 300 }, ErrSellLocked},
+		{"another line", func(sell *models.SellInfo) {
+			sell.Lines = append(sell.Lines, models.SellLine{Product_id: phone.Id, Quantity: 1})
+		}, ErrSellLocked},
+		{"another customer", func(sell *models.SellInfo) { sell.Customer_id = other.Id }, ErrSellLocked},
+		{"another amount", func(sell *models.SellInfo) { sell.Amount = 1500 }, ErrSellLocked},
+		{"more paid", func(sell *models.SellInfo) { sell.Paid = 1900 }, ErrSellLocked},
+		{"a note", func(sell *models.SellInfo) { sell.Note = "delivered to the shop" }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit := sold
+			edit.Lines = append([]models.SellLine{}, sold.Lines...)
+			tt.edit(&edit)
+			if _, err := EditSell(f.ctx, f.store, sold.Id, edit); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	stored, err := f.store.Sells.FindByID(f.ctx, sold.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Amount != 1900 || stored.Paid != 500 || stored.Lines[0].Quantity != 2 || stored.Customer_id != f.customer.Id || stored.Note != "delivered to the shop" {
+		t.Errorf("sell = %+v", stored)
+	}
+}
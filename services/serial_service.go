@@ -0,0 +1,177 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidSerial is returned when the serials of a movement do not name the units it moves
+var ErrInvalidSerial = errors.New("invalid serial")
+
+// leavingStatus is what becomes of a unit taken out of stock by each movement type
+var leavingStatus = map[string]string{
+	models.MovementSale:       models.SerialSold,
+	models.MovementWarranty:   models.SerialSold,
+	models.MovementDamage:     models.SerialDamaged,
+	models.MovementAdjustment: models.SerialRemoved,
+	models.MovementTransfer:   models.SerialTransferred,
+}
+
+// trackSerials moves the units named by movement in or out of the stock of
+// product, returning their trimmed serials. A serialized product needs one
+// serial for every unit moved; other products take none. Units leave only
+// from stock and come back only when they are not already in it, returns
+// only to the sale they left on. Unknown serials brought in are registered.
+func trackSerials(ctx context.Context, store *repositories.Store, product models.Product, movement models.StockMovement) ([]string, error) {
+	if !product.Serialized {
+		if len(movement.Serials) > 0 {
+			return nil, fmt.Errorf("%w: %s does not track serials", ErrInvalidSerial, product.Model)
+		}
+		return nil, nil
+	}
+	quantity := movement.Quantity
+	if quantity < 0 {
+		quantity = -quantity
+	}
+	if len(movement.Serials) != quantity {
+		return nil, fmt.Errorf("%w: %s needs %d serials, got %d", ErrInvalidSerial, product.Model, quantity, len(movement.Serials))
+	}
+
+	reference := movement.Reference_id
+	if reference.IsZero() {
+		reference = movement.Id
+	}
+	date := movement.Date.Time()
+	serials := make([]string, len(movement.Serials))
+	seen := map[string]bool{}
+	for i, serial := range movement.Serials {
+		serial = strings.TrimSpace(serial)
+		if serial == "" || seen[serial] {
+			return nil, fmt.Errorf("%w: serials of %s must be given once each", ErrInvalidSerial, product.Model)
+		}
+		seen[serial] = true
+		serials[i] = serial
+
+		unit, err := store.Serials.FindBySerial(ctx, serial)
+		found := err == nil
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return nil, err
+		}
+		if movement.Quantity < 0 {
+			if !found || unit.Product_id != product.Id || unit.Status != models.SerialInStock {
+				return nil, fmt.Errorf("%w: %s is not in stock of %s", ErrInvalidSerial, serial, product.Model)
+			}
+			unit.Status = leavingStatus[movement.Type]
+			if unit.Status == "" {
+				unit.Status = models.SerialRemoved
+			}
+			if movement.Type == models.MovementSale {
+				unit.Sell_id = movement.Reference_id
+			}
+		} else {
+			switch {
+			case movement.Type == models.MovementReturn:
+				if !found || unit.Product_id != product.Id || unit.Status != models.SerialSold || unit.Sell_id != movement.Reference_id {
+					return nil, fmt.Errorf("%w: %s was not sold on this sale", ErrInvalidSerial, serial)
+				}
+			case !found:
+				unit = models.SerialUnit{Id: primitive.NewObjectID(), Serial: serial, Product_id: product.Id, Received_at: date}
+			case unit.Product_id != product.Id || unit.Status == models.SerialInStock:
+				return nil, fmt.Errorf("%w: %s is already registered", ErrInvalidSerial, serial)
+			}
+			unit.Status = models.SerialInStock
+			unit.Sell_id, unit.Customer_id = primitive.NilObjectID, primitive.NilObjectID
+			unit.Sold_at, unit.Warranty_until = nil, nil
+		}
+		unit.Events = append(unit.Events, models.SerialEvent{
+			Type:         movement.Type,
+			Status:       unit.Status,
+			Reference_id: reference,
+			User_id:      movement.User_id,
+			Date:         date,
+		})
+		if found {
+			_, err = store.Serials.Save(ctx, unit)
+		} else {
+			err = store.Serials.Create(ctx, &unit)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return serials, nil
+}
+
+// handOver records that the units named by serials, already taken out of
+// stock, belong to customerId under sellId from soldAt, under warranty for
+// the warranty days of product
+func handOver(ctx context.Context, store *repositories.Store, serials []string, product models.Product, sellId primitive.ObjectID, customerId primitive.ObjectID, soldAt time.Time) error {
+	var until *time.Time
+	if product.Warranty_days > 0 {
+		end := soldAt.AddDate(0, 0, product.Warranty_days)
+		until = &end
+	}
+	for _, serial := range serials {
+		unit, err := store.Serials.FindBySerial(ctx, serial)
+		if err != nil {
+			return fmt.Errorf("serial %s: %w", serial, err)
+		}
+		unit.Sell_id = sellId
+		unit.Customer_id = customerId
+		unit.Sold_at = &soldAt
+		unit.Warranty_until = until
+		if _, err := store.Serials.Save(ctx, unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LookupSerial finds a unit of the organization ctx is scoped to by its
+// serial, with the product it is, who bought it on which sale and its
+// warranty claims
+func LookupSerial(ctx context.Context, store *repositories.Store, serial string, now time.Time) (models.SerialLookup, error) {
+	var lookup models.SerialLookup
+
+	unit, err := store.Serials.FindBySerial(ctx, strings.TrimSpace(serial))
+	if err != nil {
+		return lookup, err
+	}
+	lookup.Unit = unit
+	lookup.Under_warranty = underWarranty(unit, now)
+	if lookup.Product, err = store.Products.FindByID(ctx, unit.Product_id); err != nil {
+		return lookup, fmt.Errorf("product %s: %w", unit.Product_id.Hex(), err)
+	}
+	if !unit.Customer_id.IsZero() {
+		customer, err := store.Customers.FindByID(ctx, unit.Customer_id)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return lookup, err
+		}
+		if err == nil {
+			lookup.Customer = &customer
+		}
+	}
+	if !unit.Sell_id.IsZero() {
+		sell, err := store.Sells.FindByID(ctx, unit.Sell_id)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return lookup, err
+		}
+		lookup.Sell_number = sell.Number
+	}
+	if lookup.Claims, err = store.Claims.FindByUnit(ctx, unit.Id); err != nil {
+		return lookup, err
+	}
+	return lookup, nil
+}
+
+// underWarranty tells whether a sold unit is still covered at now
+func underWarranty(unit models.SerialUnit, now time.Time) bool {
+	return unit.Status == models.SerialSold && unit.Warranty_until != nil && now.Before(*unit.Warranty_until)
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// addSerialized stores a serialized phone under warranty for a year and
+// receives the units named by serials
+func (f *fixture) addSerialized(t *testing.T, serials ...string) models.Product {
+	t.Helper()
+	product := f.addCatalogued(t, models.Product{Model: "A15", Price: 1000, Cost: 700, Serialized: true, Warranty_days: 365})
+	if _, err := RecordMovement(f.ctx, f.store, product.Id, models.StockMovement{Type: models.MovementPurchase, Quantity: len(serials), Reason: "delivery", Serials: serials}, primitive.NewObjectID()); err != nil {
+		t.Fatal(err)
+	}
+	return product
+}
+
+func (f *fixture) sellSerials(t *testing.T, product models.Product, serials ...string) models.SellInfo {
+	t.Helper()
+	sell, _, err := CreateSell(f.ctx, f.store, models.SellInfo{Customer_id: f.customer.Id, Lines: []models.SellLine{{Product_id: product.Id, Quantity: len(serials), Serials: serials}}}, primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sell
+}
+
+func serialStatus(t *testing.T, f *fixture, serial string) string {
+	t.Helper()
+	unit, err := f.store.Serials.FindBySerial(f.ctx, serial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unit.Status
+}
+
+func TestSerialsFollowTheirUnits(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phone := f.addSerialized(t, "IMEI-1", " IMEI-2 ", "IMEI-3", "IMEI-4")
+	if got := stock(t, f, phone.Id); got != 4 {
+		t.Fatalf("stock = %d, want 4", got)
+	}
+
+	sell := f.sellSerials(t, phone, "IMEI-1", "IMEI-2")
+	if !reflect.DeepEqual(sell.Lines[0].Serials, []string{"IMEI-1", "IMEI-2"}) {
+		t.Errorf("sold serials = %v", sell.Lines[0].Serials)
+	}
+	unit, err := f.store.Serials.FindBySerial(f.ctx, "IMEI-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unit.Status != models.SerialSold || unit.Sell_id != sell.Id || unit.Customer_id != f.customer.Id {
+		t.Errorf("sold unit = %+v", unit)
+	}
+	if unit.Sold_at == nil || unit.Warranty_until == nil || !unit.Warranty_until.Equal(unit.Sold_at.AddDate(0, 0, 365)) {
+		t.Errorf("sold unit warranty runs from %v to %v, want a year", unit.Sold_at, unit.Warranty_until)
+	}
+
+	if _, err := ReturnSell(f.ctx, f.store, sell.Id, models.ReturnRequest{Reason: "changed mind", Lines: []models.ReturnLine{{Product_id: phone.Id, Quantity: 1, Serials: []string{"IMEI-2"}}}}, primitive.NewObjectID()); err != nil {
+		t.Fatal(err)
+	}
+	unit, _ = f.store.Serials.FindBySerial(f.ctx, "IMEI-2")
+	if unit.Status != models.SerialInStock || !unit.Sell_id.IsZero() || !unit.Customer_id.IsZero() || unit.Warranty_until != nil {
+		t.Errorf("returned unit = %+v, want it back in stock with no buyer", unit)
+	}
+	var events []string
+	for _, event := range unit.Events {
+		events = append(events, event.Type+":"+event.Status)
+	}
+	if want := []string{"purchase:in_stock", "sale:sold", "return:in_stock"}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	if _, err := RecordMovement(f.ctx, f.store, phone.Id, models.StockMovement{Type: models.MovementDamage, Quantity: 1, Reason: "dropped", Serials: []string{"IMEI-3"}}, primitive.NewObjectID()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VoidSell(f.ctx, f.store, sell.Id, models.ReturnRequest{Reason: "wrong customer"}, primitive.NewObjectID()); err != nil {
+		t.Fatal(err)
+	}
+	for serial, want := range map[string]string{"IMEI-1": models.SerialInStock, "IMEI-2": models.SerialInStock, "IMEI-3": models.SerialDamaged, "IMEI-4": models.SerialInStock} {
+		if got := serialStatus(t, f, serial); got != want {
+			t.Errorf("%s is %s, want %s", serial, got, want)
+		}
+	}
+	if got := stock(t, f, phone.Id); got != 3 {
+		t.Errorf("stock = %d, want 3", got)
+	}
+}
+
+func TestSerialsRefused(t *testing.T) {
+	tests := []struct {
+		name string
+		move func(f *fixture, phone, charger models.Product, sell models.SellInfo) error
+	}{
+		{
+			name: "more serials than units",
+			move: func(f *fixture, phone, charger models.Product, sell models.SellInfo) error {
+				_, _, err := CreateSell(f.ctx, f.store, models.SellInfo{Customer_id: f.customer.Id, Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 1, Serials: []string{"IMEI-2", "IMEI-3"}}}}, primitive.NewObjectID())
+				return err
+			},
+		},
+		{
+			name: "serial given twice",
+			move: func(f *fixture, phone, charger models.Product, sell models.SellInfo) error {
+				_, err := RecordMovement(f.ctx, f.store, phone.Id, models.StockMovement{Type: models.MovementPurchase, Quantity: 2, Reason: "delivery", Serials: []string{"IMEI-9", " IMEI-9"}}, primitive.NewObjectID())
+				return err
+			},
+		},
+		{
+			name: "serial already in stock",
+			move: func(f *fixture, phone, charger models.Product, sell models.SellInfo) error {
+				_, err := RecordMovement(f.ctx, f.store, phone.Id, models.StockMovement{Type: models.MovementPurchase, Quantity: 1, Reason: "delivery", Serials: []string{"IMEI-2"}}, primitive.NewObjectID())
+				return err
+			},
+		},
+		{
+			name: "selling a sold unit",
+			move: func(f *fixture, phone, charger models.Product, sell models.SellInfo) error {
+				_, _, err := CreateSell(f.ctx, f.store, models.SellInfo{Customer_id: f.customer.Id, Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 1, Serials: []string{"IMEI-1"}}}}, primitive.NewObjectID())
+				return err
+			},
+		},
+		{
+			name: "selling an unknown unit",
+			move: func(f *fixture, phone, charger models.Product, sell models.SellInfo) error {
+				_, _, err := CreateSell(f.ctx, f.store, models.SellInfo{Customer_id: f.customer.Id, Lines: []models.SellLine{{Product_id: phone.Id, Quantity: 1, Serials: []string{"IMEI-9"}}}}, primitive.NewObjectID())
+				return err
+			},
+		},
+		{
+			name: "returning a unit of another sale",
+			move: func(f *fixture, phone, charger models.Product, sell models.SellInfo) error {
+				_, err := ReturnSell(f.ctx, f.store, sell.Id, models.ReturnRequest{Reason: "faulty", Lines: []models.ReturnLine{{Product_id: phone.Id, Quantity: 1, Serials: []string{"IMEI-2"}}}}, primitive.NewObjectID())
+				return err
+			},
+		},
+		{
+			name: "serials of a product without them",
+			move: func(f *fixture, phone, charger models.Product, sell models.SellInfo) error {
+				_, err := RecordMovement(f.ctx, f.store, charger.Id, models.StockMovement{Type: models.MovementPurchase, Quantity: 1, Reason: "delivery", Serials: []string{"C-1"}}, primitive.NewObjectID())
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, models.Orgnization{})
+			phone := f.addSerialized(t, "IMEI-1", "IMEI-2")
+			charger := f.addProduct(t, "Charger", 100, 40, 3)
+			sell := f.sellSerials(t, phone, "IMEI-1")
+
+			if err := tt.move(f, phone, charger, sell); !errors.Is(err, ErrInvalidSerial) {
+				t.Fatalf("error = %v, want ErrInvalidSerial", err)
+			}
+			if got := stock(t, f, phone.Id); got != 1 {
+				t.Errorf("stock = %d, want 1", got)
+			}
+			if got := serialStatus(t, f, "IMEI-2"); got != models.SerialInStock {
+				t.Errorf("IMEI-2 is %s, want it left in stock", got)
+			}
+		})
+	}
+}
+
+func TestLookupSerial(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phone := f.addSerialized(t, "IMEI-1", "IMEI-2")
+	sell := f.sellSerials(t, phone, "IMEI-1")
+
+	lookup, err := LookupSerial(f.ctx, f.store, " IMEI-1 ", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookup.Product.Id != phone.Id || lookup.Customer == nil || lookup.Customer.Id != f.customer.Id || lookup.Sell_number != sell.Number || !lookup.Under_warranty {
+		t.Errorf("lookup = %+v", lookup)
+	}
+	if lookup, _ := LookupSerial(f.ctx, f.store, "IMEI-1", time.Now().AddDate(1, 0, 1)); lookup.Under_warranty {
+		t.Errorf("unit still under warranty a year and a day after its sale")
+	}
+
+	lookup, err = LookupSerial(f.ctx, f.store, "IMEI-2", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookup.Customer != nil || lookup.Sell_number != "" || lookup.Under_warranty {
+		t.Errorf("unit in stock = %+v, want no buyer and no warranty", lookup)
+	}
+
+	other := repositories.WithOrganization(context.Background(), primitive.NewObjectID())
+	if _, err := LookupSerial(other, f.store, "IMEI-1", time.Now()); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("lookup from another organization = %v, want ErrNotFound", err)
+	}
+}
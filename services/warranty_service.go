@@ -0,0 +1,168 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidClaim is returned when a warranty claim cannot be made or moved as requested
+	ErrInvalidClaim = errors.New("invalid warranty claim")
+	// ErrWarrantyExpired is returned for claims on units whose warranty is over
+	ErrWarrantyExpired = errors.New("warranty has expired")
+	// ErrClaimOpen is returned for claims on units that already have one in progress
+	ErrClaimOpen = errors.New("unit already has a warranty claim in progress")
+)
+
+// CreateWarrantyClaim opens a claim made by userId on a sold unit of the
+// organization ctx is scoped to. The unit must still be under warranty and
+// every earlier claim on it closed.
+func CreateWarrantyClaim(ctx context.Context, store *repositories.Store, claim models.WarrantyClaim, userId primitive.ObjectID, now time.Time) (models.WarrantyClaim, error) {
+	var newClaim models.WarrantyClaim
+
+	problem := strings.TrimSpace(claim.Problem)
+	if problem == "" {
+		return newClaim, fmt.Errorf("%w: a problem is required", ErrInvalidClaim)
+	}
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		unit, err := store.Serials.FindBySerial(ctx, strings.TrimSpace(claim.Serial))
+		if err != nil {
+			return fmt.Errorf("serial %s: %w", claim.Serial, err)
+		}
+		if unit.Status != models.SerialSold {
+			return fmt.Errorf("%w: %s has not been sold", ErrInvalidClaim, unit.Serial)
+		}
+		if !underWarranty(unit, now) {
+			return fmt.Errorf("%w: %s", ErrWarrantyExpired, unit.Serial)
+		}
+		claims, err := store.Claims.FindByUnit(ctx, unit.Id)
+		if err != nil {
+			return err
+		}
+		for _, earlier := range claims {
+			if earlier.Status != models.ClaimClosed {
+				return fmt.Errorf("%w: %s", ErrClaimOpen, unit.Serial)
+			}
+		}
+
+		newClaim = models.WarrantyClaim{
+			Id:             primitive.NewObjectID(),
+			Serial:         unit.Serial,
+			Unit_id:        unit.Id,
+			Product_id:     unit.Product_id,
+			Customer_id:    unit.Customer_id,
+			Sell_id:        unit.Sell_id,
+			Problem:        problem,
+			Status:         models.ClaimOpen,
+			Warranty_until: *unit.Warranty_until,
+			Events:         []models.ClaimEvent{{Status: models.ClaimOpen, Note: problem, User_id: userId, Date: now}},
+			User_id:        userId,
+			Created_at:     now,
+			Updated_at:     now,
+		}
+		if err := store.Claims.Create(ctx, &newClaim); err != nil {
+			return err
+		}
+		unit.Events = append(unit.Events, models.SerialEvent{
+			Type:         models.MovementWarranty,
+			Status:       unit.Status,
+			Reference_id: newClaim.Id,
+			User_id:      userId,
+			Date:         now,
+		})
+		_, err = store.Serials.Save(ctx, unit)
+		return err
+	})
+	return newClaim, err
+}
+
+// UpdateWarrantyClaim moves a claim to the status of update when its
+// workflow allows it. Replacing hands the customer a unit in stock of the
+// same product, which leaves through a warranty movement and takes over the
+// sale and warranty of the faulty unit.
+func UpdateWarrantyClaim(ctx context.Context, store *repositories.Store, claimId primitive.ObjectID, update models.ClaimUpdate, userId primitive.ObjectID, now time.Time) (models.WarrantyClaim, error) {
+	var updated models.WarrantyClaim
+
+	replacement := strings.TrimSpace(update.Replacement_serial)
+	if update.Status == models.ClaimReplaced && replacement == "" {
+		return updated, fmt.Errorf("%w: a replacement serial is required", ErrInvalidClaim)
+	}
+	if update.Status != models.ClaimReplaced && replacement != "" {
+		return updated, fmt.Errorf("%w: only replaced claims take a replacement serial", ErrInvalidClaim)
+	}
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		claim, err := store.Claims.FindByID(ctx, claimId)
+		if err != nil {
+			return err
+		}
+		allowed := false
+		for _, status := range models.ClaimTransitions[claim.Status] {
+			allowed = allowed || status == update.Status
+		}
+		if !allowed {
+			return fmt.Errorf("%w: claim is %s and cannot become %s", ErrInvalidClaim, claim.Status, update.Status)
+		}
+		if update.Status == models.ClaimReplaced {
+			if err := replaceUnit(ctx, store, claim, replacement, userId, now); err != nil {
+				return err
+			}
+		}
+		updated, err = store.Claims.Advance(ctx, claim.Id, models.ClaimEvent{
+			Status:  update.Status,
+			Note:    strings.TrimSpace(update.Note),
+			User_id: userId,
+			Date:    now,
+		}, replacement)
+		return err
+	})
+	return updated, err
+}
+
+// replaceUnit swaps the faulty unit of claim for the unit in stock named by serial
+func replaceUnit(ctx context.Context, store *repositories.Store, claim models.WarrantyClaim, serial string, userId primitive.ObjectID, now time.Time) error {
+	faulty, err := store.Serials.FindBySerial(ctx, claim.Serial)
+	if err != nil {
+		return fmt.Errorf("serial %s: %w", claim.Serial, err)
+	}
+	if faulty.Status != models.SerialSold {
+		return fmt.Errorf("%w: %s is no longer with the customer", ErrInvalidClaim, faulty.Serial)
+	}
+	if _, _, err := moveStock(ctx, store, models.StockMovement{
+		Product_id:   claim.Product_id,
+		Type:         models.MovementWarranty,
+		Quantity:     -1,
+		Reason:       "warranty replacement of " + faulty.Serial,
+		User_id:      userId,
+		Reference_id: claim.Id,
+		Date:         primitive.NewDateTimeFromTime(now),
+		Serials:      []string{serial},
+	}); err != nil {
+		return err
+	}
+	unit, err := store.Serials.FindBySerial(ctx, serial)
+	if err != nil {
+		return err
+	}
+	unit.Sell_id, unit.Customer_id = faulty.Sell_id, faulty.Customer_id
+	unit.Sold_at, unit.Warranty_until = faulty.Sold_at, faulty.Warranty_until
+	if _, err := store.Serials.Save(ctx, unit); err != nil {
+		return err
+	}
+	faulty.Status = models.SerialReplaced
+	faulty.Events = append(faulty.Events, models.SerialEvent{
+		Type:         models.MovementWarranty,
+		Status:       faulty.Status,
+		Reference_id: claim.Id,
+		User_id:      userId,
+		Date:         now,
+	})
+	_, err = store.Serials.Save(ctx, faulty)
+	return err
+}
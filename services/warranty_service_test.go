@@ -0,0 +1,130 @@
+package services
+
+import (
+	"appadming/models"
+	"appadming/repositories"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWarrantyClaimReplacesTheUnit(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phone := f.addSerialized(t, "IMEI-1", "IMEI-2")
+	sell := f.sellSerials(t, phone, "IMEI-1")
+	now := time.Now().AddDate(0, 1, 0)
+	userId := primitive.NewObjectID()
+
+	claim, err := CreateWarrantyClaim(f.ctx, f.store, models.WarrantyClaim{Serial: " IMEI-1 ", Problem: " screen flickers "}, userId, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claim.Status != models.ClaimOpen || claim.Problem != "screen flickers" || claim.Sell_id != sell.Id || claim.Customer_id != f.customer.Id || claim.Product_id != phone.Id {
+		t.Errorf("claim = %+v", claim)
+	}
+	if _, err := CreateWarrantyClaim(f.ctx, f.store, models.WarrantyClaim{Serial: "IMEI-1", Problem: "again"}, userId, now); !errors.Is(err, ErrClaimOpen) {
+		t.Errorf("second claim while one is open = %v, want ErrClaimOpen", err)
+	}
+
+	if _, err := UpdateWarrantyClaim(f.ctx, f.store, claim.Id, models.ClaimUpdate{Status: models.ClaimInRepair, Note: "sent to service centre"}, userId, now); err != nil {
+		t.Fatal(err)
+	}
+	claim, err = UpdateWarrantyClaim(f.ctx, f.store, claim.Id, models.ClaimUpdate{Status: models.ClaimReplaced, Replacement_serial: "IMEI-2"}, userId, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claim.Status != models.ClaimReplaced || claim.Replacement_serial != "IMEI-2" || len(claim.Events) != 3 {
+		t.Errorf("replaced claim = %+v", claim)
+	}
+
+	faulty, _ := f.store.Serials.FindBySerial(f.ctx, "IMEI-1")
+	replacement, _ := f.store.Serials.FindBySerial(f.ctx, "IMEI-2")
+	if faulty.Status != models.SerialReplaced {
+		t.Errorf("faulty unit is %s, want replaced", faulty.Status)
+	}
+	if replacement.Status != models.SerialSold || replacement.Sell_id != sell.Id || replacement.Customer_id != f.customer.Id || !replacement.Warranty_until.Equal(*faulty.Warranty_until) {
+		t.Errorf("replacement = %+v, want it to take over the sale and warranty of IMEI-1", replacement)
+	}
+	if got := stock(t, f, phone.Id); got != 0 {
+		t.Errorf("stock = %d, want the replacement taken out", got)
+	}
+
+	if _, err := UpdateWarrantyClaim(f.ctx, f.store, claim.Id, models.ClaimUpdate{Status: models.ClaimClosed}, userId, now); err != nil {
+		t.Fatal(err)
+	}
+	// the replacement carries on under the warranty of the unit it replaced
+	if _, err := CreateWarrantyClaim(f.ctx, f.store, models.WarrantyClaim{Serial: "IMEI-2", Problem: "no signal"}, userId, now); err != nil {
+		t.Errorf("claim on the replacement = %v", err)
+	}
+	lookup, err := LookupSerial(f.ctx, f.store, "IMEI-1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lookup.Claims) != 1 || lookup.Claims[0].Status != models.ClaimClosed {
+		t.Errorf("claims of IMEI-1 = %+v", lookup.Claims)
+	}
+}
+
+func TestWarrantyClaimRefused(t *testing.T) {
+	f := newFixture(t, models.Orgnization{})
+	phone := f.addSerialized(t, "IMEI-1", "IMEI-2", "IMEI-3")
+	f.sellSerials(t, phone, "IMEI-1")
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		claim   models.WarrantyClaim
+		now     time.Time
+		wantErr error
+	}{
+		{name: "no problem", claim: models.WarrantyClaim{Serial: "IMEI-1", Problem: " "}, now: now, wantErr: ErrInvalidClaim},
+		{name: "unit in stock", claim: models.WarrantyClaim{Serial: "IMEI-2", Problem: "dead"}, now: now, wantErr: ErrInvalidClaim},
+		{name: "warranty over", claim: models.WarrantyClaim{Serial: "IMEI-1", Problem: "dead"}, now: now.AddDate(1, 0, 1), wantErr: ErrWarrantyExpired},
+		{name: "unknown serial", claim: models.WarrantyClaim{Serial: "IMEI-9", Problem: "dead"}, now: now, wantErr: repositories.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateWarrantyClaim(f.ctx, f.store, tt.claim, primitive.NewObjectID(), tt.now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if claims, _ := f.store.Claims.List(f.ctx, repositories.ListQuery{}); claims.Total != 0 {
+		t.Errorf("%d claims stored", claims.Total)
+	}
+
+	claim, err := CreateWarrantyClaim(f.ctx, f.store, models.WarrantyClaim{Serial: "IMEI-1", Problem: "dead"}, primitive.NewObjectID(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates := []struct {
+		name   string
+		update models.ClaimUpdate
+	}{
+		{name: "closing an open claim", update: models.ClaimUpdate{Status: models.ClaimClosed}},
+		{name: "repaired before repair", update: models.ClaimUpdate{Status: models.ClaimRepaired}},
+		{name: "replacing without a serial", update: models.ClaimUpdate{Status: models.ClaimReplaced}},
+		{name: "a serial without replacing", update: models.ClaimUpdate{Status: models.ClaimRejected, Replacement_serial: "IMEI-2"}},
+		{name: "replacing with a sold unit", update: models.ClaimUpdate{Status: models.ClaimReplaced, Replacement_serial: "IMEI-1"}},
+	}
+	for _, tt := range updates {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UpdateWarrantyClaim(f.ctx, f.store, claim.Id, tt.update, primitive.NewObjectID(), now)
+			if !errors.Is(err, ErrInvalidClaim) && !errors.Is(err, ErrInvalidSerial) {
+				t.Errorf("error = %v, want ErrInvalidClaim or ErrInvalidSerial", err)
+			}
+			stored, err := f.store.Claims.FindByID(f.ctx, claim.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != models.ClaimOpen || len(stored.Events) != 1 {
+				t.Errorf("refused update left the claim %s with %d events", stored.Status, len(stored.Events))
+			}
+		})
+	}
+	if got := stock(t, f, phone.Id); got != 2 {
+		t.Errorf("stock = %d, want 2", got)
+	}
+}
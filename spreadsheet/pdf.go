@@ -0,0 +1,206 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page layout of printed tables: A4 landscape in points
+const (
+	pdfPageWidth  = 842
+	pdfPageHeight = 595
+	pdfMargin     = 36
+	pdfFontSize   = 8
+	pdfRowHeight  = 12
+	// pdfCharWidth approximates the width of a Helvetica character, used to cut long cells
+	pdfCharWidth = 0.5 * pdfFontSize
+)
+
+// Objects written before the pages; the page tree is written last, once its
+// pages are known, under the number reserved for it
+const (
+	pdfCatalog  = 1
+	pdfPages    = 2
+	pdfFont     = 3
+	pdfBoldFont = 4
+)
+
+// pdfWriter prints a table with the standard Helvetica fonts, which need no
+// embedding. The first row is the header, repeated at the top of every page.
+// Text is encoded as WinAnsi and other characters print as "?".
+type pdfWriter struct {
+	w       io.Writer
+	written int64
+	err     error
+	offsets map[int]int64
+	next    int
+	pages   []int
+
+	title  string
+	header []string
+	page   bytes.Buffer
+	y      float64
+}
+
+func newPDFWriter(w io.Writer, title string) *pdfWriter {
+	p := &pdfWriter{w: w, title: title, offsets: map[int]int64{}, next: pdfBoldFont + 1}
+	p.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	p.object(pdfCatalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPages))
+	p.object(pdfFont, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	p.object(pdfBoldFont, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	return p
+}
+
+func (p *pdfWriter) printf(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(p.w, format, args...)
+	p.written += int64(n)
+	p.err = err
+}
+
+func (p *pdfWriter) object(number int, body string) {
+	p.offsets[number] = p.written
+	p.printf("%d 0 obj\n%s\nendobj\n", number, body)
+}
+
+func (p *pdfWriter) Write(row []string) error {
+	if p.header == nil {
+		p.header = append([]string{}, row...)
+		return p.err
+	}
+	if p.page.Len() == 0 || p.y < pdfMargin+pdfRowHeight {
+		p.startPage()
+	}
+	p.row(row, pdfFont)
+	return p.err
+}
+
+// startPage finishes the current page and heads a new one with the title and the header
+func (p *pdfWriter) startPage() {
+	p.flushPage()
+	p.y = pdfPageHeight - pdfMargin
+	p.text(pdfMargin, p.y, pdfBoldFont, 12, p.title)
+	p.y -= 2 * pdfRowHeight
+	p.row(p.header, pdfBoldFont)
+}
+
+func (p *pdfWriter) row(values []string, font int) {
+	columns := len(p.header)
+	if columns == 0 {
+		columns = len(values)
+	}
+	width := float64(pdfPageWidth-2*pdfMargin) / float64(columns)
+	for i, value := range values {
+		if i >= columns {
+			break
+		}
+		p.text(pdfMargin+float64(i)*width, p.y, font, pdfFontSize, fit(value, width))
+	}
+	p.y -= pdfRowHeight
+}
+
+func (p *pdfWriter) text(x float64, y float64, font int, size int, value string) {
+	fmt.Fprintf(&p.page, "BT /F%d %d Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, PDFString(value))
+}
+
+// fit cuts value to the number of characters that fit in width
+func fit(value string, width float64) string {
+	limit := int(width/pdfCharWidth) - 1
+	runes := []rune(value)
+	if limit < 1 || len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// PDFString encodes value as the content of a WinAnsi literal string, printing
+// characters outside WinAnsi as "?"
+func PDFString(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		c, ok := winAnsi(r)
+		switch {
+		case !ok:
+			b.WriteByte('?')
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// winAnsi maps r to its WinAnsi code: Latin-1 plus a few punctuation marks
+func winAnsi(r rune) (byte, bool) {
+	switch r {
+	case '…':
+		return 0x85, true
+	case '–':
+		return 0x96, true
+	case '—':
+		return 0x97, true
+	case '‘':
+		return 0x91, true
+	case '’':
+		return 0x92, true
+	case '“':
+		return 0x93, true
+	case '”':
+		return 0x94, true
+	case '€':
+		return 0x80, true
+	case '\t', '\n', '\r':
+		return ' ', true
+	}
+	if (r >= 32 && r < 127) || (r >= 0xa0 && r <= 0xff) {
+		return byte(r), true
+	}
+	return 0, false
+}
+
+// flushPage writes the content stream and page object of the current page
+func (p *pdfWriter) flushPage() {
+	if p.page.Len() == 0 {
+		return
+	}
+	footer := fmt.Sprintf("Page %d", len(p.pages)+1)
+	p.text(pdfPageWidth-pdfMargin-float64(len(footer))*pdfCharWidth, pdfMargin/2, pdfFont, pdfFontSize, footer)
+
+	content, page := p.next, p.next+1
+	p.next += 2
+	p.object(content, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.page.Len(), p.page.String()))
+	p.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F%d %d 0 R /F%d %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPages, pdfPageWidth, pdfPageHeight, pdfFont, pdfFont, pdfBoldFont, pdfBoldFont, content))
+	p.pages = append(p.pages, page)
+	p.page.Reset()
+}
+
+func (p *pdfWriter) Close() error {
+	if len(p.pages) == 0 && p.page.Len() == 0 {
+		// a table without rows still prints its title and header
+		p.startPage()
+	}
+	p.flushPage()
+
+	kids := make([]string, len(p.pages))
+	for i, page := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	p.object(pdfPages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+
+	xref := p.written
+	p.printf("xref\n0 %d\n0000000000 65535 f \n", p.next)
+	for number := 1; number < p.next; number++ {
+		p.printf("%010d 00000 n \n", p.offsets[number])
+	}
+	p.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", p.next, pdfCatalog, xref)
+	return p.err
+}
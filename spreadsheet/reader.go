@@ -0,0 +1,214 @@
+// Package spreadsheet reads and writes the CSV and XLSX files exchanged with
+// spreadsheet programs and prints tables as PDF. XLSX support covers the parts
+// of the format needed for plain tables: the first worksheet, shared and
+// inline strings and numbers.
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Formats understood by ReadAll
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// ErrUnsupportedFormat is returned for files that are neither CSV nor XLSX
+var ErrUnsupportedFormat = errors.New("unsupported spreadsheet format")
+
+// FormatOf guesses the format of a file from its name
+func FormatOf(filename string) string {
+	return strings.TrimPrefix(strings.ToLower(path.Ext(filename)), ".")
+}
+
+// ReadAll returns every row of the first sheet of data
+func ReadAll(data []byte, format string) ([][]string, error) {
+	switch format {
+	case CSV:
+		return readCSV(data)
+	case XLSX:
+		return readXLSX(data)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	// spreadsheet programs often start UTF-8 files with a byte order mark
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader.ReadAll()
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Id string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var s strings.Builder
+	for _, run := range t.Runs {
+		s.WriteString(run.T)
+	}
+	return s.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: not an xlsx file", ErrUnsupportedFormat)
+	}
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	decode := func(name string, v interface{}) error {
+		file, ok := files[name]
+		if !ok {
+			return fmt.Errorf("%w: missing %s", ErrUnsupportedFormat, name)
+		}
+		r, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		return xml.NewDecoder(r).Decode(v)
+	}
+
+	sheetPath, err := firstSheet(decode)
+	if err != nil {
+		return nil, err
+	}
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decode("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	var sheet xlsxSheet
+	if err := decode(sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var values []string
+		for i, cell := range row.Cells {
+			column := i
+			if cell.Ref != "" {
+				column = columnIndex(cell.Ref)
+			}
+			for len(values) <= column {
+				values = append(values, "")
+			}
+			switch cell.Type {
+			case "s":
+				n, err := strconv.Atoi(cell.Value)
+				if err != nil || n < 0 || n >= len(shared.Items) {
+					return nil, fmt.Errorf("%w: bad shared string in %s", ErrUnsupportedFormat, cell.Ref)
+				}
+				values[column] = shared.Items[n].String()
+			case "inlineStr":
+				values[column] = cell.Inline.String()
+			case "b":
+				values[column] = map[string]string{"1": "true", "0": "false"}[cell.Value]
+			default:
+				values[column] = cell.Value
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// firstSheet finds the part holding the first worksheet of the workbook
+func firstSheet(decode func(name string, v interface{}) error) (string, error) {
+	var workbook xlsxWorkbook
+	if err := decode("xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	var relationships xlsxRelationships
+	if err := decode("xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("%w: the workbook has no sheet", ErrUnsupportedFormat)
+	}
+	for _, rel := range relationships.Relationships {
+		if rel.Id == workbook.Sheets[0].Id {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return "", fmt.Errorf("%w: the first sheet is missing", ErrUnsupportedFormat)
+}
+
+// columnIndex turns the letters of a cell reference such as "AB12" into a zero based column
+func columnIndex(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+	}
+	return column - 1
+}
+
+// ReadLimited reads at most limit bytes from r, failing when there is more
+func ReadLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("the file is larger than %d bytes", limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,176 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PDF prints tables; it can be written but not read
+const PDF = "pdf"
+
+// Writer streams the rows of a table. Close finishes the file without closing
+// the underlying writer.
+type Writer interface {
+	Write(row []string) error
+	Close() error
+}
+
+// ContentType is the media type of files in format
+func ContentType(format string) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case PDF:
+		return "application/pdf"
+	}
+	return "application/octet-stream"
+}
+
+// Writable reports whether NewWriter supports format
+func Writable(format string) bool {
+	return format == CSV || format == XLSX || format == PDF
+}
+
+// FormatFor picks the format matching a media type of an Accept header
+func FormatFor(mediaType string) (string, bool) {
+	for _, format := range []string{CSV, XLSX, PDF} {
+		if strings.HasPrefix(ContentType(format), mediaType) {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// NewWriter returns a Writer producing a format file on w. title names the
+// sheet of XLSX files and heads every page of PDF files.
+func NewWriter(w io.Writer, format string, title string) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{csv.NewWriter(w), 0}, nil
+	case XLSX:
+		return newXLSXWriter(w, title)
+	case PDF:
+		return newPDFWriter(w, title), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// csvFlushEvery is how many rows are buffered before they are sent
+const csvFlushEvery = 100
+
+type csvWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func (c *csvWriter) Write(row []string) error {
+	if err := c.w.Write(row); err != nil {
+		return err
+	}
+	c.rows++
+	if c.rows%csvFlushEvery == 0 {
+		c.w.Flush()
+	}
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// xlsxParts are the fixed parts of a one sheet workbook, %s being the sheet name
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// xlsxWriter writes the sheet last so that its rows can be streamed into the archive
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+}
+
+func newXLSXWriter(w io.Writer, title string) (*xlsxWriter, error) {
+	archive := zip.NewWriter(w)
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	if len([]rune(name)) > 31 {
+		name = string([]rune(name)[:31])
+	}
+	for _, part := range xlsxParts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		content := part.content
+		if strings.Contains(content, "%s") {
+			content = fmt.Sprintf(content, xmlEscape(name))
+		}
+		if _, err := io.WriteString(file, content); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return &xlsxWriter{archive, sheet}, err
+}
+
+func (x *xlsxWriter) Write(row []string) error {
+	var b strings.Builder
+	b.WriteString("<row>")
+	for _, value := range row {
+		if isNumeric(value) {
+			fmt.Fprintf(&b, "<c><v>%s</v></c>", value)
+			continue
+		}
+		fmt.Fprintf(&b, `<c t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, xmlEscape(value))
+	}
+	b.WriteString("</row>")
+	_, err := io.WriteString(x.sheet, b.String())
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, "</sheetData></worksheet>"); err != nil {
+		return err
+	}
+	return x.archive.Close()
+}
+
+// isNumeric reports whether value is stored as a number. Values with leading
+// zeros, such as phone numbers, stay text so that the zeros are kept.
+func isNumeric(value string) bool {
+	if value == "" || (len(value) > 1 && value[0] == '0' && value[1] != '.') {
+		return false
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil && !strings.ContainsAny(value, "xXpPnN")
+}
+
+func xmlEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
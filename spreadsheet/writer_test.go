@@ -0,0 +1,121 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"name", "phone", "amount", "note"},
+		{"Karim, Jr.", "01711000001", "1200", `said "later"`},
+		{"রহিম", "1711", "-12.5", "<b> & co"},
+		{"", "0", "0.50", "line\nbreak"},
+	}
+	for _, format := range []string{CSV, XLSX} {
+		t.Run(format, func(t *testing.T) {
+			var out bytes.Buffer
+			writer, err := NewWriter(&out, format, "customers: [all]")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				if err := writer.Write(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+			read, err := ReadAll(out.Bytes(), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(read, rows) {
+				t.Errorf("read back %q, want %q", read, rows)
+			}
+		})
+	}
+}
+
+func TestIsNumeric(t *testing.T) {
+	tests := map[string]bool{
+		"1200": true, "-12.5": true, "0": true, "0.50": true,
+		"01711": false, "": false, "1e3": true, "0x1F": false, "NaN": false, "Inf": false, "12a": false,
+	}
+	for value, want := range tests {
+		if got := isNumeric(value); got != want {
+			t.Errorf("isNumeric(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Invoice (copy)", `Invoice \(copy\)`},
+		{`C:\shop`, `C:\\shop`},
+		{"Café – 5€", `Caf\351 \226 5\200`},
+		{"রহিম", "????"},
+		{"a\tb", "a b"},
+	}
+	for _, tt := range tests {
+		if got := PDFString(tt.value); got != tt.want {
+			t.Errorf("PDFString(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestPDFWriterPaginates(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWriter(&out, PDF, "sells")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write([]string{"id", "amount"})
+	for i := 0; i < 100; i++ {
+		if err := writer.Write([]string{strconv.Itoa(i), "1000"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	pdf := out.String()
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatalf("not a PDF file: %q", pdf[:20])
+	}
+
+	// every page repeats the title and the header
+	pages := regexp.MustCompile(`/Count (\d+)`).FindStringSubmatch(pdf)
+	if pages == nil || pages[1] != "3" {
+		t.Errorf("page count = %v, want 3", pages)
+	}
+	if got := strings.Count(pdf, "(sells) Tj"); got != 3 {
+		t.Errorf("title printed %d times, want 3", got)
+	}
+	if got := strings.Count(pdf, "(99) Tj"); got != 1 {
+		t.Errorf("last row printed %d times, want 1", got)
+	}
+
+	// the cross-reference table points at every object
+	xref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	offset, _ := strconv.Atoi(xref[1])
+	if !strings.HasPrefix(pdf[offset:], "xref\n") {
+		t.Fatalf("startxref %d does not point at the xref table", offset)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf[offset:], -1)
+	for i, entry := range entries {
+		at, _ := strconv.Atoi(entry[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(pdf[at:], want) {
+			t.Errorf("xref entry %d points at %q", i+1, pdf[at:at+10])
+		}
+	}
+}